@@ -0,0 +1,310 @@
+package controller
+
+import (
+	"context"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+type AdherenceController struct{}
+
+func NewAdherenceController() interfaces.AdherenceInterface {
+	return &AdherenceController{}
+}
+
+// trackedLine loads a structured prescription line the viewer may see
+func trackedLine(viewerID, lineID uint) (*model.PrescriptionLine, error) {
+	var line model.PrescriptionLine
+	if err := database.DB.Preload("Drug").First(&line, lineID).Error; err != nil {
+		return nil, errors.New("prescription line not found")
+	}
+	if err := ensurePatientAccess(viewerID, line.PatientID); err != nil {
+		return nil, err
+	}
+	if line.Unstructured || line.Drug == nil {
+		return nil, errors.New("adherence can only be tracked for structured prescription lines")
+	}
+	return &line, nil
+}
+
+// adherenceRecords loads the dispensing events and check-ins of the lines, by line
+func adherenceRecords(lineIDs []uint) (map[uint][]model.DispensingEvent, map[uint][]model.AdherenceCheckIn, error) {
+	events := map[uint][]model.DispensingEvent{}
+	checkIns := map[uint][]model.AdherenceCheckIn{}
+	if len(lineIDs) == 0 {
+		return events, checkIns, nil
+	}
+
+	var allEvents []model.DispensingEvent
+	if err := database.DB.Where("prescription_line_id IN ?", lineIDs).Order("dispensed_at").Find(&allEvents).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, e := range allEvents {
+		events[e.PrescriptionLineID] = append(events[e.PrescriptionLineID], e)
+	}
+	var allCheckIns []model.AdherenceCheckIn
+	if err := database.DB.Where("prescription_line_id IN ?", lineIDs).Order("checked_at").Find(&allCheckIns).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, c := range allCheckIns {
+		checkIns[c.PrescriptionLineID] = append(checkIns[c.PrescriptionLineID], c)
+	}
+	return events, checkIns, nil
+}
+
+// currentAdherence measures each line over its adherence window up to now
+func currentAdherence(lines []model.PrescriptionLine) ([]model.LineAdherence, error) {
+	lineIDs := make([]uint, 0, len(lines))
+	for _, l := range lines {
+		lineIDs = append(lineIDs, l.ID)
+	}
+	events, checkIns, err := adherenceRecords(lineIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := make([]model.LineAdherence, 0, len(lines))
+	for i := range lines {
+		from, to := model.AdherenceWindow(&lines[i], now)
+		result = append(result, model.ComputeAdherence(&lines[i], events[lines[i].ID], checkIns[lines[i].ID], from, to, now))
+	}
+	return result, nil
+}
+
+// sessionAdherence gives each trajectory point the patient's adherence since the previous point,
+// or over AdherenceLookbackDays before the first, and flags sessions where a lack of response may
+// be down to poor adherence rather than the treatment
+func sessionAdherence(patientID uint, points []model.TrajectoryPoint) error {
+	var lines []model.PrescriptionLine
+	if err := database.DB.Preload("Drug").
+		Where("patient_id = ? AND unstructured = ? AND drug_id IS NOT NULL", patientID, false).
+		Find(&lines).Error; err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	lineIDs := make([]uint, 0, len(lines))
+	for _, l := range lines {
+		lineIDs = append(lineIDs, l.ID)
+	}
+	events, checkIns, err := adherenceRecords(lineIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range points {
+		point := &points[i]
+		from := point.Date.AddDate(0, 0, -model.AdherenceLookbackDays)
+		if i > 0 {
+			from = points[i-1].Date.Add(time.Second)
+		}
+
+		var snapshot *model.AdherenceSnapshot
+		for j := range lines {
+			line := &lines[j]
+			lineFrom, lineTo := from, point.Date
+			if line.StartDate != nil && line.StartDate.After(lineFrom) {
+				lineFrom = *line.StartDate
+			}
+			if line.StopDate != nil && line.StopDate.Before(lineTo) {
+				lineTo = *line.StopDate
+			}
+			if !lineTo.After(lineFrom) {
+				continue
+			}
+
+			a := model.ComputeAdherence(line, events[line.ID], checkIns[line.ID], lineFrom, lineTo, point.Date)
+			if snapshot == nil {
+				snapshot = &model.AdherenceSnapshot{}
+			}
+			snapshot.MissedDoses += a.MissedDoses
+			snapshot.CheckIns += a.CheckIns
+			if a.PDC != nil && (snapshot.PDC == nil || *a.PDC < *snapshot.PDC) {
+				snapshot.PDC = a.PDC
+			}
+		}
+		point.Adherence = snapshot
+
+		if i > 0 && snapshot != nil && snapshot.Poor() && !slices.Contains(point.Flags, model.TrajectoryResponse) {
+			point.Flags = append(point.Flags, model.TrajectoryPossibleNonAdherence)
+		}
+	}
+	return nil
+}
+
+// RecordDispensing records a supply of a line's drug handed to the patient by the viewer, now
+// unless DispensedAt is given
+func (c *AdherenceController) RecordDispensing(ctx context.Context, viewerID, lineID uint, event *model.DispensingEvent) (*model.DispensingEvent, error) {
+	line, err := trackedLine(viewerID, lineID)
+	if err != nil {
+		return nil, err
+	}
+	if event.DaysSupply <= 0 {
+		return nil, errors.New("days supplied must be positive")
+	}
+	if event.DispensedAt.IsZero() {
+		event.DispensedAt = time.Now()
+	}
+	if event.DispensedAt.After(time.Now()) {
+		return nil, errors.New("dispensing date cannot be in the future")
+	}
+
+	event.ID = 0
+	event.PrescriptionLineID, event.PrescriptionLine = line.ID, nil
+	event.PatientID = line.PatientID
+	event.DispensedByID, event.DispensedBy = &viewerID, nil
+	event.Quantity = strings.TrimSpace(event.Quantity)
+	if err := database.DB.WithContext(ctx).Create(event).Error; err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// RecordCheckIn records how many doses of a line's drug the patient reports missing over the last
+// PeriodDays (7 unless given). A check-in taken at a session is dated to the session.
+func (c *AdherenceController) RecordCheckIn(ctx context.Context, viewerID, lineID uint, checkIn *model.AdherenceCheckIn) (*model.AdherenceCheckIn, error) {
+	db := database.DB.WithContext(ctx)
+
+	line, err := trackedLine(viewerID, lineID)
+	if err != nil {
+		return nil, err
+	}
+	if checkIn.MissedDoses < 0 {
+		return nil, errors.New("missed doses cannot be negative")
+	}
+	if checkIn.PeriodDays == 0 {
+		checkIn.PeriodDays = 7
+	}
+	if checkIn.PeriodDays < 0 {
+		return nil, errors.New("period must be positive")
+	}
+
+	if checkIn.SessionID != nil {
+		var session model.Session
+		if err := db.Select("id", "patient_id", "date").First(&session, *checkIn.SessionID).Error; err != nil {
+			return nil, errors.New("session not found")
+		}
+		if session.PatientID != line.PatientID {
+			return nil, errors.New("session belongs to another patient")
+		}
+		if checkIn.CheckedAt.IsZero() {
+			checkIn.CheckedAt = session.Date
+		}
+	}
+	if checkIn.CheckedAt.IsZero() {
+		checkIn.CheckedAt = time.Now()
+	}
+
+	checkIn.ID = 0
+	checkIn.PrescriptionLineID, checkIn.PrescriptionLine = line.ID, nil
+	checkIn.PatientID = line.PatientID
+	checkIn.RecordedByID, checkIn.RecordedBy = &viewerID, nil
+	if err := db.Create(checkIn).Error; err != nil {
+		return nil, err
+	}
+	return checkIn, nil
+}
+
+// GetLineAdherence computes a line's adherence and returns it with its dispensing events and
+// check-ins
+func (c *AdherenceController) GetLineAdherence(viewerID, lineID uint) (*model.AdherenceDetail, error) {
+	line, err := trackedLine(viewerID, lineID)
+	if err != nil {
+		return nil, err
+	}
+	adherence, err := currentAdherence([]model.PrescriptionLine{*line})
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &model.AdherenceDetail{Line: line, Adherence: adherence[0]}
+	if err := database.DB.Preload("DispensedBy").
+		Where("prescription_line_id = ?", line.ID).
+		Order("dispensed_at DESC").Find(&detail.Dispensings).Error; err != nil {
+		return nil, err
+	}
+	if err := database.DB.Preload("RecordedBy").
+		Where("prescription_line_id = ?", line.ID).
+		Order("checked_at DESC").Find(&detail.CheckIns).Error; err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// GetPatientAdherence computes the adherence of every drug a patient is currently on
+func (c *AdherenceController) GetPatientAdherence(viewerID, patientID uint) ([]model.LineAdherence, error) {
+	if err := ensurePatientAccess(viewerID, patientID); err != nil {
+		return nil, err
+	}
+
+	var lines []model.PrescriptionLine
+	if err := database.DB.Scopes(activeLines).Preload("Drug").
+		Where("patient_id = ? AND unstructured = ? AND drug_id IS NOT NULL", patientID, false).
+		Order("start_date").Find(&lines).Error; err != nil {
+		return nil, err
+	}
+	return currentAdherence(lines)
+}
+
+// GetAtRiskPatients lists the patients in a health worker's care, on their care team or prescribed
+// by them, with a current drug at risk of poor adherence or an overdue refill, worst first.
+// healthWorkerID 0 means the viewer.
+func (c *AdherenceController) GetAtRiskPatients(viewerID, healthWorkerID uint) ([]model.AdherenceRisk, error) {
+	if healthWorkerID == 0 {
+		healthWorkerID = viewerID
+	}
+
+	var lines []model.PrescriptionLine
+	if err := database.DB.Scopes(activeLines, accessiblePatients(viewerID, "patient_id")).
+		Preload("Drug").Preload("Patient").
+		Where("unstructured = ? AND drug_id IS NOT NULL", false).
+		Where("(prescriber_id = ? OR patient_id IN (SELECT patient_id FROM care_team_members WHERE health_worker_id = ? AND deleted_at IS NULL))", healthWorkerID, healthWorkerID).
+		Where("patient_id IN (SELECT id FROM patients WHERE is_active AND deleted_at IS NULL)").
+		Find(&lines).Error; err != nil {
+		return nil, err
+	}
+	adherence, err := currentAdherence(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	byPatient := map[uint]*model.AdherenceRisk{}
+	risks := []*model.AdherenceRisk{}
+	for i, a := range adherence {
+		if !a.AtRisk {
+			continue
+		}
+		risk, ok := byPatient[a.PatientID]
+		if !ok {
+			risk = &model.AdherenceRisk{PatientID: a.PatientID, Patient: lines[i].Patient}
+			byPatient[a.PatientID] = risk
+			risks = append(risks, risk)
+		}
+		risk.Lines = append(risk.Lines, a)
+		if a.PDC != nil && (risk.WorstPDC == nil || *a.PDC < *risk.WorstPDC) {
+			risk.WorstPDC = a.PDC
+		}
+	}
+
+	// Lowest PDC first; patients with no dispensing record to go on come last
+	sort.SliceStable(risks, func(a, b int) bool {
+		pa, pb := risks[a].WorstPDC, risks[b].WorstPDC
+		if pa == nil || pb == nil {
+			return pa != nil
+		}
+		return *pa < *pb
+	})
+	result := make([]model.AdherenceRisk, 0, len(risks))
+	for _, r := range risks {
+		result = append(result, *r)
+	}
+	return result, nil
+}
@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"context"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/util"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AdverseReactionController struct{}
+
+func NewAdverseReactionController() interfaces.AdverseReactionInterface {
+	return &AdverseReactionController{}
+}
+
+// withReactionDetails preloads what a reaction is read with
+func withReactionDetails(tx *gorm.DB) *gorm.DB {
+	return tx.Preload("PrescriptionLine.Drug").Preload("ReportedBy")
+}
+
+// suspectedDrug works out the drug a reaction is reported against. A line, when given, must be
+// one of the medication history entry's; an entry with a single structured line implies it.
+func suspectedDrug(tx *gorm.DB, patientID uint, r *model.AdverseReaction) error {
+	var medHist model.MedicationHistory
+	if err := tx.Preload("Lines", withPrescriptionDetails).First(&medHist, r.MedicationHistoryID).Error; err != nil {
+		return errors.New("medication history entry not found")
+	}
+	if medHist.PatientID != patientID {
+		return errors.New("medication history entry belongs to another patient")
+	}
+
+	var line *model.PrescriptionLine
+	var structured []*model.PrescriptionLine
+	for i := range medHist.Lines {
+		l := &medHist.Lines[i]
+		if r.PrescriptionLineID != nil && l.ID == *r.PrescriptionLineID {
+			line = l
+		}
+		if !l.Unstructured && l.Drug != nil {
+			structured = append(structured, l)
+		}
+	}
+	if r.PrescriptionLineID != nil && line == nil {
+		return errors.New("the suspected prescription line is not part of this medication history entry")
+	}
+	if line == nil && len(structured) == 1 {
+		line = structured[0]
+	}
+
+	r.PrescriptionLineID, r.PrescriptionLine = nil, nil
+	r.SuspectedDrug = strings.TrimSpace(r.SuspectedDrug)
+	if line != nil {
+		r.PrescriptionLineID = &line.ID
+		if r.SuspectedDrug == "" && line.Drug != nil {
+			r.SuspectedDrug = drugLabel(line)
+		}
+	}
+	if r.SuspectedDrug == "" {
+		r.SuspectedDrug = strings.TrimSpace(medHist.Prescription)
+	}
+	if r.SuspectedDrug == "" {
+		return errors.New("name the suspected drug")
+	}
+	return nil
+}
+
+// ReportReaction records a side effect reported at a session, reported by the viewer. With
+// RecordAs set the suspected drug is also added to the patient's allergies or intolerances, so it
+// is flagged the next time it is prescribed.
+func (c *AdverseReactionController) ReportReaction(ctx context.Context, viewerID, sessionID uint, r *model.AdverseReaction) (*model.AdverseReaction, error) {
+	db := database.DB.WithContext(ctx)
+
+	if err := ensureSessionAccess(viewerID, sessionID); err != nil {
+		return nil, err
+	}
+	var session model.Session
+	if err := db.Select("id", "patient_id").First(&session, sessionID).Error; err != nil {
+		return nil, err
+	}
+
+	r.ID = 0
+	r.PatientID, r.Patient = session.PatientID, nil
+	r.SessionID, r.Session = session.ID, nil
+	r.MedicationHistory = nil
+	r.ReportedByID, r.ReportedBy = &viewerID, nil
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	if err := suspectedDrug(db, session.PatientID, r); err != nil {
+		return nil, err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("PrescriptionLine").Create(r).Error; err != nil {
+			return err
+		}
+		if r.RecordAs == "" {
+			return nil
+		}
+
+		// The generic name catches the drug under any brand
+		substance := r.SuspectedDrug
+		if r.PrescriptionLineID != nil {
+			var line model.PrescriptionLine
+			if err := tx.Preload("Drug").First(&line, *r.PrescriptionLineID).Error; err == nil && line.Drug != nil {
+				substance = line.Drug.GenericName
+			}
+		}
+		recorded, err := allergyRecorded(tx, r.PatientID, substance)
+		if err != nil || recorded {
+			return err
+		}
+		_, err = recordAllergy(tx, viewerID, r.PatientID, &model.PatientAllergy{
+			Kind:              r.RecordAs,
+			Substance:         substance,
+			Reaction:          r.Reaction,
+			AdverseReactionID: &r.ID,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// UpdateReaction follows a reaction up: its severity, the action taken and the outcome. The session
+// and suspected drug stay as reported. Fields left empty are kept; serious and notes are kept when
+// nil, so a reaction marked serious by mistake can be cleared.
+func (c *AdverseReactionController) UpdateReaction(ctx context.Context, viewerID, id uint, updated *model.AdverseReaction, serious *bool, notes *string) (*model.AdverseReaction, error) {
+	db := database.DB.WithContext(ctx)
+
+	var r model.AdverseReaction
+	if err := db.First(&r, id).Error; err != nil {
+		return nil, errors.New("adverse reaction not found")
+	}
+	if err := ensurePatientAccess(viewerID, r.PatientID); err != nil {
+		return nil, err
+	}
+
+	if updated.Reaction != "" {
+		r.Reaction = updated.Reaction
+	}
+	if updated.Severity != "" {
+		r.Severity = updated.Severity
+	}
+	if !updated.OnsetDate.IsZero() {
+		r.OnsetDate = updated.OnsetDate
+	}
+	if updated.ActionTaken != "" {
+		r.ActionTaken = updated.ActionTaken
+	}
+	if updated.Outcome != "" {
+		r.Outcome = updated.Outcome
+	}
+	if updated.OutcomeDate != nil {
+		r.OutcomeDate = updated.OutcomeDate
+	}
+	if serious != nil {
+		r.Serious = *serious
+	}
+	if notes != nil {
+		r.Notes = *notes
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := db.Save(&r).Error; err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetSessionReactions lists the reactions reported at a session
+func (c *AdverseReactionController) GetSessionReactions(viewerID, sessionID uint) ([]model.AdverseReaction, error) {
+	if err := ensureSessionAccess(viewerID, sessionID); err != nil {
+		return nil, err
+	}
+
+	var reactions []model.AdverseReaction
+	if err := database.DB.Scopes(withReactionDetails).
+		Where("session_id = ?", sessionID).
+		Order("onset_date").Find(&reactions).Error; err != nil {
+		return nil, err
+	}
+	return reactions, nil
+}
+
+// GetPatientReactions lists every reaction a patient has reported, newest first
+func (c *AdverseReactionController) GetPatientReactions(viewerID, patientID uint) ([]model.AdverseReaction, error) {
+	if err := ensurePatientAccess(viewerID, patientID); err != nil {
+		return nil, err
+	}
+
+	var reactions []model.AdverseReaction
+	if err := database.DB.Scopes(withReactionDetails).
+		Where("patient_id = ?", patientID).
+		Order("onset_date DESC").Find(&reactions).Error; err != nil {
+		return nil, err
+	}
+	return reactions, nil
+}
+
+// ExportADRForms writes the reactions reported between from and to, of patients the viewer has
+// access to, as CSV in the layout of the national ADR reporting form. The reporting facility is
+// taken from FACILITY_NAME. The patients whose reactions are in the export are returned for the
+// audit trail.
+func (c *AdverseReactionController) ExportADRForms(viewerID uint, from, to time.Time) (string, []uint, error) {
+	var reactions []model.AdverseReaction
+	if err := database.DB.Scopes(accessiblePatients(viewerID, "patient_id")).
+		Preload("Patient").
+		Preload("Session.Diagnosis").
+		Preload("PrescriptionLine.Drug").
+		Preload("ReportedBy.PersonnelType").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Order("created_at, id").Find(&reactions).Error; err != nil {
+		return "", nil, err
+	}
+
+	facility := os.Getenv("FACILITY_NAME")
+	rows := make([][]string, 0, len(reactions))
+	patientIDs := make([]uint, 0, len(reactions))
+	for i := range reactions {
+		rows = append(rows, reactions[i].ADRFormRow(facility))
+		patientIDs = append(patientIDs, reactions[i].PatientID)
+	}
+	export, err := util.RenderCSV(model.ADRFormColumns, rows)
+	return export, patientIDs, err
+}
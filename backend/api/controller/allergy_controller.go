@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type AllergyController struct{}
+
+func NewAllergyController() interfaces.AllergyInterface {
+	return &AllergyController{}
+}
+
+// GetPatientAllergies lists the drugs and substances a patient is known to be allergic to or not
+// tolerate
+func (c *AllergyController) GetPatientAllergies(viewerID, patientID uint) ([]model.PatientAllergy, error) {
+	if err := ensurePatientAccess(viewerID, patientID); err != nil {
+		return nil, err
+	}
+
+	var allergies []model.PatientAllergy
+	if err := database.DB.Preload("RecordedBy").
+		Where("patient_id = ?", patientID).
+		Order("kind, created_at").
+		Find(&allergies).Error; err != nil {
+		return nil, err
+	}
+	return allergies, nil
+}
+
+// AddAllergy records an allergy or intolerance, recorded by the viewer. It is checked on every
+// later prescription.
+func (c *AllergyController) AddAllergy(ctx context.Context, viewerID, patientID uint, allergy *model.PatientAllergy) (*model.PatientAllergy, error) {
+	if err := ensurePatientAccess(viewerID, patientID); err != nil {
+		return nil, err
+	}
+	allergy.AdverseReactionID = nil
+	return recordAllergy(database.DB.WithContext(ctx), viewerID, patientID, allergy)
+}
+
+// allergyRecorded reports whether the patient already has an allergy or intolerance to substance
+func allergyRecorded(db *gorm.DB, patientID uint, substance string) (bool, error) {
+	var count int64
+	err := db.Model(&model.PatientAllergy{}).
+		Where("patient_id = ? AND LOWER(substance) = ?", patientID, strings.ToLower(strings.TrimSpace(substance))).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// recordAllergy adds an allergy or intolerance to a patient's list
+func recordAllergy(db *gorm.DB, viewerID, patientID uint, allergy *model.PatientAllergy) (*model.PatientAllergy, error) {
+	allergy.ID = 0
+	if allergy.Kind == "" {
+		allergy.Kind = model.AllergyKindAllergy
+	}
+	if !model.ValidAllergyKind(allergy.Kind) {
+		return nil, errors.New("kind must be allergy or intolerance")
+	}
+	allergy.PatientID = patientID
+	allergy.Substance = strings.TrimSpace(allergy.Substance)
+	allergy.Reaction = strings.TrimSpace(allergy.Reaction)
+	allergy.RecordedByID, allergy.RecordedBy = &viewerID, nil
+	if allergy.Substance == "" {
+		return nil, errors.New("the drug or substance is required")
+	}
+
+	recorded, err := allergyRecorded(db, patientID, allergy.Substance)
+	if err != nil {
+		return nil, err
+	}
+	if recorded {
+		return nil, fmt.Errorf("an allergy or intolerance to %s is already recorded", allergy.Substance)
+	}
+
+	if err := db.Create(allergy).Error; err != nil {
+		return nil, err
+	}
+	return allergy, nil
+}
+
+// RemoveAllergy deletes an allergy or intolerance recorded in error
+func (c *AllergyController) RemoveAllergy(ctx context.Context, viewerID, patientID, id uint) error {
+	db := database.DB.WithContext(ctx)
+
+	if err := ensurePatientAccess(viewerID, patientID); err != nil {
+		return err
+	}
+
+	var allergy model.PatientAllergy
+	if err := db.Where("patient_id = ?", patientID).First(&allergy, id).Error; err != nil {
+		return errors.New("allergy not found")
+	}
+	return db.Delete(&allergy).Error
+}
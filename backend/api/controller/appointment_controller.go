@@ -0,0 +1,560 @@
+package controller
+
+import (
+	"context"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrAppointmentConflict is returned when a booking clashes with another appointment, with leave
+// or with the health worker's working hours
+var ErrAppointmentConflict = errors.New("appointment time is not available")
+
+// activeAppointmentStatuses are the statuses in which an appointment holds its time
+var activeAppointmentStatuses = []string{model.AppointmentBooked, model.AppointmentCheckedIn}
+
+type AppointmentController struct{}
+
+func NewAppointmentController() interfaces.AppointmentInterface {
+	return &AppointmentController{}
+}
+
+// sameDay reports whether two times fall on the same local date
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.In(time.Local).Date()
+	by, bm, bd := b.In(time.Local).Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// bookAppointment checks that the appointment fits the health worker's working hours, avoids their
+// leave and clashes with no other active appointment of the worker or the patient, then creates it.
+// The worker and patient rows are locked so concurrent bookings cannot take the same time.
+func bookAppointment(tx *gorm.DB, actorID uint, appt *model.Appointment) error {
+	var worker model.HealthWorker
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&worker, appt.HealthWorkerID).Error; err != nil {
+		return errors.New("health worker not found")
+	}
+	if !worker.IsActive {
+		return errors.New("health worker is not active")
+	}
+	var patient model.Patient
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&patient, appt.PatientID).Error; err != nil {
+		return errors.New("patient not found")
+	}
+
+	if appt.StartsAt.Before(time.Now()) {
+		return errors.New("appointments cannot be booked in the past")
+	}
+	start := appt.StartsAt.In(time.Local)
+
+	var hours []model.WorkingHours
+	if err := tx.Where("health_worker_id = ? AND weekday = ?", worker.ID, start.Weekday()).Find(&hours).Error; err != nil {
+		return err
+	}
+	var block *model.WorkingHours
+	for idx := range hours {
+		opens, closes := hours[idx].On(start)
+		if !start.Before(opens) && start.Before(closes) {
+			block = &hours[idx]
+			break
+		}
+	}
+	if block == nil {
+		return fmt.Errorf("%w: %s %s does not work at %s", ErrAppointmentConflict, worker.FirstName, worker.LastName, start.Format("Mon 2 Jan 15:04"))
+	}
+
+	if appt.EndsAt.IsZero() {
+		appt.EndsAt = appt.StartsAt.Add(time.Duration(block.SlotMinutes) * time.Minute)
+	}
+	if !appt.EndsAt.After(appt.StartsAt) {
+		return errors.New("an appointment must end after it starts")
+	}
+	if _, closes := block.On(start); appt.EndsAt.After(closes) {
+		return fmt.Errorf("%w: the appointment runs past the end of working hours at %s", ErrAppointmentConflict, block.EndTime)
+	}
+
+	var onLeave int64
+	if err := tx.Model(&model.HealthWorkerLeave{}).
+		Where("health_worker_id = ? AND starts_at < ? AND ends_at > ?", worker.ID, appt.EndsAt, appt.StartsAt).
+		Count(&onLeave).Error; err != nil {
+		return err
+	}
+	if onLeave > 0 {
+		return fmt.Errorf("%w: %s %s is on leave then", ErrAppointmentConflict, worker.FirstName, worker.LastName)
+	}
+
+	var clash model.Appointment
+	err := tx.Where("status IN ? AND starts_at < ? AND ends_at > ? AND (health_worker_id = ? OR patient_id = ?)",
+		activeAppointmentStatuses, appt.EndsAt, appt.StartsAt, worker.ID, appt.PatientID).
+		First(&clash).Error
+	switch {
+	case err == nil && clash.HealthWorkerID == worker.ID:
+		return fmt.Errorf("%w: the health worker already has an appointment at %s", ErrAppointmentConflict, clash.StartsAt.In(time.Local).Format("15:04"))
+	case err == nil:
+		return fmt.Errorf("%w: the patient already has an appointment at %s", ErrAppointmentConflict, clash.StartsAt.In(time.Local).Format("Mon 2 Jan 15:04"))
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+
+	// The session that asked for the follow-up points at the booking
+	if appt.PreviousSessionID != nil {
+		var previous model.Session
+		if err := tx.First(&previous, *appt.PreviousSessionID).Error; err != nil || previous.PatientID != appt.PatientID {
+			return errors.New("the previous session does not belong to this patient")
+		}
+		if err := tx.Model(&previous).Update("next_session_date", appt.StartsAt).Error; err != nil {
+			return err
+		}
+	}
+
+	appt.ID = 0
+	appt.Status = model.AppointmentBooked
+	appt.DepartmentID = worker.DepartmentID
+	appt.BookedByID = &actorID
+	appt.SessionID = nil
+	return tx.Create(appt).Error
+}
+
+// closeAppointment takes a booked appointment out of the calendar with a documented reason
+func closeAppointment(tx *gorm.DB, appt *model.Appointment, status string, actorID uint, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("a reason is required")
+	}
+	if appt.Status != model.AppointmentBooked {
+		return fmt.Errorf("a %s appointment cannot be %s", appt.Status, status)
+	}
+
+	now := time.Now()
+	appt.Status, appt.StatusReason, appt.StatusChangedByID, appt.StatusChangedAt = status, reason, &actorID, &now
+	return tx.Model(appt).Updates(map[string]interface{}{
+		"status":               status,
+		"status_reason":        reason,
+		"status_changed_by_id": actorID,
+		"status_changed_at":    now,
+	}).Error
+}
+
+// lockAppointment loads an appointment for update and checks the actor may see its patient
+func lockAppointment(tx *gorm.DB, actorID, id uint) (*model.Appointment, error) {
+	var appt model.Appointment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&appt, id).Error; err != nil {
+		return nil, errors.New("appointment not found")
+	}
+	if err := ensurePatientAccess(actorID, appt.PatientID); err != nil {
+		return nil, err
+	}
+	return &appt, nil
+}
+
+// BookAppointment books a patient into a health worker's time
+func (c *AppointmentController) BookAppointment(ctx context.Context, actorID uint, appt *model.Appointment) (*model.Appointment, error) {
+	db := database.DB.WithContext(ctx)
+
+	if err := ensurePatientAccess(actorID, appt.PatientID); err != nil {
+		return nil, err
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return bookAppointment(tx, actorID, appt)
+	}); err != nil {
+		return nil, err
+	}
+	return appt, nil
+}
+
+// GetAppointmentByID fetches an appointment with its patient, health worker and sessions
+func (c *AppointmentController) GetAppointmentByID(viewerID, id uint) (*model.Appointment, error) {
+	var appt model.Appointment
+	if err := database.DB.Preload("Patient").Preload("HealthWorker").Preload("PreviousSession").Preload("Session").
+		First(&appt, id).Error; err != nil {
+		return nil, err
+	}
+	if err := ensurePatientAccess(viewerID, appt.PatientID); err != nil {
+		return nil, err
+	}
+	return &appt, nil
+}
+
+// GetAppointmentsByPatient lists a patient's appointments, most recent first
+func (c *AppointmentController) GetAppointmentsByPatient(viewerID, patientID uint) ([]model.Appointment, error) {
+	if err := ensurePatientAccess(viewerID, patientID); err != nil {
+		return nil, err
+	}
+
+	var appointments []model.Appointment
+	if err := database.DB.Preload("HealthWorker").
+		Where("patient_id = ?", patientID).
+		Order("starts_at DESC").
+		Find(&appointments).Error; err != nil {
+		return nil, err
+	}
+	return appointments, nil
+}
+
+// RescheduleAppointment moves a booked appointment to a new time, optionally with another health
+// worker. The original is kept as rescheduled, with the reason, and the new booking points back at it.
+func (c *AppointmentController) RescheduleAppointment(ctx context.Context, actorID, id, healthWorkerID uint, startsAt time.Time, reason string) (*model.Appointment, error) {
+	db := database.DB.WithContext(ctx)
+
+	var replacement model.Appointment
+	err := db.Transaction(func(tx *gorm.DB) error {
+		original, err := lockAppointment(tx, actorID, id)
+		if err != nil {
+			return err
+		}
+		if err := closeAppointment(tx, original, model.AppointmentRescheduled, actorID, reason); err != nil {
+			return err
+		}
+
+		if healthWorkerID == 0 {
+			healthWorkerID = original.HealthWorkerID
+		}
+		replacement = model.Appointment{
+			PatientID:         original.PatientID,
+			HealthWorkerID:    healthWorkerID,
+			StartsAt:          startsAt,
+			EndsAt:            startsAt.Add(original.EndsAt.Sub(original.StartsAt)),
+			Purpose:           original.Purpose,
+			PreviousSessionID: original.PreviousSessionID,
+			RescheduledFromID: &original.ID,
+		}
+		return bookAppointment(tx, actorID, &replacement)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &replacement, nil
+}
+
+// CancelAppointment frees a booked appointment's time, recording why
+func (c *AppointmentController) CancelAppointment(ctx context.Context, actorID, id uint, reason string) (*model.Appointment, error) {
+	db := database.DB.WithContext(ctx)
+
+	var appt *model.Appointment
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if appt, err = lockAppointment(tx, actorID, id); err != nil {
+			return err
+		}
+		if err := closeAppointment(tx, appt, model.AppointmentCancelled, actorID, reason); err != nil {
+			return err
+		}
+
+		// The previous session no longer has a follow-up booked
+		if appt.PreviousSessionID == nil {
+			return nil
+		}
+		var previous model.Session
+		if err := tx.First(&previous, *appt.PreviousSessionID).Error; err != nil {
+			return err
+		}
+		if previous.NextSessionDate == nil || !previous.NextSessionDate.Equal(appt.StartsAt) {
+			return nil
+		}
+		return tx.Model(&previous).Update("next_session_date", nil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return appt, nil
+}
+
+// CheckInAppointment records the patient's arrival on the day of the appointment and opens the
+// follow-up session, checked in and linked to the patient's previous session
+func (c *AppointmentController) CheckInAppointment(ctx context.Context, actorID, id uint) (*model.Appointment, error) {
+	db := database.DB.WithContext(ctx)
+
+	var appt *model.Appointment
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if appt, err = lockAppointment(tx, actorID, id); err != nil {
+			return err
+		}
+		if appt.Status != model.AppointmentBooked {
+			return fmt.Errorf("a %s appointment cannot be checked in", appt.Status)
+		}
+		now := time.Now()
+		if !sameDay(appt.StartsAt, now) {
+			return errors.New("appointments can only be checked in on the day they are booked for")
+		}
+
+		// Without a session to follow up, the new session continues the patient's latest one
+		previousID := appt.PreviousSessionID
+		if previousID == nil {
+			var latest model.Session
+			err := tx.Where("patient_id = ? AND status NOT IN ?", appt.PatientID, []string{model.SessionCancelled, model.SessionNoShow}).
+				Order("date DESC").First(&latest).Error
+			switch {
+			case err == nil:
+				previousID = &latest.ID
+			case !errors.Is(err, gorm.ErrRecordNotFound):
+				return err
+			}
+		}
+
+		session := model.Session{
+			HealthWorkerID:    appt.HealthWorkerID,
+			PatientID:         appt.PatientID,
+			Date:              now,
+			Status:            model.SessionCheckedIn,
+			PreviousSessionID: previousID,
+			SessionIssue:      appt.Purpose,
+		}
+		if err := openSession(tx, actorID, &session); err != nil {
+			return err
+		}
+
+		appt.Status, appt.SessionID, appt.StatusChangedByID, appt.StatusChangedAt = model.AppointmentCheckedIn, &session.ID, &actorID, &now
+		return tx.Model(appt).Updates(map[string]interface{}{
+			"status":               model.AppointmentCheckedIn,
+			"session_id":           session.ID,
+			"status_changed_by_id": actorID,
+			"status_changed_at":    now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The health worker seeing the patient joins the care team, as for any session
+	if err := addToCareTeam(appt.PatientID, appt.HealthWorkerID, model.CareTeamRoleMember); err != nil {
+		return nil, errors.New("failed to assign care team: " + err.Error())
+	}
+	return appt, nil
+}
+
+// GetAvailableSlots lists a health worker's free slots on a day
+func (c *AppointmentController) GetAvailableSlots(healthWorkerID uint, day time.Time) ([]model.AppointmentSlot, error) {
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+	calendar, err := buildCalendar([]model.HealthWorker{{Model: gorm.Model{ID: healthWorkerID}}}, from, from.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	return calendar.Workers[0].Days[0].FreeSlots, nil
+}
+
+// GetCalendar shows a day or week of one health worker's calendar, or of every active health
+// worker in a department. Appointments of patients the viewer may not see keep their time and
+// status but nothing about who they are with or why.
+func (c *AppointmentController) GetCalendar(viewerID, healthWorkerID, departmentID uint, from time.Time, days int) (*model.Calendar, error) {
+	var workers []model.HealthWorker
+	query := database.DB.Where("is_active = ?", true).Order("last_name, first_name")
+	switch {
+	case healthWorkerID != 0:
+		query = query.Where("id = ?", healthWorkerID)
+	case departmentID != 0:
+		query = query.Where("department_id = ? AND role = ?", departmentID, model.RoleHealthWorker)
+	default:
+		return nil, errors.New("a health worker or a department is required")
+	}
+	if err := query.Find(&workers).Error; err != nil {
+		return nil, err
+	}
+	if len(workers) == 0 {
+		return nil, errors.New("no active health workers found")
+	}
+
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.Local)
+	calendar, err := buildCalendar(workers, from, from.AddDate(0, 0, days))
+	if err != nil {
+		return nil, err
+	}
+
+	var patientIDs []uint
+	for _, worker := range calendar.Workers {
+		for _, day := range worker.Days {
+			for _, appt := range day.Appointments {
+				patientIDs = append(patientIDs, appt.PatientID)
+			}
+		}
+	}
+	if len(patientIDs) == 0 {
+		return calendar, nil
+	}
+	var visible []uint
+	if err := database.DB.Model(&model.Patient{}).Scopes(accessiblePatients(viewerID, "id")).
+		Where("id IN ?", patientIDs).Pluck("id", &visible).Error; err != nil {
+		return nil, err
+	}
+	allowed := make(map[uint]bool, len(visible))
+	for _, id := range visible {
+		allowed[id] = true
+	}
+	for w := range calendar.Workers {
+		for d := range calendar.Workers[w].Days {
+			appointments := calendar.Workers[w].Days[d].Appointments
+			for a := range appointments {
+				if appt := appointments[a]; !allowed[appt.PatientID] {
+					appointments[a] = model.Appointment{
+						Model:          appt.Model,
+						HealthWorkerID: appt.HealthWorkerID,
+						DepartmentID:   appt.DepartmentID,
+						StartsAt:       appt.StartsAt,
+						EndsAt:         appt.EndsAt,
+						Status:         appt.Status,
+					}
+				}
+			}
+		}
+	}
+	return calendar, nil
+}
+
+// buildCalendar lays out the working hours, leave, active appointments and free slots of each
+// worker for every day in [from, to)
+func buildCalendar(workers []model.HealthWorker, from, to time.Time) (*model.Calendar, error) {
+	ids := make([]uint, len(workers))
+	for i, w := range workers {
+		ids[i] = w.ID
+	}
+
+	var hours []model.WorkingHours
+	if err := database.DB.Where("health_worker_id IN ?", ids).Order("weekday, start_time").Find(&hours).Error; err != nil {
+		return nil, err
+	}
+	var leave []model.HealthWorkerLeave
+	if err := database.DB.Where("health_worker_id IN ? AND starts_at < ? AND ends_at > ?", ids, to, from).
+		Order("starts_at").Find(&leave).Error; err != nil {
+		return nil, err
+	}
+	var appointments []model.Appointment
+	if err := database.DB.Preload("Patient").
+		Where("health_worker_id IN ? AND status IN ? AND starts_at < ? AND ends_at > ?", ids, activeAppointmentStatuses, to, from).
+		Order("starts_at").Find(&appointments).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	calendar := &model.Calendar{From: from, To: to}
+	for _, worker := range workers {
+		entry := model.WorkerCalendar{HealthWorker: worker}
+		for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+			next := day.AddDate(0, 0, 1)
+			cal := model.CalendarDay{
+				Date:         day.Format("2006-01-02"),
+				WorkingHours: []model.WorkingHours{},
+				Appointments: []model.Appointment{},
+			}
+			for _, h := range hours {
+				if h.HealthWorkerID == worker.ID && h.Weekday == day.Weekday() {
+					cal.WorkingHours = append(cal.WorkingHours, h)
+				}
+			}
+			for _, l := range leave {
+				if l.HealthWorkerID == worker.ID && model.Overlaps(day, next, l.StartsAt, l.EndsAt) {
+					cal.Leave = append(cal.Leave, l)
+				}
+			}
+			for _, a := range appointments {
+				if a.HealthWorkerID == worker.ID && model.Overlaps(day, next, a.StartsAt, a.EndsAt) {
+					cal.Appointments = append(cal.Appointments, a)
+				}
+			}
+			cal.FreeSlots = model.FreeSlots(day, cal.WorkingHours, cal.Leave, cal.Appointments, now)
+			entry.Days = append(entry.Days, cal)
+		}
+		calendar.Workers = append(calendar.Workers, entry)
+	}
+	return calendar, nil
+}
+
+// GetWorkingHours lists a health worker's weekly working hours
+func (c *AppointmentController) GetWorkingHours(healthWorkerID uint) ([]model.WorkingHours, error) {
+	var hours []model.WorkingHours
+	if err := database.DB.Where("health_worker_id = ?", healthWorkerID).
+		Order("weekday, start_time").Find(&hours).Error; err != nil {
+		return nil, err
+	}
+	return hours, nil
+}
+
+// SetWorkingHours replaces a health worker's weekly working hours. Appointments already booked
+// are kept even if they now fall outside them.
+func (c *AppointmentController) SetWorkingHours(healthWorkerID uint, hours []model.WorkingHours) ([]model.WorkingHours, error) {
+	var worker model.HealthWorker
+	if err := database.DB.First(&worker, healthWorkerID).Error; err != nil {
+		return nil, errors.New("health worker not found")
+	}
+	for idx := range hours {
+		hours[idx].ID = 0
+		hours[idx].HealthWorkerID = healthWorkerID
+	}
+	if err := model.ValidateWeek(hours); err != nil {
+		return nil, err
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("health_worker_id = ?", healthWorkerID).Delete(&model.WorkingHours{}).Error; err != nil {
+			return err
+		}
+		if len(hours) == 0 {
+			return nil
+		}
+		return tx.Create(&hours).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.GetWorkingHours(healthWorkerID)
+}
+
+// GetLeave lists a health worker's current and upcoming leave
+func (c *AppointmentController) GetLeave(healthWorkerID uint) ([]model.HealthWorkerLeave, error) {
+	var leave []model.HealthWorkerLeave
+	if err := database.DB.Where("health_worker_id = ? AND ends_at > ?", healthWorkerID, time.Now()).
+		Order("starts_at").Find(&leave).Error; err != nil {
+		return nil, err
+	}
+	return leave, nil
+}
+
+// CreateLeave records leave for a health worker. Appointments booked within it must be rescheduled
+// or cancelled first.
+func (c *AppointmentController) CreateLeave(actorID uint, leave *model.HealthWorkerLeave) (*model.HealthWorkerLeave, error) {
+	if !leave.EndsAt.After(leave.StartsAt) {
+		return nil, errors.New("leave must end after it starts")
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var worker model.HealthWorker
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&worker, leave.HealthWorkerID).Error; err != nil {
+			return errors.New("health worker not found")
+		}
+
+		var booked int64
+		if err := tx.Model(&model.Appointment{}).
+			Where("health_worker_id = ? AND status IN ? AND starts_at < ? AND ends_at > ?",
+				worker.ID, activeAppointmentStatuses, leave.EndsAt, leave.StartsAt).
+			Count(&booked).Error; err != nil {
+			return err
+		}
+		if booked > 0 {
+			return fmt.Errorf("%w: %d appointments are booked during this leave; reschedule or cancel them first", ErrAppointmentConflict, booked)
+		}
+
+		leave.ID = 0
+		leave.RecordedByID = &actorID
+		return tx.Create(leave).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return leave, nil
+}
+
+// DeleteLeave removes a period of leave, making its slots bookable again
+func (c *AppointmentController) DeleteLeave(id uint) error {
+	var leave model.HealthWorkerLeave
+	if err := database.DB.First(&leave, id).Error; err != nil {
+		return err
+	}
+	return database.DB.Delete(&leave).Error
+}
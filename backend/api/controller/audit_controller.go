@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxAuditLogResults caps how many entries a single audit query returns
+const maxAuditLogResults = 1000
+
+type AuditController struct{}
+
+func NewAuditController() interfaces.AuditInterface {
+	return &AuditController{}
+}
+
+// parseAuditTime accepts either a full RFC 3339 timestamp or a plain date
+func parseAuditTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// SearchAuditLogs filters the audit trail by patient, health worker, entity, action and time range
+func (c *AuditController) SearchAuditLogs(queryParams map[string]string) ([]model.AuditLog, error) {
+	dbQuery := database.DB.Preload("Actor")
+
+	if patientID, ok := queryParams["patient_id"]; ok && patientID != "" {
+		dbQuery = dbQuery.Where("patient_id = ?", patientID)
+	}
+	if healthWorkerID, ok := queryParams["health_worker_id"]; ok && healthWorkerID != "" {
+		dbQuery = dbQuery.Where("actor_id = ?", healthWorkerID)
+	}
+	if entityType, ok := queryParams["entity_type"]; ok && entityType != "" {
+		dbQuery = dbQuery.Where("entity_type = ?", entityType)
+	}
+	if entityID, ok := queryParams["entity_id"]; ok && entityID != "" {
+		dbQuery = dbQuery.Where("entity_id = ?", entityID)
+	}
+	if action, ok := queryParams["action"]; ok && action != "" {
+		dbQuery = dbQuery.Where("action = ?", action)
+	}
+	if from, ok := queryParams["from"]; ok && from != "" {
+		t, err := parseAuditTime(from)
+		if err != nil {
+			return nil, errors.New("invalid 'from' time")
+		}
+		dbQuery = dbQuery.Where("created_at >= ?", t)
+	}
+	if to, ok := queryParams["to"]; ok && to != "" {
+		t, err := parseAuditTime(to)
+		if err != nil {
+			return nil, errors.New("invalid 'to' time")
+		}
+		dbQuery = dbQuery.Where("created_at <= ?", t)
+	}
+
+	limit := maxAuditLogResults
+	if l, ok := queryParams["limit"]; ok && l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 {
+			return nil, errors.New("invalid limit")
+		}
+		if n < limit {
+			limit = n
+		}
+	}
+
+	var logs []model.AuditLog
+	if err := dbQuery.Order("id DESC").Limit(limit).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// GetAuditLogsByPatient lists every recorded access to a patient's data, newest first
+func (c *AuditController) GetAuditLogsByPatient(patientID uint) ([]model.AuditLog, error) {
+	return c.SearchAuditLogs(map[string]string{"patient_id": strconv.FormatUint(uint64(patientID), 10)})
+}
+
+// GetAuditLogsByHealthWorker lists everything a health worker has read or changed, newest first
+func (c *AuditController) GetAuditLogsByHealthWorker(healthWorkerID uint) ([]model.AuditLog, error) {
+	return c.SearchAuditLogs(map[string]string{"health_worker_id": strconv.FormatUint(uint64(healthWorkerID), 10)})
+}
+
+// VerifyAuditChain walks the whole audit trail and reports the first entry whose hash or link to
+// the previous entry does not match
+func (c *AuditController) VerifyAuditChain() (*model.AuditChainStatus, error) {
+	status := &model.AuditChainStatus{Valid: true}
+	prevHash := ""
+
+	var batch []model.AuditLog
+	result := database.DB.Order("id ASC").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			entry := &batch[i]
+			if entry.PrevHash != prevHash || entry.Hash != entry.ComputeHash() {
+				status.Valid = false
+				status.BrokenAtID = &entry.ID
+				return errChainBroken
+			}
+			prevHash = entry.Hash
+			status.Checked++
+		}
+		return nil
+	})
+	if result.Error != nil && !errors.Is(result.Error, errChainBroken) {
+		return nil, result.Error
+	}
+	return status, nil
+}
+
+// errChainBroken stops the batch walk early once tampering has been found
+var errChainBroken = errors.New("audit chain broken")
@@ -0,0 +1,229 @@
+package controller
+
+import (
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/util"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// How far back and ahead a subscribed calendar reaches
+const (
+	calendarFeedPast   = 30 * 24 * time.Hour
+	calendarFeedFuture = 365 * 24 * time.Hour
+)
+
+// icalUIDDomain makes event UIDs globally unique, as RFC 5545 asks
+const icalUIDDomain = "@depression-diagnosis-system"
+
+// ErrCalendarFeedNotFound is returned for unknown or revoked feed tokens
+var ErrCalendarFeedNotFound = errors.New("calendar feed not found")
+
+type CalendarFeedController struct{}
+
+func NewCalendarFeedController() interfaces.CalendarFeedInterface {
+	return &CalendarFeedController{}
+}
+
+// patientCodeOnly preloads nothing of a patient but the patient code, so calendars never carry
+// names or other identifying details
+func patientCodeOnly(tx *gorm.DB) *gorm.DB {
+	return tx.Select("id", "patient_code")
+}
+
+func sessionEvent(s *model.Session) util.ICalEvent {
+	status := "CONFIRMED"
+	switch s.Status {
+	case model.SessionScheduled:
+		status = "TENTATIVE"
+	case model.SessionCancelled, model.SessionNoShow:
+		status = "CANCELLED"
+	}
+	return util.ICalEvent{
+		UID:         fmt.Sprintf("session-%d%s", s.ID, icalUIDDomain),
+		Start:       s.Date,
+		End:         s.Date.Add(model.DefaultSlotMinutes * time.Minute),
+		Summary:     "Session: " + s.Patient.PatientCode,
+		Description: "Session " + s.SessionCode,
+		Status:      status,
+	}
+}
+
+// followUpEvent shows a session's NextSessionDate that has not been booked as an appointment
+func followUpEvent(s *model.Session) util.ICalEvent {
+	return util.ICalEvent{
+		UID:         fmt.Sprintf("follow-up-%d%s", s.ID, icalUIDDomain),
+		Start:       *s.NextSessionDate,
+		End:         s.NextSessionDate.Add(model.DefaultSlotMinutes * time.Minute),
+		Summary:     "Follow-up due: " + s.Patient.PatientCode,
+		Description: "Follow-up of session " + s.SessionCode + " (not yet booked)",
+		Status:      "TENTATIVE",
+	}
+}
+
+func appointmentEvent(a *model.Appointment) util.ICalEvent {
+	status := "CONFIRMED"
+	if !a.IsActive() {
+		status = "CANCELLED"
+	}
+	return util.ICalEvent{
+		UID:     fmt.Sprintf("appointment-%d%s", a.ID, icalUIDDomain),
+		Start:   a.StartsAt,
+		End:     a.EndsAt,
+		Summary: "Appointment: " + a.Patient.PatientCode,
+		Status:  status,
+	}
+}
+
+// bookedFollowUps returns which of the sessions have an active appointment booked as their
+// follow-up, with any health worker
+func bookedFollowUps(sessionIDs []uint) (map[uint]bool, error) {
+	booked := map[uint]bool{}
+	if len(sessionIDs) == 0 {
+		return booked, nil
+	}
+	var ids []uint
+	if err := database.DB.Model(&model.Appointment{}).
+		Where("status IN ? AND previous_session_id IN ?", activeAppointmentStatuses, sessionIDs).
+		Pluck("previous_session_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		booked[id] = true
+	}
+	return booked, nil
+}
+
+// IssueFeedToken creates a calendar subscription token for a health worker and returns it in plain
+// text. Any earlier token stops working.
+func (c *CalendarFeedController) IssueFeedToken(healthWorkerID uint) (string, error) {
+	token, err := util.GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("health_worker_id = ?", healthWorkerID).Delete(&model.CalendarFeedToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.CalendarFeedToken{HealthWorkerID: healthWorkerID, TokenHash: util.HashToken(token)}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RevokeFeedToken stops a health worker's calendar subscription
+func (c *CalendarFeedController) RevokeFeedToken(healthWorkerID uint) error {
+	result := database.DB.Unscoped().Where("health_worker_id = ?", healthWorkerID).Delete(&model.CalendarFeedToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCalendarFeedNotFound
+	}
+	return nil
+}
+
+// GetCalendarFeed renders the schedule of the health worker a feed token belongs to: their
+// sessions, booked appointments and follow-ups due but not yet booked, from a month ago to a year
+// ahead. Patients appear only by patient code. The feed's health worker and the patients in it are
+// returned for the audit trail.
+func (c *CalendarFeedController) GetCalendarFeed(token string) (string, uint, []uint, error) {
+	var feed model.CalendarFeedToken
+	if err := database.DB.Preload("HealthWorker").Where("token_hash = ?", util.HashToken(token)).First(&feed).Error; err != nil {
+		return "", 0, nil, ErrCalendarFeedNotFound
+	}
+	if !feed.HealthWorker.IsActive {
+		return "", 0, nil, ErrCalendarFeedNotFound
+	}
+
+	now := time.Now()
+	from, to := now.Add(-calendarFeedPast), now.Add(calendarFeedFuture)
+
+	var sessions []model.Session
+	if err := database.DB.Preload("Patient", patientCodeOnly).
+		Where("health_worker_id = ? AND status NOT IN ?", feed.HealthWorkerID, []string{model.SessionCancelled, model.SessionNoShow}).
+		Where("(date BETWEEN ? AND ? OR next_session_date BETWEEN ? AND ?)", from, to, from, to).
+		Order("date").Find(&sessions).Error; err != nil {
+		return "", 0, nil, err
+	}
+	// Checked-in appointments already show as their session
+	var appointments []model.Appointment
+	if err := database.DB.Preload("Patient", patientCodeOnly).
+		Where("health_worker_id = ? AND status = ? AND starts_at BETWEEN ? AND ?", feed.HealthWorkerID, model.AppointmentBooked, from, to).
+		Order("starts_at").Find(&appointments).Error; err != nil {
+		return "", 0, nil, err
+	}
+
+	sessionIDs := make([]uint, len(sessions))
+	for i := range sessions {
+		sessionIDs[i] = sessions[i].ID
+	}
+	booked, err := bookedFollowUps(sessionIDs)
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	var events []util.ICalEvent
+	var patientIDs []uint
+	for i := range sessions {
+		s := &sessions[i]
+		shown := false
+		if !s.Date.Before(from) && !s.Date.After(to) {
+			events = append(events, sessionEvent(s))
+			shown = true
+		}
+		if s.NextSessionDate != nil && !booked[s.ID] && !s.NextSessionDate.Before(from) && !s.NextSessionDate.After(to) {
+			events = append(events, followUpEvent(s))
+			shown = true
+		}
+		if shown {
+			patientIDs = append(patientIDs, s.PatientID)
+		}
+	}
+	for i := range appointments {
+		events = append(events, appointmentEvent(&appointments[i]))
+		patientIDs = append(patientIDs, appointments[i].PatientID)
+	}
+
+	if err := database.DB.Model(&feed).Update("last_used_at", now).Error; err != nil {
+		return "", 0, nil, err
+	}
+	name := fmt.Sprintf("%s %s - schedule", feed.HealthWorker.FirstName, feed.HealthWorker.LastName)
+	return util.RenderICalendar(name, events), feed.HealthWorkerID, patientIDs, nil
+}
+
+// GetSessionCalendar renders a session, and its follow-up or the appointment booked for it, as an
+// .ics download. Patients appear only by patient code.
+func (c *CalendarFeedController) GetSessionCalendar(viewerID, sessionID uint) (string, error) {
+	if err := ensureSessionAccess(viewerID, sessionID); err != nil {
+		return "", err
+	}
+
+	var session model.Session
+	if err := database.DB.Preload("Patient", patientCodeOnly).First(&session, sessionID).Error; err != nil {
+		return "", err
+	}
+	var appointments []model.Appointment
+	if err := database.DB.Preload("Patient", patientCodeOnly).
+		Where("previous_session_id = ? AND status IN ?", session.ID, activeAppointmentStatuses).
+		Order("starts_at").Find(&appointments).Error; err != nil {
+		return "", err
+	}
+
+	events := []util.ICalEvent{sessionEvent(&session)}
+	for i := range appointments {
+		events = append(events, appointmentEvent(&appointments[i]))
+	}
+	if session.NextSessionDate != nil && len(appointments) == 0 {
+		events = append(events, followUpEvent(&session))
+	}
+	return util.RenderICalendar("Session "+session.SessionCode, events), nil
+}
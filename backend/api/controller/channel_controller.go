@@ -0,0 +1,772 @@
+package controller
+
+import (
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/middleware"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ChannelPageSize is how many channel messages are returned at a time
+const ChannelPageSize = 50
+
+var (
+	ErrNotChannelMember = errors.New("you are not a member of this channel")
+	ErrNotChannelAdmin  = errors.New("only the channel's admins may do this")
+
+	errLastGroupAdmin = errors.New("the group must keep an admin; make another member admin first")
+)
+
+type ChannelController struct{}
+
+func NewChannelController() interfaces.ChannelInterface {
+	return &ChannelController{}
+}
+
+// canModerateChannels reports whether the health worker may moderate every channel
+func canModerateChannels(healthWorkerID uint) (bool, error) {
+	var hw model.HealthWorker
+	if err := database.DB.Preload("PersonnelType").First(&hw, healthWorkerID).Error; err != nil {
+		return false, err
+	}
+	return middleware.CheckPermission(hw.Role, hw.PersonnelType.Name, model.PermChannelModerate)
+}
+
+// syncChannelMembers brings the members of a department or care-team channel in line with the
+// department's active health workers or the patient's care team. Roles and mutes of those who stay
+// are kept; ad-hoc groups are left alone.
+func syncChannelMembers(tx *gorm.DB, ch *model.Channel) error {
+	var want []uint
+	switch {
+	case ch.Kind == model.ChannelDepartment && ch.DepartmentID != nil:
+		if err := tx.Model(&model.HealthWorker{}).
+			Where("department_id = ? AND is_active", *ch.DepartmentID).
+			Pluck("id", &want).Error; err != nil {
+			return err
+		}
+	case ch.Kind == model.ChannelCareTeam && ch.PatientID != nil:
+		if err := tx.Model(&model.CareTeamMember{}).
+			Where("patient_id = ?", *ch.PatientID).
+			Pluck("health_worker_id", &want).Error; err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+
+	var have []uint
+	if err := tx.Model(&model.ChannelMember{}).Where("channel_id = ?", ch.ID).Pluck("health_worker_id", &have).Error; err != nil {
+		return err
+	}
+	wanted := make(map[uint]bool, len(want))
+	for _, id := range want {
+		wanted[id] = true
+	}
+	had := make(map[uint]bool, len(have))
+	var gone []uint
+	for _, id := range have {
+		had[id] = true
+		if !wanted[id] {
+			gone = append(gone, id)
+		}
+	}
+
+	if len(gone) > 0 {
+		if err := tx.Unscoped().Where("channel_id = ? AND health_worker_id IN ?", ch.ID, gone).Delete(&model.ChannelMember{}).Error; err != nil {
+			return err
+		}
+	}
+	for _, id := range want {
+		if had[id] {
+			continue
+		}
+		if err := tx.Create(&model.ChannelMember{ChannelID: ch.ID, HealthWorkerID: id, Role: model.ChannelRoleMember}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// departmentChannel returns a department's channel, creating it on first use
+func departmentChannel(tx *gorm.DB, departmentID uint) (*model.Channel, error) {
+	var department model.Department
+	if err := tx.First(&department, departmentID).Error; err != nil {
+		return nil, errors.New("department not found")
+	}
+	ch := model.Channel{DepartmentID: &department.ID}
+	if err := tx.Where(ch).Attrs(model.Channel{
+		Kind:        model.ChannelDepartment,
+		Name:        department.Name,
+		Description: "Everyone in " + department.Name,
+	}).FirstOrCreate(&ch).Error; err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// SyncDepartmentChannels gives every department its channel and brings their members up to date
+func SyncDepartmentChannels() {
+	var departmentIDs []uint
+	if err := database.DB.Model(&model.Department{}).Pluck("id", &departmentIDs).Error; err != nil {
+		log.Printf("❌ Failed to load departments for their channels: %v", err)
+		return
+	}
+	for _, id := range departmentIDs {
+		ch, err := departmentChannel(database.DB, id)
+		if err == nil {
+			err = syncChannelMembers(database.DB, ch)
+		}
+		if err != nil {
+			log.Printf("❌ Failed to sync the channel of department %d: %v", id, err)
+		}
+	}
+}
+
+// channelMembership loads a channel and the viewer's membership, syncing automatic channels
+// first. Moderators who are not members get a nil membership, provided they have access to the
+// patient of a care-team channel; anyone else gets ErrNotChannelMember.
+func channelMembership(viewerID, channelID uint) (*model.Channel, *model.ChannelMember, error) {
+	var ch model.Channel
+	if err := database.DB.First(&ch, channelID).Error; err != nil {
+		return nil, nil, errors.New("channel not found")
+	}
+	if ch.Automatic() {
+		if err := syncChannelMembers(database.DB, &ch); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var members []model.ChannelMember
+	if err := database.DB.Where("channel_id = ? AND health_worker_id = ?", ch.ID, viewerID).Limit(1).Find(&members).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(members) == 1 {
+		return &ch, &members[0], nil
+	}
+	moderator, err := canModerateChannels(viewerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !moderator {
+		return nil, nil, ErrNotChannelMember
+	}
+	// Moderating channels does not open a patient's care-team channel to someone without access
+	// to the patient
+	if ch.Kind == model.ChannelCareTeam && ch.PatientID != nil {
+		if err := ensurePatientAccess(viewerID, *ch.PatientID); err != nil {
+			return nil, nil, err
+		}
+	}
+	return &ch, nil, nil
+}
+
+// channelAdmin loads a channel the viewer administers, as one of its admins or a moderator
+func channelAdmin(viewerID, channelID uint) (*model.Channel, error) {
+	ch, member, err := channelMembership(viewerID, channelID)
+	if err != nil {
+		if errors.Is(err, ErrNotChannelMember) {
+			return nil, ErrNotChannelAdmin
+		}
+		return nil, err
+	}
+	if member != nil && member.Role != model.ChannelRoleAdmin {
+		moderator, err := canModerateChannels(viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if !moderator {
+			return nil, ErrNotChannelAdmin
+		}
+	}
+	return ch, nil
+}
+
+// lastGroupAdmin reports whether the member is the only admin left in an ad-hoc group. Callers
+// lock the channel row first so two admins cannot step down at once.
+func lastGroupAdmin(tx *gorm.DB, ch *model.Channel, member *model.ChannelMember) (bool, error) {
+	if ch.Kind != model.ChannelGroup || member.Role != model.ChannelRoleAdmin {
+		return false, nil
+	}
+	var admins int64
+	err := tx.Model(&model.ChannelMember{}).Where("channel_id = ? AND role = ?", ch.ID, model.ChannelRoleAdmin).Count(&admins).Error
+	return admins <= 1, err
+}
+
+// channelMemberIDs lists the health workers in a channel
+func channelMemberIDs(tx *gorm.DB, channelID uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Model(&model.ChannelMember{}).Where("channel_id = ?", channelID).Pluck("health_worker_id", &ids).Error
+	return ids, err
+}
+
+// addChannelMember adds an active health worker to a channel unless they already are in it
+func addChannelMember(tx *gorm.DB, channelID, healthWorkerID uint, role string, addedByID *uint) error {
+	var hw model.HealthWorker
+	if err := tx.Select("id", "is_active").First(&hw, healthWorkerID).Error; err != nil || !hw.IsActive {
+		return errors.New("invalid health worker ID")
+	}
+	member := model.ChannelMember{ChannelID: channelID, HealthWorkerID: healthWorkerID}
+	return tx.Where(member).Attrs(model.ChannelMember{Role: role, AddedByID: addedByID}).FirstOrCreate(&member).Error
+}
+
+// sendChannelMessage posts a message to a channel the sender may post in and pushes it to its
+// members, with an unread receipt for each of them but the sender
+func sendChannelMessage(senderID, channelID uint, text string) (*model.Message, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.New("message is empty")
+	}
+	ch, member, err := channelMembership(senderID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrNotChannelMember
+	}
+	if ch.ArchivedAt != nil {
+		return nil, errors.New("this channel is archived")
+	}
+	if member.Muted(time.Now()) {
+		return nil, errors.New("you have been muted in this channel")
+	}
+
+	msg := &model.Message{SenderID: senderID, ChannelID: &ch.ID, Message: text, Timestamp: time.Now()}
+	var memberIDs []uint
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if memberIDs, err = channelMemberIDs(tx, ch.ID); err != nil {
+			return err
+		}
+		recipients := make([]uint, 0, len(memberIDs))
+		for _, id := range memberIDs {
+			if id != senderID {
+				recipients = append(recipients, id)
+			}
+		}
+		return storeMessage(tx, msg, recipients)
+	}); err != nil {
+		return nil, err
+	}
+	if err := database.DB.Preload("Sender").Preload("Receipts").First(msg, msg.ID).Error; err != nil {
+		return nil, err
+	}
+	hub.publish(model.RealtimeMessage, memberIDs, msg)
+	return msg, nil
+}
+
+// channelTyping tells a channel's other members the sender started or stopped typing there
+func channelTyping(senderID, channelID uint, typing bool) error {
+	_, member, err := channelMembership(senderID, channelID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return ErrNotChannelMember
+	}
+	memberIDs, err := channelMemberIDs(database.DB, channelID)
+	if err != nil {
+		return err
+	}
+	recipients := make([]uint, 0, len(memberIDs))
+	for _, id := range memberIDs {
+		if id != senderID {
+			recipients = append(recipients, id)
+		}
+	}
+	if len(recipients) > 0 {
+		hub.publish(model.RealtimeTyping, recipients, model.TypingPayload{SenderID: senderID, ChannelID: channelID, Typing: typing})
+	}
+	return nil
+}
+
+// GetMyChannels lists the channels a health worker is in, most recently active first, each with
+// its latest message and unread count. Their department and care-team channels are synced first.
+func (c *ChannelController) GetMyChannels(userID uint) ([]model.ChannelThread, error) {
+	var hw model.HealthWorker
+	if err := database.DB.Select("id", "department_id").First(&hw, userID).Error; err != nil {
+		return nil, err
+	}
+	if hw.DepartmentID != 0 {
+		if _, err := departmentChannel(database.DB, hw.DepartmentID); err != nil {
+			return nil, err
+		}
+	}
+	var automatic []model.Channel
+	if err := database.DB.
+		Where("kind = ? AND department_id = ?", model.ChannelDepartment, hw.DepartmentID).
+		Or("kind = ? AND patient_id IN (SELECT patient_id FROM care_team_members WHERE health_worker_id = ? AND deleted_at IS NULL)", model.ChannelCareTeam, userID).
+		Or("kind IN ? AND id IN (SELECT channel_id FROM channel_members WHERE health_worker_id = ? AND deleted_at IS NULL)", []string{model.ChannelDepartment, model.ChannelCareTeam}, userID).
+		Find(&automatic).Error; err != nil {
+		return nil, err
+	}
+	for i := range automatic {
+		if err := syncChannelMembers(database.DB, &automatic[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	var memberships []model.ChannelMember
+	if err := database.DB.Where("health_worker_id = ?", userID).Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+	if len(memberships) == 0 {
+		return []model.ChannelThread{}, nil
+	}
+	channelIDs := make([]uint, 0, len(memberships))
+	for _, m := range memberships {
+		channelIDs = append(channelIDs, m.ChannelID)
+	}
+
+	var channels []model.Channel
+	if err := database.DB.Find(&channels, channelIDs).Error; err != nil {
+		return nil, err
+	}
+	channelBy := make(map[uint]*model.Channel, len(channels))
+	for i := range channels {
+		channelBy[channels[i].ID] = &channels[i]
+	}
+
+	var latest []struct {
+		ChannelID     uint
+		LastMessageID uint
+	}
+	if err := database.DB.Model(&model.Message{}).
+		Select("channel_id, MAX(id) AS last_message_id").
+		Where("channel_id IN ?", channelIDs).
+		Group("channel_id").
+		Scan(&latest).Error; err != nil {
+		return nil, err
+	}
+	lastIDs := make([]uint, 0, len(latest))
+	lastIDBy := make(map[uint]uint, len(latest))
+	for _, l := range latest {
+		lastIDs = append(lastIDs, l.LastMessageID)
+		lastIDBy[l.ChannelID] = l.LastMessageID
+	}
+	var last []model.Message
+	if len(lastIDs) > 0 {
+		if err := database.DB.Preload("Sender").Find(&last, lastIDs).Error; err != nil {
+			return nil, err
+		}
+	}
+	lastBy := make(map[uint]*model.Message, len(last))
+	for i := range last {
+		lastBy[last[i].ID] = &last[i]
+	}
+
+	var unread []struct {
+		ChannelID uint
+		Unread    int64
+	}
+	if err := database.DB.Table("message_receipts r").
+		Select("m.channel_id, COUNT(*) AS unread").
+		Joins("JOIN messages m ON m.id = r.message_id AND m.deleted_at IS NULL").
+		Where("r.recipient_id = ? AND r.read_at IS NULL AND r.deleted_at IS NULL AND m.channel_id IN ?", userID, channelIDs).
+		Group("m.channel_id").
+		Scan(&unread).Error; err != nil {
+		return nil, err
+	}
+	unreadBy := make(map[uint]int64, len(unread))
+	for _, u := range unread {
+		unreadBy[u.ChannelID] = u.Unread
+	}
+
+	threads := make([]model.ChannelThread, 0, len(memberships))
+	for _, m := range memberships {
+		ch := channelBy[m.ChannelID]
+		if ch == nil {
+			continue
+		}
+		threads = append(threads, model.ChannelThread{
+			Channel:     ch,
+			Role:        m.Role,
+			LastMessage: lastBy[lastIDBy[ch.ID]],
+			UnreadCount: unreadBy[ch.ID],
+		})
+	}
+	sort.SliceStable(threads, func(a, b int) bool {
+		la, lb := lastIDBy[threads[a].Channel.ID], lastIDBy[threads[b].Channel.ID]
+		if la != lb {
+			return la > lb
+		}
+		return threads[a].Channel.Name < threads[b].Channel.Name
+	})
+	return threads, nil
+}
+
+// GetOpenGroups lists the open ad-hoc groups the health worker could join
+func (c *ChannelController) GetOpenGroups(userID uint) ([]model.Channel, error) {
+	var channels []model.Channel
+	if err := database.DB.
+		Where("kind = ? AND open AND archived_at IS NULL", model.ChannelGroup).
+		Where("id NOT IN (SELECT channel_id FROM channel_members WHERE health_worker_id = ? AND deleted_at IS NULL)", userID).
+		Order("name").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// GetChannel returns a channel the viewer is in, or moderates, with its members
+func (c *ChannelController) GetChannel(viewerID, channelID uint) (*model.Channel, error) {
+	ch, _, err := channelMembership(viewerID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.DB.Preload("Members.HealthWorker").Preload("Department").First(ch, ch.ID).Error; err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// CreateGroup creates an ad-hoc group with the viewer as its admin and the given health workers as
+// members
+func (c *ChannelController) CreateGroup(viewerID uint, ch *model.Channel, memberIDs []uint) (*model.Channel, error) {
+	ch.Name = strings.TrimSpace(ch.Name)
+	if ch.Name == "" {
+		return nil, errors.New("the group needs a name")
+	}
+	ch.ID = 0
+	ch.Kind = model.ChannelGroup
+	ch.DepartmentID, ch.Department = nil, nil
+	ch.PatientID, ch.Patient = nil, nil
+	ch.ArchivedAt = nil
+	ch.CreatedByID = &viewerID
+	ch.Members = nil
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(ch).Error; err != nil {
+			return err
+		}
+		if err := addChannelMember(tx, ch.ID, viewerID, model.ChannelRoleAdmin, &viewerID); err != nil {
+			return err
+		}
+		for _, id := range memberIDs {
+			if id == viewerID {
+				continue
+			}
+			if err := addChannelMember(tx, ch.ID, id, model.ChannelRoleMember, &viewerID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.GetChannel(viewerID, ch.ID)
+}
+
+// OpenCareTeamChannel returns the channel of a patient's care team, creating it on first use. Only
+// care team members, and moderators, may open it.
+func (c *ChannelController) OpenCareTeamChannel(viewerID, patientID uint) (*model.Channel, error) {
+	if err := ensurePatientAccess(viewerID, patientID); err != nil {
+		return nil, err
+	}
+	var patient model.Patient
+	if err := database.DB.Select("id", "patient_code").First(&patient, patientID).Error; err != nil {
+		return nil, errors.New("patient not found")
+	}
+
+	// Check before the channel is created, so a viewer who may not open it leaves nothing behind
+	var onTeam int64
+	if err := database.DB.Model(&model.CareTeamMember{}).
+		Where("patient_id = ? AND health_worker_id = ?", patient.ID, viewerID).
+		Count(&onTeam).Error; err != nil {
+		return nil, err
+	}
+	if onTeam == 0 {
+		moderator, err := canModerateChannels(viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if !moderator {
+			return nil, ErrNotChannelMember
+		}
+	}
+
+	ch := model.Channel{PatientID: &patient.ID}
+	if err := database.DB.Where(ch).Attrs(model.Channel{
+		Kind:        model.ChannelCareTeam,
+		Name:        "Care team " + patient.PatientCode,
+		Description: "Care team of patient " + patient.PatientCode,
+		CreatedByID: &viewerID,
+	}).FirstOrCreate(&ch).Error; err != nil {
+		return nil, err
+	}
+	return c.GetChannel(viewerID, ch.ID)
+}
+
+// UpdateChannel renames, opens or closes, or archives a channel the viewer administers. Department
+// and care-team channels keep their names; only groups can be opened.
+func (c *ChannelController) UpdateChannel(viewerID, channelID uint, name, description *string, open, archived *bool) (*model.Channel, error) {
+	ch, err := channelAdmin(viewerID, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != nil {
+		if ch.Automatic() {
+			return nil, errors.New("department and care-team channels cannot be renamed")
+		}
+		if strings.TrimSpace(*name) == "" {
+			return nil, errors.New("the group needs a name")
+		}
+		ch.Name = strings.TrimSpace(*name)
+	}
+	if description != nil {
+		ch.Description = *description
+	}
+	if open != nil {
+		if ch.Kind != model.ChannelGroup && *open {
+			return nil, errors.New("only ad-hoc groups can be opened")
+		}
+		ch.Open = *open
+	}
+	if archived != nil {
+		if !*archived {
+			ch.ArchivedAt = nil
+		} else if ch.ArchivedAt == nil {
+			now := time.Now()
+			ch.ArchivedAt = &now
+		}
+	}
+
+	if err := database.DB.Save(ch).Error; err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// JoinChannel adds the viewer to an open ad-hoc group
+func (c *ChannelController) JoinChannel(viewerID, channelID uint) (*model.Channel, error) {
+	var ch model.Channel
+	if err := database.DB.First(&ch, channelID).Error; err != nil {
+		return nil, errors.New("channel not found")
+	}
+	if ch.Kind != model.ChannelGroup || !ch.Open || ch.ArchivedAt != nil {
+		return nil, errors.New("this channel cannot be joined")
+	}
+	if err := addChannelMember(database.DB, ch.ID, viewerID, model.ChannelRoleMember, &viewerID); err != nil {
+		return nil, err
+	}
+	return c.GetChannel(viewerID, ch.ID)
+}
+
+// LeaveChannel takes the viewer out of an ad-hoc group. A last admin leaving hands the group to
+// its longest-standing member.
+func (c *ChannelController) LeaveChannel(viewerID, channelID uint) error {
+	ch, member, err := channelMembership(viewerID, channelID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return ErrNotChannelMember
+	}
+	if ch.Automatic() {
+		return errors.New("department and care-team channel members follow the department and care team")
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the channel so two admins leaving at once cannot both see the other still there
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&model.Channel{}, ch.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(member).Error; err != nil {
+			return err
+		}
+		if member.Role != model.ChannelRoleAdmin {
+			return nil
+		}
+		var admins int64
+		if err := tx.Model(&model.ChannelMember{}).Where("channel_id = ? AND role = ?", ch.ID, model.ChannelRoleAdmin).Count(&admins).Error; err != nil || admins > 0 {
+			return err
+		}
+		var next []model.ChannelMember
+		if err := tx.Where("channel_id = ?", ch.ID).Order("created_at, id").Limit(1).Find(&next).Error; err != nil || len(next) == 0 {
+			return err
+		}
+		return tx.Model(&next[0]).Update("role", model.ChannelRoleAdmin).Error
+	})
+}
+
+// AddChannelMember adds a health worker to an ad-hoc group the viewer administers
+func (c *ChannelController) AddChannelMember(viewerID, channelID, healthWorkerID uint, role string) (*model.Channel, error) {
+	ch, err := channelAdmin(viewerID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if ch.Automatic() {
+		return nil, errors.New("department and care-team channel members follow the department and care team")
+	}
+	if role == "" {
+		role = model.ChannelRoleMember
+	}
+	if role != model.ChannelRoleAdmin && role != model.ChannelRoleMember {
+		return nil, errors.New("channel role must be 'admin' or 'member'")
+	}
+	if err := addChannelMember(database.DB, ch.ID, healthWorkerID, role, &viewerID); err != nil {
+		return nil, err
+	}
+	return c.GetChannel(viewerID, ch.ID)
+}
+
+// UpdateChannelMember changes a member's role, when role is set, and their mute, when setMute is:
+// they are muted until mutedUntil, and a nil or past mutedUntil lifts the mute. The last admin of
+// an ad-hoc group cannot be demoted.
+func (c *ChannelController) UpdateChannelMember(viewerID, channelID, healthWorkerID uint, role string, setMute bool, mutedUntil *time.Time) (*model.ChannelMember, error) {
+	ch, err := channelAdmin(viewerID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if role != "" && role != model.ChannelRoleAdmin && role != model.ChannelRoleMember {
+		return nil, errors.New("channel role must be 'admin' or 'member'")
+	}
+
+	var member model.ChannelMember
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&model.Channel{}, ch.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("channel_id = ? AND health_worker_id = ?", ch.ID, healthWorkerID).First(&member).Error; err != nil {
+			return errors.New("health worker is not a member of this channel")
+		}
+
+		if role != "" && role != member.Role {
+			last, err := lastGroupAdmin(tx, ch, &member)
+			if err != nil {
+				return err
+			}
+			if last {
+				return errLastGroupAdmin
+			}
+			member.Role = role
+		}
+		if setMute {
+			member.MutedUntil = nil
+			if mutedUntil != nil && mutedUntil.After(time.Now()) {
+				member.MutedUntil = mutedUntil
+			}
+		}
+		return tx.Save(&member).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// RemoveChannelMember takes a health worker out of an ad-hoc group the viewer administers. The
+// group's last admin cannot be removed.
+func (c *ChannelController) RemoveChannelMember(viewerID, channelID, healthWorkerID uint) error {
+	ch, err := channelAdmin(viewerID, channelID)
+	if err != nil {
+		return err
+	}
+	if ch.Automatic() {
+		return errors.New("department and care-team channel members follow the department and care team")
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&model.Channel{}, ch.ID).Error; err != nil {
+			return err
+		}
+		var member model.ChannelMember
+		if err := tx.Where("channel_id = ? AND health_worker_id = ?", ch.ID, healthWorkerID).First(&member).Error; err != nil {
+			return errors.New("health worker is not a member of this channel")
+		}
+		last, err := lastGroupAdmin(tx, ch, &member)
+		if err != nil {
+			return err
+		}
+		if last {
+			return errLastGroupAdmin
+		}
+		return tx.Unscoped().Delete(&member).Error
+	})
+}
+
+// GetChannelMessages returns a page of a channel's messages before the message ID cursor, or the
+// latest when it is 0, newest first, and whether there are older ones. They are marked delivered.
+func (c *ChannelController) GetChannelMessages(viewerID, channelID, before uint) ([]model.Message, bool, error) {
+	ch, _, err := channelMembership(viewerID, channelID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	query := database.DB.Preload("Sender").Preload("Receipts").Where("channel_id = ?", ch.ID)
+	if before > 0 {
+		query = query.Where("id < ?", before)
+	}
+	var messages []model.Message
+	if err := query.Order("id DESC").Limit(ChannelPageSize + 1).Find(&messages).Error; err != nil {
+		return nil, false, err
+	}
+	more := len(messages) > ChannelPageSize
+	if more {
+		messages = messages[:ChannelPageSize]
+	}
+	if err := markDelivered(viewerID, messageIDs(messages)); err != nil {
+		return nil, false, err
+	}
+	return messages, more, nil
+}
+
+// SendChannelMessage posts a message to a channel the viewer is in
+func (c *ChannelController) SendChannelMessage(viewerID, channelID uint, text string) (*model.Message, error) {
+	return sendChannelMessage(viewerID, channelID, text)
+}
+
+// MarkChannelRead marks a channel's messages as read by the viewer, up to and including the message
+// ID upTo, or all of them when upTo is 0. It returns how many were unread.
+func (c *ChannelController) MarkChannelRead(viewerID, channelID, upTo uint) (int, error) {
+	ch, _, err := channelMembership(viewerID, channelID)
+	if err != nil {
+		return 0, err
+	}
+
+	unread := database.DB.Model(&model.MessageReceipt{}).
+		Where("recipient_id = ? AND read_at IS NULL", viewerID).
+		Where("message_id IN (SELECT id FROM messages WHERE channel_id = ? AND deleted_at IS NULL)", ch.ID)
+	if upTo > 0 {
+		unread = unread.Where("message_id <= ?", upTo)
+	}
+	var ids []uint
+	if err := unread.Pluck("message_id", &ids).Error; err != nil {
+		return 0, err
+	}
+	return len(ids), markRead(viewerID, ids)
+}
+
+// DeleteChannelMessage removes a message from a channel. Senders may remove their own; admins and
+// moderators any.
+func (c *ChannelController) DeleteChannelMessage(viewerID, channelID, messageID uint) error {
+	var msg model.Message
+	if err := database.DB.Where("id = ? AND channel_id = ?", messageID, channelID).First(&msg).Error; err != nil {
+		return errors.New("message not found")
+	}
+	if msg.SenderID != viewerID {
+		if _, err := channelAdmin(viewerID, channelID); err != nil {
+			return err
+		}
+	} else if _, _, err := channelMembership(viewerID, channelID); err != nil {
+		return err
+	}
+
+	if err := database.DB.Delete(&msg).Error; err != nil {
+		return err
+	}
+	memberIDs, err := channelMemberIDs(database.DB, channelID)
+	if err != nil {
+		return err
+	}
+	hub.publish(model.RealtimeDeleted, memberIDs, model.MessageDeletedPayload{MessageID: msg.ID, ChannelID: channelID})
+	return nil
+}
@@ -0,0 +1,312 @@
+package controller
+
+import (
+	"context"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// missedFollowUpGrace is how long after a follow-up was due it counts as missed
+const missedFollowUpGrace = 24 * time.Hour
+
+// selfHarmLookback is how far back a risk alert flags a defaulter
+const selfHarmLookback = 180 * 24 * time.Hour
+
+// attendedStatuses are the session statuses that mean the patient came
+var attendedStatuses = []string{model.SessionCheckedIn, model.SessionOngoing, model.SessionCompleted}
+
+type DefaulterController struct{}
+
+func NewDefaulterController() interfaces.DefaulterInterface {
+	return &DefaulterController{}
+}
+
+// StartMissedFollowUpJob runs DetectMissedFollowUps now and then every interval until ctx is
+// cancelled
+func StartMissedFollowUpJob(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if found, err := detectMissedFollowUps(time.Now()); err != nil {
+				log.Printf("❌ Failed to detect missed follow-ups: %v", err)
+			} else if found > 0 {
+				log.Printf("📋 Marked %d missed follow-ups", found)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// recordMissedFollowUp opens a missed follow-up unless the same one is already recorded
+func recordMissedFollowUp(tx *gorm.DB, patientID uint, source string, sourceID uint, dueAt time.Time) error {
+	missed := model.MissedFollowUp{Source: source, SourceID: sourceID}
+	return tx.Where(missed).Attrs(model.MissedFollowUp{PatientID: patientID, DueAt: dueAt}).FirstOrCreate(&missed).Error
+}
+
+// detectMissedFollowUps marks booked appointments never checked in as missed, scheduled sessions
+// never attended as no_show, and notes patients who did not come back by a session's
+// NextSessionDate, each a day after it was due. Open missed follow-ups of patients who have since
+// attended are resolved. It returns how many new missed follow-ups were found.
+func detectMissedFollowUps(now time.Time) (int, error) {
+	cutoff := now.Add(-missedFollowUpGrace)
+	found := 0
+
+	var appointments []model.Appointment
+	if err := database.DB.Where("status = ? AND ends_at < ?", model.AppointmentBooked, cutoff).Find(&appointments).Error; err != nil {
+		return found, err
+	}
+	for _, appt := range appointments {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&appt).Where("status = ?", model.AppointmentBooked).Updates(map[string]interface{}{
+				"status":            model.AppointmentMissed,
+				"status_reason":     "Not checked in",
+				"status_changed_at": now,
+			})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			return recordMissedFollowUp(tx, appt.PatientID, model.MissedSourceAppointment, appt.ID, appt.StartsAt)
+		})
+		if err != nil {
+			return found, err
+		}
+		found++
+	}
+
+	var scheduled []model.Session
+	if err := database.DB.Where("status = ? AND date < ?", model.SessionScheduled, cutoff).Find(&scheduled).Error; err != nil {
+		return found, err
+	}
+	for _, s := range scheduled {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if _, err := transitionSession(tx, s.ID, model.SessionNoShow, 0, "Not attended"); err != nil {
+				return err
+			}
+			return recordMissedFollowUp(tx, s.PatientID, model.MissedSourceSession, s.ID, s.Date)
+		})
+		if errors.Is(err, ErrSessionTransition) {
+			log.Printf("⚠️ Could not mark session %d as no-show: %v", s.ID, err)
+			continue
+		}
+		if err != nil {
+			return found, err
+		}
+		found++
+	}
+
+	// A follow-up date is missed when the patient has not been seen since the session that set it,
+	// and no appointment was booked for it (a booked one is caught above when it is missed)
+	var overdue []model.Session
+	if err := database.DB.
+		Where("next_session_date < ? AND status IN ?", cutoff, attendedStatuses).
+		Where("patient_id IN (SELECT id FROM patients WHERE is_active AND deleted_at IS NULL)").
+		Where("NOT EXISTS (SELECT 1 FROM sessions later WHERE later.patient_id = sessions.patient_id AND later.date > sessions.date AND later.status IN ? AND later.deleted_at IS NULL)", attendedStatuses).
+		Where("NOT EXISTS (SELECT 1 FROM appointments a WHERE a.previous_session_id = sessions.id AND a.status IN ? AND a.deleted_at IS NULL)",
+			[]string{model.AppointmentBooked, model.AppointmentCheckedIn, model.AppointmentMissed}).
+		Where("NOT EXISTS (SELECT 1 FROM missed_follow_ups m WHERE m.source = ? AND m.source_id = sessions.id)", model.MissedSourceNextSession).
+		Find(&overdue).Error; err != nil {
+		return found, err
+	}
+	for _, s := range overdue {
+		if err := recordMissedFollowUp(database.DB, s.PatientID, model.MissedSourceNextSession, s.ID, *s.NextSessionDate); err != nil {
+			return found, err
+		}
+		found++
+	}
+
+	// Patients who came back are no longer defaulting
+	if err := database.DB.Model(&model.MissedFollowUp{}).
+		Where("resolved_at IS NULL").
+		Where("EXISTS (SELECT 1 FROM sessions s WHERE s.patient_id = missed_follow_ups.patient_id AND s.date > missed_follow_ups.due_at AND s.status IN ? AND s.deleted_at IS NULL)", attendedStatuses).
+		Updates(map[string]interface{}{"resolved_at": now, "outcome": model.OutreachReturned}).Error; err != nil {
+		return found, err
+	}
+	return found, nil
+}
+
+// DetectMissedFollowUps runs the missed follow-up job now rather than waiting for its next run
+func (c *DefaulterController) DetectMissedFollowUps() (int, error) {
+	return detectMissedFollowUps(time.Now())
+}
+
+// attendanceOf computes the attendance of each patient
+func attendanceOf(patientIDs []uint) (map[uint]model.Attendance, error) {
+	type count struct {
+		PatientID uint
+		N         int64
+	}
+	var attended, missed []count
+	if err := database.DB.Model(&model.Session{}).Select("patient_id, COUNT(*) AS n").
+		Where("patient_id IN ? AND status IN ?", patientIDs, attendedStatuses).
+		Group("patient_id").Scan(&attended).Error; err != nil {
+		return nil, err
+	}
+	if err := database.DB.Model(&model.MissedFollowUp{}).Select("patient_id, COUNT(*) AS n").
+		Where("patient_id IN ?", patientIDs).
+		Group("patient_id").Scan(&missed).Error; err != nil {
+		return nil, err
+	}
+
+	attendedBy := make(map[uint]int64, len(attended))
+	for _, a := range attended {
+		attendedBy[a.PatientID] = a.N
+	}
+	missedBy := make(map[uint]int64, len(missed))
+	for _, m := range missed {
+		missedBy[m.PatientID] = m.N
+	}
+	result := make(map[uint]model.Attendance, len(patientIDs))
+	for _, id := range patientIDs {
+		result[id] = model.NewAttendance(id, attendedBy[id], missedBy[id])
+	}
+	return result, nil
+}
+
+// GetPatientAttendance returns how reliably a patient attends
+func (c *DefaulterController) GetPatientAttendance(viewerID, patientID uint) (*model.Attendance, error) {
+	if err := ensurePatientAccess(viewerID, patientID); err != nil {
+		return nil, err
+	}
+	attendance, err := attendanceOf([]uint{patientID})
+	if err != nil {
+		return nil, err
+	}
+	a := attendance[patientID]
+	return &a, nil
+}
+
+// GetDefaulterWorklist lists the department's patients with open missed follow-ups, highest
+// priority first, for community outreach. departmentID 0 means the viewer's own department.
+func (c *DefaulterController) GetDefaulterWorklist(viewerID, departmentID uint) ([]model.Defaulter, error) {
+	if departmentID == 0 {
+		var viewer model.HealthWorker
+		if err := database.DB.Select("id", "department_id").First(&viewer, viewerID).Error; err != nil {
+			return nil, err
+		}
+		departmentID = viewer.DepartmentID
+	}
+
+	var open []model.MissedFollowUp
+	if err := database.DB.Preload("Patient").
+		Scopes(accessiblePatients(viewerID, "patient_id")).
+		Where("resolved_at IS NULL").
+		Where("patient_id IN (SELECT id FROM patients WHERE department_id = ? AND deleted_at IS NULL)", departmentID).
+		Order("due_at").Find(&open).Error; err != nil {
+		return nil, err
+	}
+	if len(open) == 0 {
+		return []model.Defaulter{}, nil
+	}
+
+	byPatient := map[uint]*model.Defaulter{}
+	var patientIDs []uint
+	for _, m := range open {
+		d, ok := byPatient[m.PatientID]
+		if !ok {
+			d = &model.Defaulter{PatientID: m.PatientID}
+			if m.Patient != nil {
+				d.PatientCode, d.FirstName, d.LastName = m.Patient.PatientCode, m.Patient.FirstName, m.Patient.LastName
+				d.Contact, d.Address = m.Patient.Contact, m.Patient.Address
+			}
+			byPatient[m.PatientID] = d
+			patientIDs = append(patientIDs, m.PatientID)
+		}
+		m.Patient = nil
+		d.MissedFollowUp = append(d.MissedFollowUp, m)
+	}
+
+	// The latest PHQ-9 of each patient
+	var latest []struct {
+		PatientID uint
+		Phq9Score int
+		Severity  string
+	}
+	if err := database.DB.Raw(`SELECT DISTINCT ON (s.patient_id) s.patient_id, d.phq9_score, d.severity
+		FROM diagnoses d JOIN sessions s ON s.id = d.session_id
+		WHERE s.patient_id IN ? AND d.deleted_at IS NULL AND s.deleted_at IS NULL
+		ORDER BY s.patient_id, s.date DESC`, patientIDs).Scan(&latest).Error; err != nil {
+		return nil, err
+	}
+	for _, l := range latest {
+		score := l.Phq9Score
+		byPatient[l.PatientID].LastPhq9Score = &score
+		byPatient[l.PatientID].LastSeverity = l.Severity
+	}
+
+	var flagged []uint
+	if err := database.DB.Model(&model.RiskAlert{}).
+		Where("patient_id IN ? AND created_at > ?", patientIDs, time.Now().Add(-selfHarmLookback)).
+		Distinct().Pluck("patient_id", &flagged).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range flagged {
+		byPatient[id].SelfHarmFlag = true
+	}
+
+	attendance, err := attendanceOf(patientIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	worklist := make([]model.Defaulter, 0, len(patientIDs))
+	for _, id := range patientIDs {
+		d := byPatient[id]
+		d.Attendance = attendance[id]
+		d.DaysOverdue = int(now.Sub(d.MissedFollowUp[0].DueAt).Hours() / 24)
+		d.Priority = model.DefaulterPriority(d.LastSeverity, d.SelfHarmFlag, d.DaysOverdue, d.Attendance.Rate)
+		worklist = append(worklist, *d)
+	}
+	sort.SliceStable(worklist, func(a, b int) bool { return worklist[a].Priority > worklist[b].Priority })
+	return worklist, nil
+}
+
+// ResolveMissedFollowUp records the outcome of following up a missed appointment
+func (c *DefaulterController) ResolveMissedFollowUp(actorID, id uint, outcome, notes string) (*model.MissedFollowUp, error) {
+	outcome = strings.ToLower(strings.TrimSpace(outcome))
+	valid := false
+	for _, o := range model.OutreachOutcomes {
+		valid = valid || o == outcome
+	}
+	if !valid {
+		return nil, fmt.Errorf("outcome must be one of %s", strings.Join(model.OutreachOutcomes, ", "))
+	}
+
+	var missed model.MissedFollowUp
+	if err := database.DB.First(&missed, id).Error; err != nil {
+		return nil, errors.New("missed follow-up not found")
+	}
+	if err := ensurePatientAccess(actorID, missed.PatientID); err != nil {
+		return nil, err
+	}
+	if missed.ResolvedAt != nil {
+		return nil, errors.New("missed follow-up is already resolved")
+	}
+
+	now := time.Now()
+	missed.ResolvedAt, missed.ResolvedByID, missed.Outcome, missed.Notes = &now, &actorID, outcome, strings.TrimSpace(notes)
+	if err := database.DB.Model(&missed).Updates(map[string]interface{}{
+		"resolved_at":    now,
+		"resolved_by_id": actorID,
+		"outcome":        outcome,
+		"notes":          missed.Notes,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &missed, nil
+}
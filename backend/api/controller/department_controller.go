@@ -67,6 +67,59 @@ func (c *DepartmentController) UpdateDepartment(id uint, updated *model.Departme
 	return &dept, nil
 }
 
+// SetOnCallPsychiatrist names the psychiatrist who receives the department's suicide-risk alerts.
+// A nil health worker ID clears the assignment.
+func (c *DepartmentController) SetOnCallPsychiatrist(id uint, healthWorkerID *uint) (*model.Department, error) {
+	var dept model.Department
+	if err := database.DB.First(&dept, id).Error; err != nil {
+		return nil, err
+	}
+
+	if healthWorkerID != nil {
+		var hw model.HealthWorker
+		if err := database.DB.Preload("PersonnelType").First(&hw, *healthWorkerID).Error; err != nil {
+			return nil, errors.New("invalid health worker ID")
+		}
+		if !strings.EqualFold(hw.PersonnelType.Name, "psychiatrist") {
+			return nil, errors.New("the on-call health worker must be a psychiatrist")
+		}
+		if !hw.IsActive {
+			return nil, errors.New("the on-call psychiatrist's account is inactive")
+		}
+	}
+
+	dept.OnCallPsychiatristID = healthWorkerID
+	if err := database.DB.Model(&dept).Update("on_call_psychiatrist_id", healthWorkerID).Error; err != nil {
+		return nil, err
+	}
+	return &dept, nil
+}
+
+// SetDefaultNoteTemplate picks the note template session summaries of the department's patients
+// are written on by default. Pass nil to clear it.
+func (c *DepartmentController) SetDefaultNoteTemplate(id uint, templateID *uint) (*model.Department, error) {
+	var dept model.Department
+	if err := database.DB.First(&dept, id).Error; err != nil {
+		return nil, err
+	}
+
+	if templateID != nil {
+		var template model.NoteTemplate
+		if err := database.DB.First(&template, *templateID).Error; err != nil {
+			return nil, errors.New("invalid note template ID")
+		}
+		if !template.IsActive {
+			return nil, errors.New("the note template is no longer in use")
+		}
+	}
+
+	dept.DefaultNoteTemplateID = templateID
+	if err := database.DB.Model(&dept).Update("default_note_template_id", templateID).Error; err != nil {
+		return nil, err
+	}
+	return &dept, nil
+}
+
 // DeleteDepartment deletes a department by ID
 func (c *DepartmentController) DeleteDepartment(id uint) error {
 	var dept model.Department
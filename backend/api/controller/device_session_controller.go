@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/middleware"
+	"depression-diagnosis-system/api/util"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// refreshTokenTTL is how long a refresh token can be used; every rotation starts a new window
+const refreshTokenTTL = 7 * 24 * time.Hour
+
+var (
+	errInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	errRefreshTokenReuse   = errors.New("refresh token reuse detected; the session has been revoked")
+)
+
+type DeviceSessionController struct{}
+
+func NewDeviceSessionController() interfaces.DeviceSessionInterface {
+	return &DeviceSessionController{}
+}
+
+// StartSession opens a device session after a successful login and issues the first token pair
+func (c *DeviceSessionController) StartSession(hw *model.HealthWorker, deviceName, userAgent, ipAddress string) (*model.TokenPair, error) {
+	session := model.DeviceSession{
+		HealthWorkerID: hw.ID,
+		DeviceName:     deviceName,
+		UserAgent:      userAgent,
+		IPAddress:      ipAddress,
+		LastUsedAt:     time.Now(),
+	}
+
+	var pair *model.TokenPair
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		var err error
+		pair, err = issueTokenPair(tx, hw, &session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Refresh rotates a refresh token. Presenting an already rotated token revokes the whole session.
+func (c *DeviceSessionController) Refresh(refreshToken, userAgent, ipAddress string) (*model.TokenPair, error) {
+	var pair *model.TokenPair
+	var reused *model.DeviceSession
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var current model.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", util.HashToken(refreshToken)).
+			First(&current).Error; err != nil {
+			return errInvalidRefreshToken
+		}
+
+		var session model.DeviceSession
+		if err := tx.First(&session, current.DeviceSessionID).Error; err != nil || session.RevokedAt != nil {
+			return errInvalidRefreshToken
+		}
+
+		now := time.Now()
+		if current.UsedAt != nil {
+			reused = &session
+			return revokeSession(tx, &session, "refresh token reuse detected")
+		}
+		if now.After(current.ExpiresAt) {
+			return errInvalidRefreshToken
+		}
+
+		var hw model.HealthWorker
+		if err := tx.First(&hw, session.HealthWorkerID).Error; err != nil {
+			return errInvalidRefreshToken
+		}
+		if !hw.IsActive {
+			return errors.New("account is deactivated")
+		}
+
+		current.UsedAt = &now
+		if err := tx.Save(&current).Error; err != nil {
+			return err
+		}
+
+		session.LastUsedAt = now
+		session.UserAgent = userAgent
+		session.IPAddress = ipAddress
+		if err := tx.Save(&session).Error; err != nil {
+			return err
+		}
+
+		var err error
+		pair, err = issueTokenPair(tx, &hw, &session)
+		return err
+	})
+	if reused != nil && err == nil {
+		disconnectRealtime(reused.HealthWorkerID, reused.ID)
+		return nil, errRefreshTokenReuse
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// GetActiveSessions lists the health worker's device sessions that have not been revoked
+func (c *DeviceSessionController) GetActiveSessions(healthWorkerID uint) ([]model.DeviceSession, error) {
+	var sessions []model.DeviceSession
+	if err := database.DB.
+		Where("health_worker_id = ? AND revoked_at IS NULL", healthWorkerID).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs a single device out; its access and refresh tokens stop working immediately
+func (c *DeviceSessionController) RevokeSession(healthWorkerID, sessionID uint, reason string) error {
+	var session model.DeviceSession
+	if err := database.DB.Where("health_worker_id = ?", healthWorkerID).First(&session, sessionID).Error; err != nil {
+		return errors.New("session not found")
+	}
+	if session.RevokedAt != nil {
+		return nil
+	}
+	if err := revokeSession(database.DB, &session, reason); err != nil {
+		return err
+	}
+	disconnectRealtime(healthWorkerID, session.ID)
+	return nil
+}
+
+// RevokeAllSessions signs every device of the health worker out
+func (c *DeviceSessionController) RevokeAllSessions(healthWorkerID uint, reason string) error {
+	if err := revokeAllSessions(database.DB, healthWorkerID, reason); err != nil {
+		return err
+	}
+	disconnectRealtime(healthWorkerID, 0)
+	return nil
+}
+
+// issueTokenPair creates a new refresh token in the session's family and a matching access token
+func issueTokenPair(tx *gorm.DB, hw *model.HealthWorker, session *model.DeviceSession) (*model.TokenPair, error) {
+	rawRefresh, err := util.GenerateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh := model.RefreshToken{
+		DeviceSessionID: session.ID,
+		TokenHash:       util.HashToken(rawRefresh),
+		ExpiresAt:       time.Now().Add(refreshTokenTTL),
+	}
+	if err := tx.Create(&refresh).Error; err != nil {
+		return nil, err
+	}
+
+	accessToken, accessExpiresAt, err := middleware.GenerateToken(hw.ID, hw.Email, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     rawRefresh,
+		RefreshExpiresAt: refresh.ExpiresAt,
+		Session:          *session,
+	}, nil
+}
+
+func revokeAllSessions(tx *gorm.DB, healthWorkerID uint, reason string) error {
+	now := time.Now()
+	return tx.Model(&model.DeviceSession{}).
+		Where("health_worker_id = ? AND revoked_at IS NULL", healthWorkerID).
+		Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": reason}).Error
+}
+
+func revokeSession(tx *gorm.DB, session *model.DeviceSession, reason string) error {
+	now := time.Now()
+	session.RevokedAt = &now
+	session.RevokedReason = reason
+	return tx.Save(session).Error
+}
@@ -1,10 +1,14 @@
 package controller
 
 import (
+	"context"
 	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/util"
 	"depression-diagnosis-system/database"
 	"depression-diagnosis-system/database/model"
 	"errors"
+
+	"gorm.io/gorm"
 )
 
 type DiagnosisController struct{}
@@ -13,36 +17,76 @@ func NewDiagnosisController() interfaces.DiagnosisInterface {
 	return &DiagnosisController{}
 }
 
-// CreateDiagnosis creates a new diagnosis linked to a session
-func (c *DiagnosisController) CreateDiagnosis(diagnosis *model.Diagnosis) (*model.Diagnosis, error) {
+// deriveDiagnosis reads the session's scored PHQ-9 response and creates or refreshes its Diagnosis
+func deriveDiagnosis(tx *gorm.DB, sessionID uint) (*model.Diagnosis, error) {
+	var response model.InstrumentResponse
+	err := tx.Where("session_id = ? AND instrument_id IN (SELECT id FROM instruments WHERE code = ?)", sessionID, model.InstrumentPHQ9).
+		First(&response).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("no PHQ-9 responses recorded for this session")
+		}
+		return nil, err
+	}
+
+	var diagnosis model.Diagnosis
+	if err := tx.Where("session_id = ?", sessionID).First(&diagnosis).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	diagnosis.SessionID = sessionID
+	diagnosis.Phq9Score = response.TotalScore
+	diagnosis.Severity = util.DetermineSeverity(response.TotalScore)
+
+	if err := tx.Save(&diagnosis).Error; err != nil {
+		return nil, err
+	}
+	return &diagnosis, nil
+}
+
+// CreateDiagnosis scores the session's stored PHQ-9 responses and records the resulting diagnosis.
+// Calling it again re-scores the session rather than adding a second diagnosis.
+func (c *DiagnosisController) CreateDiagnosis(ctx context.Context, viewerID uint, diagnosis *model.Diagnosis) (*model.Diagnosis, error) {
+	db := database.DB.WithContext(ctx)
+
 	// Check if session exists
 	var session model.Session
-	if err := database.DB.First(&session, diagnosis.SessionID).Error; err != nil {
+	if err := db.First(&session, diagnosis.SessionID).Error; err != nil {
 		return nil, errors.New("session not found")
 	}
-
-	// Validate severity (optional, add your own validation logic if needed)
-	if diagnosis.Severity == "" {
-		return nil, errors.New("severity cannot be empty")
+	if err := ensurePatientAccess(viewerID, session.PatientID); err != nil {
+		return nil, err
 	}
 
-	if err := database.DB.Create(diagnosis).Error; err != nil {
+	var derived *model.Diagnosis
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		derived, err = deriveDiagnosis(tx, diagnosis.SessionID)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
-	return diagnosis, nil
+	return derived, nil
 }
 
 // GetDiagnosisByID fetches a diagnosis by its ID
-func (c *DiagnosisController) GetDiagnosisByID(id uint) (*model.Diagnosis, error) {
+func (c *DiagnosisController) GetDiagnosisByID(viewerID, id uint) (*model.Diagnosis, error) {
 	var diagnosis model.Diagnosis
 	if err := database.DB.Preload("Session").First(&diagnosis, id).Error; err != nil {
 		return nil, err
 	}
+	if err := ensureSessionAccess(viewerID, diagnosis.SessionID); err != nil {
+		return nil, err
+	}
 	return &diagnosis, nil
 }
 
 // GetDiagnosisBySessionID fetches diagnosis for a given session
-func (c *DiagnosisController) GetDiagnosisBySessionID(sessionID uint) (*model.Diagnosis, error) {
+func (c *DiagnosisController) GetDiagnosisBySessionID(viewerID, sessionID uint) (*model.Diagnosis, error) {
+	if err := ensureSessionAccess(viewerID, sessionID); err != nil {
+		return nil, err
+	}
+
 	var diagnosis model.Diagnosis
 	if err := database.DB.Preload("Session").Where("session_id = ?", sessionID).First(&diagnosis).Error; err != nil {
 		return nil, err
@@ -50,31 +94,38 @@ func (c *DiagnosisController) GetDiagnosisBySessionID(sessionID uint) (*model.Di
 	return &diagnosis, nil
 }
 
-// UpdateDiagnosis updates an existing diagnosis
-func (c *DiagnosisController) UpdateDiagnosis(id uint, updated *model.Diagnosis) (*model.Diagnosis, error) {
+// UpdateDiagnosis re-scores an existing diagnosis from its session's stored PHQ-9 response. The
+// score always follows the response and cannot be set by hand.
+func (c *DiagnosisController) UpdateDiagnosis(ctx context.Context, viewerID, id uint) (*model.Diagnosis, error) {
+	db := database.DB.WithContext(ctx)
+
 	var diagnosis model.Diagnosis
-	if err := database.DB.First(&diagnosis, id).Error; err != nil {
+	if err := db.First(&diagnosis, id).Error; err != nil {
 		return nil, err
 	}
-
-	if updated.Phq9Score != 0 {
-		diagnosis.Phq9Score = updated.Phq9Score
-	}
-	if updated.Severity != "" {
-		diagnosis.Severity = updated.Severity
+	if err := ensureSessionAccess(viewerID, diagnosis.SessionID); err != nil {
+		return nil, err
 	}
 
-	if err := database.DB.Save(&diagnosis).Error; err != nil {
+	var derived *model.Diagnosis
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		derived, err = deriveDiagnosis(tx, diagnosis.SessionID)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
-	return &diagnosis, nil
+	return derived, nil
 }
 
 // DeleteDiagnosis deletes diagnosis by ID
-func (c *DiagnosisController) DeleteDiagnosis(id uint) error {
+func (c *DiagnosisController) DeleteDiagnosis(ctx context.Context, id uint) error {
+	db := database.DB.WithContext(ctx)
+
 	var diagnosis model.Diagnosis
-	if err := database.DB.First(&diagnosis, id).Error; err != nil {
+	if err := db.First(&diagnosis, id).Error; err != nil {
 		return err
 	}
-	return database.DB.Delete(&diagnosis).Error
+	return db.Delete(&diagnosis).Error
 }
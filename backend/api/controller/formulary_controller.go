@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type FormularyController struct{}
+
+func NewFormularyController() interfaces.FormularyInterface {
+	return &FormularyController{}
+}
+
+// ensureUniqueDrugName rejects a name another formulary entry already uses
+func ensureUniqueDrugName(name string, exceptID uint) error {
+	var count int64
+	if err := database.DB.Model(&model.Drug{}).
+		Where("LOWER(name) = ? AND id <> ?", strings.ToLower(strings.TrimSpace(name)), exceptID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%s is already in the formulary", name)
+	}
+	return nil
+}
+
+// GetDrugs lists the formulary, optionally filtered by a search on name, generic name or ATC code.
+// Withdrawn drugs are only listed when includeInactive is set.
+func (c *FormularyController) GetDrugs(query string, includeInactive bool) ([]model.Drug, error) {
+	var drugs []model.Drug
+	dbQuery := database.DB.Order("name")
+	if query = strings.TrimSpace(query); query != "" {
+		dbQuery = dbQuery.Where("name ILIKE ? OR generic_name ILIKE ? OR atc_code ILIKE ?", "%"+query+"%", "%"+query+"%", query+"%")
+	}
+	if !includeInactive {
+		dbQuery = dbQuery.Where("is_active")
+	}
+	if err := dbQuery.Find(&drugs).Error; err != nil {
+		return nil, err
+	}
+	return drugs, nil
+}
+
+// GetDrugByID fetches a formulary entry
+func (c *FormularyController) GetDrugByID(id uint) (*model.Drug, error) {
+	var drug model.Drug
+	if err := database.DB.First(&drug, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("drug not found")
+		}
+		return nil, err
+	}
+	return &drug, nil
+}
+
+// CreateDrug adds a drug to the formulary
+func (c *FormularyController) CreateDrug(drug *model.Drug) (*model.Drug, error) {
+	drug.ID = 0
+	if err := drug.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ensureUniqueDrugName(drug.Name, 0); err != nil {
+		return nil, err
+	}
+
+	drug.IsActive = true
+	if err := database.DB.Create(drug).Error; err != nil {
+		return nil, err
+	}
+	return drug, nil
+}
+
+// UpdateDrug changes a formulary entry. Existing prescription lines keep the strength and form
+// they were written with.
+func (c *FormularyController) UpdateDrug(id uint, updated *model.Drug) (*model.Drug, error) {
+	drug, err := c.GetDrugByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if updated.Name != "" {
+		drug.Name = updated.Name
+	}
+	if updated.GenericName != "" {
+		drug.GenericName = updated.GenericName
+	}
+	if updated.ATCCode != "" {
+		drug.ATCCode = updated.ATCCode
+	}
+	if updated.ATCClass != "" {
+		drug.ATCClass = updated.ATCClass
+	}
+	if len(updated.Strengths) > 0 {
+		drug.Strengths = updated.Strengths
+	}
+	if len(updated.Forms) > 0 {
+		drug.Forms = updated.Forms
+	}
+	if err := drug.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ensureUniqueDrugName(drug.Name, drug.ID); err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Save(drug).Error; err != nil {
+		return nil, err
+	}
+	return drug, nil
+}
+
+// SetActiveStatus withdraws or restores a drug. Withdrawn drugs stay on existing prescriptions but
+// cannot be prescribed.
+func (c *FormularyController) SetActiveStatus(id uint, active bool) error {
+	var drug model.Drug
+	if err := database.DB.First(&drug, id).Error; err != nil {
+		return err
+	}
+	return database.DB.Model(&drug).Update("is_active", active).Error
+}
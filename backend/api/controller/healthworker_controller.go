@@ -9,6 +9,18 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// passwordResetTTL is how long an admin-issued reset token stays valid
+const passwordResetTTL = 24 * time.Hour
+
+var (
+	errInvalidCredentials = errors.New("invalid credentials")
+	errInvalidResetToken  = errors.New("invalid or expired reset token")
+	errWeakPassword       = errors.New("password must be at least 8 characters and include upper and lower case letters, a digit and a special character")
 )
 
 type HealthWorkerController struct{}
@@ -17,12 +29,17 @@ func NewHealthWorkerController() interfaces.HealthWorkerInterface {
 	return &HealthWorkerController{}
 }
 
-// CreateHealthWorker registers a new health worker (admin or not)
-func (c *HealthWorkerController) CreateHealthWorker(hw *model.HealthWorker) (*model.HealthWorker, error) {
+// CreateHealthWorker registers a new health worker (admin or not) with an initial password
+// that must be rotated on first login
+func (c *HealthWorkerController) CreateHealthWorker(hw *model.HealthWorker, password string) (*model.HealthWorker, error) {
 	if !util.IsValidEmail(hw.Email) {
 		return nil, errors.New("invalid email format")
 	}
 
+	if !util.IsValidPassword(password) {
+		return nil, errWeakPassword
+	}
+
 	var exists model.HealthWorker
 	if err := database.DB.Where("email = ?", hw.Email).First(&exists).Error; err == nil {
 		return nil, errors.New("a health worker with this email already exists")
@@ -40,6 +57,13 @@ func (c *HealthWorkerController) CreateHealthWorker(hw *model.HealthWorker) (*mo
 		hw.Role = model.RoleHealthWorker
 	}
 
+	hashed, err := util.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	hw.PasswordHash = hashed
+	hw.MustChangePassword = true
+
 	if err := database.DB.Create(hw).Error; err != nil {
 		return nil, err
 	}
@@ -117,7 +141,11 @@ func (c *HealthWorkerController) DeleteHealthWorker(id uint) error {
 	if err := database.DB.First(&hw, id).Error; err != nil {
 		return err
 	}
-	return database.DB.Delete(&hw).Error
+	if err := database.DB.Delete(&hw).Error; err != nil {
+		return err
+	}
+	disconnectRealtime(hw.ID, 0)
+	return nil
 }
 
 // GetHealthWorkersByDepartment retrieves all workers in a specific department
@@ -157,31 +185,36 @@ func (c *HealthWorkerController) SetActiveStatus(id uint, active bool) (*model.H
     if err := database.DB.Save(&hw).Error; err != nil {
         return nil, err
     }
+    // Their access tokens stop working at once; close the connections opened with them too
+    if !active {
+        disconnectRealtime(hw.ID, 0)
+    }
 
     return &hw, nil
 }
 
 
-// Login authenticates a health worker using email or employee ID and returns a token
+// Login authenticates a health worker using email or employee ID and verifies the password
 func (c *HealthWorkerController) Login(identifier string, password string) (*model.HealthWorker, error) {
 	var hw model.HealthWorker
 
 	query := database.DB.
 	Preload("Department").
 	Preload("PersonnelType").
-	Preload("Supervisor").
-	Where("email = ?", identifier)
+	Preload("Supervisor")
 	if strings.Contains(identifier, "-EMP-") {
-		query = database.DB.Where("employee_id = ?", identifier)
+		query = query.Where("employee_id = ?", identifier)
+	} else {
+		query = query.Where("email = ?", identifier)
 	}
 	if err := query.First(&hw).Error; err != nil {
-		return nil, errors.New("invalid email or employee ID")
+		return nil, errInvalidCredentials
 	}
 
-	if err := query.First(&hw).Error; err != nil {
-		return nil, errors.New("invalid email or employee ID")
+	if hw.PasswordHash == "" || !util.CheckPassword(hw.PasswordHash, password) {
+		return nil, errInvalidCredentials
 	}
-	
+
 	if !hw.IsActive {
 		return nil, errors.New("account is deactivated")
 	}
@@ -189,7 +222,128 @@ func (c *HealthWorkerController) Login(identifier string, password string) (*mod
 	return &hw, nil
 }
 
-// Logout blacklists the token, preventing further use
+// ChangePassword lets a health worker replace their own password after confirming the current one
+func (c *HealthWorkerController) ChangePassword(id uint, currentPassword, newPassword string) error {
+	var hw model.HealthWorker
+	if err := database.DB.First(&hw, id).Error; err != nil {
+		return err
+	}
+
+	if !util.CheckPassword(hw.PasswordHash, currentPassword) {
+		return errors.New("current password is incorrect")
+	}
+	if currentPassword == newPassword {
+		return errors.New("new password must differ from the current password")
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := setPassword(tx, &hw, newPassword); err != nil {
+			return err
+		}
+		return revokeAllSessions(tx, hw.ID, "password changed")
+	}); err != nil {
+		return err
+	}
+	return revokeAllTokens(hw.ID)
+}
+
+// IssuePasswordReset creates a one-time reset token for a health worker and returns it in plain text.
+// Any earlier unused tokens for the same worker are invalidated.
+func (c *HealthWorkerController) IssuePasswordReset(id uint, issuedByID uint) (string, error) {
+	var hw model.HealthWorker
+	if err := database.DB.First(&hw, id).Error; err != nil {
+		return "", err
+	}
+
+	token, err := util.GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if err := database.DB.Model(&model.PasswordResetToken{}).
+		Where("health_worker_id = ? AND used_at IS NULL", hw.ID).
+		Update("used_at", now).Error; err != nil {
+		return "", err
+	}
+
+	reset := model.PasswordResetToken{
+		HealthWorkerID: hw.ID,
+		TokenHash:      util.HashToken(token),
+		IssuedByID:     issuedByID,
+		ExpiresAt:      now.Add(passwordResetTTL),
+	}
+	if err := database.DB.Create(&reset).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ResetPassword consumes a one-time reset token and sets the new password. The token is claimed
+// with a single conditional update so it cannot be used twice, even by concurrent requests.
+func (c *HealthWorkerController) ResetPassword(token string, newPassword string) error {
+	tokenHash := util.HashToken(token)
+
+	var hw model.HealthWorker
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		claimed := tx.Model(&model.PasswordResetToken{}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+			Update("used_at", now)
+		if claimed.Error != nil {
+			return claimed.Error
+		}
+		if claimed.RowsAffected == 0 {
+			return errInvalidResetToken
+		}
+
+		var reset model.PasswordResetToken
+		if err := tx.Where("token_hash = ?", tokenHash).First(&reset).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&hw, reset.HealthWorkerID).Error; err != nil {
+			return err
+		}
+		if err := setPassword(tx, &hw, newPassword); err != nil {
+			return err
+		}
+		return revokeAllSessions(tx, hw.ID, "password reset")
+	}); err != nil {
+		return err
+	}
+	return revokeAllTokens(hw.ID)
+}
+
+// setPassword validates, hashes and stores a new password and clears any forced rotation
+func setPassword(tx *gorm.DB, hw *model.HealthWorker, newPassword string) error {
+	if !util.IsValidPassword(newPassword) {
+		return errWeakPassword
+	}
+
+	hashed, err := util.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	hw.PasswordHash = hashed
+	hw.MustChangePassword = false
+	hw.PasswordChangedAt = &now
+	return tx.Save(hw).Error
+}
+
+// revokeAllTokens signs the health worker out of every access token issued so far, after their
+// password changed, and closes their realtime connections
+func revokeAllTokens(healthWorkerID uint) error {
+	err := middleware.RevokeAllTokens(healthWorkerID)
+	disconnectRealtime(healthWorkerID, 0) // their device sessions are revoked either way
+	if err != nil {
+		return fmt.Errorf("password updated but existing tokens could not be revoked: %v", err)
+	}
+	return nil
+}
+
+// Logout revokes the token, preventing further use
 func (c *HealthWorkerController) Logout(token string) error {
 	if err := middleware.InvalidateToken(token); err != nil {
 		return fmt.Errorf("failed to log out: %v", err)
@@ -197,6 +351,16 @@ func (c *HealthWorkerController) Logout(token string) error {
 	return nil
 }
 
+// LogoutAllDevices revokes every token issued to the health worker so far and closes their
+// realtime connections
+func (c *HealthWorkerController) LogoutAllDevices(id uint) error {
+	if err := middleware.RevokeAllTokens(id); err != nil {
+		return fmt.Errorf("failed to log out all devices: %v", err)
+	}
+	disconnectRealtime(id, 0)
+	return nil
+}
+
 func (c *HealthWorkerController) SearchHealthWorkers(
     queryParams map[string]string,
 ) ([]model.HealthWorker, error) {
@@ -0,0 +1,743 @@
+package controller
+
+import (
+	"context"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InstrumentController struct{}
+
+func NewInstrumentController() interfaces.InstrumentInterface {
+	return &InstrumentController{}
+}
+
+// loadInstrument fetches an instrument with the full definition of its published version
+func loadInstrument(tx *gorm.DB, id uint) (*model.Instrument, error) {
+	var inst model.Instrument
+	if err := tx.First(&inst, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("instrument not found")
+		}
+		return nil, err
+	}
+	if err := attachCurrentVersion(tx, &inst); err != nil {
+		return nil, err
+	}
+	return &inst, nil
+}
+
+// loadInstrumentByCode fetches an instrument and its published version by code, e.g. "PHQ-9"
+func loadInstrumentByCode(tx *gorm.DB, code string) (*model.Instrument, error) {
+	var inst model.Instrument
+	if err := tx.Where("code = ?", code).First(&inst).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("instrument %s not found", code)
+		}
+		return nil, err
+	}
+	if err := attachCurrentVersion(tx, &inst); err != nil {
+		return nil, err
+	}
+	return &inst, nil
+}
+
+// attachCurrentVersion loads the instrument's published version, if it has one yet
+func attachCurrentVersion(tx *gorm.DB, inst *model.Instrument) error {
+	var version model.InstrumentVersion
+	err := tx.Scopes(model.WithInstrumentDefinition).
+		Where("instrument_id = ? AND status = ?", inst.ID, model.InstrumentVersionPublished).
+		First(&version).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	inst.CurrentVersion = &version
+	return nil
+}
+
+// loadVersion fetches an instrument version with its items, options and severity bands
+func loadVersion(tx *gorm.DB, id uint) (*model.InstrumentVersion, error) {
+	var version model.InstrumentVersion
+	if err := tx.Scopes(model.WithInstrumentDefinition).Preload("Instrument").First(&version, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("instrument version not found")
+		}
+		return nil, err
+	}
+	return &version, nil
+}
+
+// loadDraft fetches a version that can still be edited
+func loadDraft(tx *gorm.DB, id uint) (*model.InstrumentVersion, error) {
+	version, err := loadVersion(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if version.Status != model.InstrumentVersionDraft {
+		return nil, fmt.Errorf("version %d is %s and cannot be changed; start a new draft instead", version.Version, version.Status)
+	}
+	return version, nil
+}
+
+// resetDefinition clears the IDs and links of a submitted definition so it is stored as new rows.
+// An option belongs to the version or to one of its items.
+func resetDefinition(v *model.InstrumentVersion) {
+	v.ID = 0
+	for i := range v.Options {
+		v.Options[i].Model = gorm.Model{}
+		v.Options[i].InstrumentVersionID, v.Options[i].InstrumentItemID = nil, nil
+	}
+	for i := range v.Items {
+		v.Items[i].Model = gorm.Model{}
+		v.Items[i].InstrumentVersionID = 0
+		v.Items[i].Translations = nil // added and reviewed through the translation routes
+		for j := range v.Items[i].Options {
+			v.Items[i].Options[j].Model = gorm.Model{}
+			v.Items[i].Options[j].InstrumentVersionID, v.Items[i].Options[j].InstrumentItemID = nil, nil
+		}
+	}
+	for i := range v.SeverityBands {
+		v.SeverityBands[i].Model = gorm.Model{}
+		v.SeverityBands[i].InstrumentVersionID = 0
+	}
+}
+
+// startDraft adds the next version of an instrument as a draft. Without items the draft starts as
+// a copy of the published version, translations included. An instrument has at most one draft at a
+// time.
+func startDraft(tx *gorm.DB, inst *model.Instrument, draft *model.InstrumentVersion) error {
+	var drafts int64
+	if err := tx.Model(&model.InstrumentVersion{}).
+		Where("instrument_id = ? AND status = ?", inst.ID, model.InstrumentVersionDraft).
+		Count(&drafts).Error; err != nil {
+		return err
+	}
+	if drafts > 0 {
+		return fmt.Errorf("%s already has a draft version", inst.Code)
+	}
+
+	var source *model.InstrumentVersion
+	if len(draft.Items) == 0 && inst.CurrentVersion != nil {
+		source = inst.CurrentVersion
+		// Copy the slices so resetting the draft leaves the source intact
+		draft.Items = nil
+		for _, item := range source.Items {
+			item.Options = append([]model.InstrumentOption(nil), item.Options...)
+			draft.Items = append(draft.Items, item)
+		}
+		draft.Options = append([]model.InstrumentOption(nil), source.Options...)
+		draft.SeverityBands = append([]model.InstrumentSeverityBand(nil), source.SeverityBands...)
+		if draft.Instructions == "" {
+			draft.Instructions = source.Instructions
+		}
+	}
+	resetDefinition(draft)
+
+	// Deleted drafts keep their number
+	var last int
+	if err := tx.Unscoped().Model(&model.InstrumentVersion{}).
+		Where("instrument_id = ?", inst.ID).
+		Select("COALESCE(MAX(version), 0)").Scan(&last).Error; err != nil {
+		return err
+	}
+
+	draft.InstrumentID = inst.ID
+	draft.Version = last + 1
+	draft.Status = model.InstrumentVersionDraft
+	draft.PublishedAt, draft.PublishedByID = nil, nil
+	if err := tx.Omit("Instrument", "PublishedBy").Create(draft).Error; err != nil {
+		return err
+	}
+	if source != nil {
+		return copyTranslations(tx, source, draft)
+	}
+	return nil
+}
+
+// publishVersion validates a draft, makes it the version new responses are recorded against and
+// supersedes the version published before it. The instrument row is locked so concurrent publishes
+// of its versions run one after the other.
+func publishVersion(tx *gorm.DB, version *model.InstrumentVersion, publisherID uint) error {
+	if err := version.Validate(); err != nil {
+		return err
+	}
+	var inst model.Instrument
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&inst, version.InstrumentID).Error; err != nil {
+		return err
+	}
+	var current model.InstrumentVersion
+	if err := tx.Select("id", "version", "status").First(&current, version.ID).Error; err != nil {
+		return err
+	}
+	if current.Status != model.InstrumentVersionDraft {
+		return fmt.Errorf("version %d is already %s", current.Version, current.Status)
+	}
+
+	if err := tx.Model(&model.InstrumentVersion{}).
+		Where("instrument_id = ? AND status = ?", version.InstrumentID, model.InstrumentVersionPublished).
+		Update("status", model.InstrumentVersionSuperseded).Error; err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"status":       model.InstrumentVersionPublished,
+		"published_at": time.Now(),
+	}
+	if publisherID != 0 {
+		updates["published_by_id"] = publisherID
+	}
+	return tx.Model(version).Updates(updates).Error
+}
+
+// attachItems points each answer at the item it answers, so the item text is returned as the
+// patient saw it
+func attachItems(version *model.InstrumentVersion, answers []model.InstrumentAnswer) {
+	items := make(map[uint]*model.InstrumentItem, len(version.Items))
+	for idx := range version.Items {
+		items[version.Items[idx].ID] = &version.Items[idx]
+	}
+	for i := range answers {
+		answers[i].Item = items[answers[i].InstrumentItemID]
+	}
+}
+
+// cleanAnswers keeps only the item and score of submitted answers
+func cleanAnswers(answers []model.InstrumentAnswer) []model.InstrumentAnswer {
+	cleaned := make([]model.InstrumentAnswer, len(answers))
+	for i, a := range answers {
+		cleaned[i] = model.InstrumentAnswer{InstrumentItemID: a.InstrumentItemID, Score: a.Score}
+	}
+	return cleaned
+}
+
+// ensureNoResponse rejects a second administration of the same instrument in a session. It locks
+// the session so concurrent submissions are checked one after the other, and must run in the
+// transaction that stores the response.
+func ensureNoResponse(tx *gorm.DB, inst *model.Instrument, sessionID uint) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&model.Session{}, sessionID).Error; err != nil {
+		return errors.New("session not found")
+	}
+	var count int64
+	if err := tx.Model(&model.InstrumentResponse{}).
+		Where("session_id = ? AND instrument_id = ?", sessionID, inst.ID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%s responses already recorded for session %d", inst.Code, sessionID)
+	}
+	return nil
+}
+
+// applyInstrumentResponse refreshes the session's Diagnosis when the answers are PHQ-9 and raises
+// any risk alerts the answers trigger
+func applyInstrumentResponse(tx *gorm.DB, inst *model.Instrument, version *model.InstrumentVersion, response *model.InstrumentResponse, actorID uint) error {
+	if inst.Code == model.InstrumentPHQ9 {
+		diagnosis, err := deriveDiagnosis(tx, response.SessionID)
+		if err != nil {
+			return err
+		}
+		response.Diagnosis = diagnosis
+	}
+
+	alerts, err := raiseRiskAlerts(tx, inst, version, response, actorID)
+	if err != nil {
+		return err
+	}
+	response.RiskAlerts = alerts
+	return nil
+}
+
+// recordInstrumentResponse scores and stores a new set of answers for a session against the
+// instrument's published version, which the response stays pinned to
+func recordInstrumentResponse(db *gorm.DB, viewerID uint, inst *model.Instrument, response *model.InstrumentResponse) error {
+	if response.SessionID == 0 || len(response.Answers) == 0 {
+		return errors.New("sessionID and answers are required")
+	}
+	if !inst.IsActive {
+		return fmt.Errorf("%s is no longer in use", inst.Code)
+	}
+	version := inst.CurrentVersion
+	if version == nil {
+		return fmt.Errorf("%s has no published version", inst.Code)
+	}
+	response.Language = strings.ToLower(strings.TrimSpace(response.Language))
+	if response.Language == "" {
+		response.Language = model.LocaleEnglish
+	}
+	if !model.IsSupportedLocale(response.Language) {
+		return fmt.Errorf("unsupported language %q", response.Language)
+	}
+	if err := ensureSessionAccess(viewerID, response.SessionID); err != nil {
+		return err
+	}
+
+	// The response is rendered later with the translations approved by the time it was recorded
+	response.CreatedAt = time.Now()
+	language, err := shownLanguage(db, version.ID, response.Language, response.CreatedAt)
+	if err != nil {
+		return err
+	}
+	response.Language = language
+
+	answers := cleanAnswers(response.Answers)
+	total, severity, err := version.Score(answers)
+	if err != nil {
+		return fmt.Errorf("invalid response data: %w", err)
+	}
+
+	response.InstrumentID = inst.ID
+	response.InstrumentVersionID = version.ID
+	response.TotalScore = total
+	response.Severity = severity
+	response.Answers = answers
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureNoResponse(tx, inst, response.SessionID); err != nil {
+			return err
+		}
+		if err := tx.Create(response).Error; err != nil {
+			return err
+		}
+		return applyInstrumentResponse(tx, inst, version, response, viewerID)
+	})
+	if err != nil {
+		return err
+	}
+	attachItems(version, response.Answers)
+	return localizeResponse(db, response)
+}
+
+// changeInstrumentResponse replaces a response's answers and/or moves it to another session, then
+// re-scores it against the version it was first answered on. Pass nil answers to keep the current
+// ones and sessionID 0 to keep the session.
+func changeInstrumentResponse(db *gorm.DB, viewerID uint, response *model.InstrumentResponse, sessionID uint, answers []model.InstrumentAnswer) error {
+	if err := ensureSessionAccess(viewerID, response.SessionID); err != nil {
+		return err
+	}
+	inst, err := loadInstrument(db, response.InstrumentID)
+	if err != nil {
+		return err
+	}
+	version, err := loadVersion(db, response.InstrumentVersionID)
+	if err != nil {
+		return err
+	}
+
+	previousSessionID := response.SessionID
+	if sessionID != 0 && sessionID != previousSessionID {
+		if err := ensureSessionAccess(viewerID, sessionID); err != nil {
+			return err
+		}
+		response.SessionID = sessionID
+	}
+
+	if answers != nil {
+		answers = cleanAnswers(answers)
+		total, severity, err := version.Score(answers)
+		if err != nil {
+			return fmt.Errorf("invalid response data: %w", err)
+		}
+		response.TotalScore = total
+		response.Severity = severity
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if response.SessionID != previousSessionID {
+			if err := ensureNoResponse(tx, inst, response.SessionID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Omit("Answers").Save(response).Error; err != nil {
+			return err
+		}
+		if answers != nil {
+			if err := tx.Where("instrument_response_id = ?", response.ID).Delete(&model.InstrumentAnswer{}).Error; err != nil {
+				return err
+			}
+			for i := range answers {
+				answers[i].InstrumentResponseID = response.ID
+			}
+			if err := tx.Create(&answers).Error; err != nil {
+				return err
+			}
+			response.Answers = answers
+		}
+
+		if response.SessionID != previousSessionID {
+			// The old session no longer has answers to support its diagnosis
+			if inst.Code == model.InstrumentPHQ9 {
+				if err := tx.Where("session_id = ?", previousSessionID).Delete(&model.Diagnosis{}).Error; err != nil {
+					return err
+				}
+			}
+			// Alerts raised by these answers follow them, so they guard completing the right session
+			var session model.Session
+			if err := tx.Select("id", "patient_id").First(&session, response.SessionID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&model.RiskAlert{}).Where("instrument_response_id = ?", response.ID).
+				Updates(map[string]interface{}{"session_id": session.ID, "patient_id": session.PatientID}).Error; err != nil {
+				return err
+			}
+		}
+		return applyInstrumentResponse(tx, inst, version, response, viewerID)
+	})
+	if err != nil {
+		return err
+	}
+	attachItems(version, response.Answers)
+	return localizeResponse(db, response)
+}
+
+// removeInstrumentResponse deletes a response, its answers and, for PHQ-9, the diagnosis scored from it
+func removeInstrumentResponse(db *gorm.DB, viewerID uint, response *model.InstrumentResponse) error {
+	if err := ensureSessionAccess(viewerID, response.SessionID); err != nil {
+		return err
+	}
+	var inst model.Instrument
+	if err := db.First(&inst, response.InstrumentID).Error; err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if inst.Code == model.InstrumentPHQ9 {
+			if err := tx.Where("session_id = ?", response.SessionID).Delete(&model.Diagnosis{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("instrument_response_id = ?", response.ID).Delete(&model.InstrumentAnswer{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(response).Error
+	})
+}
+
+// localizeInstrument shows the items of the instrument's published version in locale
+func localizeInstrument(tx *gorm.DB, inst *model.Instrument, locale string) error {
+	if inst.CurrentVersion == nil {
+		return nil
+	}
+	return localizeItems(tx, locale, nil, versionItems(inst.CurrentVersion))
+}
+
+// GetAllInstruments lists every instrument with the definition of its published version, its
+// items in locale where an approved translation exists
+func (c *InstrumentController) GetAllInstruments(locale string) ([]model.Instrument, error) {
+	var instruments []model.Instrument
+	if err := database.DB.Order("code").Find(&instruments).Error; err != nil {
+		return nil, err
+	}
+	for i := range instruments {
+		if err := attachCurrentVersion(database.DB, &instruments[i]); err != nil {
+			return nil, err
+		}
+		if err := localizeInstrument(database.DB, &instruments[i], locale); err != nil {
+			return nil, err
+		}
+	}
+	return instruments, nil
+}
+
+// GetInstrumentByID fetches an instrument and its published version, items in locale
+func (c *InstrumentController) GetInstrumentByID(id uint, locale string) (*model.Instrument, error) {
+	inst, err := loadInstrument(database.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := localizeInstrument(database.DB, inst, locale); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+// GetInstrumentByCode fetches an instrument and its published version by its code, items in locale
+func (c *InstrumentController) GetInstrumentByCode(code, locale string) (*model.Instrument, error) {
+	inst, err := loadInstrumentByCode(database.DB, code)
+	if err != nil {
+		return nil, err
+	}
+	if err := localizeInstrument(database.DB, inst, locale); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+// CreateInstrument adds a new questionnaire with its first version as a draft, or published
+// straight away when publish is set
+func (c *InstrumentController) CreateInstrument(actorID uint, inst *model.Instrument, first *model.InstrumentVersion, publish bool) (*model.Instrument, error) {
+	inst.ID = 0
+	inst.Versions, inst.CurrentVersion = nil, nil
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	resetDefinition(first)
+	if err := first.Validate(); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := database.DB.Model(&model.Instrument{}).Where("code = ?", inst.Code).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("an instrument with code %s already exists", inst.Code)
+	}
+
+	inst.IsActive = true
+	if first.ChangeNote == "" {
+		first.ChangeNote = "Initial version"
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(inst).Error; err != nil {
+			return err
+		}
+		if err := startDraft(tx, inst, first); err != nil {
+			return err
+		}
+		if publish {
+			return publishVersion(tx, first, actorID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := loadInstrument(database.DB, inst.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !publish {
+		created.Versions = []model.InstrumentVersion{*first}
+	}
+	return created, nil
+}
+
+// SetActiveStatus retires or restores an instrument. Retired instruments keep their responses but
+// cannot be administered.
+func (c *InstrumentController) SetActiveStatus(id uint, active bool) error {
+	var inst model.Instrument
+	if err := database.DB.First(&inst, id).Error; err != nil {
+		return err
+	}
+	return database.DB.Model(&inst).Update("is_active", active).Error
+}
+
+// GetVersions lists an instrument's versions, newest first, without their definitions
+func (c *InstrumentController) GetVersions(instrumentID uint) ([]model.InstrumentVersion, error) {
+	var versions []model.InstrumentVersion
+	if err := database.DB.Preload("PublishedBy").
+		Where("instrument_id = ?", instrumentID).
+		Order("version DESC").
+		Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetVersionByID fetches any version's full definition, including superseded ones
+func (c *InstrumentController) GetVersionByID(id uint) (*model.InstrumentVersion, error) {
+	return loadVersion(database.DB, id)
+}
+
+// CreateVersion starts a new draft of an instrument. Without items it copies the published version.
+func (c *InstrumentController) CreateVersion(instrumentID uint, draft *model.InstrumentVersion) (*model.InstrumentVersion, error) {
+	inst, err := loadInstrument(database.DB, instrumentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := startDraft(database.DB, inst, draft); err != nil {
+		return nil, err
+	}
+	return loadVersion(database.DB, draft.ID)
+}
+
+// UpdateVersion changes a draft's instructions and change note and, when items are given,
+// replaces its items, options and severity bands
+func (c *InstrumentController) UpdateVersion(id uint, updated *model.InstrumentVersion) (*model.InstrumentVersion, error) {
+	version, err := loadDraft(database.DB, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(version).Updates(map[string]interface{}{
+			"instructions": updated.Instructions,
+			"change_note":  updated.ChangeNote,
+		}).Error; err != nil {
+			return err
+		}
+		if len(updated.Items) == 0 {
+			return nil
+		}
+
+		// Drafts have never been answered, so their old definition can go for good
+		if err := tx.Unscoped().Where("instrument_version_id = ?", version.ID).Delete(&model.InstrumentItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("instrument_version_id = ?", version.ID).Delete(&model.InstrumentOption{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("instrument_version_id = ?", version.ID).Delete(&model.InstrumentSeverityBand{}).Error; err != nil {
+			return err
+		}
+
+		resetDefinition(updated)
+		versionID := version.ID
+		for i := range updated.Items {
+			updated.Items[i].InstrumentVersionID = versionID
+		}
+		for i := range updated.Options {
+			updated.Options[i].InstrumentVersionID = &versionID
+		}
+		for i := range updated.SeverityBands {
+			updated.SeverityBands[i].InstrumentVersionID = versionID
+		}
+		if err := tx.Create(&updated.Items).Error; err != nil {
+			return err
+		}
+		if len(updated.Options) > 0 {
+			if err := tx.Create(&updated.Options).Error; err != nil {
+				return err
+			}
+		}
+		if len(updated.SeverityBands) > 0 {
+			if err := tx.Create(&updated.SeverityBands).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return loadVersion(database.DB, id)
+}
+
+// PublishVersion makes a draft the version new responses are recorded against. The version it
+// replaces is kept, read-only, for the responses pinned to it.
+func (c *InstrumentController) PublishVersion(actorID, id uint) (*model.InstrumentVersion, error) {
+	version, err := loadDraft(database.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return publishVersion(tx, version, actorID)
+	}); err != nil {
+		return nil, err
+	}
+	return loadVersion(database.DB, id)
+}
+
+// DeleteVersion discards a draft. Published versions cannot be deleted.
+func (c *InstrumentController) DeleteVersion(id uint) error {
+	version, err := loadDraft(database.DB, id)
+	if err != nil {
+		return err
+	}
+	return database.DB.Delete(version).Error
+}
+
+// CreateResponse scores and stores a session's answers to an instrument
+func (c *InstrumentController) CreateResponse(ctx context.Context, viewerID uint, response *model.InstrumentResponse) (*model.InstrumentResponse, error) {
+	db := database.DB.WithContext(ctx)
+
+	inst, err := loadInstrument(db, response.InstrumentID)
+	if err != nil {
+		return nil, err
+	}
+	response.ID = 0
+	if err := recordInstrumentResponse(db, viewerID, inst, response); err != nil {
+		return nil, err
+	}
+	response.Instrument = inst
+	return response, nil
+}
+
+// GetResponsesBySession lists every instrument administered during a session
+func (c *InstrumentController) GetResponsesBySession(viewerID, sessionID uint) ([]model.InstrumentResponse, error) {
+	if err := ensureSessionAccess(viewerID, sessionID); err != nil {
+		return nil, err
+	}
+
+	var responses []model.InstrumentResponse
+	if err := database.DB.
+		Preload("Instrument").
+		Preload("InstrumentVersion").
+		Preload("Answers.Item").
+		Where("session_id = ?", sessionID).
+		Order("created_at ASC").
+		Find(&responses).Error; err != nil {
+		return nil, err
+	}
+	for i := range responses {
+		if err := localizeResponse(database.DB, &responses[i]); err != nil {
+			return nil, err
+		}
+	}
+	return responses, nil
+}
+
+// GetResponseByID fetches a single response with its answers and the items they answer
+func (c *InstrumentController) GetResponseByID(viewerID, id uint) (*model.InstrumentResponse, error) {
+	var response model.InstrumentResponse
+	if err := database.DB.Preload("Instrument").Preload("InstrumentVersion").Preload("Answers.Item").First(&response, id).Error; err != nil {
+		return nil, err
+	}
+	if err := ensureSessionAccess(viewerID, response.SessionID); err != nil {
+		return nil, err
+	}
+	if err := localizeResponse(database.DB, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// UpdateResponse replaces a response's answers, corrects the language it was answered in or moves
+// it to another session, and re-scores it
+func (c *InstrumentController) UpdateResponse(ctx context.Context, viewerID, id uint, updated *model.InstrumentResponse) (*model.InstrumentResponse, error) {
+	db := database.DB.WithContext(ctx)
+
+	// The answers are loaded so risk rules are re-run against them when only the session or
+	// language changes
+	var response model.InstrumentResponse
+	if err := db.Preload("Answers.Item").First(&response, id).Error; err != nil {
+		return nil, err
+	}
+	if language := strings.ToLower(strings.TrimSpace(updated.Language)); language != "" {
+		if !model.IsSupportedLocale(language) {
+			return nil, fmt.Errorf("unsupported language %q", language)
+		}
+		language, err := shownLanguage(db, response.InstrumentVersionID, language, response.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		response.Language = language
+	}
+	if err := changeInstrumentResponse(db, viewerID, &response, updated.SessionID, updated.Answers); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DeleteResponse removes a response and anything scored from it
+func (c *InstrumentController) DeleteResponse(ctx context.Context, viewerID, id uint) error {
+	db := database.DB.WithContext(ctx)
+
+	var response model.InstrumentResponse
+	if err := db.First(&response, id).Error; err != nil {
+		return err
+	}
+	return removeInstrumentResponse(db, viewerID, &response)
+}
@@ -0,0 +1,255 @@
+package controller
+
+import (
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type InstrumentTranslationController struct{}
+
+func NewInstrumentTranslationController() interfaces.InstrumentTranslationInterface {
+	return &InstrumentTranslationController{}
+}
+
+// versionItems collects pointers to a version's items for localizeItems
+func versionItems(version *model.InstrumentVersion) []*model.InstrumentItem {
+	items := make([]*model.InstrumentItem, len(version.Items))
+	for i := range version.Items {
+		items[i] = &version.Items[i]
+	}
+	return items
+}
+
+// answerItems collects the loaded items of a response's answers for localizeItems
+func answerItems(answers []model.InstrumentAnswer) []*model.InstrumentItem {
+	items := make([]*model.InstrumentItem, 0, len(answers))
+	for i := range answers {
+		if answers[i].Item != nil {
+			items = append(items, answers[i].Item)
+		}
+	}
+	return items
+}
+
+// localizeItems swaps each item's text for its approved translation into locale. Items without an
+// approved translation stay in English; Locale records which language each ended up in. With asOf
+// set only translations approved by then are used, so the items read as they did at that time.
+func localizeItems(tx *gorm.DB, locale string, asOf *time.Time, items []*model.InstrumentItem) error {
+	for _, item := range items {
+		item.Locale = model.LocaleEnglish
+	}
+	if locale == model.LocaleEnglish || len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	query := tx.Where("instrument_item_id IN ? AND locale = ? AND status = ?", ids, locale, model.TranslationApproved)
+	if asOf != nil {
+		query = query.Where("reviewed_at <= ?", *asOf)
+	}
+	var translations []model.InstrumentItemTranslation
+	if err := query.Find(&translations).Error; err != nil {
+		return err
+	}
+
+	byItem := make(map[uint]string, len(translations))
+	for _, t := range translations {
+		byItem[t.InstrumentItemID] = t.Text
+	}
+	for _, item := range items {
+		if text, ok := byItem[item.ID]; ok {
+			item.Text = text
+			item.Locale = locale
+		}
+	}
+	return nil
+}
+
+// localizeResponse shows a response's items as the patient read them: in the language it was
+// answered in, with only the translations approved when it was recorded
+func localizeResponse(tx *gorm.DB, response *model.InstrumentResponse) error {
+	return localizeItems(tx, response.Language, &response.CreatedAt, answerItems(response.Answers))
+}
+
+// shownLanguage works out the language a version was administered in when locale was asked for at
+// asOf. Without a single approved translation into locale by then the patient read the English.
+func shownLanguage(tx *gorm.DB, versionID uint, locale string, asOf time.Time) (string, error) {
+	if locale == model.LocaleEnglish {
+		return locale, nil
+	}
+	var count int64
+	if err := tx.Model(&model.InstrumentItemTranslation{}).
+		Where("instrument_item_id IN (SELECT id FROM instrument_items WHERE instrument_version_id = ?)", versionID).
+		Where("locale = ? AND status = ? AND reviewed_at <= ?", locale, model.TranslationApproved, asOf).
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return model.LocaleEnglish, nil
+	}
+	return locale, nil
+}
+
+// copyTranslations carries the translations of one version's items over to the matching items,
+// by item number, of a version cloned from it
+func copyTranslations(tx *gorm.DB, from, to *model.InstrumentVersion) error {
+	positions := make(map[uint]int, len(from.Items))
+	ids := make([]uint, len(from.Items))
+	for i, item := range from.Items {
+		positions[item.ID] = item.Position
+		ids[i] = item.ID
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var translations []model.InstrumentItemTranslation
+	if err := tx.Where("instrument_item_id IN ?", ids).Find(&translations).Error; err != nil {
+		return err
+	}
+
+	var copies []model.InstrumentItemTranslation
+	for _, t := range translations {
+		item := to.ItemAt(positions[t.InstrumentItemID])
+		if item == nil {
+			continue
+		}
+		copies = append(copies, model.InstrumentItemTranslation{
+			InstrumentItemID: item.ID,
+			Locale:           t.Locale,
+			Text:             t.Text,
+			Status:           t.Status,
+			TranslatedByID:   t.TranslatedByID,
+			ReviewedByID:     t.ReviewedByID,
+			ReviewedAt:       t.ReviewedAt,
+		})
+	}
+	if len(copies) == 0 {
+		return nil
+	}
+	return tx.Create(&copies).Error
+}
+
+// ensureTranslationEditable rejects changes to an approved translation once its version is published
+func ensureTranslationEditable(tx *gorm.DB, t *model.InstrumentItemTranslation) error {
+	if t.Status != model.TranslationApproved {
+		return nil
+	}
+	var version model.InstrumentVersion
+	if err := tx.Where("id = (SELECT instrument_version_id FROM instrument_items WHERE id = ?)", t.InstrumentItemID).
+		First(&version).Error; err != nil {
+		return err
+	}
+	if version.Status != model.InstrumentVersionDraft {
+		return fmt.Errorf("the approved %s translation of a %s version cannot be changed; revise it in a new version", t.Locale, version.Status)
+	}
+	return nil
+}
+
+// GetVersionTranslations lists a version's items with their translations, optionally only those
+// into locale, so reviewers can compare them with the English and spot missing ones
+func (c *InstrumentTranslationController) GetVersionTranslations(versionID uint, locale string) ([]model.InstrumentItem, error) {
+	var items []model.InstrumentItem
+	err := database.DB.
+		Preload("Translations", func(tx *gorm.DB) *gorm.DB {
+			if locale != "" {
+				tx = tx.Where("locale = ?", locale)
+			}
+			return tx.Order("locale")
+		}).
+		Preload("Translations.TranslatedBy").
+		Preload("Translations.ReviewedBy").
+		Where("instrument_version_id = ?", versionID).
+		Order("position").
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// SaveTranslation adds or rewords an item's translation into locale. The text then awaits review
+// before patients see it.
+func (c *InstrumentTranslationController) SaveTranslation(translatorID, itemID uint, locale, text string) (*model.InstrumentItemTranslation, error) {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	text = strings.TrimSpace(text)
+	if !model.IsSupportedLocale(locale) || locale == model.LocaleEnglish {
+		return nil, fmt.Errorf("unsupported translation language %q", locale)
+	}
+	if text == "" {
+		return nil, errors.New("translation text is required")
+	}
+
+	var item model.InstrumentItem
+	if err := database.DB.First(&item, itemID).Error; err != nil {
+		return nil, errors.New("item not found")
+	}
+
+	var translation model.InstrumentItemTranslation
+	err := database.DB.Where("instrument_item_id = ? AND locale = ?", itemID, locale).First(&translation).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err := ensureTranslationEditable(database.DB, &translation); err != nil {
+		return nil, err
+	}
+
+	translation.InstrumentItemID = itemID
+	translation.Locale = locale
+	translation.Text = text
+	translation.Status = model.TranslationPending
+	translation.TranslatedByID = &translatorID
+	translation.ReviewedByID, translation.ReviewedAt = nil, nil
+	if err := database.DB.Save(&translation).Error; err != nil {
+		return nil, err
+	}
+	return &translation, nil
+}
+
+// ApproveTranslation releases a pending translation to patients. Someone other than its translator
+// must approve it.
+func (c *InstrumentTranslationController) ApproveTranslation(reviewerID, id uint) (*model.InstrumentItemTranslation, error) {
+	var translation model.InstrumentItemTranslation
+	if err := database.DB.First(&translation, id).Error; err != nil {
+		return nil, err
+	}
+	if translation.Status != model.TranslationPending {
+		return nil, errors.New("translation is not awaiting review")
+	}
+	if translation.TranslatedByID != nil && *translation.TranslatedByID == reviewerID {
+		return nil, errors.New("translations must be reviewed by someone other than their translator")
+	}
+
+	now := time.Now()
+	if err := database.DB.Model(&translation).Updates(map[string]interface{}{
+		"status":         model.TranslationApproved,
+		"reviewed_by_id": reviewerID,
+		"reviewed_at":    now,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &translation, nil
+}
+
+// DeleteTranslation removes a translation that is not yet fixed by a published version
+func (c *InstrumentTranslationController) DeleteTranslation(id uint) error {
+	var translation model.InstrumentItemTranslation
+	if err := database.DB.First(&translation, id).Error; err != nil {
+		return err
+	}
+	if err := ensureTranslationEditable(database.DB, &translation); err != nil {
+		return err
+	}
+	// Hard delete so the language can be translated again
+	return database.DB.Unscoped().Delete(&translation).Error
+}
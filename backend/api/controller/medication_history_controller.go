@@ -1,58 +1,111 @@
 package controller
 
 import (
+	"context"
 	"depression-diagnosis-system/api/interfaces"
 	"depression-diagnosis-system/database"
 	"depression-diagnosis-system/database/model"
 	"errors"
+	"time"
+
+	"gorm.io/gorm"
 )
 
+// ErrMedicationHistoryInUse is returned when an entry that already has dispensings, adherence
+// check-ins or adverse reactions recorded against it is moved to another patient
+var ErrMedicationHistoryInUse = errors.New("this medication history has dispensings, check-ins or adverse reactions recorded and cannot move to another patient")
+
 type MedicationHistoryController struct{}
 
 func NewMedicationHistoryController() interfaces.MedicationHistoryInterface {
 	return &MedicationHistoryController{}
 }
 
-// CreateMedicationHistory creates a new medication history record
-func (mc *MedicationHistoryController) CreateMedicationHistory(medHist *model.MedicationHistory) (*model.MedicationHistory, error) {
+// applySafetyWarnings checks the entry's lines and records what was found. A severe warning
+// without an override reason fails with ErrOverrideRequired; the entry still carries the warnings
+// so they can be shown. ownerID is the entry's ID when its lines are being replaced.
+func applySafetyWarnings(tx *gorm.DB, medHist *model.MedicationHistory, ownerID uint, lines []model.PrescriptionLine) error {
+	return reviewPrescriptionSafety(tx, medHist.PatientID, "medication_history_id", ownerID, lines, &medHist.SafetyWarnings, &medHist.OverrideReason)
+}
+
+// CreateMedicationHistory creates a new medication history record. Its lines are checked against
+// the formulary and, when no prescription text is given, written out as the prescription; text
+// given without lines is kept as an unstructured line. They are
+// also checked for interactions, allergies and pregnancy contraindications; severe warnings need
+// an override reason.
+func (mc *MedicationHistoryController) CreateMedicationHistory(ctx context.Context, viewerID uint, medHist *model.MedicationHistory) (*model.MedicationHistory, error) {
+	db := database.DB.WithContext(ctx)
+
 	// Check patient exists
 	var patient model.Patient
-	if err := database.DB.First(&patient, medHist.PatientID).Error; err != nil {
+	if err := db.First(&patient, medHist.PatientID).Error; err != nil {
 		return nil, errors.New("invalid patient ID")
 	}
+	if err := ensurePatientAccess(viewerID, medHist.PatientID); err != nil {
+		return nil, err
+	}
 
 	// Optional: if PrescribingDoctorID != nil, verify doctor exists
 	if medHist.PrescribingDoctorID != nil {
 		var doctor model.HealthWorker
-		if err := database.DB.First(&doctor, *medHist.PrescribingDoctorID).Error; err != nil {
+		if err := db.First(&doctor, *medHist.PrescribingDoctorID).Error; err != nil {
 			return nil, errors.New("invalid prescribing doctor ID")
 		}
 	}
 
-	if err := database.DB.Create(medHist).Error; err != nil {
+	lines := medHist.Lines
+	if err := preparePrescriptionLines(db, medHist.PatientID, medHist.PrescribingDoctorID, nil, lines); err != nil {
 		return nil, err
 	}
+	if medHist.Prescription == "" {
+		medHist.Prescription = model.DescribePrescription(lines)
+	}
+	if err := applySafetyWarnings(db, medHist, 0, lines); err != nil {
+		return medHist, err
+	}
+	if len(lines) == 0 {
+		lines = freeTextLines(medHist.PatientID, medHist.PrescribingDoctorID, medHist.Prescription, time.Now())
+	}
 
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Lines").Create(medHist).Error; err != nil {
+			return err
+		}
+		return syncPrescriptionLines(tx, "medication_history_id", medHist.ID, nil, lines)
+	})
+	if err != nil {
+		return nil, err
+	}
+	medHist.Lines = lines
 	return medHist, nil
 }
 
 // GetMedicationHistoryByID fetches a medication history record by ID
-func (mc *MedicationHistoryController) GetMedicationHistoryByID(id uint) (*model.MedicationHistory, error) {
+func (mc *MedicationHistoryController) GetMedicationHistoryByID(viewerID, id uint) (*model.MedicationHistory, error) {
 	var medHist model.MedicationHistory
 	if err := database.DB.
 		Preload("Patient").
 		Preload("PrescribingDoctor").
+		Preload("Lines", withPrescriptionDetails).
 		First(&medHist, id).Error; err != nil {
 		return nil, err
 	}
+	if err := ensurePatientAccess(viewerID, medHist.PatientID); err != nil {
+		return nil, err
+	}
 	return &medHist, nil
 }
 
 // GetMedicationHistoriesByPatient fetches all medication histories for a patient
-func (mc *MedicationHistoryController) GetMedicationHistoriesByPatient(patientID uint) ([]model.MedicationHistory, error) {
+func (mc *MedicationHistoryController) GetMedicationHistoriesByPatient(viewerID, patientID uint) ([]model.MedicationHistory, error) {
+	if err := ensurePatientAccess(viewerID, patientID); err != nil {
+		return nil, err
+	}
+
 	var medHists []model.MedicationHistory
 	if err := database.DB.
 		Preload("PrescribingDoctor").
+		Preload("Lines", withPrescriptionDetails).
 		Where("patient_id = ?", patientID).
 		Find(&medHists).Error; err != nil {
 		return nil, err
@@ -60,26 +113,65 @@ func (mc *MedicationHistoryController) GetMedicationHistoriesByPatient(patientID
 	return medHists, nil
 }
 
-// UpdateMedicationHistory updates an existing medication history record
-func (mc *MedicationHistoryController) UpdateMedicationHistory(id uint, updated *model.MedicationHistory) (*model.MedicationHistory, error) {
+// medicationHistoryInUse reports whether dispensings, adherence check-ins or adverse reactions,
+// which name the patient and session they were recorded in, refer to the entry
+func medicationHistoryInUse(tx *gorm.DB, id uint) (bool, error) {
+	lines := tx.Model(&model.PrescriptionLine{}).Select("id").Where("medication_history_id = ?", id)
+	checks := []*gorm.DB{
+		tx.Model(&model.DispensingEvent{}).Where("prescription_line_id IN (?)", lines),
+		tx.Model(&model.AdherenceCheckIn{}).Where("prescription_line_id IN (?)", lines),
+		tx.Model(&model.AdverseReaction{}).Where("medication_history_id = ?", id),
+	}
+	for _, check := range checks {
+		var count int64
+		if err := check.Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// UpdateMedicationHistory updates an existing medication history record. Lines, when given, are
+// checked the same way as on create; lines sent with their ID are edited in place and lines left out
+// are stopped. Moving the record to another patient moves its lines with it, and lines it keeps
+// are checked again against that patient; an entry that has been dispensed, checked in on or
+// reported in an adverse reaction cannot move.
+func (mc *MedicationHistoryController) UpdateMedicationHistory(ctx context.Context, viewerID, id uint, updated *model.MedicationHistory) (*model.MedicationHistory, error) {
+	db := database.DB.WithContext(ctx)
+
 	var medHist model.MedicationHistory
-	if err := database.DB.First(&medHist, id).Error; err != nil {
+	if err := db.First(&medHist, id).Error; err != nil {
+		return nil, err
+	}
+	if err := ensurePatientAccess(viewerID, medHist.PatientID); err != nil {
 		return nil, err
 	}
 
 	// Optional: Validate patient ID if changed
-	if updated.PatientID != 0 && updated.PatientID != medHist.PatientID {
+	patientChanged := updated.PatientID != 0 && updated.PatientID != medHist.PatientID
+	if patientChanged {
 		var patient model.Patient
-		if err := database.DB.First(&patient, updated.PatientID).Error; err != nil {
+		if err := db.First(&patient, updated.PatientID).Error; err != nil {
 			return nil, errors.New("invalid patient ID")
 		}
+		if err := ensurePatientAccess(viewerID, updated.PatientID); err != nil {
+			return nil, err
+		}
+		if inUse, err := medicationHistoryInUse(db, medHist.ID); err != nil {
+			return nil, err
+		} else if inUse {
+			return nil, ErrMedicationHistoryInUse
+		}
 		medHist.PatientID = updated.PatientID
 	}
 
 	// Optional: Validate prescribing doctor ID if changed
 	if updated.PrescribingDoctorID != nil && (medHist.PrescribingDoctorID == nil || *updated.PrescribingDoctorID != *medHist.PrescribingDoctorID) {
 		var doctor model.HealthWorker
-		if err := database.DB.First(&doctor, *updated.PrescribingDoctorID).Error; err != nil {
+		if err := db.First(&doctor, *updated.PrescribingDoctorID).Error; err != nil {
 			return nil, errors.New("invalid prescribing doctor ID")
 		}
 		medHist.PrescribingDoctorID = updated.PrescribingDoctorID
@@ -91,28 +183,81 @@ func (mc *MedicationHistoryController) UpdateMedicationHistory(id uint, updated
 	medHist.ExternalDoctorContact = updated.ExternalDoctorContact
 	medHist.HealthCenter = updated.HealthCenter
 
-	if err := database.DB.Save(&medHist).Error; err != nil {
+	current, err := ownedPrescriptionLines(db, "medication_history_id", medHist.ID)
+	if err != nil {
 		return nil, err
 	}
+	lines := updated.Lines
+	if lines != nil {
+		if err := preparePrescriptionLines(db, medHist.PatientID, medHist.PrescribingDoctorID, current, lines); err != nil {
+			return nil, err
+		}
+		if medHist.Prescription == "" {
+			medHist.Prescription = model.DescribePrescription(lines)
+		}
+		medHist.OverrideReason = updated.OverrideReason
+		if err := applySafetyWarnings(db, &medHist, medHist.ID, lines); err != nil {
+			return &medHist, err
+		}
+	} else if patientChanged {
+		// The lines the entry keeps move with it, so they are checked against the new patient
+		var moving []model.PrescriptionLine
+		if err := db.Preload("Drug").
+			Where("medication_history_id = ? AND (stop_date IS NULL OR stop_date > ?)", medHist.ID, time.Now()).
+			Find(&moving).Error; err != nil {
+			return nil, err
+		}
+		medHist.OverrideReason = updated.OverrideReason
+		if err := applySafetyWarnings(db, &medHist, medHist.ID, moving); err != nil {
+			return &medHist, err
+		}
+	}
 
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Lines").Save(&medHist).Error; err != nil {
+			return err
+		}
+		if lines != nil {
+			if err := syncPrescriptionLines(tx, "medication_history_id", medHist.ID, current, lines); err != nil {
+				return err
+			}
+		}
+		if !patientChanged {
+			return nil
+		}
+		return tx.Model(&model.PrescriptionLine{}).Where("medication_history_id = ?", medHist.ID).
+			Update("patient_id", medHist.PatientID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Scopes(withPrescriptionDetails).Where("medication_history_id = ?", medHist.ID).Find(&medHist.Lines).Error; err != nil {
+		return nil, err
+	}
 	return &medHist, nil
 }
 
-// DeleteMedicationHistory deletes a medication history record
-func (mc *MedicationHistoryController) DeleteMedicationHistory(id uint) error {
+// DeleteMedicationHistory deletes a medication history record of a patient the viewer has access to
+func (mc *MedicationHistoryController) DeleteMedicationHistory(ctx context.Context, viewerID, id uint) error {
+	db := database.DB.WithContext(ctx)
+
 	var medHist model.MedicationHistory
-	if err := database.DB.First(&medHist, id).Error; err != nil {
+	if err := db.First(&medHist, id).Error; err != nil {
 		return err
 	}
-	return database.DB.Delete(&medHist).Error
+	if err := ensurePatientAccess(viewerID, medHist.PatientID); err != nil {
+		return err
+	}
+	return db.Delete(&medHist).Error
 }
 
-// GetAllMedicationHistories returns all medication histories
-func (mc *MedicationHistoryController) GetAllMedicationHistories() ([]model.MedicationHistory, error) {
+// GetAllMedicationHistories returns the medication histories of every patient the viewer has access to
+func (mc *MedicationHistoryController) GetAllMedicationHistories(viewerID uint) ([]model.MedicationHistory, error) {
 	var medHists []model.MedicationHistory
-	if err := database.DB.
+	if err := database.DB.Scopes(accessiblePatients(viewerID, "patient_id")).
 		Preload("Patient").
 		Preload("PrescribingDoctor").
+		Preload("Lines", withPrescriptionDetails).
 		Find(&medHists).Error; err != nil {
 		return nil, err
 	}
@@ -120,9 +265,9 @@ func (mc *MedicationHistoryController) GetAllMedicationHistories() ([]model.Medi
 }
 
 // SearchMedicationHistories searches medication histories by query params
-func (mc *MedicationHistoryController) SearchMedicationHistories(queryParams map[string]string) ([]model.MedicationHistory, error) {
+func (mc *MedicationHistoryController) SearchMedicationHistories(viewerID uint, queryParams map[string]string) ([]model.MedicationHistory, error) {
 	var medHists []model.MedicationHistory
-	dbQuery := database.DB.Preload("Patient").Preload("PrescribingDoctor")
+	dbQuery := database.DB.Scopes(accessiblePatients(viewerID, "patient_id")).Preload("Patient").Preload("PrescribingDoctor").Preload("Lines", withPrescriptionDetails)
 
 	if patientID, ok := queryParams["patient_id"]; ok && patientID != "" {
 		dbQuery = dbQuery.Where("patient_id = ?", patientID)
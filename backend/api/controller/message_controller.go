@@ -4,25 +4,136 @@ import (
 	"depression-diagnosis-system/api/interfaces"
 	"depression-diagnosis-system/database"
 	"depression-diagnosis-system/database/model"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
 )
 
+// ReplayLimit is the most messages replayed at once; clients ask again from the returned cursor
+const ReplayLimit = 200
+
 type MessageController struct{}
 
 func NewMessageController() interfaces.MessageInterface {
 	return &MessageController{}
 }
 
+// storeMessage creates a message with an unread receipt for each recipient
+func storeMessage(tx *gorm.DB, msg *model.Message, recipientIDs []uint) error {
+	if err := tx.Omit("Sender", "Receiver", "Receipts").Create(msg).Error; err != nil {
+		return err
+	}
+	if len(recipientIDs) == 0 {
+		return nil
+	}
+	receipts := make([]model.MessageReceipt, 0, len(recipientIDs))
+	for _, id := range recipientIDs {
+		receipts = append(receipts, model.MessageReceipt{MessageID: msg.ID, RecipientID: id})
+	}
+	return tx.Create(&receipts).Error
+}
+
+// notifyReceipt tells the senders of the messages, and the recipient's other connections, that the
+// recipient received or read them
+func notifyReceipt(recipientID uint, messageIDs []uint, deliveredAt, readAt *time.Time) {
+	var senders []uint
+	if err := database.DB.Model(&model.Message{}).Where("id IN ?", messageIDs).Distinct().Pluck("sender_id", &senders).Error; err != nil {
+		return
+	}
+	hub.publish(model.RealtimeReceipt, append(senders, recipientID), model.ReceiptPayload{
+		RecipientID: recipientID,
+		MessageIDs:  messageIDs,
+		DeliveredAt: deliveredAt,
+		ReadAt:      readAt,
+	})
+}
+
+// markDelivered records that messages reached a recipient, every message received when messageIDs
+// is nil, and tells their senders
+func markDelivered(recipientID uint, messageIDs []uint) error {
+	if messageIDs != nil && len(messageIDs) == 0 {
+		return nil
+	}
+	pending := database.DB.Model(&model.MessageReceipt{}).Where("recipient_id = ? AND delivered_at IS NULL", recipientID)
+	if messageIDs != nil {
+		pending = pending.Where("message_id IN ?", messageIDs)
+	}
+	var ids []uint
+	if err := pending.Pluck("message_id", &ids).Error; err != nil || len(ids) == 0 {
+		return err
+	}
+
+	now := time.Now()
+	if err := database.DB.Model(&model.MessageReceipt{}).
+		Where("recipient_id = ? AND message_id IN ? AND delivered_at IS NULL", recipientID, ids).
+		Update("delivered_at", now).Error; err != nil {
+		return err
+	}
+	notifyReceipt(recipientID, ids, &now, nil)
+	return nil
+}
+
+// markRead records that a recipient read messages, delivered now if they had not been, and tells
+// their senders
+func markRead(recipientID uint, messageIDs []uint) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	if err := database.DB.Model(&model.MessageReceipt{}).
+		Where("recipient_id = ? AND message_id IN ? AND read_at IS NULL", recipientID, messageIDs).
+		Updates(map[string]interface{}{
+			"read_at":      now,
+			"delivered_at": gorm.Expr("COALESCE(delivered_at, ?)", now),
+		}).Error; err != nil {
+		return err
+	}
+	notifyReceipt(recipientID, messageIDs, nil, &now)
+	return nil
+}
+
+// messageIDs lists the IDs of messages
+func messageIDs(messages []model.Message) []uint {
+	ids := make([]uint, 0, len(messages))
+	for _, m := range messages {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+// SendMessage stores a message and pushes it to the receiver, and to the sender's other
+// connections, if they are online
 func (mc *MessageController) SendMessage(msg *model.Message) (*model.Message, error) {
-	if err := database.DB.Create(msg).Error; err != nil {
+	msg.Message = strings.TrimSpace(msg.Message)
+	if msg.Message == "" {
+		return nil, errors.New("message is empty")
+	}
+	var receiver model.HealthWorker
+	if msg.ReceiverID == nil || database.DB.Select("id").First(&receiver, *msg.ReceiverID).Error != nil {
+		return nil, errors.New("receiver not found")
+	}
+	msg.ChannelID = nil
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return storeMessage(tx, msg, []uint{receiver.ID})
+	}); err != nil {
+		return nil, err
+	}
+	if err := database.DB.Preload("Sender").Preload("Receiver").Preload("Receipts").First(msg, msg.ID).Error; err != nil {
 		return nil, err
 	}
+	hub.publish(model.RealtimeMessage, []uint{receiver.ID, msg.SenderID}, msg)
 	return msg, nil
 }
 
 func (mc *MessageController) GetMessagesBetween(senderID, receiverID uint) ([]model.Message, error) {
 	var messages []model.Message
 	err := database.DB.
-		Preload("Sender").Preload("Receiver").
+		Preload("Sender").Preload("Receiver").Preload("Receipts").
 		Where("(sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?)",
 			senderID, receiverID, receiverID, senderID).
 		Order("timestamp ASC").
@@ -30,12 +141,253 @@ func (mc *MessageController) GetMessagesBetween(senderID, receiverID uint) ([]mo
 	return messages, err
 }
 
+// GetInboxForUser lists every message a health worker received, newest first, marking them
+// delivered
 func (mc *MessageController) GetInboxForUser(userID uint) ([]model.Message, error) {
 	var messages []model.Message
 	err := database.DB.
-		Preload("Sender").Preload("Receiver").
+		Preload("Sender").Preload("Receiver").Preload("Receipts").
 		Where("receiver_id = ?", userID).
 		Order("timestamp DESC").
 		Find(&messages).Error
-	return messages, err
+	if err != nil {
+		return nil, err
+	}
+	return messages, markDelivered(userID, nil)
+}
+
+// GetMessagesSince returns the messages sent or received by a health worker, directly or in their
+// channels, after the cursor, a message ID, oldest first, and whether there are more than ReplayLimit. Those received are marked
+// delivered.
+func (mc *MessageController) GetMessagesSince(userID, cursor uint) ([]model.Message, bool, error) {
+	var messages []model.Message
+	if err := database.DB.
+		Preload("Sender").Preload("Receiver").Preload("Receipts").
+		Where("(receiver_id = ? OR sender_id = ? OR channel_id IN (SELECT channel_id FROM channel_members WHERE health_worker_id = ? AND deleted_at IS NULL)) AND id > ?", userID, userID, userID, cursor).
+		Order("id ASC").Limit(ReplayLimit + 1).
+		Find(&messages).Error; err != nil {
+		return nil, false, err
+	}
+	more := len(messages) > ReplayLimit
+	if more {
+		messages = messages[:ReplayLimit]
+	}
+	if err := markDelivered(userID, messageIDs(messages)); err != nil {
+		return nil, false, err
+	}
+	return messages, more, nil
+}
+
+// MarkMessageRead marks a message the health worker received as read
+func (mc *MessageController) MarkMessageRead(userID, messageID uint) error {
+	var receipt model.MessageReceipt
+	if err := database.DB.Where("message_id = ? AND recipient_id = ?", messageID, userID).First(&receipt).Error; err != nil {
+		return errors.New("message not found")
+	}
+	if receipt.ReadAt != nil {
+		return nil
+	}
+	return markRead(userID, []uint{messageID})
+}
+
+// MarkConversationRead marks the messages a health worker received from a counterpart as read, up
+// to and including the message ID upTo, or all of them when upTo is 0. It returns how many were
+// unread.
+func (mc *MessageController) MarkConversationRead(userID, counterpartID, upTo uint) (int, error) {
+	unread := database.DB.Model(&model.MessageReceipt{}).
+		Where("recipient_id = ? AND read_at IS NULL", userID).
+		Where("message_id IN (SELECT id FROM messages WHERE sender_id = ? AND receiver_id = ? AND deleted_at IS NULL)", counterpartID, userID)
+	if upTo > 0 {
+		unread = unread.Where("message_id <= ?", upTo)
+	}
+	var ids []uint
+	if err := unread.Pluck("message_id", &ids).Error; err != nil {
+		return 0, err
+	}
+	return len(ids), markRead(userID, ids)
+}
+
+// GetConversations groups a health worker's direct messages into a thread per counterpart, most recently
+// active first, each with its latest message and unread count
+func (mc *MessageController) GetConversations(userID uint) ([]model.Conversation, error) {
+	var threads []struct {
+		CounterpartID uint
+		LastMessageID uint
+	}
+	if err := database.DB.Model(&model.Message{}).
+		Select("CASE WHEN sender_id = ? THEN receiver_id ELSE sender_id END AS counterpart_id, MAX(id) AS last_message_id", userID).
+		Where("(sender_id = ? OR receiver_id = ?) AND channel_id IS NULL", userID, userID).
+		Group("counterpart_id").Order("last_message_id DESC").
+		Scan(&threads).Error; err != nil {
+		return nil, err
+	}
+	if len(threads) == 0 {
+		return []model.Conversation{}, nil
+	}
+
+	var unread []struct {
+		SenderID uint
+		Unread   int64
+	}
+	if err := database.DB.Table("message_receipts r").
+		Select("m.sender_id, COUNT(*) AS unread").
+		Joins("JOIN messages m ON m.id = r.message_id AND m.deleted_at IS NULL AND m.channel_id IS NULL").
+		Where("r.recipient_id = ? AND r.read_at IS NULL AND r.deleted_at IS NULL", userID).
+		Group("m.sender_id").
+		Scan(&unread).Error; err != nil {
+		return nil, err
+	}
+	unreadBy := make(map[uint]int64, len(unread))
+	for _, u := range unread {
+		unreadBy[u.SenderID] = u.Unread
+	}
+
+	lastIDs := make([]uint, 0, len(threads))
+	counterpartIDs := make([]uint, 0, len(threads))
+	for _, t := range threads {
+		lastIDs = append(lastIDs, t.LastMessageID)
+		counterpartIDs = append(counterpartIDs, t.CounterpartID)
+	}
+	var last []model.Message
+	if err := database.DB.Preload("Receipts").Find(&last, lastIDs).Error; err != nil {
+		return nil, err
+	}
+	lastBy := make(map[uint]*model.Message, len(last))
+	for i := range last {
+		lastBy[last[i].ID] = &last[i]
+	}
+	var counterparts []model.HealthWorker
+	if err := database.DB.Preload("PersonnelType").Find(&counterparts, counterpartIDs).Error; err != nil {
+		return nil, err
+	}
+	counterpartBy := make(map[uint]*model.HealthWorker, len(counterparts))
+	for i := range counterparts {
+		counterpartBy[counterparts[i].ID] = &counterparts[i]
+	}
+	online := map[uint]bool{}
+	for _, id := range hub.online() {
+		online[id] = true
+	}
+
+	conversations := make([]model.Conversation, 0, len(threads))
+	for _, t := range threads {
+		conversations = append(conversations, model.Conversation{
+			CounterpartID: t.CounterpartID,
+			Counterpart:   counterpartBy[t.CounterpartID],
+			Online:        online[t.CounterpartID],
+			LastMessage:   lastBy[t.LastMessageID],
+			UnreadCount:   unreadBy[t.CounterpartID],
+		})
+	}
+	if err := markDelivered(userID, lastIDs); err != nil {
+		return nil, err
+	}
+	return conversations, nil
+}
+
+// GetOnlineHealthWorkers lists the IDs of the health workers with a realtime connection open
+func (mc *MessageController) GetOnlineHealthWorkers() []uint {
+	return hub.online()
+}
+
+// Connect serves a health worker's WebSocket connection until it closes. Messages received after
+// since are replayed first, so a client reconnecting with the last message ID it saw misses
+// nothing; a message arriving during the replay may be pushed twice and clients drop IDs they have.
+// The connection is closed when the device session it was opened from is revoked.
+func (mc *MessageController) Connect(conn *websocket.Conn, healthWorkerID, sessionID, since uint, expiresAt time.Time) {
+	cl := newRealtimeClient(conn, healthWorkerID, sessionID)
+	hub.register(cl)
+	defer hub.unregister(cl)
+	go cl.writeLoop(expiresAt)
+
+	if since > 0 {
+		if err := mc.replay(cl, since); err != nil {
+			cl.shut(websocket.CloseInternalServerErr, "replay failed")
+		}
+	}
+
+	conn.SetReadLimit(realtimeMaxFrame)
+	conn.SetReadDeadline(time.Now().Add(realtimePongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(realtimePongWait))
+	})
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(realtimePongWait))
+
+		var frame model.ClientFrame
+		if err = json.Unmarshal(data, &frame); err != nil {
+			err = errors.New("frames must be JSON")
+		} else {
+			err = mc.handleFrame(cl, &frame)
+		}
+		if err != nil {
+			if reply, ferr := realtimeFrame(model.RealtimeError, map[string]string{"message": err.Error()}); ferr == nil {
+				cl.push(reply)
+			}
+		}
+	}
+}
+
+// handleFrame acts on a frame sent by a client: a message to send, a typing indicator or a replay
+// request
+func (mc *MessageController) handleFrame(cl *realtimeClient, frame *model.ClientFrame) error {
+	switch frame.Type {
+	case model.RealtimeMessage:
+		if frame.ChannelID != 0 {
+			_, err := sendChannelMessage(cl.healthWorkerID, frame.ChannelID, frame.Message)
+			return err
+		}
+		_, err := mc.SendMessage(&model.Message{
+			SenderID:   cl.healthWorkerID,
+			ReceiverID: &frame.ReceiverID,
+			Message:    frame.Message,
+			Timestamp:  time.Now(),
+		})
+		return err
+	case model.RealtimeTyping:
+		if frame.ChannelID != 0 {
+			return channelTyping(cl.healthWorkerID, frame.ChannelID, frame.Typing)
+		}
+		if frame.ReceiverID == 0 {
+			return errors.New("receiver_id or channel_id is required")
+		}
+		hub.publish(model.RealtimeTyping, []uint{frame.ReceiverID}, model.TypingPayload{SenderID: cl.healthWorkerID, Typing: frame.Typing})
+		return nil
+	case model.RealtimeReplay:
+		return mc.replay(cl, frame.Since)
+	default:
+		return errors.New("unknown frame type")
+	}
+}
+
+// replay pushes the messages after since to a client, then a replay frame with the cursor to ask
+// from next and whether there are more
+func (mc *MessageController) replay(cl *realtimeClient, since uint) error {
+	messages, more, err := mc.GetMessagesSince(cl.healthWorkerID, since)
+	if err != nil {
+		return err
+	}
+
+	cursor := since
+	for i := range messages {
+		frame, err := realtimeFrame(model.RealtimeMessage, &messages[i])
+		if err != nil {
+			return err
+		}
+		if !cl.queue(frame) {
+			return nil
+		}
+		cursor = messages[i].ID
+	}
+
+	frame, err := realtimeFrame(model.RealtimeReplay, map[string]interface{}{"cursor": cursor, "more": more})
+	if err != nil {
+		return err
+	}
+	cl.queue(frame)
+	return nil
 }
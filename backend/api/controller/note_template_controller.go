@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type NoteTemplateController struct{}
+
+func NewNoteTemplateController() interfaces.NoteTemplateInterface {
+	return &NoteTemplateController{}
+}
+
+// withNoteSections preloads a template's sections in order
+func withNoteSections(tx *gorm.DB) *gorm.DB {
+	return tx.Preload("Sections", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	})
+}
+
+// loadNoteTemplate fetches a template with its sections
+func loadNoteTemplate(tx *gorm.DB, id uint) (*model.NoteTemplate, error) {
+	var template model.NoteTemplate
+	if err := tx.Scopes(withNoteSections).First(&template, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("note template not found")
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// departmentNoteTemplate returns the default template of the department of the session's patient,
+// or nil when the department has none
+func departmentNoteTemplate(tx *gorm.DB, sessionID uint) (*model.NoteTemplate, error) {
+	var dept model.Department
+	err := tx.Joins("JOIN patients ON patients.department_id = departments.id").
+		Joins("JOIN sessions ON sessions.patient_id = patients.id").
+		Where("sessions.id = ?", sessionID).
+		First(&dept).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if dept.DefaultNoteTemplateID == nil {
+		return nil, nil
+	}
+	return loadNoteTemplate(tx, *dept.DefaultNoteTemplateID)
+}
+
+// resetNoteSections clears the IDs and links of submitted sections so they are stored as new rows
+func resetNoteSections(t *model.NoteTemplate) {
+	for i := range t.Sections {
+		t.Sections[i].Model = gorm.Model{}
+		t.Sections[i].NoteTemplateID = 0
+	}
+}
+
+// GetAllNoteTemplates lists every note template with its sections
+func (c *NoteTemplateController) GetAllNoteTemplates() ([]model.NoteTemplate, error) {
+	var templates []model.NoteTemplate
+	if err := database.DB.Scopes(withNoteSections).Order("code").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// GetNoteTemplateByID fetches a note template with its sections
+func (c *NoteTemplateController) GetNoteTemplateByID(id uint) (*model.NoteTemplate, error) {
+	return loadNoteTemplate(database.DB, id)
+}
+
+// GetSessionNoteTemplate returns the template a summary of the session is written on by default,
+// or nil when the patient's department has none
+func (c *NoteTemplateController) GetSessionNoteTemplate(viewerID, sessionID uint) (*model.NoteTemplate, error) {
+	if err := ensureSessionAccess(viewerID, sessionID); err != nil {
+		return nil, err
+	}
+	return departmentNoteTemplate(database.DB, sessionID)
+}
+
+// CreateNoteTemplate adds a new note template
+func (c *NoteTemplateController) CreateNoteTemplate(t *model.NoteTemplate) (*model.NoteTemplate, error) {
+	t.ID = 0
+	resetNoteSections(t)
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := database.DB.Model(&model.NoteTemplate{}).Where("code = ?", t.Code).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("a note template with code %s already exists", t.Code)
+	}
+
+	t.IsActive = true
+	if err := database.DB.Create(t).Error; err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// UpdateNoteTemplate changes a template's name, description and, when given, replaces its
+// sections. Summaries already written keep the section titles they were saved with.
+func (c *NoteTemplateController) UpdateNoteTemplate(id uint, updated *model.NoteTemplate) (*model.NoteTemplate, error) {
+	template, err := loadNoteTemplate(database.DB, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if updated.Name != "" {
+		template.Name = updated.Name
+	}
+	if updated.Description != "" {
+		template.Description = updated.Description
+	}
+	replaceSections := len(updated.Sections) > 0
+	if replaceSections {
+		resetNoteSections(updated)
+		template.Sections = updated.Sections
+	}
+	if err := template.Validate(); err != nil {
+		return nil, err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(template).Updates(map[string]interface{}{
+			"name":        template.Name,
+			"description": template.Description,
+		}).Error; err != nil {
+			return err
+		}
+		if !replaceSections {
+			return nil
+		}
+		if err := tx.Unscoped().Where("note_template_id = ?", template.ID).Delete(&model.NoteTemplateSection{}).Error; err != nil {
+			return err
+		}
+		for i := range template.Sections {
+			template.Sections[i].NoteTemplateID = template.ID
+		}
+		return tx.Create(&template.Sections).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// SetActiveStatus retires or restores a note template. Retired templates keep their summaries but
+// cannot be used for new ones.
+func (c *NoteTemplateController) SetActiveStatus(id uint, active bool) error {
+	var template model.NoteTemplate
+	if err := database.DB.First(&template, id).Error; err != nil {
+		return err
+	}
+	return database.DB.Model(&template).Update("is_active", active).Error
+}
@@ -0,0 +1,289 @@
+package controller
+
+import (
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/middleware"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// breakGlassTTL is how long an emergency override grants access to a patient
+const breakGlassTTL = 4 * time.Hour
+
+// ErrPatientAccessDenied is returned when a health worker is not allowed to see a patient's records
+var ErrPatientAccessDenied = errors.New("you do not have access to this patient's records")
+
+type PatientAccessController struct{}
+
+func NewPatientAccessController() interfaces.PatientAccessInterface {
+	return &PatientAccessController{}
+}
+
+// accessiblePatients limits a query to the patients the viewer may see. column is the patient ID
+// column of the queried table, e.g. "id" for patients or "patient_id" for sessions.
+// A viewer may see a patient when they hold patient:access:all, admitted the patient, ran one of
+// the patient's sessions, are on the care team, are covered by a department rule, or hold an
+// unexpired break-the-glass override.
+func accessiblePatients(viewerID uint, column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		var viewer model.HealthWorker
+		if err := database.DB.Preload("PersonnelType").First(&viewer, viewerID).Error; err != nil {
+			db.AddError(ErrPatientAccessDenied)
+			return db
+		}
+
+		allowed, err := middleware.CheckPermission(viewer.Role, viewer.PersonnelType.Name, model.PermPatientAccessAll)
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+		if allowed {
+			return db
+		}
+
+		return db.Where(
+			column+" IN (SELECT id FROM patients WHERE admitted_by_id = ? AND deleted_at IS NULL)"+
+				" OR "+column+" IN (SELECT patient_id FROM sessions WHERE health_worker_id = ? AND deleted_at IS NULL)"+
+				" OR "+column+" IN (SELECT patient_id FROM care_team_members WHERE health_worker_id = ? AND deleted_at IS NULL)"+
+				" OR "+column+" IN (SELECT patient_id FROM break_glass_accesses WHERE health_worker_id = ? AND expires_at > ? AND deleted_at IS NULL)"+
+				" OR "+column+" IN (SELECT p.id FROM patients p JOIN department_access_rules r ON r.department_id = p.department_id"+
+				" WHERE r.department_id = ? AND (r.personnel_type = '' OR r.personnel_type = ?) AND r.deleted_at IS NULL AND p.deleted_at IS NULL)",
+			viewerID, viewerID, viewerID, viewerID, time.Now(),
+			viewer.DepartmentID, strings.ToLower(viewer.PersonnelType.Name),
+		)
+	}
+}
+
+// ensurePatientAccess returns ErrPatientAccessDenied unless the viewer may see the patient
+func ensurePatientAccess(viewerID, patientID uint) error {
+	var count int64
+	if err := database.DB.Model(&model.Patient{}).
+		Scopes(accessiblePatients(viewerID, "id")).
+		Where("id = ?", patientID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrPatientAccessDenied
+	}
+	return nil
+}
+
+// ensureSessionAccess checks access to the patient a session belongs to
+func ensureSessionAccess(viewerID, sessionID uint) error {
+	var session model.Session
+	if err := database.DB.Select("id", "patient_id").First(&session, sessionID).Error; err != nil {
+		return errors.New("session not found")
+	}
+	return ensurePatientAccess(viewerID, session.PatientID)
+}
+
+// addToCareTeam records a health worker as a care team member unless they already are one
+func addToCareTeam(patientID, healthWorkerID uint, role string) error {
+	member := model.CareTeamMember{PatientID: patientID, HealthWorkerID: healthWorkerID}
+	return database.DB.Where(member).Attrs(model.CareTeamMember{Role: role}).FirstOrCreate(&member).Error
+}
+
+// CanAccessPatient reports whether the health worker may see the patient's records
+func (c *PatientAccessController) CanAccessPatient(healthWorkerID, patientID uint) (bool, error) {
+	err := ensurePatientAccess(healthWorkerID, patientID)
+	if errors.Is(err, ErrPatientAccessDenied) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetCareTeam lists the care team of a patient the viewer has access to
+func (c *PatientAccessController) GetCareTeam(viewerID, patientID uint) ([]model.CareTeamMember, error) {
+	if err := ensurePatientAccess(viewerID, patientID); err != nil {
+		return nil, err
+	}
+
+	var members []model.CareTeamMember
+	if err := database.DB.Preload("HealthWorker").Where("patient_id = ?", patientID).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// AddCareTeamMember assigns a health worker to a patient's care team
+func (c *PatientAccessController) AddCareTeamMember(member *model.CareTeamMember) (*model.CareTeamMember, error) {
+	var patient model.Patient
+	if err := database.DB.First(&patient, member.PatientID).Error; err != nil {
+		return nil, errors.New("invalid patient ID")
+	}
+	var hw model.HealthWorker
+	if err := database.DB.First(&hw, member.HealthWorkerID).Error; err != nil {
+		return nil, errors.New("invalid health worker ID")
+	}
+
+	if member.Role == "" {
+		member.Role = model.CareTeamRoleMember
+	}
+	if member.Role != model.CareTeamRolePrimary && member.Role != model.CareTeamRoleMember {
+		return nil, errors.New("care team role must be 'primary' or 'member'")
+	}
+
+	var existing model.CareTeamMember
+	if err := database.DB.Where("patient_id = ? AND health_worker_id = ?", member.PatientID, member.HealthWorkerID).
+		First(&existing).Error; err == nil {
+		existing.Role = member.Role
+		if err := database.DB.Save(&existing).Error; err != nil {
+			return nil, err
+		}
+		return &existing, nil
+	}
+
+	if err := database.DB.Create(member).Error; err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveCareTeamMember takes a health worker off a patient's care team
+func (c *PatientAccessController) RemoveCareTeamMember(patientID, healthWorkerID uint) error {
+	var member model.CareTeamMember
+	if err := database.DB.Where("patient_id = ? AND health_worker_id = ?", patientID, healthWorkerID).First(&member).Error; err != nil {
+		return err
+	}
+	return database.DB.Unscoped().Delete(&member).Error
+}
+
+// GetDepartmentAccessRules lists every department-wide visibility rule
+func (c *PatientAccessController) GetDepartmentAccessRules() ([]model.DepartmentAccessRule, error) {
+	var rules []model.DepartmentAccessRule
+	if err := database.DB.Order("department_id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// CreateDepartmentAccessRule opens a department's patients to its own staff
+func (c *PatientAccessController) CreateDepartmentAccessRule(rule *model.DepartmentAccessRule) (*model.DepartmentAccessRule, error) {
+	var dept model.Department
+	if err := database.DB.First(&dept, rule.DepartmentID).Error; err != nil {
+		return nil, errors.New("invalid department ID")
+	}
+
+	rule.PersonnelType = strings.ToLower(strings.TrimSpace(rule.PersonnelType))
+	if rule.PersonnelType != "" {
+		var pt model.PersonnelType
+		if err := database.DB.Where("LOWER(name) = ?", rule.PersonnelType).First(&pt).Error; err != nil {
+			return nil, errors.New("invalid personnel type")
+		}
+	}
+
+	var existing model.DepartmentAccessRule
+	if err := database.DB.Where("department_id = ? AND personnel_type = ?", rule.DepartmentID, rule.PersonnelType).
+		First(&existing).Error; err == nil {
+		return &existing, nil // Already exists
+	}
+
+	if err := database.DB.Create(rule).Error; err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeleteDepartmentAccessRule removes a department-wide visibility rule
+func (c *PatientAccessController) DeleteDepartmentAccessRule(id uint) error {
+	var rule model.DepartmentAccessRule
+	if err := database.DB.First(&rule, id).Error; err != nil {
+		return err
+	}
+	return database.DB.Delete(&rule).Error
+}
+
+// BreakGlass grants temporary emergency access to a patient. A reason is mandatory and the
+// access stays flagged until a supervisor reviews it.
+func (c *PatientAccessController) BreakGlass(healthWorkerID, patientID uint, reason string) (*model.BreakGlassAccess, error) {
+	reason = strings.TrimSpace(reason)
+	if len(reason) < 10 {
+		return nil, errors.New("a reason of at least 10 characters is required to break the glass")
+	}
+
+	var patient model.Patient
+	if err := database.DB.First(&patient, patientID).Error; err != nil {
+		return nil, errors.New("invalid patient ID")
+	}
+
+	access := model.BreakGlassAccess{
+		HealthWorkerID: healthWorkerID,
+		PatientID:      patientID,
+		Reason:         reason,
+		ExpiresAt:      time.Now().Add(breakGlassTTL),
+	}
+	if err := database.DB.Create(&access).Error; err != nil {
+		return nil, err
+	}
+	return &access, nil
+}
+
+// GetPendingBreakGlassReviews lists unreviewed overrides by the reviewer's supervisees, or all of
+// them when the reviewer holds break_glass:review
+func (c *PatientAccessController) GetPendingBreakGlassReviews(reviewerID uint) ([]model.BreakGlassAccess, error) {
+	var reviewer model.HealthWorker
+	if err := database.DB.Preload("PersonnelType").First(&reviewer, reviewerID).Error; err != nil {
+		return nil, err
+	}
+
+	query := database.DB.Preload("HealthWorker").Preload("Patient").Where("reviewed_at IS NULL")
+
+	canReviewAll, err := middleware.CheckPermission(reviewer.Role, reviewer.PersonnelType.Name, model.PermBreakGlassReview)
+	if err != nil {
+		return nil, err
+	}
+	if !canReviewAll {
+		query = query.Where("health_worker_id IN (SELECT id FROM health_workers WHERE supervisor_id = ?)", reviewerID)
+	}
+
+	var accesses []model.BreakGlassAccess
+	if err := query.Order("created_at ASC").Find(&accesses).Error; err != nil {
+		return nil, err
+	}
+	return accesses, nil
+}
+
+// ReviewBreakGlass marks an override as reviewed by the requester's supervisor (or a reviewer
+// holding break_glass:review)
+func (c *PatientAccessController) ReviewBreakGlass(id, reviewerID uint, notes string) (*model.BreakGlassAccess, error) {
+	var access model.BreakGlassAccess
+	if err := database.DB.Preload("HealthWorker").First(&access, id).Error; err != nil {
+		return nil, err
+	}
+	if access.ReviewedAt != nil {
+		return nil, errors.New("this access has already been reviewed")
+	}
+	if access.HealthWorkerID == reviewerID {
+		return nil, errors.New("you cannot review your own emergency access")
+	}
+
+	isSupervisor := access.HealthWorker.SupervisorID != nil && *access.HealthWorker.SupervisorID == reviewerID
+	if !isSupervisor {
+		var reviewer model.HealthWorker
+		if err := database.DB.Preload("PersonnelType").First(&reviewer, reviewerID).Error; err != nil {
+			return nil, err
+		}
+		allowed, err := middleware.CheckPermission(reviewer.Role, reviewer.PersonnelType.Name, model.PermBreakGlassReview)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, errors.New("only the health worker's supervisor can review this access")
+		}
+	}
+
+	now := time.Now()
+	access.ReviewedByID = &reviewerID
+	access.ReviewedAt = &now
+	access.ReviewNotes = notes
+	if err := database.DB.Save(&access).Error; err != nil {
+		return nil, err
+	}
+	return &access, nil
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"depression-diagnosis-system/api/interfaces"
 	"depression-diagnosis-system/api/util"
 	"depression-diagnosis-system/database"
@@ -16,28 +17,40 @@ func NewPatientController() interfaces.PatientInterface {
 }
 
 // CreatePatient creates a new patient and generates a unique PatientCode
-func (pc *PatientController) CreatePatient(patient *model.Patient) (*model.Patient, error) {
+func (pc *PatientController) CreatePatient(ctx context.Context, patient *model.Patient) (*model.Patient, error) {
+	db := database.DB.WithContext(ctx)
+
 	if patient.Email != "" && !util.IsValidEmail(patient.Email) {
 		return nil, errors.New("invalid email format")
 	}
+	if !model.ValidPregnancyStatus(patient.PregnancyStatus) {
+		return nil, errors.New("pregnancy status must be empty, pregnant or breastfeeding")
+	}
 
 	var department model.Department
-	if err := database.DB.First(&department, patient.DepartmentID).Error; err != nil {
+	if err := db.First(&department, patient.DepartmentID).Error; err != nil {
 		return nil, errors.New("invalid department ID")
 	}
 
 	patient.PatientCode = util.GeneratePatientCode(department.Name)
 
 	// First, save the patient
-	if err := database.DB.Create(patient).Error; err != nil {
+	if err := db.Create(patient).Error; err != nil {
 		return nil, err
 	}
 
+	// The admitting health worker leads the care team
+	if patient.AdmittedByID != 0 {
+		if err := addToCareTeam(patient.ID, patient.AdmittedByID, model.CareTeamRolePrimary); err != nil {
+			return nil, errors.New("failed to assign care team: " + err.Error())
+		}
+	}
+
 	// Save medication histories if any
 	if len(patient.MedicationHistories) > 0 {
 		for i := range patient.MedicationHistories {
 			patient.MedicationHistories[i].PatientID = patient.ID
-			if err := database.DB.Create(&patient.MedicationHistories[i]).Error; err != nil {
+			if err := db.Create(&patient.MedicationHistories[i]).Error; err != nil {
 				return nil, errors.New("failed to create medication history: " + err.Error())
 			}
 		}
@@ -47,10 +60,11 @@ func (pc *PatientController) CreatePatient(patient *model.Patient) (*model.Patie
 }
 
 
-// GetAllPatients retrieves all patients
-func (pc *PatientController) GetAllPatients() ([]model.Patient, error) {
+// GetAllPatients retrieves all patients the viewer has access to
+func (pc *PatientController) GetAllPatients(viewerID uint) ([]model.Patient, error) {
 	var patients []model.Patient
 	if err := database.DB.
+		Scopes(accessiblePatients(viewerID, "id")).
 		Preload("Department").
 		Preload("AdmittedBy").
 		Preload("MedicationHistories.PrescribingDoctor").
@@ -63,7 +77,11 @@ func (pc *PatientController) GetAllPatients() ([]model.Patient, error) {
 }
 
 // GetPatientByID fetches a patient by their database ID
-func (pc *PatientController) GetPatientByID(id uint) (*model.Patient, error) {
+func (pc *PatientController) GetPatientByID(viewerID, id uint) (*model.Patient, error) {
+	if err := ensurePatientAccess(viewerID, id); err != nil {
+		return nil, err
+	}
+
 	var patient model.Patient
 	if err := database.DB.
 		Preload("Department").
@@ -109,10 +127,11 @@ func (pc *PatientController) GetPatientByHealthWorker(healthWorkerID, patientID
 	return &patient, nil
 }
 
-// GetPatientsByDepartment fetches patients assigned to a given department
-func (pc *PatientController) GetPatientsByDepartment(departmentID uint) ([]model.Patient, error) {
+// GetPatientsByDepartment fetches the viewer's accessible patients in a given department
+func (pc *PatientController) GetPatientsByDepartment(viewerID, departmentID uint) ([]model.Patient, error) {
 	var patients []model.Patient
 	if err := database.DB.
+		Scopes(accessiblePatients(viewerID, "id")).
 		Preload("Department").
 		Preload("AdmittedBy").
 		Preload("MedicationHistories.PrescribingDoctor").
@@ -126,15 +145,24 @@ func (pc *PatientController) GetPatientsByDepartment(departmentID uint) ([]model
 }
 
 // UpdatePatient modifies an existing patient record
-func (pc *PatientController) UpdatePatient(id uint, updated *model.Patient) (*model.Patient, error) {
+func (pc *PatientController) UpdatePatient(ctx context.Context, viewerID, id uint, updated *model.Patient) (*model.Patient, error) {
+	db := database.DB.WithContext(ctx)
+
+	if err := ensurePatientAccess(viewerID, id); err != nil {
+		return nil, err
+	}
+
 	var patient model.Patient
-	if err := database.DB.First(&patient, id).Error; err != nil {
+	if err := db.First(&patient, id).Error; err != nil {
 		return nil, err
 	}
 
 	if updated.Email != "" && !util.IsValidEmail(updated.Email) {
 		return nil, errors.New("invalid email format")
 	}
+	if !model.ValidPregnancyStatus(updated.PregnancyStatus) {
+		return nil, errors.New("pregnancy status must be empty, pregnant or breastfeeding")
+	}
 
 	// Update basic fields
 	patient.FirstName = updated.FirstName
@@ -147,25 +175,26 @@ func (pc *PatientController) UpdatePatient(id uint, updated *model.Patient) (*mo
 	patient.NationalID = updated.NationalID
 	patient.Description = updated.Description
 	patient.PreviousDiagnosis = updated.PreviousDiagnosis
+	patient.PregnancyStatus = updated.PregnancyStatus
 	patient.AdmissionDate = updated.AdmissionDate
 	patient.DepartmentID = updated.DepartmentID
 	patient.AdmittedByID = updated.AdmittedByID
 
-	if err := database.DB.Save(&patient).Error; err != nil {
+	if err := db.Save(&patient).Error; err != nil {
 		return nil, err
 	}
 
 	// Update medication histories (optional strategy: delete and recreate)
 	if len(updated.MedicationHistories) > 0 {
 		// Delete existing histories
-		if err := database.DB.Where("patient_id = ?", patient.ID).Delete(&model.MedicationHistory{}).Error; err != nil {
+		if err := db.Where("patient_id = ?", patient.ID).Delete(&model.MedicationHistory{}).Error; err != nil {
 			return &patient, errors.New("failed to clear old medication histories: " + err.Error())
 		}
 
 		// Save new histories
 		for i := range updated.MedicationHistories {
 			updated.MedicationHistories[i].PatientID = patient.ID
-			if err := database.DB.Create(&updated.MedicationHistories[i]).Error; err != nil {
+			if err := db.Create(&updated.MedicationHistories[i]).Error; err != nil {
 				return &patient, errors.New("failed to add medication history: " + err.Error())
 			}
 		}
@@ -175,10 +204,16 @@ func (pc *PatientController) UpdatePatient(id uint, updated *model.Patient) (*mo
 }
 
 
-// DeletePatient deletes a patient from the system
-func (pc *PatientController) DeletePatient(id uint) error {
+// DeletePatient deletes a patient the viewer has access to from the system
+func (pc *PatientController) DeletePatient(ctx context.Context, viewerID, id uint) error {
+	db := database.DB.WithContext(ctx)
+
+	if err := ensurePatientAccess(viewerID, id); err != nil {
+		return err
+	}
+
     var patient model.Patient
-    if err := database.DB.First(&patient, id).Error; err != nil {
+    if err := db.First(&patient, id).Error; err != nil {
         return err
     }
 
@@ -186,28 +221,31 @@ func (pc *PatientController) DeletePatient(id uint) error {
         return errors.New("cannot delete an active patient; please deactivate first")
     }
 
-    return database.DB.Delete(&patient).Error
+    return db.Delete(&patient).Error
 }
 
 
-func (pc *PatientController) SetActiveStatus(id uint, active bool) (*model.Patient, error) {
+func (pc *PatientController) SetActiveStatus(ctx context.Context, id uint, active bool) (*model.Patient, error) {
+	db := database.DB.WithContext(ctx)
+
     var patient model.Patient
-    if err := database.DB.First(&patient, id).Error; err != nil {
+    if err := db.First(&patient, id).Error; err != nil {
         return nil, err
     }
 
     patient.IsActive = active
 
-    if err := database.DB.Save(&patient).Error; err != nil {
+    if err := db.Save(&patient).Error; err != nil {
         return nil, err
     }
 
     return &patient, nil
 }
 
-func (pc *PatientController) SearchPatients(queryParams map[string]string) ([]model.Patient, error) {
+func (pc *PatientController) SearchPatients(viewerID uint, queryParams map[string]string) ([]model.Patient, error) {
     var patients []model.Patient
 	dbQuery := database.DB.
+	Scopes(accessiblePatients(viewerID, "id")).
 	Preload("Department").
 	Preload("AdmittedBy").
 	Preload("MedicationHistories.PrescribingDoctor").
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"strings"
+)
+
+type PermissionController struct{}
+
+func NewPermissionController() interfaces.PermissionInterface {
+	return &PermissionController{}
+}
+
+// GetAllGrants returns every permission grant
+func (c *PermissionController) GetAllGrants() ([]model.PermissionGrant, error) {
+	var grants []model.PermissionGrant
+	if err := database.DB.Order("subject_type, subject, permission").Find(&grants).Error; err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// GetGrantsForSubject returns the grants held by a role or personnel type
+func (c *PermissionController) GetGrantsForSubject(subjectType, subject string) ([]model.PermissionGrant, error) {
+	var grants []model.PermissionGrant
+	if err := database.DB.
+		Where("subject_type = ? AND LOWER(subject) = ?", strings.TrimSpace(subjectType), model.NormalizeSubject(subject)).
+		Order("permission").
+		Find(&grants).Error; err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// CreateGrant gives a permission to a role or personnel type. The subject is normalized before the
+// duplicate check, so "Psychiatrist" and "psychiatrist" are the same grant.
+func (c *PermissionController) CreateGrant(grant *model.PermissionGrant) (*model.PermissionGrant, error) {
+	grant.ID = 0
+	grant.SubjectType = strings.TrimSpace(grant.SubjectType)
+	grant.Subject = model.NormalizeSubject(grant.Subject)
+	grant.Permission = strings.TrimSpace(grant.Permission)
+
+	if grant.SubjectType != model.SubjectRole && grant.SubjectType != model.SubjectPersonnelType {
+		return nil, errors.New("subject type must be 'role' or 'personnel_type'")
+	}
+
+	if grant.Subject == "" {
+		return nil, errors.New("subject is required")
+	}
+	if !model.IsKnownPermission(grant.Permission) {
+		return nil, errors.New("unknown permission: " + grant.Permission)
+	}
+
+	var existing model.PermissionGrant
+	// Grants written before subjects were normalized may still be stored in mixed case
+	if err := database.DB.Where("subject_type = ? AND LOWER(subject) = ? AND permission = ?",
+		grant.SubjectType, grant.Subject, grant.Permission).First(&existing).Error; err == nil {
+		return &existing, nil // Already granted
+	}
+
+	if err := database.DB.Create(grant).Error; err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
+// DeleteGrant revokes a permission grant
+func (c *PermissionController) DeleteGrant(id uint) error {
+	var grant model.PermissionGrant
+	if err := database.DB.First(&grant, id).Error; err != nil {
+		return err
+	}
+	return database.DB.Unscoped().Delete(&grant).Error
+}
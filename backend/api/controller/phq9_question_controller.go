@@ -5,44 +5,128 @@ import (
 	"depression-diagnosis-system/database"
 	"depression-diagnosis-system/database/model"
 	"errors"
+
+	"gorm.io/gorm"
 )
 
+// Phq9QuestionController keeps the PHQ-9 question routes working on top of the PHQ-9 instrument.
+// A question is an instrument item and its ID is the item's ID. Published PHQ-9 versions are
+// immutable, so new questions go into a draft version that must be published before use.
 type Phq9QuestionController struct{}
 
 func NewPhq9QuestionController() interfaces.Phq9QuestionInterface {
 	return &Phq9QuestionController{}
 }
 
+// questionFromItem presents an instrument item as a legacy PHQ-9 question
+func questionFromItem(item model.InstrumentItem) model.Phq9Question {
+	return model.Phq9Question{Model: item.Model, Question: item.Text, Locale: item.Locale}
+}
+
+// findPhq9Item fetches an item of any PHQ-9 version by ID
+func findPhq9Item(tx *gorm.DB, id uint) (*model.InstrumentItem, error) {
+	var item model.InstrumentItem
+	err := tx.Where(`id = ? AND instrument_version_id IN (SELECT v.id FROM instrument_versions v
+		JOIN instruments i ON i.id = v.instrument_id WHERE i.code = ?)`, id, model.InstrumentPHQ9).
+		First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// phq9Draft returns the PHQ-9 draft version, starting one from the published version if needed
+func phq9Draft(tx *gorm.DB) (*model.InstrumentVersion, error) {
+	phq9, err := loadInstrumentByCode(tx, model.InstrumentPHQ9)
+	if err != nil {
+		return nil, err
+	}
+
+	var draft model.InstrumentVersion
+	err = tx.Where("instrument_id = ? AND status = ?", phq9.ID, model.InstrumentVersionDraft).First(&draft).Error
+	if err == nil {
+		return &draft, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	draft = model.InstrumentVersion{ChangeNote: "Questions added through the PHQ-9 question API"}
+	if err := startDraft(tx, phq9, &draft); err != nil {
+		return nil, err
+	}
+	return &draft, nil
+}
+
+// CreateQuestion adds a question to the PHQ-9 draft version. It is asked once the draft is published.
 func (c *Phq9QuestionController) CreateQuestion(q *model.Phq9Question) (*model.Phq9Question, error) {
 	if q.Question == "" {
 		return nil, errors.New("question cannot be empty")
 	}
-	if err := database.DB.Create(q).Error; err != nil {
+
+	var item model.InstrumentItem
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		draft, err := phq9Draft(tx)
+		if err != nil {
+			return err
+		}
+
+		var last int
+		if err := tx.Model(&model.InstrumentItem{}).
+			Where("instrument_version_id = ?", draft.ID).
+			Select("COALESCE(MAX(position), 0)").Scan(&last).Error; err != nil {
+			return err
+		}
+
+		item = model.InstrumentItem{InstrumentVersionID: draft.ID, Position: last + 1, Text: q.Question}
+		return tx.Create(&item).Error
+	})
+	if err != nil {
 		return nil, err
 	}
-	return q, nil
+	question := questionFromItem(item)
+	return &question, nil
 }
 
-func (c *Phq9QuestionController) GetAllQuestions() ([]model.Phq9Question, error) {
-	var questions []model.Phq9Question
-	if err := database.DB.Find(&questions).Error; err != nil {
+// GetAllQuestions lists the questions of the published PHQ-9 version in locale, falling back to
+// English for questions without an approved translation
+func (c *Phq9QuestionController) GetAllQuestions(locale string) ([]model.Phq9Question, error) {
+	phq9, err := loadInstrumentByCode(database.DB, model.InstrumentPHQ9)
+	if err != nil {
+		return nil, err
+	}
+	if phq9.CurrentVersion == nil {
+		return []model.Phq9Question{}, nil
+	}
+	if err := localizeInstrument(database.DB, phq9, locale); err != nil {
 		return nil, err
 	}
+	questions := make([]model.Phq9Question, 0, len(phq9.CurrentVersion.Items))
+	for _, item := range phq9.CurrentVersion.Items {
+		questions = append(questions, questionFromItem(item))
+	}
 	return questions, nil
 }
 
+// GetQuestionByID fetches a question of any PHQ-9 version, so past responses can still be read
 func (c *Phq9QuestionController) GetQuestionByID(id uint) (*model.Phq9Question, error) {
-	var question model.Phq9Question
-	if err := database.DB.First(&question, id).Error; err != nil {
+	item, err := findPhq9Item(database.DB, id)
+	if err != nil {
 		return nil, err
 	}
+	question := questionFromItem(*item)
 	return &question, nil
 }
 
+// DeleteQuestion removes a question from the PHQ-9 draft version. Published questions stay, since
+// responses refer to them.
 func (c *Phq9QuestionController) DeleteQuestion(id uint) error {
-	var question model.Phq9Question
-	if err := database.DB.First(&question, id).Error; err != nil {
+	item, err := findPhq9Item(database.DB, id)
+	if err != nil {
 		return err
 	}
-	return database.DB.Delete(&question).Error
+	if _, err := loadDraft(database.DB, item.InstrumentVersionID); err != nil {
+		return errors.New("published questions cannot be deleted; remove the question from a draft version instead")
+	}
+	return database.DB.Unscoped().Delete(item).Error
 }
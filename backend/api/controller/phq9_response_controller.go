@@ -1,98 +1,147 @@
 package controller
 
 import (
+	"context"
 	"depression-diagnosis-system/api/interfaces"
 	"depression-diagnosis-system/database"
 	"depression-diagnosis-system/database/model"
 	"encoding/json"
 	"errors"
-	"fmt"
 
 	"gorm.io/gorm"
 )
 
+// Phq9ResponseController keeps the PHQ-9 response routes working on top of the PHQ-9 instrument.
+// Question IDs are PHQ-9 item IDs and response IDs are instrument response IDs.
 type Phq9ResponseController struct{}
 
 func NewPhq9ResponseController() interfaces.Phq9ResponseInterface {
 	return &Phq9ResponseController{}
 }
 
-func (c *Phq9ResponseController) CreateResponse(sessionID uint, resp []model.Phq9ResponseStruct) (*model.Phq9Response, error) {
-	if sessionID == 0 || len(resp) == 0 {
-		return nil, errors.New("sessionID and responses are required")
-	}
-	//🔒 Prevent duplicate response submissions
-	var existing model.Phq9Response
-	if err := database.DB.Where("session_id = ?", sessionID).First(&existing).Error; err == nil {
-		return nil, fmt.Errorf("PHQ-9 responses already recorded for session %d", sessionID)
+// answersFromPhq9 converts legacy {question_id, response} pairs to instrument answers
+func answersFromPhq9(list []model.Phq9ResponseStruct) []model.InstrumentAnswer {
+	answers := make([]model.InstrumentAnswer, len(list))
+	for i, r := range list {
+		answers[i] = model.InstrumentAnswer{InstrumentItemID: r.QuestionID, Score: r.Response}
 	}
+	return answers
+}
 
-	// Validate response content
-	for _, r := range resp {
-		if r.QuestionID == 0 {
-			return nil, fmt.Errorf("invalid response data: missing question ID")
+// phq9FromAnswers converts instrument answers back to legacy {question_id, response} pairs,
+// with the question text of the version they were answered on when the items are loaded
+func phq9FromAnswers(answers []model.InstrumentAnswer) []model.Phq9ResponseStruct {
+	list := make([]model.Phq9ResponseStruct, len(answers))
+	for i, a := range answers {
+		list[i] = model.Phq9ResponseStruct{QuestionID: a.InstrumentItemID, Response: a.Score}
+		if a.Item != nil {
+			list[i].Question = a.Item.Text
 		}
 	}
+	return list
+}
 
-	// Encode JSON and create new response
-	responseJSON, err := json.Marshal(resp)
+// phq9Compat presents an instrument response in the legacy PHQ-9 response shape
+func phq9Compat(response *model.InstrumentResponse) (*model.Phq9Response, error) {
+	raw, err := json.Marshal(phq9FromAnswers(response.Answers))
 	if err != nil {
 		return nil, err
 	}
+	return &model.Phq9Response{
+		Model:      response.Model,
+		SessionID:  response.SessionID,
+		Responses:  raw,
+		Language:   response.Language,
+		Diagnosis:  response.Diagnosis,
+		RiskAlerts: response.RiskAlerts,
+	}, nil
+}
 
-	response := &model.Phq9Response{
-		SessionID: sessionID,
-		Responses: responseJSON,
+// findPhq9Response fetches a PHQ-9 instrument response by ID
+func findPhq9Response(tx *gorm.DB, id uint) (*model.InstrumentResponse, error) {
+	var response model.InstrumentResponse
+	err := tx.Preload("Answers.Item").
+		Where("id = ? AND instrument_id IN (SELECT id FROM instruments WHERE code = ?)", id, model.InstrumentPHQ9).
+		First(&response).Error
+	if err != nil {
+		return nil, err
 	}
+	return &response, nil
+}
 
-	if err := database.DB.Create(response).Error; err != nil {
+// CreateResponse stores a session's PHQ-9 answers, given in language, and derives its Diagnosis
+// from them
+func (c *Phq9ResponseController) CreateResponse(ctx context.Context, viewerID, sessionID uint, language string, resp []model.Phq9ResponseStruct) (*model.Phq9Response, error) {
+	db := database.DB.WithContext(ctx)
+
+	if sessionID == 0 || len(resp) == 0 {
+		return nil, errors.New("sessionID and responses are required")
+	}
+	phq9, err := loadInstrumentByCode(db, model.InstrumentPHQ9)
+	if err != nil {
 		return nil, err
 	}
 
-	return response, nil
-	
+	response := &model.InstrumentResponse{SessionID: sessionID, Language: language, Answers: answersFromPhq9(resp)}
+	if err := recordInstrumentResponse(db, viewerID, phq9, response); err != nil {
+		return nil, err
+	}
+	return phq9Compat(response)
 }
 
-func (c *Phq9ResponseController) GetResponseBySessionID(sessionID uint) ([]model.Phq9ResponseStruct, error) {
-	var response model.Phq9Response
-	if err := database.DB.Where("session_id = ?", sessionID).First(&response).Error; err != nil {
+func (c *Phq9ResponseController) GetResponseBySessionID(viewerID, sessionID uint) ([]model.Phq9ResponseStruct, error) {
+	if err := ensureSessionAccess(viewerID, sessionID); err != nil {
+		return nil, err
+	}
+
+	var response model.InstrumentResponse
+	err := database.DB.Preload("Answers.Item").
+		Where("session_id = ? AND instrument_id IN (SELECT id FROM instruments WHERE code = ?)", sessionID, model.InstrumentPHQ9).
+		First(&response).Error
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return []model.Phq9ResponseStruct{}, nil
 		}
 		return nil, err
 	}
-
-	var responseStructs []model.Phq9ResponseStruct
-	if err := json.Unmarshal(response.Responses, &responseStructs); err != nil {
+	if err := localizeResponse(database.DB, &response); err != nil {
 		return nil, err
 	}
 
-	return responseStructs, nil
+	return phq9FromAnswers(response.Answers), nil
 }
 
-func (c *Phq9ResponseController) UpdateResponse(id uint, updated *model.Phq9Response) (*model.Phq9Response, error) {
-	var resp model.Phq9Response
-	if err := database.DB.First(&resp, id).Error; err != nil {
+// UpdateResponse changes a session's PHQ-9 answers and re-scores its Diagnosis
+func (c *Phq9ResponseController) UpdateResponse(ctx context.Context, viewerID, id uint, updated *model.Phq9Response) (*model.Phq9Response, error) {
+	db := database.DB.WithContext(ctx)
+
+	response, err := findPhq9Response(db, id)
+	if err != nil {
 		return nil, err
 	}
 
+	var answers []model.InstrumentAnswer
 	if updated.Responses != nil {
-		resp.Responses = updated.Responses
-	}
-	if updated.SessionID != 0 {
-		resp.SessionID = updated.SessionID
+		var list []model.Phq9ResponseStruct
+		if err := json.Unmarshal(updated.Responses, &list); err != nil {
+			return nil, errors.New("invalid response data: responses must be a list of answers")
+		}
+		answers = answersFromPhq9(list)
 	}
 
-	if err := database.DB.Save(&resp).Error; err != nil {
+	if err := changeInstrumentResponse(db, viewerID, response, updated.SessionID, answers); err != nil {
 		return nil, err
 	}
-	return &resp, nil
+	return phq9Compat(response)
 }
 
-func (c *Phq9ResponseController) DeleteResponse(id uint) error {
-	var resp model.Phq9Response
-	if err := database.DB.First(&resp, id).Error; err != nil {
+// DeleteResponse removes a PHQ-9 response together with the diagnosis scored from it
+func (c *Phq9ResponseController) DeleteResponse(ctx context.Context, viewerID, id uint) error {
+	db := database.DB.WithContext(ctx)
+
+	response, err := findPhq9Response(db, id)
+	if err != nil {
 		return err
 	}
-	return database.DB.Delete(&resp).Error
+	return removeInstrumentResponse(db, viewerID, response)
 }
@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"context"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PrescriptionController struct{}
+
+func NewPrescriptionController() interfaces.PrescriptionInterface {
+	return &PrescriptionController{}
+}
+
+// ownedPrescriptionLines loads the lines of a medication history entry or session by ID, so edits
+// can be matched to them. column is "medication_history_id" or "session_id".
+func ownedPrescriptionLines(tx *gorm.DB, column string, ownerID uint) (map[uint]model.PrescriptionLine, error) {
+	var lines []model.PrescriptionLine
+	if err := tx.Where(column+" = ?", ownerID).Find(&lines).Error; err != nil {
+		return nil, err
+	}
+	owned := make(map[uint]model.PrescriptionLine, len(lines))
+	for _, l := range lines {
+		owned[l.ID] = l
+	}
+	return owned, nil
+}
+
+// preparePrescriptionLines checks submitted lines against the formulary and ties them to the
+// patient. Lines that name no prescriber are written by prescriberID. A line with an ID edits that
+// line of current, the lines the entry already has; it keeps its start date unless a new one is given.
+func preparePrescriptionLines(tx *gorm.DB, patientID uint, prescriberID *uint, current map[uint]model.PrescriptionLine, lines []model.PrescriptionLine) error {
+	seen := make(map[uint]bool, len(lines))
+	for i := range lines {
+		l := &lines[i]
+		if l.ID != 0 {
+			prev, ok := current[l.ID]
+			if !ok || seen[l.ID] {
+				return fmt.Errorf("prescription line %d is not part of this prescription", l.ID)
+			}
+			seen[l.ID] = true
+			l.Model = prev.Model
+			if l.StartDate == nil {
+				l.StartDate = prev.StartDate
+			}
+		} else {
+			l.Model = gorm.Model{}
+		}
+		l.PatientID = patientID
+		l.MedicationHistoryID, l.SessionID = nil, nil
+		l.Patient, l.Prescriber = nil, nil
+
+		if l.Unstructured {
+			l.DrugID, l.Drug = nil, nil
+		} else {
+			if l.DrugID == nil {
+				return fmt.Errorf("prescription line %d needs a drug from the formulary", i+1)
+			}
+			var drug model.Drug
+			if err := tx.First(&drug, *l.DrugID).Error; err != nil {
+				return fmt.Errorf("drug %d is not in the formulary", *l.DrugID)
+			}
+			l.Drug = &drug
+		}
+
+		if l.PrescriberID == nil {
+			l.PrescriberID = prescriberID
+		} else {
+			var prescriber model.HealthWorker
+			if err := tx.Select("id").First(&prescriber, *l.PrescriberID).Error; err != nil {
+				return errors.New("invalid prescriber ID")
+			}
+		}
+
+		if err := l.Validate(); err != nil {
+			return fmt.Errorf("prescription line %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// freeTextLines turns a prescription written only as free text into a single unstructured line,
+// so it is listed with the patient's other prescriptions. It returns nil for blank text.
+func freeTextLines(patientID uint, prescriberID *uint, text string, start time.Time) []model.PrescriptionLine {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	return []model.PrescriptionLine{{
+		PatientID:    patientID,
+		Unstructured: true,
+		Text:         text,
+		StartDate:    &start,
+		PrescriberID: prescriberID,
+	}}
+}
+
+// syncPrescriptionLines writes the submitted lines of a medication history entry or session.
+// Lines of current that were resubmitted are updated in place, so dispensings, check-ins and
+// adverse reactions recorded against them stay attached; new lines are added and lines left out
+// are stopped rather than deleted. column is "medication_history_id" or "session_id".
+func syncPrescriptionLines(tx *gorm.DB, column string, ownerID uint, current map[uint]model.PrescriptionLine, lines []model.PrescriptionLine) error {
+	kept := make(map[uint]bool, len(lines))
+	for i := range lines {
+		if column == "session_id" {
+			lines[i].SessionID = &ownerID
+		} else {
+			lines[i].MedicationHistoryID = &ownerID
+		}
+		if lines[i].ID == 0 {
+			if err := tx.Omit("Patient", "Drug", "Prescriber").Create(&lines[i]).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		kept[lines[i].ID] = true
+		if err := tx.Omit("Patient", "Drug", "Prescriber").Save(&lines[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for id, l := range current {
+		if kept[id] || (l.StopDate != nil && !l.StopDate.After(now)) {
+			continue
+		}
+		stop := now
+		if l.StartDate != nil && l.StartDate.After(now) {
+			stop = *l.StartDate
+		}
+		if err := tx.Model(&model.PrescriptionLine{}).Where("id = ?", id).Update("stop_date", stop).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withPrescriptionDetails preloads what a prescription line is read with
+func withPrescriptionDetails(tx *gorm.DB) *gorm.DB {
+	return tx.Preload("Drug").Preload("Prescriber")
+}
+
+// activeLines keeps the lines that have started and not yet stopped
+func activeLines(tx *gorm.DB) *gorm.DB {
+	now := time.Now()
+	return tx.Where("(start_date IS NULL OR start_date <= ?) AND (stop_date IS NULL OR stop_date > ?)", now, now)
+}
+
+// GetPatientPrescriptions lists a patient's prescription lines, newest first, or only the drugs
+// they are currently on when activeOnly is set
+func (c *PrescriptionController) GetPatientPrescriptions(viewerID, patientID uint, activeOnly bool) ([]model.PrescriptionLine, error) {
+	if err := ensurePatientAccess(viewerID, patientID); err != nil {
+		return nil, err
+	}
+
+	var lines []model.PrescriptionLine
+	dbQuery := database.DB.Scopes(withPrescriptionDetails).Where("patient_id = ?", patientID)
+	if activeOnly {
+		dbQuery = dbQuery.Scopes(activeLines)
+	}
+	if err := dbQuery.Order("start_date DESC NULLS LAST, id DESC").Find(&lines).Error; err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// SearchPrescriptions finds the structured lines of a drug, matched by name or generic name, in an
+// optional strength, among patients the viewer has access to. It answers questions like "who is
+// on fluoxetine 20 mg".
+func (c *PrescriptionController) SearchPrescriptions(viewerID uint, drug, strength string, activeOnly bool) ([]model.PrescriptionLine, error) {
+	drug = strings.TrimSpace(drug)
+	if drug == "" {
+		return nil, errors.New("a drug name is required")
+	}
+
+	var lines []model.PrescriptionLine
+	dbQuery := database.DB.Scopes(withPrescriptionDetails, accessiblePatients(viewerID, "patient_id")).
+		Preload("Patient").
+		Where("drug_id IN (SELECT id FROM drugs WHERE name ILIKE ? OR generic_name ILIKE ?)", drug, drug)
+	if strength = strings.TrimSpace(strength); strength != "" {
+		dbQuery = dbQuery.Where("REPLACE(LOWER(strength), ' ', '') = ?", strings.ToLower(strings.ReplaceAll(strength, " ", "")))
+	}
+	if activeOnly {
+		dbQuery = dbQuery.Scopes(activeLines)
+	}
+	if err := dbQuery.Order("patient_id, start_date DESC").Find(&lines).Error; err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// StopPrescriptionLine records that a drug was stopped, today unless stopDate is given
+func (c *PrescriptionController) StopPrescriptionLine(ctx context.Context, viewerID, id uint, stopDate *time.Time) (*model.PrescriptionLine, error) {
+	db := database.DB.WithContext(ctx)
+
+	var line model.PrescriptionLine
+	if err := db.First(&line, id).Error; err != nil {
+		return nil, err
+	}
+	if err := ensurePatientAccess(viewerID, line.PatientID); err != nil {
+		return nil, err
+	}
+
+	stop := time.Now()
+	if stopDate != nil {
+		stop = *stopDate
+	}
+	if line.StartDate != nil && stop.Before(*line.StartDate) {
+		return nil, errors.New("stop date is before the start date")
+	}
+	if line.StopDate != nil && !line.StopDate.After(time.Now()) {
+		return nil, errors.New("prescription line has already stopped")
+	}
+
+	line.StopDate = &stop
+	if err := db.Model(&line).Update("stop_date", stop).Error; err != nil {
+		return nil, err
+	}
+	return &line, nil
+}
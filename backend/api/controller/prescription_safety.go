@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"depression-diagnosis-system/database/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrOverrideRequired is returned when a prescription raises a severe warning and no override
+// reason was given
+var ErrOverrideRequired = errors.New("a severe prescribing warning needs an override reason")
+
+// reviewPrescriptionSafety checks the lines being written to a medication history entry or session
+// and records the warnings found on it. A severe warning without an override reason fails with
+// ErrOverrideRequired; the warnings are still recorded so they can be shown. A reason given when
+// nothing severe was found is dropped.
+func reviewPrescriptionSafety(tx *gorm.DB, patientID uint, column string, ownerID uint, lines []model.PrescriptionLine, warnings *json.RawMessage, reason *string) error {
+	found, err := checkPrescriptionSafety(tx, patientID, column, ownerID, lines)
+	if err != nil {
+		return err
+	}
+	*warnings, _ = json.Marshal(found)
+	*reason = strings.TrimSpace(*reason)
+	if !model.HasSevereWarning(found) {
+		*reason = ""
+		return nil
+	}
+	if *reason == "" {
+		return ErrOverrideRequired
+	}
+	return nil
+}
+
+// drugLabel names a line's drug in a warning
+func drugLabel(l *model.PrescriptionLine) string {
+	return fmt.Sprintf("%s %s", l.Drug.Name, l.Strength)
+}
+
+// checkPrescriptionSafety checks structured lines, whose drugs must be loaded, against each other,
+// the patient's other active lines, known allergies and intolerances and pregnancy status. Lines of the record being
+// rewritten, where column = ownerID, are not counted as other medication. Each pair of drugs is
+// reported once, at its most severe.
+func checkPrescriptionSafety(tx *gorm.DB, patientID uint, column string, ownerID uint, lines []model.PrescriptionLine) ([]model.SafetyWarning, error) {
+	var prescribed []*model.PrescriptionLine
+	for i := range lines {
+		if !lines[i].Unstructured && lines[i].Drug != nil {
+			prescribed = append(prescribed, &lines[i])
+		}
+	}
+	if len(prescribed) == 0 {
+		return nil, nil
+	}
+
+	var patient model.Patient
+	if err := tx.Select("id", "pregnancy_status").First(&patient, patientID).Error; err != nil {
+		return nil, err
+	}
+	var current []model.PrescriptionLine
+	dbQuery := tx.Scopes(activeLines).Preload("Drug").
+		Where("patient_id = ? AND drug_id IS NOT NULL", patientID)
+	if ownerID != 0 {
+		dbQuery = dbQuery.Where("("+column+" IS NULL OR "+column+" <> ?)", ownerID)
+	}
+	if err := dbQuery.Find(&current).Error; err != nil {
+		return nil, err
+	}
+	var allergies []model.PatientAllergy
+	if err := tx.Where("patient_id = ?", patientID).Find(&allergies).Error; err != nil {
+		return nil, err
+	}
+	var interactions []model.DrugInteraction
+	if err := tx.Find(&interactions).Error; err != nil {
+		return nil, err
+	}
+
+	var warnings []model.SafetyWarning
+	pairs := map[[2]uint]int{} // drug pair to its index in warnings
+	warnPair := func(a, b *model.PrescriptionLine) {
+		if *a.DrugID == *b.DrugID {
+			return
+		}
+		key := [2]uint{*a.DrugID, *b.DrugID}
+		if key[0] > key[1] {
+			key[0], key[1] = key[1], key[0]
+		}
+		for _, interaction := range interactions {
+			if !interaction.Between(a.Drug, b.Drug) {
+				continue
+			}
+			w := model.SafetyWarning{
+				Kind:        model.SafetyWarningInteraction,
+				Severity:    interaction.Severity,
+				Drug:        drugLabel(a),
+				With:        drugLabel(b),
+				Description: interaction.Description,
+			}
+			if i, seen := pairs[key]; !seen {
+				pairs[key] = len(warnings)
+				warnings = append(warnings, w)
+			} else if severityRank(w.Severity) > severityRank(warnings[i].Severity) {
+				warnings[i] = w
+			}
+		}
+	}
+
+	for i, l := range prescribed {
+		for _, other := range prescribed[i+1:] {
+			warnPair(l, other)
+		}
+		for j := range current {
+			if current[j].Drug != nil {
+				warnPair(l, &current[j])
+			}
+		}
+
+		for _, allergy := range allergies {
+			if model.SubjectMatches(allergy.Substance, l.Drug) {
+				kind, severity := model.SafetyWarningAllergy, model.InteractionSevere
+				if allergy.Kind == model.AllergyKindIntolerance {
+					kind, severity = model.SafetyWarningIntolerance, model.InteractionModerate
+				}
+				description := "Recorded " + kind + " to " + allergy.Substance
+				if allergy.Reaction != "" {
+					description += ": " + allergy.Reaction
+				}
+				warnings = append(warnings, model.SafetyWarning{
+					Kind:        kind,
+					Severity:    severity,
+					Drug:        drugLabel(l),
+					With:        allergy.Substance,
+					Description: description,
+				})
+			}
+		}
+
+		if patient.PregnancyStatus == "" {
+			continue
+		}
+		for _, interaction := range interactions {
+			if interaction.Kind == model.InteractionPregnancy && interaction.SubjectB == patient.PregnancyStatus &&
+				model.SubjectMatches(interaction.SubjectA, l.Drug) {
+				warnings = append(warnings, model.SafetyWarning{
+					Kind:        model.SafetyWarningPregnancy,
+					Severity:    interaction.Severity,
+					Drug:        drugLabel(l),
+					With:        patient.PregnancyStatus,
+					Description: interaction.Description,
+				})
+			}
+		}
+	}
+	return warnings, nil
+}
+
+// severityRank orders severities so the worst of several warnings can be kept
+func severityRank(severity string) int {
+	switch severity {
+	case model.InteractionSevere:
+		return 3
+	case model.InteractionModerate:
+		return 2
+	case model.InteractionMinor:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,519 @@
+package controller
+
+import (
+	"context"
+	"depression-diagnosis-system/database/model"
+	"encoding/json"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// Realtime connection timings and limits
+const (
+	realtimeWriteWait    = 10 * time.Second // a frame must be written within this
+	realtimePongWait     = 60 * time.Second // a connection silent for this long is dropped
+	realtimePingInterval = 50 * time.Second // must be shorter than realtimePongWait
+	realtimeHeartbeat    = 30 * time.Second // how often an instance announces who is connected to it
+	realtimePresenceTTL  = 90 * time.Second // presence an instance has not refreshed for this long lapses
+	realtimeSendBuffer   = 64               // frames queued for a slow client before it is dropped
+	realtimeMaxFrame     = 16 << 10         // largest frame a client may send
+)
+
+// CloseTokenExpired is the close code sent when the access token a connection was opened with
+// expires; the client reconnects with a fresh token, passing the last message ID it saw as since
+const CloseTokenExpired = 4001
+
+// CloseTokenRevoked is the close code sent when the health worker signed out, or the device
+// session a connection was opened from was revoked; the client must sign in again
+const CloseTokenRevoked = 4002
+
+// realtimePresenceSync is published by every instance each heartbeat with the health workers
+// connected to it. It keeps presence right on instances started later and lets the presence of a
+// crashed instance lapse; it is not passed on to clients.
+const realtimePresenceSync = "presence_sync"
+
+// realtimeRevoke is published when a health worker's tokens or one of their device sessions are
+// revoked, so every instance closes the connections opened with them; it is not passed on to
+// clients
+const realtimeRevoke = "revoke"
+
+// revokePayload names the health worker whose connections to close, and the device session when
+// only that session's are
+type revokePayload struct {
+	HealthWorkerID uint `json:"health_worker_id"`
+	SessionID      uint `json:"session_id,omitempty"`
+}
+
+// Broadcaster carries realtime events to every server instance. The default implementation is
+// in-process, which is enough for a single instance; to run several instances behind a load
+// balancer a shared backend (e.g. Redis pub/sub) can be plugged in with SetBroadcaster.
+type Broadcaster interface {
+	// Publish sends an event to every subscriber, on this instance and others
+	Publish(event model.RealtimeEvent) error
+	// Subscribe calls handle with every event published until ctx is cancelled. handle must not block.
+	Subscribe(ctx context.Context, handle func(model.RealtimeEvent)) error
+}
+
+var broadcaster Broadcaster = NewMemoryBroadcaster()
+
+// SetBroadcaster replaces the backend realtime events travel through. It must be called before
+// StartRealtimeHub.
+func SetBroadcaster(b Broadcaster) {
+	broadcaster = b
+}
+
+// MemoryBroadcaster delivers events to subscribers in the same process
+type MemoryBroadcaster struct {
+	mu       sync.RWMutex
+	handlers map[int]func(model.RealtimeEvent)
+	next     int
+}
+
+func NewMemoryBroadcaster() *MemoryBroadcaster {
+	return &MemoryBroadcaster{handlers: make(map[int]func(model.RealtimeEvent))}
+}
+
+func (b *MemoryBroadcaster) Publish(event model.RealtimeEvent) error {
+	b.mu.RLock()
+	handlers := make([]func(model.RealtimeEvent), 0, len(b.handlers))
+	for _, h := range b.handlers {
+		handlers = append(handlers, h)
+	}
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(event)
+	}
+	return nil
+}
+
+func (b *MemoryBroadcaster) Subscribe(ctx context.Context, handle func(model.RealtimeEvent)) error {
+	b.mu.Lock()
+	id := b.next
+	b.next++
+	b.handlers[id] = handle
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.handlers, id)
+		b.mu.Unlock()
+	}()
+	return nil
+}
+
+// realtimeClient is one open WebSocket connection of a health worker. Only its write loop writes
+// to the connection.
+type realtimeClient struct {
+	healthWorkerID uint
+	sessionID      uint // device session of the access token the connection was opened with
+	conn           *websocket.Conn
+	send           chan []byte   // frames waiting to be written
+	closeWith      chan []byte   // close frame to end the connection with
+	done           chan struct{} // closed when the write loop has stopped
+}
+
+func newRealtimeClient(conn *websocket.Conn, healthWorkerID, sessionID uint) *realtimeClient {
+	return &realtimeClient{
+		healthWorkerID: healthWorkerID,
+		sessionID:      sessionID,
+		conn:           conn,
+		send:           make(chan []byte, realtimeSendBuffer),
+		closeWith:      make(chan []byte, 1),
+		done:           make(chan struct{}),
+	}
+}
+
+// push queues a frame without waiting. A client too slow to keep up is disconnected; it catches up
+// on reconnect by replaying from the last message it saw.
+func (cl *realtimeClient) push(frame []byte) {
+	select {
+	case cl.send <- frame:
+	default:
+		cl.shut(websocket.CloseTryAgainLater, "client too slow")
+	}
+}
+
+// queue waits until a frame is queued, and reports false if the connection closed first
+func (cl *realtimeClient) queue(frame []byte) bool {
+	select {
+	case cl.send <- frame:
+		return true
+	case <-cl.done:
+		return false
+	}
+}
+
+// shut asks the write loop to close the connection
+func (cl *realtimeClient) shut(code int, reason string) {
+	select {
+	case cl.closeWith <- websocket.FormatCloseMessage(code, reason):
+	default:
+	}
+}
+
+// writeLoop writes queued frames and keeps the connection alive with pings until it is shut, a
+// write fails or the access token expires
+func (cl *realtimeClient) writeLoop(expiresAt time.Time) {
+	ping := time.NewTicker(realtimePingInterval)
+	expiry := time.NewTimer(time.Until(expiresAt))
+	defer func() {
+		ping.Stop()
+		expiry.Stop()
+		cl.conn.Close()
+		close(cl.done)
+	}()
+
+	for {
+		select {
+		case frame := <-cl.send:
+			cl.conn.SetWriteDeadline(time.Now().Add(realtimeWriteWait))
+			if err := cl.conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := cl.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(realtimeWriteWait)); err != nil {
+				return
+			}
+		case <-expiry.C:
+			cl.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(CloseTokenExpired, "access token expired"), time.Now().Add(realtimeWriteWait))
+			return
+		case frame := <-cl.closeWith:
+			cl.conn.WriteControl(websocket.CloseMessage, frame, time.Now().Add(realtimeWriteWait))
+			return
+		}
+	}
+}
+
+// realtimeFrame encodes an event for clients
+func realtimeFrame(eventType string, payload interface{}) ([]byte, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(model.RealtimeEvent{Type: eventType, Payload: raw, SentAt: time.Now()})
+}
+
+// realtimeHub tracks the connections open on this instance and which health workers are connected
+// to other instances
+type realtimeHub struct {
+	mu         sync.RWMutex
+	instanceID string
+	clients    map[uint]map[*realtimeClient]struct{}
+	remote     map[uint]map[string]time.Time // health worker -> instance -> last announced there
+}
+
+var hub = &realtimeHub{
+	instanceID: uuid.NewString(),
+	clients:    make(map[uint]map[*realtimeClient]struct{}),
+	remote:     make(map[uint]map[string]time.Time),
+}
+
+// StartRealtimeHub subscribes this instance to realtime events and announces its connections
+// every heartbeat. When ctx is cancelled open connections are closed as going away, so clients
+// reconnect to another instance.
+func StartRealtimeHub(ctx context.Context) error {
+	if err := broadcaster.Subscribe(ctx, hub.handle); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(realtimeHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				hub.closeAll(websocket.CloseGoingAway, "server shutting down")
+				return
+			case now := <-ticker.C:
+				hub.heartbeat(now)
+			}
+		}
+	}()
+	return nil
+}
+
+// publish sends an event through the broadcaster to the recipients, or to everyone connected
+func (h *realtimeHub) publish(eventType string, recipients []uint, payload interface{}) {
+	raw, err := json.Marshal(payload)
+	if err == nil {
+		err = broadcaster.Publish(model.RealtimeEvent{
+			Type:       eventType,
+			Recipients: recipients,
+			Payload:    raw,
+			SentAt:     time.Now(),
+			Origin:     h.instanceID,
+		})
+	}
+	if err != nil {
+		log.Printf("❌ Failed to publish %s event: %v", eventType, err)
+	}
+}
+
+// register adds a connection, announcing the health worker online on their first
+func (h *realtimeHub) register(cl *realtimeClient) {
+	h.mu.Lock()
+	conns, ok := h.clients[cl.healthWorkerID]
+	if !ok {
+		conns = make(map[*realtimeClient]struct{})
+		h.clients[cl.healthWorkerID] = conns
+	}
+	conns[cl] = struct{}{}
+	first := len(conns) == 1
+	h.mu.Unlock()
+
+	if first {
+		h.publish(model.RealtimePresence, nil, model.PresencePayload{HealthWorkerID: cl.healthWorkerID, Online: true})
+	}
+}
+
+// unregister removes a connection, announcing the health worker offline after their last
+func (h *realtimeHub) unregister(cl *realtimeClient) {
+	h.mu.Lock()
+	conns := h.clients[cl.healthWorkerID]
+	delete(conns, cl)
+	last := len(conns) == 0
+	if last {
+		delete(h.clients, cl.healthWorkerID)
+	}
+	h.mu.Unlock()
+
+	if last {
+		h.publish(model.RealtimePresence, nil, model.PresencePayload{HealthWorkerID: cl.healthWorkerID, Online: false})
+	}
+}
+
+// onlineLocked reports whether a health worker is connected to any instance; h.mu must be held
+func (h *realtimeHub) onlineLocked(healthWorkerID uint) bool {
+	return len(h.clients[healthWorkerID]) > 0 || len(h.remote[healthWorkerID]) > 0
+}
+
+// setRemoteLocked records whether a health worker is connected to another instance and reports
+// whether that changed their presence overall; h.mu must be held
+func (h *realtimeHub) setRemoteLocked(healthWorkerID uint, origin string, online bool, now time.Time) bool {
+	before := h.onlineLocked(healthWorkerID)
+	if online {
+		if h.remote[healthWorkerID] == nil {
+			h.remote[healthWorkerID] = make(map[string]time.Time)
+		}
+		h.remote[healthWorkerID][origin] = now
+	} else if origins, ok := h.remote[healthWorkerID]; ok {
+		delete(origins, origin)
+		if len(origins) == 0 {
+			delete(h.remote, healthWorkerID)
+		}
+	}
+	return before != h.onlineLocked(healthWorkerID)
+}
+
+// handle receives every published event and passes it on to the connections it is meant for
+func (h *realtimeHub) handle(event model.RealtimeEvent) {
+	switch event.Type {
+	case model.RealtimePresence:
+		var p model.PresencePayload
+		if err := json.Unmarshal(event.Payload, &p); err != nil {
+			return
+		}
+		h.mu.Lock()
+		var changed bool
+		if event.Origin == h.instanceID {
+			// Connections here only change overall presence if there are none elsewhere
+			changed = len(h.remote[p.HealthWorkerID]) == 0
+		} else {
+			changed = h.setRemoteLocked(p.HealthWorkerID, event.Origin, p.Online, time.Now())
+		}
+		online := h.onlineLocked(p.HealthWorkerID)
+		h.mu.Unlock()
+		if changed {
+			h.announce(p.HealthWorkerID, online)
+		}
+
+	case realtimePresenceSync:
+		if event.Origin == h.instanceID {
+			return
+		}
+		var ids []uint
+		if err := json.Unmarshal(event.Payload, &ids); err != nil {
+			return
+		}
+		connected := make(map[uint]bool, len(ids))
+		for _, id := range ids {
+			connected[id] = true
+		}
+
+		now := time.Now()
+		changes := map[uint]bool{}
+		h.mu.Lock()
+		for _, id := range ids {
+			if h.setRemoteLocked(id, event.Origin, true, now) {
+				changes[id] = true
+			}
+		}
+		for id, origins := range h.remote {
+			if _, ok := origins[event.Origin]; ok && !connected[id] && h.setRemoteLocked(id, event.Origin, false, now) {
+				changes[id] = false
+			}
+		}
+		h.mu.Unlock()
+		for id, online := range changes {
+			h.announce(id, online)
+		}
+
+	case realtimeRevoke:
+		var p revokePayload
+		if err := json.Unmarshal(event.Payload, &p); err != nil {
+			return
+		}
+		h.closeRevoked(p.HealthWorkerID, p.SessionID)
+
+	default:
+		frame, err := json.Marshal(model.RealtimeEvent{Type: event.Type, Payload: event.Payload, SentAt: event.SentAt})
+		if err != nil {
+			return
+		}
+		reached := h.deliver(event.Recipients, frame)
+		if event.Type == model.RealtimeMessage {
+			h.delivered(event, reached)
+		}
+	}
+}
+
+// delivered records that a message pushed to connections here reached its recipients
+func (h *realtimeHub) delivered(event model.RealtimeEvent, reached []uint) {
+	var msg struct {
+		ID       uint `json:"ID"`
+		SenderID uint `json:"sender_id"`
+	}
+	if err := json.Unmarshal(event.Payload, &msg); err != nil {
+		return
+	}
+	for _, id := range reached {
+		if id == msg.SenderID {
+			continue
+		}
+		if err := markDelivered(id, []uint{msg.ID}); err != nil {
+			log.Printf("❌ Failed to mark message %d delivered: %v", msg.ID, err)
+		}
+	}
+}
+
+// announce tells everyone connected here that a health worker came online or went offline
+func (h *realtimeHub) announce(healthWorkerID uint, online bool) {
+	frame, err := realtimeFrame(model.RealtimePresence, model.PresencePayload{HealthWorkerID: healthWorkerID, Online: online})
+	if err != nil {
+		return
+	}
+	h.deliver(nil, frame)
+}
+
+// deliver pushes a frame to the recipients' connections here, or to every connection, and returns
+// the recipients it reached
+func (h *realtimeHub) deliver(recipients []uint, frame []byte) []uint {
+	h.mu.RLock()
+	var targets []*realtimeClient
+	var reached []uint
+	if len(recipients) == 0 {
+		for _, conns := range h.clients {
+			for cl := range conns {
+				targets = append(targets, cl)
+			}
+		}
+	} else {
+		seen := make(map[uint]bool, len(recipients))
+		for _, id := range recipients {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if len(h.clients[id]) > 0 {
+				reached = append(reached, id)
+			}
+			for cl := range h.clients[id] {
+				targets = append(targets, cl)
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, cl := range targets {
+		cl.push(frame)
+	}
+	return reached
+}
+
+// heartbeat announces the health workers connected here and lets presence other instances stopped
+// refreshing lapse
+func (h *realtimeHub) heartbeat(now time.Time) {
+	lapsed := []uint{}
+	h.mu.Lock()
+	local := make([]uint, 0, len(h.clients))
+	for id := range h.clients {
+		local = append(local, id)
+	}
+	for id, origins := range h.remote {
+		for origin, seen := range origins {
+			if now.Sub(seen) > realtimePresenceTTL && h.setRemoteLocked(id, origin, false, now) {
+				lapsed = append(lapsed, id)
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	for _, id := range lapsed {
+		h.announce(id, false)
+	}
+	h.publish(realtimePresenceSync, nil, local)
+}
+
+// closeAll closes every connection on this instance
+func (h *realtimeHub) closeAll(code int, reason string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, conns := range h.clients {
+		for cl := range conns {
+			cl.shut(code, reason)
+		}
+	}
+}
+
+// closeRevoked closes the health worker's connections on this instance, or only those opened from
+// the device session when sessionID is set
+func (h *realtimeHub) closeRevoked(healthWorkerID, sessionID uint) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for cl := range h.clients[healthWorkerID] {
+		if sessionID == 0 || cl.sessionID == sessionID {
+			cl.shut(CloseTokenRevoked, "signed out")
+		}
+	}
+}
+
+// disconnectRealtime closes, on every instance, the connections of a health worker whose tokens
+// were revoked, or only those of one device session when sessionID is set
+func disconnectRealtime(healthWorkerID, sessionID uint) {
+	hub.publish(realtimeRevoke, nil, revokePayload{HealthWorkerID: healthWorkerID, SessionID: sessionID})
+}
+
+// online lists the health workers connected to any instance
+func (h *realtimeHub) online() []uint {
+	h.mu.RLock()
+	ids := make([]uint, 0, len(h.clients)+len(h.remote))
+	for id := range h.clients {
+		ids = append(ids, id)
+	}
+	for id := range h.remote {
+		if len(h.clients[id]) == 0 {
+			ids = append(ids, id)
+		}
+	}
+	h.mu.RUnlock()
+
+	sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+	return ids
+}
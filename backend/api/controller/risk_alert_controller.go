@@ -0,0 +1,383 @@
+package controller
+
+import (
+	"context"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/middleware"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// minSafetyPlanLength keeps acknowledgements from being a one-word formality
+const minSafetyPlanLength = 20
+
+// ErrOpenRiskAlerts blocks completing a session while a risk alert lacks a safety plan
+var ErrOpenRiskAlerts = errors.New("this session has unacknowledged risk alerts; acknowledge them with a safety plan first")
+
+type RiskAlertController struct{}
+
+func NewRiskAlertController() interfaces.RiskAlertInterface {
+	return &RiskAlertController{}
+}
+
+func init() {
+	// A session with open risk alerts cannot be completed
+	BeforeSessionTransition(model.SessionCompleted, func(tx *gorm.DB, event *SessionEvent) error {
+		return ensureRiskAlertsResolved(tx, event.Session.ID)
+	})
+}
+
+// ensureRiskAlertsResolved returns ErrOpenRiskAlerts if the session still has open alerts
+func ensureRiskAlertsResolved(tx *gorm.DB, sessionID uint) error {
+	var open int64
+	if err := tx.Model(&model.RiskAlert{}).
+		Where("session_id = ? AND status = ?", sessionID, model.RiskAlertOpen).
+		Count(&open).Error; err != nil {
+		return err
+	}
+	if open > 0 {
+		return ErrOpenRiskAlerts
+	}
+	return nil
+}
+
+// ruleFires checks a rule against the answers (keyed by item number) and total score. It returns
+// the reason to record on the alert.
+func ruleFires(rule model.RiskRule, code string, answers map[int]int, total int) (string, bool) {
+	if rule.ItemNumber == 0 {
+		if total >= rule.MinResponse {
+			return fmt.Sprintf("%s: %s total score is %d", rule.Name, code, total), true
+		}
+		return "", false
+	}
+	answer, ok := answers[rule.ItemNumber]
+	if ok && answer >= rule.MinResponse {
+		return fmt.Sprintf("%s: %s item %d answered %d", rule.Name, code, rule.ItemNumber, answer), true
+	}
+	return "", false
+}
+
+// raiseRiskAlerts evaluates the instrument's active risk rules against a stored response and opens
+// an alert for each rule that fires, unless one is already open for the session. Item numbers are
+// read from the version the response was answered against. Each new alert is sent to the session's
+// health worker, their supervisor and the department's on-call psychiatrist.
+func raiseRiskAlerts(tx *gorm.DB, inst *model.Instrument, version *model.InstrumentVersion, response *model.InstrumentResponse, actorID uint) ([]model.RiskAlert, error) {
+	var rules []model.RiskRule
+	if err := tx.Where("is_active = ? AND instrument_id = ?", true, inst.ID).Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	positions := make(map[uint]int, len(version.Items))
+	for _, item := range version.Items {
+		positions[item.ID] = item.Position
+	}
+	answers := make(map[int]int, len(response.Answers))
+	for _, a := range response.Answers {
+		answers[positions[a.InstrumentItemID]] = a.Score
+	}
+
+	var session model.Session
+	if err := tx.Preload("Patient").Preload("HealthWorker").First(&session, response.SessionID).Error; err != nil {
+		return nil, errors.New("session not found")
+	}
+
+	var raised []model.RiskAlert
+	for _, rule := range rules {
+		reason, fires := ruleFires(rule, inst.Code, answers, response.TotalScore)
+		if !fires {
+			continue
+		}
+
+		var open int64
+		if err := tx.Model(&model.RiskAlert{}).
+			Where("session_id = ? AND risk_rule_id = ? AND status = ?", session.ID, rule.ID, model.RiskAlertOpen).
+			Count(&open).Error; err != nil {
+			return nil, err
+		}
+		if open > 0 {
+			continue
+		}
+
+		alert := model.RiskAlert{
+			SessionID:            session.ID,
+			PatientID:            session.PatientID,
+			InstrumentResponseID: response.ID,
+			RiskRuleID:           rule.ID,
+			Reason:               reason,
+			Status:               model.RiskAlertOpen,
+		}
+		if err := tx.Create(&alert).Error; err != nil {
+			return nil, err
+		}
+		if err := notifyRiskAlert(tx, &alert, &session, actorID); err != nil {
+			return nil, err
+		}
+		raised = append(raised, alert)
+	}
+	return raised, nil
+}
+
+// riskAlertRecipients lists the session's health worker, their supervisor and the on-call
+// psychiatrist of the patient's department, without duplicates
+func riskAlertRecipients(tx *gorm.DB, session *model.Session) ([]uint, error) {
+	recipients := []uint{session.HealthWorkerID}
+	if session.HealthWorker.SupervisorID != nil {
+		recipients = append(recipients, *session.HealthWorker.SupervisorID)
+	}
+
+	var dept model.Department
+	if err := tx.First(&dept, session.Patient.DepartmentID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if dept.OnCallPsychiatristID != nil {
+		recipients = append(recipients, *dept.OnCallPsychiatristID)
+	}
+
+	seen := map[uint]bool{}
+	unique := recipients[:0]
+	for _, id := range recipients {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique, nil
+}
+
+// notifyRiskAlert messages every recipient through the in-app message channel. The health worker
+// who recorded the answers sees the alert in the API response, so they are not messaged themselves.
+func notifyRiskAlert(tx *gorm.DB, alert *model.RiskAlert, session *model.Session, actorID uint) error {
+	recipients, err := riskAlertRecipients(tx, session)
+	if err != nil {
+		return err
+	}
+
+	text := fmt.Sprintf(
+		"⚠️ Suicide-risk alert for patient %s %s (%s), session %s. %s. Please review and document a safety plan before the session is completed.",
+		session.Patient.FirstName, session.Patient.LastName, session.Patient.PatientCode, session.SessionCode, alert.Reason,
+	)
+
+	for _, recipientID := range recipients {
+		notification := model.RiskAlertNotification{RiskAlertID: alert.ID, HealthWorkerID: recipientID}
+		if recipientID != actorID && actorID != 0 {
+			msg := model.Message{SenderID: actorID, ReceiverID: &recipientID, Message: text}
+			if err := storeMessage(tx, &msg, []uint{recipientID}); err != nil {
+				return err
+			}
+			notification.MessageID = &msg.ID
+		}
+		if err := tx.Create(&notification).Error; err != nil {
+			return err
+		}
+		alert.Notifications = append(alert.Notifications, notification)
+	}
+	return nil
+}
+
+// canManageRiskAlerts reports whether the health worker may see and acknowledge every alert
+func canManageRiskAlerts(healthWorkerID uint) (bool, error) {
+	var hw model.HealthWorker
+	if err := database.DB.Preload("PersonnelType").First(&hw, healthWorkerID).Error; err != nil {
+		return false, err
+	}
+	return middleware.CheckPermission(hw.Role, hw.PersonnelType.Name, model.PermRiskAlertManage)
+}
+
+// GetMyOpenRiskAlerts lists open alerts the health worker was notified about or owns the session of
+func (c *RiskAlertController) GetMyOpenRiskAlerts(healthWorkerID uint) ([]model.RiskAlert, error) {
+	query := database.DB.
+		Preload("Session").
+		Preload("Patient").
+		Preload("RiskRule").
+		Preload("Notifications.HealthWorker").
+		Where("status = ?", model.RiskAlertOpen)
+
+	manager, err := canManageRiskAlerts(healthWorkerID)
+	if err != nil {
+		return nil, err
+	}
+	if !manager {
+		query = query.Where(
+			"id IN (SELECT risk_alert_id FROM risk_alert_notifications WHERE health_worker_id = ? AND deleted_at IS NULL)"+
+				" OR session_id IN (SELECT id FROM sessions WHERE health_worker_id = ? AND deleted_at IS NULL)",
+			healthWorkerID, healthWorkerID,
+		)
+	}
+
+	var alerts []model.RiskAlert
+	if err := query.Order("created_at ASC").Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// GetRiskAlertsBySession lists every alert raised for a session
+func (c *RiskAlertController) GetRiskAlertsBySession(viewerID, sessionID uint) ([]model.RiskAlert, error) {
+	if err := ensureSessionAccess(viewerID, sessionID); err != nil {
+		return nil, err
+	}
+
+	var alerts []model.RiskAlert
+	if err := database.DB.
+		Preload("RiskRule").
+		Preload("AcknowledgedBy").
+		Preload("Notifications.HealthWorker").
+		Where("session_id = ?", sessionID).
+		Order("created_at ASC").
+		Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// AcknowledgeRiskAlert closes an alert with a documented safety plan. Only the notified health
+// workers, the session's health worker or a holder of risk_alert:manage may acknowledge.
+func (c *RiskAlertController) AcknowledgeRiskAlert(ctx context.Context, id, healthWorkerID uint, safetyPlan string) (*model.RiskAlert, error) {
+	db := database.DB.WithContext(ctx)
+
+	safetyPlan = strings.TrimSpace(safetyPlan)
+	if len(safetyPlan) < minSafetyPlanLength {
+		return nil, fmt.Errorf("a safety plan of at least %d characters is required", minSafetyPlanLength)
+	}
+
+	var alert model.RiskAlert
+	if err := db.Preload("Session").First(&alert, id).Error; err != nil {
+		return nil, err
+	}
+	if alert.Status != model.RiskAlertOpen {
+		return nil, errors.New("this alert has already been acknowledged")
+	}
+
+	allowed := alert.Session != nil && alert.Session.HealthWorkerID == healthWorkerID
+	if !allowed {
+		var notified int64
+		if err := db.Model(&model.RiskAlertNotification{}).
+			Where("risk_alert_id = ? AND health_worker_id = ?", alert.ID, healthWorkerID).
+			Count(&notified).Error; err != nil {
+			return nil, err
+		}
+		allowed = notified > 0
+	}
+	if !allowed {
+		manager, err := canManageRiskAlerts(healthWorkerID)
+		if err != nil {
+			return nil, err
+		}
+		allowed = manager
+	}
+	if !allowed {
+		return nil, errors.New("you were not notified about this alert")
+	}
+
+	now := time.Now()
+	alert.Status = model.RiskAlertAcknowledged
+	alert.AcknowledgedByID = &healthWorkerID
+	alert.AcknowledgedAt = &now
+	alert.SafetyPlan = safetyPlan
+	if err := db.Omit("Session").Save(&alert).Error; err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// validateRiskRule checks the item number and threshold of a rule against the published version of
+// its instrument, which defaults to PHQ-9
+func validateRiskRule(rule *model.RiskRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return errors.New("rule name is required")
+	}
+
+	var inst *model.Instrument
+	var err error
+	if rule.InstrumentID == 0 {
+		inst, err = loadInstrumentByCode(database.DB, model.InstrumentPHQ9)
+	} else {
+		inst, err = loadInstrument(database.DB, rule.InstrumentID)
+	}
+	if err != nil {
+		return err
+	}
+	if inst.CurrentVersion == nil {
+		return fmt.Errorf("%s has no published version", inst.Code)
+	}
+	rule.InstrumentID = inst.ID
+
+	version := inst.CurrentVersion
+	max := version.MaxScore()
+	if rule.ItemNumber != 0 {
+		item := version.ItemAt(rule.ItemNumber)
+		if item == nil {
+			return fmt.Errorf("%s has no item %d; use 0 for the total score", inst.Code, rule.ItemNumber)
+		}
+		max = version.ItemMaxScore(item)
+	}
+	if rule.MinResponse < 1 || rule.MinResponse > max {
+		return fmt.Errorf("threshold must be between 1 and %d", max)
+	}
+	return nil
+}
+
+// GetRiskRules lists all risk rules
+func (c *RiskAlertController) GetRiskRules() ([]model.RiskRule, error) {
+	var rules []model.RiskRule
+	if err := database.DB.Preload("Instrument").Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// CreateRiskRule adds a new risk rule
+func (c *RiskAlertController) CreateRiskRule(rule *model.RiskRule) (*model.RiskRule, error) {
+	if err := validateRiskRule(rule); err != nil {
+		return nil, err
+	}
+	if err := database.DB.Create(rule).Error; err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// UpdateRiskRule changes a rule's threshold, item or active flag
+func (c *RiskAlertController) UpdateRiskRule(id uint, updated *model.RiskRule) (*model.RiskRule, error) {
+	var rule model.RiskRule
+	if err := database.DB.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+
+	if updated.Name != "" {
+		rule.Name = updated.Name
+	}
+	if updated.InstrumentID != 0 {
+		rule.InstrumentID = updated.InstrumentID
+	}
+	rule.ItemNumber = updated.ItemNumber
+	rule.MinResponse = updated.MinResponse
+	rule.IsActive = updated.IsActive
+	if err := validateRiskRule(&rule); err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Save(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// DeleteRiskRule removes a risk rule. Alerts it already raised are kept.
+func (c *RiskAlertController) DeleteRiskRule(id uint) error {
+	var rule model.RiskRule
+	if err := database.DB.First(&rule, id).Error; err != nil {
+		return err
+	}
+	return database.DB.Delete(&rule).Error
+}
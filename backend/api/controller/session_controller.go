@@ -1,12 +1,15 @@
 package controller
 
 import (
+	"context"
 	"depression-diagnosis-system/api/interfaces"
 	"depression-diagnosis-system/api/util"
 	"depression-diagnosis-system/database"
 	"depression-diagnosis-system/database/model"
 	"errors"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type SessionController struct{}
@@ -15,12 +18,13 @@ func NewSessionController() interfaces.SessionInterface {
 	return &SessionController{}
 }
 
-// CreateSession handles creation and unique code generation
-func (c *SessionController) CreateSession(s *model.Session) (*model.Session, error) {
+// openSession generates the session's code, picks its initial status and records it as the first
+// transition
+func openSession(tx *gorm.DB, actorID uint, s *model.Session) error {
 	// Fetch patient to get department for session code prefix
 	var patient model.Patient
-	if err := database.DB.Preload("Department").First(&patient, s.PatientID).Error; err != nil {
-		return nil, errors.New("invalid patient")
+	if err := tx.Preload("Department").First(&patient, s.PatientID).Error; err != nil {
+		return errors.New("invalid patient")
 	}
 
 	// Generate session code
@@ -32,44 +36,111 @@ func (c *SessionController) CreateSession(s *model.Session) (*model.Session, err
 		s.Date = time.Now()
 	}
 
-	s.Status = model.SessionOngoing // Set default status
+	// Sessions booked ahead start scheduled; walk-ins start ongoing unless the patient is only
+	// checked in
+	switch {
+	case s.Status == model.SessionCheckedIn:
+	case s.Date.After(time.Now()):
+		s.Status = model.SessionScheduled
+	default:
+		s.Status = model.SessionOngoing
+	}
+
+	if err := tx.Create(s).Error; err != nil {
+		return err
+	}
+	return recordSessionTransition(tx, s.ID, "", s.Status, actorID, "")
+}
+
+// CreateSession handles creation and unique code generation. Structured prescription lines are
+// prescribed by the session's health worker unless they name someone else, and are checked for
+// interactions, allergies and pregnancy contraindications like the lines of a medication history
+// entry; severe warnings need an override reason. A prescription given only as text is kept as an
+// unstructured line.
+func (c *SessionController) CreateSession(ctx context.Context, viewerID uint, s *model.Session) (*model.Session, error) {
+	db := database.DB.WithContext(ctx)
+
+	if err := ensurePatientAccess(viewerID, s.PatientID); err != nil {
+		return nil, err
+	}
+
+	lines := s.PrescriptionLines
+	s.PrescriptionLines = nil
+	if err := preparePrescriptionLines(db, s.PatientID, &s.HealthWorkerID, nil, lines); err != nil {
+		return nil, err
+	}
+	if s.CurrentPrescription == "" {
+		s.CurrentPrescription = model.DescribePrescription(lines)
+	}
+	if err := reviewPrescriptionSafety(db, s.PatientID, "session_id", 0, lines, &s.SafetyWarnings, &s.OverrideReason); err != nil {
+		s.PrescriptionLines = lines
+		return s, err
+	}
 
 	// Create session
-	if err := database.DB.Create(s).Error; err != nil {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := openSession(tx, viewerID, s); err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			lines = freeTextLines(s.PatientID, &s.HealthWorkerID, s.CurrentPrescription, s.Date)
+		}
+		return syncPrescriptionLines(tx, "session_id", s.ID, nil, lines)
+	})
+	if err != nil {
 		return nil, err
 	}
+	s.PrescriptionLines = lines
+
+	// The session's health worker joins the patient's care team
+	if err := addToCareTeam(s.PatientID, s.HealthWorkerID, model.CareTeamRoleMember); err != nil {
+		return nil, errors.New("failed to assign care team: " + err.Error())
+	}
 	return s, nil
 }
 
-// GetAllSessions returns all sessions
-func (c *SessionController) GetAllSessions() ([]model.Session, error) {
+// GetAllSessions returns all sessions of patients the viewer has access to
+func (c *SessionController) GetAllSessions(viewerID uint) ([]model.Session, error) {
 	var sessions []model.Session
-	if err := database.DB.Preload("Patient").Preload("HealthWorker").Find(&sessions).Error; err != nil {
+	if err := database.DB.Scopes(accessiblePatients(viewerID, "patient_id")).Preload("Patient").Preload("HealthWorker").Find(&sessions).Error; err != nil {
 		return nil, err
 	}
 	return sessions, nil
 }
 
 // GetSessionByID fetches a session by its numeric ID
-func (c *SessionController) GetSessionByID(id uint) (*model.Session, error) {
+func (c *SessionController) GetSessionByID(viewerID, id uint) (*model.Session, error) {
+	if err := ensureSessionAccess(viewerID, id); err != nil {
+		return nil, err
+	}
+
 	var session model.Session
-	if err := database.DB.Preload("Patient").Preload("HealthWorker").First(&session, id).Error; err != nil {
+	if err := database.DB.Preload("Patient").Preload("HealthWorker").
+		Preload("PrescriptionLines", withPrescriptionDetails).
+		First(&session, id).Error; err != nil {
 		return nil, err
 	}
 	return &session, nil
 }
 
 // GetSessionByCode retrieves session via unique string code
-func (c *SessionController) GetSessionByCode(code string) (*model.Session, error) {
+func (c *SessionController) GetSessionByCode(viewerID uint, code string) (*model.Session, error) {
 	var session model.Session
 	if err := database.DB.Preload("Patient").Preload("HealthWorker").Where("session_code = ?", code).First(&session).Error; err != nil {
 		return nil, err
 	}
+	if err := ensurePatientAccess(viewerID, session.PatientID); err != nil {
+		return nil, err
+	}
 	return &session, nil
 }
 
 // GetSessionsByPatient returns all sessions for a given patient
-func (c *SessionController) GetSessionsByPatient(patientID uint) ([]model.Session, error) {
+func (c *SessionController) GetSessionsByPatient(viewerID, patientID uint) ([]model.Session, error) {
+	if err := ensurePatientAccess(viewerID, patientID); err != nil {
+		return nil, err
+	}
+
 	var sessions []model.Session
 	if err := database.DB.Preload("Patient").Preload("HealthWorker").
 		Where("patient_id = ?", patientID).Find(&sessions).Error; err != nil {
@@ -88,15 +159,23 @@ func (c *SessionController) GetSessionsByHealthWorker(healthWorkerID uint) ([]mo
 	return sessions, nil
 }
 
-// UpdateSession allows modification of session data
-func (c *SessionController) UpdateSession(id uint, updated *model.Session) (*model.Session, error) {
+// UpdateSession allows modification of session data. A status change goes through the session
+// lifecycle like UpdateSessionStatus. Prescription lines, when given, are written and safety
+// checked like the lines of a medication history entry: edited in place by ID, added, or stopped
+// when left out.
+func (c *SessionController) UpdateSession(ctx context.Context, viewerID, id uint, updated *model.Session) (*model.Session, error) {
+	db := database.DB.WithContext(ctx)
+
+	if err := ensureSessionAccess(viewerID, id); err != nil {
+		return nil, err
+	}
+
 	var session model.Session
-	if err := database.DB.First(&session, id).Error; err != nil {
+	if err := db.First(&session, id).Error; err != nil {
 		return nil, err
 	}
 
 	// Update basic fields
-	session.Status = updated.Status
 	session.SessionIssue = updated.SessionIssue
 	session.Description = updated.Description
 	session.PatientStateAtRegistration = updated.PatientStateAtRegistration
@@ -104,48 +183,101 @@ func (c *SessionController) UpdateSession(id uint, updated *model.Session) (*mod
 	session.CurrentPrescription = updated.CurrentPrescription
 	session.PreviousSessionID = updated.PreviousSessionID
 
-	if err := session.ValidateStatus(); err != nil {
+	current, err := ownedPrescriptionLines(db, "session_id", session.ID)
+	if err != nil {
 		return nil, err
 	}
+	lines := updated.PrescriptionLines
+	if lines != nil {
+		if err := preparePrescriptionLines(db, session.PatientID, &session.HealthWorkerID, current, lines); err != nil {
+			return nil, err
+		}
+		if session.CurrentPrescription == "" {
+			session.CurrentPrescription = model.DescribePrescription(lines)
+		}
+		session.OverrideReason = updated.OverrideReason
+		if err := reviewPrescriptionSafety(db, session.PatientID, "session_id", session.ID, lines, &session.SafetyWarnings, &session.OverrideReason); err != nil {
+			session.PrescriptionLines = lines
+			return &session, err
+		}
+	}
 
-	if err := database.DB.Save(&session).Error; err != nil {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Status", "PrescriptionLines").Save(&session).Error; err != nil {
+			return err
+		}
+		if lines != nil {
+			if err := syncPrescriptionLines(tx, "session_id", session.ID, current, lines); err != nil {
+				return err
+			}
+			if err := tx.Scopes(withPrescriptionDetails).Where("session_id = ?", session.ID).Find(&session.PrescriptionLines).Error; err != nil {
+				return err
+			}
+		}
+		if updated.Status == "" || updated.Status == session.Status {
+			return nil
+		}
+		moved, err := transitionSession(tx, id, updated.Status, viewerID, "")
+		if err != nil {
+			return err
+		}
+		session.Status = moved.Status
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &session, nil
 }
 
-// DeleteSession removes a session by ID
-func (c *SessionController) DeleteSession(id uint) error {
+// DeleteSession removes a session of a patient the viewer has access to
+func (c *SessionController) DeleteSession(ctx context.Context, viewerID, id uint) error {
+	db := database.DB.WithContext(ctx)
+
+	if err := ensureSessionAccess(viewerID, id); err != nil {
+		return err
+	}
+
 	var session model.Session
-	if err := database.DB.First(&session, id).Error; err != nil {
+	if err := db.First(&session, id).Error; err != nil {
 		return err
 	}
-	return database.DB.Delete(&session).Error
+	return db.Delete(&session).Error
 }
 
 
-func (sc *SessionController) UpdateSessionStatus(id uint, status string) error {
-	var session model.Session
-	if err := database.DB.First(&session, id).Error; err != nil {
-		return errors.New("session not found")
-	}
+// UpdateSessionStatus moves a session along its lifecycle, e.g. from checked_in to ongoing. The
+// transition must be allowed from the current status and pass every registered guard.
+func (sc *SessionController) UpdateSessionStatus(ctx context.Context, viewerID, id uint, status, reason string) error {
+	db := database.DB.WithContext(ctx)
 
-	valid := map[string]bool{
-		model.SessionOngoing:   true,
-		model.SessionCompleted: true,
-		model.SessionCancelled: true,
+	if err := ensureSessionAccess(viewerID, id); err != nil {
+		return err
 	}
-	if !valid[status] {
-		return errors.New("invalid session status")
+
+	_, err := transitionSession(db, id, status, viewerID, reason)
+	return err
+}
+
+// GetSessionTransitions lists a session's status history, oldest first
+func (sc *SessionController) GetSessionTransitions(viewerID, id uint) ([]model.SessionTransition, error) {
+	if err := ensureSessionAccess(viewerID, id); err != nil {
+		return nil, err
 	}
 
-	session.Status = status
-	return database.DB.Save(&session).Error
+	var transitions []model.SessionTransition
+	if err := database.DB.Preload("Actor").
+		Where("session_id = ?", id).
+		Order("created_at ASC, id ASC").
+		Find(&transitions).Error; err != nil {
+		return nil, err
+	}
+	return transitions, nil
 }
 
-func (c *SessionController) SearchSessions (queryParams map[string]string,) ([]model.Session, error) {
+func (c *SessionController) SearchSessions (viewerID uint, queryParams map[string]string,) ([]model.Session, error) {
 	var sessions []model.Session
-	dbQuery := database.DB.Preload("HealthWorker").Preload("Patient").Preload("Diagnosis").Preload("SessionSummary")
+	dbQuery := database.DB.Scopes(accessiblePatients(viewerID, "patient_id")).Preload("HealthWorker").Preload("Patient").Preload("Diagnosis").Preload("SessionSummary")
 
 	if sessionCode, ok := queryParams["session_code"]; ok && sessionCode != "" {
         dbQuery = dbQuery.Where("session_code = ?", sessionCode)
@@ -159,16 +291,10 @@ func (c *SessionController) SearchSessions (queryParams map[string]string,) ([]m
     }
 
 	if status, ok := queryParams["status"]; ok && status != "" {
-        // assuming is_active is a boolean field in model
-        switch status {
-		case model.SessionOngoing:
-            dbQuery = dbQuery.Where("status = ?", true)
-        case model.SessionCompleted:
-            dbQuery = dbQuery.Where("status = ?", true)
-		case model.SessionCancelled:
-			dbQuery = dbQuery.Where("status = ?", true)
+		if (&model.Session{Status: status}).ValidateStatus() == nil {
+			dbQuery = dbQuery.Where("status = ?", status)
 		}
-    }
+	}
 
 	err := dbQuery.Find(&sessions).Error
     if err != nil {
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSessionTransition is returned when a session cannot move to the requested status, either
+// because the lifecycle does not allow it or because a guard vetoed it
+var ErrSessionTransition = errors.New("session transition not allowed")
+
+// SessionEvent describes a session changing status
+type SessionEvent struct {
+	Session *model.Session // already carries the new status in after-hooks
+	From    string
+	To      string
+	ActorID uint // 0 for system jobs
+	Reason  string
+}
+
+// SessionHook reacts to a session transition. Hooks run inside the transition's transaction, so
+// returning an error rolls the transition back.
+type SessionHook func(tx *gorm.DB, event *SessionEvent) error
+
+// Hooks keyed by target status; "" matches every transition. Modules register theirs from init.
+var (
+	beforeSessionHooks = map[string][]SessionHook{}
+	afterSessionHooks  = map[string][]SessionHook{}
+)
+
+// BeforeSessionTransition registers a guard run before a session moves to status, or to any status
+// when status is "". An error from the guard vetoes the transition.
+func BeforeSessionTransition(status string, hook SessionHook) {
+	beforeSessionHooks[status] = append(beforeSessionHooks[status], hook)
+}
+
+// AfterSessionTransition registers a hook run once a session has moved to status, or to any status
+// when status is ""
+func AfterSessionTransition(status string, hook SessionHook) {
+	afterSessionHooks[status] = append(afterSessionHooks[status], hook)
+}
+
+func runSessionHooks(hooks map[string][]SessionHook, tx *gorm.DB, event *SessionEvent) error {
+	for _, key := range []string{"", event.To} {
+		for _, hook := range hooks[key] {
+			if err := hook(tx, event); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	BeforeSessionTransition(model.SessionCompleted, requireSessionOutcome)
+}
+
+// requireSessionOutcome only lets a session complete once it has a diagnosis and a summary
+func requireSessionOutcome(tx *gorm.DB, event *SessionEvent) error {
+	var diagnoses, summaries int64
+	if err := tx.Model(&model.Diagnosis{}).Where("session_id = ?", event.Session.ID).Count(&diagnoses).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.SessionSummary{}).Where("session_id = ?", event.Session.ID).Count(&summaries).Error; err != nil {
+		return err
+	}
+	switch {
+	case diagnoses == 0 && summaries == 0:
+		return errors.New("record a diagnosis and a session summary before completing the session")
+	case diagnoses == 0:
+		return errors.New("record a diagnosis before completing the session")
+	case summaries == 0:
+		return errors.New("write a session summary before completing the session")
+	}
+	return nil
+}
+
+// recordSessionTransition appends a transition to the session's history
+func recordSessionTransition(tx *gorm.DB, sessionID uint, from, to string, actorID uint, reason string) error {
+	transition := model.SessionTransition{SessionID: sessionID, FromStatus: from, ToStatus: to, Reason: reason}
+	if actorID != 0 {
+		transition.ActorID = &actorID
+	}
+	return tx.Create(&transition).Error
+}
+
+// transitionSession moves a session to status if the lifecycle allows it and every guard agrees,
+// records the transition and runs the after-hooks, all in one transaction
+func transitionSession(db *gorm.DB, sessionID uint, to string, actorID uint, reason string) (*model.Session, error) {
+	var session model.Session
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, sessionID).Error; err != nil {
+			return errors.New("session not found")
+		}
+
+		from := session.Status
+		if !model.CanTransition(from, to) {
+			return fmt.Errorf("%w: a %s session cannot become %s", ErrSessionTransition, from, to)
+		}
+
+		event := &SessionEvent{Session: &session, From: from, To: to, ActorID: actorID, Reason: reason}
+		if err := runSessionHooks(beforeSessionHooks, tx, event); err != nil {
+			return fmt.Errorf("%w: %w", ErrSessionTransition, err)
+		}
+
+		session.Status = to
+		if err := tx.Model(&session).Update("status", to).Error; err != nil {
+			return err
+		}
+		if err := recordSessionTransition(tx, session.ID, from, to, actorID, reason); err != nil {
+			return err
+		}
+		return runSessionHooks(afterSessionHooks, tx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
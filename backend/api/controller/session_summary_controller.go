@@ -1,10 +1,17 @@
 package controller
 
 import (
+	"context"
 	"depression-diagnosis-system/api/interfaces"
 	"depression-diagnosis-system/database"
 	"depression-diagnosis-system/database/model"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SessionSummaryController struct{}
@@ -13,12 +20,73 @@ func NewSessionSummaryController() interfaces.SessionSummaryInterface {
 	return &SessionSummaryController{}
 }
 
-// CreateSummary creates a session summary entry
-func (c *SessionSummaryController) CreateSummary(s *model.SessionSummary) (*model.SessionSummary, error) {
-	// Check if session exists
-	var session model.Session
-	if err := database.DB.First(&session, s.SessionID).Error; err != nil {
-		return nil, errors.New("session not found")
+// summaryTemplate loads the template a summary is written on: the one named, else the default of
+// the patient's department
+func summaryTemplate(tx *gorm.DB, sessionID uint, templateID *uint) (*model.NoteTemplate, error) {
+	if templateID != nil {
+		return loadNoteTemplate(tx, *templateID)
+	}
+	template, err := departmentNoteTemplate(tx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, errors.New("no note template given and the patient's department has no default")
+	}
+	return template, nil
+}
+
+// writeStructuredNotes checks a summary's sections against its template, stores them in template
+// order and renders them into Notes
+func writeStructuredNotes(s *model.SessionSummary, template *model.NoteTemplate) error {
+	var values []model.NoteSection
+	if len(s.Sections) > 0 {
+		if err := json.Unmarshal(s.Sections, &values); err != nil {
+			return errors.New("sections must be a list of {key, value} entries")
+		}
+	}
+	filled, err := template.Fill(values)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(filled)
+	if err != nil {
+		return err
+	}
+
+	s.NoteTemplateID = &template.ID
+	s.Sections = encoded
+	s.Notes = model.RenderNote(template, filled)
+	return nil
+}
+
+// CreateSummary creates a session summary entry, authored by the viewer. A structured summary
+// names its note template, or sends its sections alone to use the default template of the
+// patient's department; a summary with only notes stays free text. A summary written after the
+// session was completed is locked straight away.
+func (c *SessionSummaryController) CreateSummary(ctx context.Context, viewerID uint, s *model.SessionSummary) (*model.SessionSummary, error) {
+	db := database.DB.WithContext(ctx)
+
+	// Check if session exists and the viewer may see its patient
+	if err := ensureSessionAccess(viewerID, s.SessionID); err != nil {
+		return nil, err
+	}
+
+	var template *model.NoteTemplate
+	s.NoteTemplate = nil
+	if s.NoteTemplateID != nil || len(s.Sections) > 0 {
+		var err error
+		if template, err = summaryTemplate(db, s.SessionID, s.NoteTemplateID); err != nil {
+			return nil, err
+		}
+		if !template.IsActive {
+			return nil, fmt.Errorf("note template %s is no longer in use", template.Code)
+		}
+		if err := writeStructuredNotes(s, template); err != nil {
+			return nil, err
+		}
+	} else {
+		s.Sections = nil
 	}
 
 	// Ensure notes are not empty
@@ -26,43 +94,138 @@ func (c *SessionSummaryController) CreateSummary(s *model.SessionSummary) (*mode
 		return nil, errors.New("summary notes cannot be empty")
 	}
 
-	if err := database.DB.Create(s).Error; err != nil {
+	var session model.Session
+	if err := db.Select("id", "status").First(&session, s.SessionID).Error; err != nil {
 		return nil, err
 	}
+	resetSummaryRecord(s)
+	if viewerID != 0 {
+		s.AuthorID = &viewerID
+	}
+	if session.Status == model.SessionCompleted {
+		now := time.Now()
+		s.LockedAt = &now
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(s).Error; err != nil {
+			return err
+		}
+		return recordSummaryVersion(tx, s, model.SummaryCreated, viewerID, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.NoteTemplate = template
 	return s, nil
 }
 
-// GetSummaryBySessionID retrieves the summary for a given session
-func (c *SessionSummaryController) GetSummaryBySessionID(sessionID uint) (*model.SessionSummary, error) {
+// GetSummaryBySessionID retrieves the summary for a given session with its addenda
+func (c *SessionSummaryController) GetSummaryBySessionID(viewerID, sessionID uint) (*model.SessionSummary, error) {
+	if err := ensureSessionAccess(viewerID, sessionID); err != nil {
+		return nil, err
+	}
+
 	var summary model.SessionSummary
-	if err := database.DB.Preload("Session").Where("session_id = ?", sessionID).First(&summary).Error; err != nil {
+	if err := database.DB.Preload("Session").
+		Preload("Addenda", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Preload("Addenda.Author").
+		Where("session_id = ?", sessionID).
+		First(&summary).Error; err != nil {
 		return nil, err
 	}
 	return &summary, nil
 }
 
-// UpdateSummary updates notes for a summary
-func (c *SessionSummaryController) UpdateSummary(id uint, updated *model.SessionSummary) (*model.SessionSummary, error) {
+// UpdateSummary updates notes for a summary that has not been signed or locked yet. A structured
+// summary is changed through its sections, which are re-rendered into its notes; sending a
+// template and sections turns a free-text summary into a structured one. Every edit is kept in the
+// summary's history.
+func (c *SessionSummaryController) UpdateSummary(ctx context.Context, viewerID, id uint, updated *model.SessionSummary) (*model.SessionSummary, error) {
+	db := database.DB.WithContext(ctx)
+
 	var summary model.SessionSummary
-	if err := database.DB.First(&summary, id).Error; err != nil {
+	if err := db.First(&summary, id).Error; err != nil {
 		return nil, err
 	}
-
-	if updated.Notes != "" {
-		summary.Notes = updated.Notes
+	if err := ensureSessionAccess(viewerID, summary.SessionID); err != nil {
+		return nil, err
 	}
 
-	if err := database.DB.Save(&summary).Error; err != nil {
+	var template *model.NoteTemplate
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockSummary(tx, &summary); err != nil {
+			return err
+		}
+		if summary.IsFinal() {
+			return fmt.Errorf("%w; add an addendum instead", ErrSummaryFinal)
+		}
+		before := summary
+
+		structured := updated.NoteTemplateID != nil || len(updated.Sections) > 0
+		switch {
+		case structured:
+			templateID := updated.NoteTemplateID
+			if templateID == nil {
+				templateID = summary.NoteTemplateID
+			}
+			var err error
+			if template, err = summaryTemplate(tx, summary.SessionID, templateID); err != nil {
+				return err
+			}
+			// A retired template still serves the summaries already written on it
+			if !template.IsActive && (summary.NoteTemplateID == nil || *summary.NoteTemplateID != template.ID) {
+				return fmt.Errorf("note template %s is no longer in use", template.Code)
+			}
+			if len(updated.Sections) > 0 {
+				summary.Sections = updated.Sections
+			}
+			if err := writeStructuredNotes(&summary, template); err != nil {
+				return err
+			}
+		case summary.NoteTemplateID != nil && updated.Notes != "":
+			return errors.New("a structured summary is edited through its sections")
+		case updated.Notes != "":
+			summary.Notes = updated.Notes
+		}
+
+		if !summaryContentChanged(&before, &summary) {
+			return nil
+		}
+		summary.Version++
+		if err := tx.Omit(clause.Associations).Save(&summary).Error; err != nil {
+			return err
+		}
+		return recordSummaryVersion(tx, &summary, model.SummaryEdited, viewerID, nil)
+	})
+	if err != nil {
 		return nil, err
 	}
+	summary.NoteTemplate = template
 	return &summary, nil
 }
 
-// DeleteSummary deletes the session summary by ID
-func (c *SessionSummaryController) DeleteSummary(id uint) error {
+// DeleteSummary deletes a session summary that has not been signed or locked yet
+func (c *SessionSummaryController) DeleteSummary(ctx context.Context, viewerID, id uint) error {
+	db := database.DB.WithContext(ctx)
+
 	var summary model.SessionSummary
-	if err := database.DB.First(&summary, id).Error; err != nil {
+	if err := db.First(&summary, id).Error; err != nil {
+		return err
+	}
+	if err := ensureSessionAccess(viewerID, summary.SessionID); err != nil {
 		return err
 	}
-	return database.DB.Delete(&summary).Error
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := lockSummary(tx, &summary); err != nil {
+			return err
+		}
+		if summary.IsFinal() {
+			return fmt.Errorf("%w and cannot be deleted", ErrSummaryFinal)
+		}
+		if err := recordSummaryVersion(tx, &summary, model.SummaryDeleted, viewerID, nil); err != nil {
+			return err
+		}
+		return tx.Delete(&summary).Error
+	})
 }
@@ -0,0 +1,254 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSummaryFinal is returned when a signed or locked summary would be edited or deleted
+var ErrSummaryFinal = errors.New("session summary is signed or locked")
+
+// ErrSummarySignature is returned when the viewer is not the one who may sign or co-sign a summary
+var ErrSummarySignature = errors.New("not allowed to sign this session summary")
+
+func init() {
+	AfterSessionTransition(model.SessionCompleted, lockSessionSummaries)
+}
+
+// lockSessionSummaries makes the summaries of a completed session final
+func lockSessionSummaries(tx *gorm.DB, event *SessionEvent) error {
+	var summaries []model.SessionSummary
+	if err := tx.Where("session_id = ? AND locked_at IS NULL", event.Session.ID).Find(&summaries).Error; err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range summaries {
+		s := &summaries[i]
+		if err := tx.Model(s).Update("locked_at", now).Error; err != nil {
+			return err
+		}
+		s.LockedAt = &now
+		if err := recordSummaryVersion(tx, s, model.SummaryLocked, event.ActorID, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resetSummaryRecord clears the version, authorship and signatures of a submitted summary so only
+// the controller sets them
+func resetSummaryRecord(s *model.SessionSummary) {
+	s.ID = 0
+	s.Version = 1
+	s.AuthorID, s.Author = nil, nil
+	s.SignedAt, s.SignedByID = nil, nil
+	s.CoSignRequired, s.CoSignedAt, s.CoSignedByID = false, nil, nil
+	s.LockedAt = nil
+	s.Addenda = nil
+}
+
+// summaryContentChanged reports whether an edit changed what the summary says
+func summaryContentChanged(before, after *model.SessionSummary) bool {
+	sameTemplate := (before.NoteTemplateID == nil) == (after.NoteTemplateID == nil) &&
+		(before.NoteTemplateID == nil || *before.NoteTemplateID == *after.NoteTemplateID)
+	return before.Notes != after.Notes || !sameTemplate || !bytes.Equal(before.Sections, after.Sections)
+}
+
+// lockSummary reloads a summary and holds its row until the transaction ends
+func lockSummary(tx *gorm.DB, s *model.SessionSummary) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(s, s.ID).Error
+}
+
+// recordSummaryVersion appends the summary's current content to its history
+func recordSummaryVersion(tx *gorm.DB, s *model.SessionSummary, event string, actorID uint, addendumID *uint) error {
+	version := model.NewSummaryVersion(s, event, actorID)
+	version.AddendumID = addendumID
+	return tx.Create(&version).Error
+}
+
+// summaryAuthor returns who wrote a summary. Summaries written before authorship was recorded
+// belong to the session's health worker.
+func summaryAuthor(tx *gorm.DB, s *model.SessionSummary) (*model.HealthWorker, error) {
+	authorID := s.AuthorID
+	if authorID == nil {
+		var session model.Session
+		if err := tx.Select("id", "health_worker_id").First(&session, s.SessionID).Error; err != nil {
+			return nil, err
+		}
+		authorID = &session.HealthWorkerID
+	}
+
+	var author model.HealthWorker
+	if err := tx.Preload("PersonnelType").First(&author, *authorID).Error; err != nil {
+		return nil, errors.New("summary author not found")
+	}
+	return &author, nil
+}
+
+// SignSummary e-signs a summary on behalf of its author, making its content final. Summaries by
+// interns and nursing staff then wait for the author's supervisor to co-sign.
+func (c *SessionSummaryController) SignSummary(ctx context.Context, viewerID, id uint) (*model.SessionSummary, error) {
+	db := database.DB.WithContext(ctx)
+
+	var summary model.SessionSummary
+	if err := db.First(&summary, id).Error; err != nil {
+		return nil, err
+	}
+	if err := ensureSessionAccess(viewerID, summary.SessionID); err != nil {
+		return nil, err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockSummary(tx, &summary); err != nil {
+			return err
+		}
+		if summary.SignedAt != nil {
+			return errors.New("session summary is already signed")
+		}
+		author, err := summaryAuthor(tx, &summary)
+		if err != nil {
+			return err
+		}
+		if author.ID != viewerID {
+			return fmt.Errorf("%w: only its author can sign it", ErrSummarySignature)
+		}
+		coSign := model.NeedsCoSignature(author.PersonnelType.Name)
+		if coSign && author.SupervisorID == nil {
+			return fmt.Errorf("summaries by a %s need a supervisor's co-signature, and you have no supervisor assigned",
+				strings.ToLower(author.PersonnelType.Name))
+		}
+
+		now := time.Now()
+		summary.SignedAt, summary.SignedByID, summary.CoSignRequired = &now, &viewerID, coSign
+		if err := tx.Model(&summary).Updates(map[string]interface{}{
+			"signed_at":        now,
+			"signed_by_id":     viewerID,
+			"co_sign_required": coSign,
+		}).Error; err != nil {
+			return err
+		}
+		return recordSummaryVersion(tx, &summary, model.SummarySigned, viewerID, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// CoSignSummary records the supervisor's co-signature on a signed summary that needs one
+func (c *SessionSummaryController) CoSignSummary(ctx context.Context, viewerID, id uint) (*model.SessionSummary, error) {
+	db := database.DB.WithContext(ctx)
+
+	var summary model.SessionSummary
+	if err := db.First(&summary, id).Error; err != nil {
+		return nil, err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockSummary(tx, &summary); err != nil {
+			return err
+		}
+		if !summary.AwaitingCoSignature() {
+			return errors.New("session summary is not awaiting a co-signature")
+		}
+		author, err := summaryAuthor(tx, &summary)
+		if err != nil {
+			return err
+		}
+		if author.SupervisorID == nil || *author.SupervisorID != viewerID {
+			return fmt.Errorf("%w: only the author's supervisor can co-sign it", ErrSummarySignature)
+		}
+
+		now := time.Now()
+		summary.CoSignedAt, summary.CoSignedByID = &now, &viewerID
+		if err := tx.Model(&summary).Updates(map[string]interface{}{
+			"co_signed_at":    now,
+			"co_signed_by_id": viewerID,
+		}).Error; err != nil {
+			return err
+		}
+		return recordSummaryVersion(tx, &summary, model.SummaryCoSigned, viewerID, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// GetPendingCoSignatures lists the signed summaries waiting for the viewer's co-signature
+func (c *SessionSummaryController) GetPendingCoSignatures(viewerID uint) ([]model.SessionSummary, error) {
+	var summaries []model.SessionSummary
+	err := database.DB.Preload("Author").
+		Joins("JOIN sessions ON sessions.id = session_summaries.session_id").
+		Where("session_summaries.signed_at IS NOT NULL AND session_summaries.co_sign_required AND session_summaries.co_signed_at IS NULL").
+		Where("COALESCE(session_summaries.author_id, sessions.health_worker_id) IN (SELECT id FROM health_workers WHERE supervisor_id = ?)", viewerID).
+		Order("session_summaries.signed_at").
+		Find(&summaries).Error
+	if err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// AddAddendum appends a correction or late entry to a signed or locked summary. Drafts are edited
+// directly instead.
+func (c *SessionSummaryController) AddAddendum(ctx context.Context, viewerID, id uint, text string) (*model.SummaryAddendum, error) {
+	db := database.DB.WithContext(ctx)
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.New("addendum text cannot be empty")
+	}
+
+	var summary model.SessionSummary
+	if err := db.First(&summary, id).Error; err != nil {
+		return nil, err
+	}
+	if err := ensureSessionAccess(viewerID, summary.SessionID); err != nil {
+		return nil, err
+	}
+	if !summary.IsFinal() {
+		return nil, errors.New("session summary is still a draft; edit it instead")
+	}
+
+	addendum := model.SummaryAddendum{SessionSummaryID: summary.ID, AuthorID: viewerID, Text: text}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&addendum).Error; err != nil {
+			return err
+		}
+		return recordSummaryVersion(tx, &summary, model.SummaryAddended, viewerID, &addendum.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &addendum, nil
+}
+
+// GetSummaryHistory lists every version of a summary, oldest first, deleted summaries included
+func (c *SessionSummaryController) GetSummaryHistory(viewerID, id uint) ([]model.SummaryVersion, error) {
+	var summary model.SessionSummary
+	if err := database.DB.Unscoped().First(&summary, id).Error; err != nil {
+		return nil, err
+	}
+	if err := ensureSessionAccess(viewerID, summary.SessionID); err != nil {
+		return nil, err
+	}
+
+	var versions []model.SummaryVersion
+	if err := database.DB.Preload("Actor").
+		Where("session_summary_id = ?", id).
+		Order("id").
+		Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
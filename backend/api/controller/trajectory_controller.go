@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+	"sort"
+)
+
+type TrajectoryController struct{}
+
+func NewTrajectoryController() interfaces.TrajectoryInterface {
+	return &TrajectoryController{}
+}
+
+// GetPatientTrajectory builds a patient's PHQ-9 trajectory from their scored sessions, oldest
+// first, with medication adherence between sessions. Cancelled sessions are left out.
+func (c *TrajectoryController) GetPatientTrajectory(viewerID, patientID uint) (*model.Trajectory, error) {
+	if err := ensurePatientAccess(viewerID, patientID); err != nil {
+		return nil, err
+	}
+
+	var sessions []model.Session
+	if err := database.DB.
+		Joins("Diagnosis").
+		Where("sessions.patient_id = ? AND sessions.status <> ?", patientID, model.SessionCancelled).
+		Order("sessions.date ASC, sessions.id ASC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	trajectory := &model.Trajectory{PatientID: patientID, Points: []model.TrajectoryPoint{}, Items: []model.ItemTrend{}, Flags: []string{}}
+	sessionIDs := make([]uint, 0, len(sessions))
+	for _, s := range sessions {
+		if s.Diagnosis.ID == 0 {
+			continue
+		}
+		trajectory.Points = append(trajectory.Points, model.TrajectoryPoint{
+			SessionID:    s.ID,
+			SessionCode:  s.SessionCode,
+			Date:         s.Date,
+			Score:        s.Diagnosis.Phq9Score,
+			Severity:     s.Diagnosis.Severity,
+			Prescription: s.CurrentPrescription,
+			Flags:        []string{},
+		})
+		sessionIDs = append(sessionIDs, s.ID)
+	}
+	if len(trajectory.Points) == 0 {
+		return trajectory, nil
+	}
+
+	baseline := trajectory.Points[0].Score
+	for i := range trajectory.Points {
+		point := &trajectory.Points[i]
+		point.Change = point.Score - baseline
+		point.PercentChange = model.PercentChange(baseline, point.Score)
+		if i > 0 {
+			point.Flags = model.TreatmentFlags(baseline, point.Score)
+		}
+	}
+	if err := sessionAdherence(patientID, trajectory.Points); err != nil {
+		return nil, err
+	}
+	trajectory.Baseline = &trajectory.Points[0]
+	trajectory.Latest = &trajectory.Points[len(trajectory.Points)-1]
+	trajectory.Flags = trajectory.Latest.Flags
+	trajectory.CurrentPrescription = prescriptionResponse(trajectory.Points)
+
+	items, err := phq9ItemTrends(sessionIDs, trajectory.Points)
+	if err != nil {
+		return nil, err
+	}
+	trajectory.Items = items
+	return trajectory, nil
+}
+
+// prescriptionResponse measures the latest prescription against the first session it was
+// recorded at, or returns nil if the latest session has none
+func prescriptionResponse(points []model.TrajectoryPoint) *model.PrescriptionResponse {
+	latest := points[len(points)-1]
+	if latest.Prescription == "" {
+		return nil
+	}
+	start := len(points) - 1
+	for start > 0 && points[start-1].Prescription == latest.Prescription {
+		start--
+	}
+
+	since := points[start]
+	flags := []string{}
+	if start < len(points)-1 {
+		flags = model.TreatmentFlags(since.Score, latest.Score)
+	}
+	return &model.PrescriptionResponse{
+		Prescription:  latest.Prescription,
+		Since:         since.Date,
+		Sessions:      len(points) - start,
+		BaselineScore: since.Score,
+		LatestScore:   latest.Score,
+		Change:        latest.Score - since.Score,
+		PercentChange: model.PercentChange(since.Score, latest.Score),
+		Flags:         flags,
+	}
+}
+
+// phq9ItemTrends follows each PHQ-9 item, by item number, across the sessions' PHQ-9 responses.
+// Item text is taken from the latest version answered.
+func phq9ItemTrends(sessionIDs []uint, points []model.TrajectoryPoint) ([]model.ItemTrend, error) {
+	var responses []model.InstrumentResponse
+	if err := database.DB.
+		Preload("Answers.Item").
+		Where("session_id IN ? AND instrument_id IN (SELECT id FROM instruments WHERE code = ?)", sessionIDs, model.InstrumentPHQ9).
+		Find(&responses).Error; err != nil {
+		return nil, err
+	}
+
+	order := make(map[uint]int, len(points))
+	for i, p := range points {
+		order[p.SessionID] = i
+	}
+	sort.Slice(responses, func(a, b int) bool {
+		return order[responses[a].SessionID] < order[responses[b].SessionID]
+	})
+
+	trends := map[int]*model.ItemTrend{}
+	for _, response := range responses {
+		date := points[order[response.SessionID]].Date
+		for _, answer := range response.Answers {
+			if answer.Item == nil {
+				continue
+			}
+			trend, ok := trends[answer.Item.Position]
+			if !ok {
+				trend = &model.ItemTrend{Position: answer.Item.Position, Baseline: answer.Score}
+				trends[answer.Item.Position] = trend
+			}
+			trend.Text = answer.Item.Text
+			trend.Scores = append(trend.Scores, model.ItemScore{SessionID: response.SessionID, Date: date, Score: answer.Score})
+			trend.Latest = answer.Score
+			trend.Change = trend.Latest - trend.Baseline
+		}
+	}
+
+	items := make([]model.ItemTrend, 0, len(trends))
+	for _, trend := range trends {
+		items = append(items, *trend)
+	}
+	sort.Slice(items, func(a, b int) bool { return items[a].Position < items[b].Position })
+	return items, nil
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"net/http"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/middleware"
+	"depression-diagnosis-system/api/util"
+	"depression-diagnosis-system/database/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdherenceHandler struct {
+	AdherenceController interfaces.AdherenceInterface
+}
+
+func NewAdherenceHandler() *AdherenceHandler {
+	return &AdherenceHandler{
+		AdherenceController: controller.NewAdherenceController(),
+	}
+}
+
+// RecordDispensing records a supply of a prescription line's drug (requires medication:update)
+func (ah *AdherenceHandler) RecordDispensing(c *gin.Context) {
+	lineID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid prescription line ID"})
+		return
+	}
+
+	var input model.DispensingEvent
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	event, err := ah.AdherenceController.RecordDispensing(c.Request.Context(), c.GetUint("userID"), lineID, &input)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to record dispensing: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Dispensing recorded successfully",
+		"dispensing": event,
+	})
+}
+
+// RecordCheckIn records the doses a patient reports missing (requires medication:update)
+func (ah *AdherenceHandler) RecordCheckIn(c *gin.Context) {
+	lineID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid prescription line ID"})
+		return
+	}
+
+	var input model.AdherenceCheckIn
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	checkIn, err := ah.AdherenceController.RecordCheckIn(c.Request.Context(), c.GetUint("userID"), lineID, &input)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to record adherence check-in: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Adherence check-in recorded successfully",
+		"check_in": checkIn,
+	})
+}
+
+// GetLineAdherence returns a prescription line's adherence with its dispensing events and check-ins
+func (ah *AdherenceHandler) GetLineAdherence(c *gin.Context) {
+	lineID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid prescription line ID"})
+		return
+	}
+
+	detail, err := ah.AdherenceController.GetLineAdherence(c.GetUint("userID"), lineID)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusNotFound), gin.H{"message": "Failed to compute adherence: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// GetPatientAdherence returns the adherence of every drug a patient is currently on
+func (ah *AdherenceHandler) GetPatientAdherence(c *gin.Context) {
+	patientID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		return
+	}
+
+	adherence, err := ah.AdherenceController.GetPatientAdherence(c.GetUint("userID"), patientID)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to compute adherence: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"adherence": adherence})
+}
+
+// GetAtRiskPatients lists patients whose adherence is at risk or whose refill is overdue:
+// ?health_worker_id= defaults to the caller
+func (ah *AdherenceHandler) GetAtRiskPatients(c *gin.Context) {
+	healthWorkerID, err := util.UintQuery(c, "health_worker_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	risks, err := ah.AdherenceController.GetAtRiskPatients(c.GetUint("userID"), healthWorkerID)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to build adherence worklist: " + err.Error()})
+		return
+	}
+
+	middleware.AuditShown(c, risks)
+	c.JSON(http.StatusOK, gin.H{"patients": risks})
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/middleware"
+	"depression-diagnosis-system/api/util"
+	"depression-diagnosis-system/database/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const csvContentType = "text/csv; charset=utf-8"
+
+type AdverseReactionHandler struct {
+	AdverseReactionController interfaces.AdverseReactionInterface
+}
+
+func NewAdverseReactionHandler() *AdverseReactionHandler {
+	return &AdverseReactionHandler{
+		AdverseReactionController: controller.NewAdverseReactionController(),
+	}
+}
+
+// ReportReaction records a side effect reported at a session (requires adverse_reaction:report)
+func (ah *AdverseReactionHandler) ReportReaction(c *gin.Context) {
+	sessionID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid session ID"})
+		return
+	}
+
+	var input model.AdverseReaction
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	reaction, err := ah.AdverseReactionController.ReportReaction(c.Request.Context(), c.GetUint("userID"), sessionID, &input)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to report adverse reaction: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":          "Adverse reaction reported successfully",
+		"adverse_reaction": reaction,
+	})
+}
+
+// UpdateReaction records the follow-up of a reaction (requires adverse_reaction:report)
+func (ah *AdverseReactionHandler) UpdateReaction(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		model.AdverseReaction
+		Serious *bool   `json:"serious"` // nil keeps the reaction's seriousness
+		Notes   *string `json:"notes"`   // nil keeps the notes
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	reaction, err := ah.AdverseReactionController.UpdateReaction(c.Request.Context(), c.GetUint("userID"), id, &input.AdverseReaction, input.Serious, input.Notes)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to update adverse reaction: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Adverse reaction updated successfully",
+		"adverse_reaction": reaction,
+	})
+}
+
+// GetSessionReactions lists the reactions reported at a session
+func (ah *AdverseReactionHandler) GetSessionReactions(c *gin.Context) {
+	sessionID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid session ID"})
+		return
+	}
+
+	reactions, err := ah.AdverseReactionController.GetSessionReactions(c.GetUint("userID"), sessionID)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to retrieve adverse reactions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"adverse_reactions": reactions})
+}
+
+// GetPatientReactions lists every reaction a patient has reported
+func (ah *AdverseReactionHandler) GetPatientReactions(c *gin.Context) {
+	patientID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		return
+	}
+
+	reactions, err := ah.AdverseReactionController.GetPatientReactions(c.GetUint("userID"), patientID)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to retrieve adverse reactions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"adverse_reactions": reactions})
+}
+
+// ExportADRForms downloads the reactions reported from ?from= to ?to= (inclusive, YYYY-MM-DD) as
+// a CSV for the national pharmacovigilance centre (requires pharmacovigilance:export). The period
+// defaults to the month up to today.
+func (ah *AdverseReactionHandler) ExportADRForms(c *gin.Context) {
+	to, err := util.DateQuery(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	from := to.AddDate(0, -1, 0)
+	if c.Query("from") != "" {
+		if from, err = util.DateQuery(c, "from"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "from must not be after to"})
+		return
+	}
+
+	export, patientIDs, err := ah.AdverseReactionController.ExportADRForms(c.GetUint("userID"), from, to.AddDate(0, 0, 1))
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to export adverse reactions: " + err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="adr-reports-%s-%s.csv"`, from.Format("20060102"), to.Format("20060102")))
+	c.Header("Cache-Control", "no-store")
+	middleware.AuditPatients(c, patientIDs...)
+	c.Data(http.StatusOK, csvContentType, []byte(export))
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/util"
+	"depression-diagnosis-system/database/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AllergyHandler struct {
+	AllergyController interfaces.AllergyInterface
+}
+
+func NewAllergyHandler() *AllergyHandler {
+	return &AllergyHandler{
+		AllergyController: controller.NewAllergyController(),
+	}
+}
+
+// GetPatientAllergies lists a patient's known allergies and intolerances
+func (ah *AllergyHandler) GetPatientAllergies(c *gin.Context) {
+	patientID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		return
+	}
+
+	allergies, err := ah.AllergyController.GetPatientAllergies(c.GetUint("userID"), patientID)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to retrieve allergies: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"allergies": allergies})
+}
+
+// AddAllergy records an allergy or intolerance for a patient (requires patient:update)
+func (ah *AllergyHandler) AddAllergy(c *gin.Context) {
+	patientID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		return
+	}
+
+	var input model.PatientAllergy
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	allergy, err := ah.AllergyController.AddAllergy(c.Request.Context(), c.GetUint("userID"), patientID, &input)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to record allergy: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Allergy recorded successfully",
+		"allergy": allergy,
+	})
+}
+
+// RemoveAllergy deletes an allergy or intolerance recorded in error (requires patient:update)
+func (ah *AllergyHandler) RemoveAllergy(c *gin.Context) {
+	patientID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		return
+	}
+	allergyID, err := strconv.ParseUint(c.Param("allergyId"), 10, 32)
+	if err != nil || allergyID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid allergy ID"})
+		return
+	}
+
+	if err := ah.AllergyController.RemoveAllergy(c.Request.Context(), c.GetUint("userID"), patientID, uint(allergyID)); err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusNotFound), gin.H{"message": "Failed to remove allergy: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Allergy removed successfully"})
+}
@@ -0,0 +1,358 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/middleware"
+	"depression-diagnosis-system/api/util"
+	"depression-diagnosis-system/database/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AppointmentHandler struct {
+	AppointmentController interfaces.AppointmentInterface
+}
+
+func NewAppointmentHandler() *AppointmentHandler {
+	return &AppointmentHandler{
+		AppointmentController: controller.NewAppointmentController(),
+	}
+}
+
+// appointmentErrorStatus maps a clash with another booking, leave or working hours to 409 and
+// denied access to 403
+func appointmentErrorStatus(err error, fallback int) int {
+	if errors.Is(err, controller.ErrAppointmentConflict) {
+		return http.StatusConflict
+	}
+	return accessErrorStatus(err, fallback)
+}
+
+// BookAppointment books a patient into a health worker's time (requires appointment:manage)
+func (ah *AppointmentHandler) BookAppointment(c *gin.Context) {
+	var input struct {
+		PatientID         uint      `json:"patient_id" binding:"required"`
+		HealthWorkerID    uint      `json:"health_worker_id" binding:"required"`
+		StartsAt          time.Time `json:"starts_at" binding:"required"`
+		EndsAt            time.Time `json:"ends_at"` // defaults to one slot
+		Purpose           string    `json:"purpose"`
+		PreviousSessionID *uint     `json:"previous_session_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	appointment, err := ah.AppointmentController.BookAppointment(c.Request.Context(), c.GetUint("userID"), &model.Appointment{
+		PatientID:         input.PatientID,
+		HealthWorkerID:    input.HealthWorkerID,
+		StartsAt:          input.StartsAt,
+		EndsAt:            input.EndsAt,
+		Purpose:           input.Purpose,
+		PreviousSessionID: input.PreviousSessionID,
+	})
+	if err != nil {
+		c.JSON(appointmentErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to book appointment: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Appointment booked successfully",
+		"appointment": appointment,
+	})
+}
+
+// GetAppointmentByID fetches an appointment
+func (ah *AppointmentHandler) GetAppointmentByID(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	appointment, err := ah.AppointmentController.GetAppointmentByID(c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusNotFound), gin.H{"message": "Appointment not found: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"appointment": appointment})
+}
+
+// GetAppointmentsByPatient lists a patient's appointments
+func (ah *AppointmentHandler) GetAppointmentsByPatient(c *gin.Context) {
+	patientID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		return
+	}
+
+	appointments, err := ah.AppointmentController.GetAppointmentsByPatient(c.GetUint("userID"), patientID)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to retrieve appointments: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"appointments": appointments})
+}
+
+// RescheduleAppointment moves an appointment to a new time (requires appointment:manage)
+func (ah *AppointmentHandler) RescheduleAppointment(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		StartsAt       time.Time `json:"starts_at" binding:"required"`
+		HealthWorkerID uint      `json:"health_worker_id"` // defaults to the same health worker
+		Reason         string    `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	appointment, err := ah.AppointmentController.RescheduleAppointment(c.Request.Context(), c.GetUint("userID"), id, input.HealthWorkerID, input.StartsAt, input.Reason)
+	if err != nil {
+		c.JSON(appointmentErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to reschedule appointment: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Appointment rescheduled successfully",
+		"appointment": appointment,
+	})
+}
+
+// CancelAppointment cancels an appointment with a reason (requires appointment:manage)
+func (ah *AppointmentHandler) CancelAppointment(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	appointment, err := ah.AppointmentController.CancelAppointment(c.Request.Context(), c.GetUint("userID"), id, input.Reason)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to cancel appointment: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Appointment cancelled successfully",
+		"appointment": appointment,
+	})
+}
+
+// CheckInAppointment records the patient's arrival and opens the follow-up session (requires
+// appointment:manage)
+func (ah *AppointmentHandler) CheckInAppointment(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	appointment, err := ah.AppointmentController.CheckInAppointment(c.Request.Context(), c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to check in appointment: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Patient checked in successfully",
+		"appointment": appointment,
+	})
+}
+
+// GetAvailableSlots lists a health worker's free slots on a day:
+// ?health_worker_id=&date=YYYY-MM-DD (defaults to today)
+func (ah *AppointmentHandler) GetAvailableSlots(c *gin.Context) {
+	healthWorkerID, err := util.UintQuery(c, "health_worker_id")
+	if err != nil || healthWorkerID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "health_worker_id is required"})
+		return
+	}
+	day, err := util.DateQuery(c, "date")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	slots, err := ah.AppointmentController.GetAvailableSlots(healthWorkerID, day)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve slots: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"slots": slots})
+}
+
+// GetCalendar shows a health worker's or a department's calendar:
+// ?health_worker_id= or ?department_id=, &date=YYYY-MM-DD (defaults to today) and &view=day|week.
+// A week runs Monday to Sunday around the date.
+func (ah *AppointmentHandler) GetCalendar(c *gin.Context) {
+	healthWorkerID, err := util.UintQuery(c, "health_worker_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	departmentID, err := util.UintQuery(c, "department_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	from, err := util.DateQuery(c, "date")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	days := 1
+	switch c.DefaultQuery("view", "day") {
+	case "day":
+	case "week":
+		days = 7
+		from = from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "view must be day or week"})
+		return
+	}
+
+	calendar, err := ah.AppointmentController.GetCalendar(c.GetUint("userID"), healthWorkerID, departmentID, from, days)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to build calendar: " + err.Error()})
+		return
+	}
+
+	middleware.AuditShown(c, calendar)
+	c.JSON(http.StatusOK, gin.H{"calendar": calendar})
+}
+
+// GetWorkingHours lists a health worker's weekly working hours
+func (ah *AppointmentHandler) GetWorkingHours(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid health worker ID"})
+		return
+	}
+
+	hours, err := ah.AppointmentController.GetWorkingHours(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve working hours: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"working_hours": hours})
+}
+
+// SetWorkingHours replaces a health worker's weekly working hours (requires schedule:manage)
+func (ah *AppointmentHandler) SetWorkingHours(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid health worker ID"})
+		return
+	}
+
+	var input struct {
+		WorkingHours []model.WorkingHours `json:"working_hours"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	hours, err := ah.AppointmentController.SetWorkingHours(id, input.WorkingHours)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to set working hours: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Working hours updated successfully",
+		"working_hours": hours,
+	})
+}
+
+// GetLeave lists a health worker's current and upcoming leave
+func (ah *AppointmentHandler) GetLeave(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid health worker ID"})
+		return
+	}
+
+	leave, err := ah.AppointmentController.GetLeave(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve leave: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"leave": leave})
+}
+
+// CreateLeave records leave for a health worker (requires schedule:manage)
+func (ah *AppointmentHandler) CreateLeave(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid health worker ID"})
+		return
+	}
+
+	var input struct {
+		StartsAt time.Time `json:"starts_at" binding:"required"`
+		EndsAt   time.Time `json:"ends_at" binding:"required"`
+		Reason   string    `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	leave, err := ah.AppointmentController.CreateLeave(c.GetUint("userID"), &model.HealthWorkerLeave{
+		HealthWorkerID: id,
+		StartsAt:       input.StartsAt,
+		EndsAt:         input.EndsAt,
+		Reason:         input.Reason,
+	})
+	if err != nil {
+		c.JSON(appointmentErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to record leave: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Leave recorded successfully",
+		"leave":   leave,
+	})
+}
+
+// DeleteLeave removes a period of leave (requires schedule:manage)
+func (ah *AppointmentHandler) DeleteLeave(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	if err := ah.AppointmentController.DeleteLeave(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete leave: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Leave deleted successfully"})
+}
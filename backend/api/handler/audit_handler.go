@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"net/http"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	AuditController interfaces.AuditInterface
+}
+
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{
+		AuditController: controller.NewAuditController(),
+	}
+}
+
+// SearchAuditLogs queries the audit trail (requires audit:view)
+func (ah *AuditHandler) SearchAuditLogs(c *gin.Context) {
+	queryParams := map[string]string{
+		"patient_id":       c.Query("patient_id"),
+		"health_worker_id": c.Query("health_worker_id"),
+		"entity_type":      c.Query("entity_type"),
+		"entity_id":        c.Query("entity_id"),
+		"action":           c.Query("action"),
+		"from":             c.Query("from"),
+		"to":               c.Query("to"),
+		"limit":            c.Query("limit"),
+	}
+
+	logs, err := ah.AuditController.SearchAuditLogs(queryParams)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to search audit logs: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"audit_logs": logs})
+}
+
+// GetAuditLogsByPatient lists who read or changed a patient's records (requires audit:view)
+func (ah *AuditHandler) GetAuditLogsByPatient(c *gin.Context) {
+	patientID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		return
+	}
+
+	logs, err := ah.AuditController.GetAuditLogsByPatient(patientID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve audit logs: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"audit_logs": logs})
+}
+
+// GetAuditLogsByHealthWorker lists a health worker's activity on patient data (requires audit:view)
+func (ah *AuditHandler) GetAuditLogsByHealthWorker(c *gin.Context) {
+	healthWorkerID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid health worker ID"})
+		return
+	}
+
+	logs, err := ah.AuditController.GetAuditLogsByHealthWorker(healthWorkerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve audit logs: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"audit_logs": logs})
+}
+
+// VerifyAuditChain checks the audit trail for tampering (requires audit:view)
+func (ah *AuditHandler) VerifyAuditChain(c *gin.Context) {
+	status, err := ah.AuditController.VerifyAuditChain()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to verify audit chain: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"chain": status})
+}
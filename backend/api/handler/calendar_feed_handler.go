@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/middleware"
+	"depression-diagnosis-system/api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+const icalContentType = "text/calendar; charset=utf-8"
+
+type CalendarFeedHandler struct {
+	CalendarFeedController interfaces.CalendarFeedInterface
+}
+
+func NewCalendarFeedHandler() *CalendarFeedHandler {
+	return &CalendarFeedHandler{
+		CalendarFeedController: controller.NewCalendarFeedController(),
+	}
+}
+
+// IssueCalendarFeed gives the caller a private subscription URL for their calendar app. The URL is
+// shown only once; issuing another replaces it.
+func (ch *CalendarFeedHandler) IssueCalendarFeed(c *gin.Context) {
+	token, err := ch.CalendarFeedController.IssueFeedToken(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to issue calendar feed: " + err.Error()})
+		return
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Calendar feed issued successfully",
+		"feed_url": fmt.Sprintf("%s://%s/api/v1/calendar-feeds/%s.ics", scheme, c.Request.Host, token),
+	})
+}
+
+// RevokeCalendarFeed stops the caller's calendar subscription
+func (ch *CalendarFeedHandler) RevokeCalendarFeed(c *gin.Context) {
+	if err := ch.CalendarFeedController.RevokeFeedToken(c.GetUint("userID")); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, controller.ErrCalendarFeedNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"message": "Failed to revoke calendar feed: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar feed revoked successfully"})
+}
+
+// GetCalendarFeed serves a subscribed calendar. The token in the URL is the only credential, as
+// calendar apps cannot send a bearer token; the fetch is audited as a read by the feed's owner.
+func (ch *CalendarFeedHandler) GetCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	ics, ownerID, patientIDs, err := ch.CalendarFeedController.GetCalendarFeed(token)
+	if err != nil {
+		if errors.Is(err, controller.ErrCalendarFeedNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Calendar feed not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to build calendar feed: " + err.Error()})
+		return
+	}
+
+	middleware.AuditAs(c, ownerID)
+	middleware.AuditPatients(c, patientIDs...)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, icalContentType, []byte(ics))
+}
+
+// GetSessionCalendar downloads a session and its follow-up as an .ics file
+func (ch *CalendarFeedHandler) GetSessionCalendar(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid session ID"})
+		return
+	}
+
+	ics, err := ch.CalendarFeedController.GetSessionCalendar(c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusNotFound), gin.H{"message": "Failed to build session calendar: " + err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="session-%d.ics"`, id))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, icalContentType, []byte(ics))
+}
@@ -0,0 +1,374 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/util"
+	"depression-diagnosis-system/database/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ChannelHandler struct {
+	ChannelController interfaces.ChannelInterface
+}
+
+func NewChannelHandler() *ChannelHandler {
+	return &ChannelHandler{
+		ChannelController: controller.NewChannelController(),
+	}
+}
+
+// channelErrorStatus maps channel membership and patient access errors to 403
+func channelErrorStatus(err error, fallback int) int {
+	if errors.Is(err, controller.ErrNotChannelMember) || errors.Is(err, controller.ErrNotChannelAdmin) {
+		return http.StatusForbidden
+	}
+	return accessErrorStatus(err, fallback)
+}
+
+// memberParam reads the :memberId path parameter, a health worker ID
+func memberParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("memberId"), 10, 32)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid member ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetMyChannels lists the caller's channels with their latest message and unread count
+func (ch *ChannelHandler) GetMyChannels(c *gin.Context) {
+	channels, err := ch.ChannelController.GetMyChannels(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not load channels: " + err.Error()})
+		return
+	}
+
+	var unread int64
+	for _, t := range channels {
+		unread += t.UnreadCount
+	}
+	c.JSON(http.StatusOK, gin.H{"channels": channels, "unread": unread})
+}
+
+// GetOpenGroups lists the open groups the caller could join
+func (ch *ChannelHandler) GetOpenGroups(c *gin.Context) {
+	groups, err := ch.ChannelController.GetOpenGroups(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not load groups: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"channels": groups})
+}
+
+// GetChannel returns a channel with its members
+func (ch *ChannelHandler) GetChannel(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid channel ID"})
+		return
+	}
+
+	channel, err := ch.ChannelController.GetChannel(c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(channelErrorStatus(err, http.StatusNotFound), gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"channel": channel})
+}
+
+// CreateGroup creates an ad-hoc group with the caller as its admin
+func (ch *ChannelHandler) CreateGroup(c *gin.Context) {
+	var input struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+		Open        bool   `json:"open"`
+		MemberIDs   []uint `json:"member_ids"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	channel, err := ch.ChannelController.CreateGroup(c.GetUint("userID"), &model.Channel{
+		Name:        input.Name,
+		Description: input.Description,
+		Open:        input.Open,
+	}, input.MemberIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to create group: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Group created successfully",
+		"channel": channel,
+	})
+}
+
+// OpenCareTeamChannel returns the channel of a patient's care team, creating it on first use
+func (ch *ChannelHandler) OpenCareTeamChannel(c *gin.Context) {
+	patientID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		return
+	}
+
+	channel, err := ch.ChannelController.OpenCareTeamChannel(c.GetUint("userID"), patientID)
+	if err != nil {
+		c.JSON(channelErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to open care team channel: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"channel": channel})
+}
+
+// UpdateChannel renames, opens or closes, or archives a channel (channel admins)
+func (ch *ChannelHandler) UpdateChannel(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid channel ID"})
+		return
+	}
+	var input struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Open        *bool   `json:"open"`
+		Archived    *bool   `json:"archived"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	channel, err := ch.ChannelController.UpdateChannel(c.GetUint("userID"), id, input.Name, input.Description, input.Open, input.Archived)
+	if err != nil {
+		c.JSON(channelErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to update channel: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Channel updated successfully",
+		"channel": channel,
+	})
+}
+
+// JoinChannel adds the caller to an open group
+func (ch *ChannelHandler) JoinChannel(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid channel ID"})
+		return
+	}
+
+	channel, err := ch.ChannelController.JoinChannel(c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to join channel: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Joined channel successfully",
+		"channel": channel,
+	})
+}
+
+// LeaveChannel takes the caller out of a group
+func (ch *ChannelHandler) LeaveChannel(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid channel ID"})
+		return
+	}
+
+	if err := ch.ChannelController.LeaveChannel(c.GetUint("userID"), id); err != nil {
+		c.JSON(channelErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to leave channel: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Left channel successfully"})
+}
+
+// AddChannelMember adds a health worker to a group (channel admins)
+func (ch *ChannelHandler) AddChannelMember(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid channel ID"})
+		return
+	}
+	var input struct {
+		HealthWorkerID uint   `json:"health_worker_id" binding:"required"`
+		Role           string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	channel, err := ch.ChannelController.AddChannelMember(c.GetUint("userID"), id, input.HealthWorkerID, input.Role)
+	if err != nil {
+		c.JSON(channelErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to add member: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Member added successfully",
+		"channel": channel,
+	})
+}
+
+// UpdateChannelMember changes a member's role or mutes them (channel admins). A null or past
+// muted_until lifts a mute; leaving it out keeps the mute as it is.
+func (ch *ChannelHandler) UpdateChannelMember(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid channel ID"})
+		return
+	}
+	memberID, ok := memberParam(c)
+	if !ok {
+		return
+	}
+	var input struct {
+		Role       string          `json:"role"`
+		MutedUntil json.RawMessage `json:"muted_until"` // nil when left out, "null" when cleared
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+	setMute := input.MutedUntil != nil
+	var mutedUntil *time.Time
+	if setMute {
+		if err := json.Unmarshal(input.MutedUntil, &mutedUntil); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+			return
+		}
+	}
+
+	member, err := ch.ChannelController.UpdateChannelMember(c.GetUint("userID"), id, memberID, input.Role, setMute, mutedUntil)
+	if err != nil {
+		c.JSON(channelErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to update member: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Member updated successfully",
+		"member":  member,
+	})
+}
+
+// RemoveChannelMember takes a health worker out of a group (channel admins)
+func (ch *ChannelHandler) RemoveChannelMember(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid channel ID"})
+		return
+	}
+	memberID, ok := memberParam(c)
+	if !ok {
+		return
+	}
+
+	if err := ch.ChannelController.RemoveChannelMember(c.GetUint("userID"), id, memberID); err != nil {
+		c.JSON(channelErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to remove member: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// GetChannelMessages returns a page of a channel's messages, newest first, before ?before
+func (ch *ChannelHandler) GetChannelMessages(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid channel ID"})
+		return
+	}
+	before, err := util.UintQuery(c, "before")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	messages, more, err := ch.ChannelController.GetChannelMessages(c.GetUint("userID"), id, before)
+	if err != nil {
+		c.JSON(channelErrorStatus(err, http.StatusNotFound), gin.H{"message": "Could not retrieve messages: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"messages": messages, "more": more})
+}
+
+// SendChannelMessage posts a message to a channel
+func (ch *ChannelHandler) SendChannelMessage(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid channel ID"})
+		return
+	}
+	var input struct {
+		Message string `json:"message" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	saved, err := ch.ChannelController.SendChannelMessage(c.GetUint("userID"), id, input.Message)
+	if err != nil {
+		c.JSON(channelErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to send message: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Message sent successfully",
+		"data":    saved,
+	})
+}
+
+// MarkChannelRead marks a channel's messages as read, up to up_to when given
+func (ch *ChannelHandler) MarkChannelRead(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid channel ID"})
+		return
+	}
+	var input struct {
+		UpTo uint `json:"up_to"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	read, err := ch.ChannelController.MarkChannelRead(c.GetUint("userID"), id, input.UpTo)
+	if err != nil {
+		c.JSON(channelErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Could not mark messages as read: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Messages marked as read", "read": read})
+}
+
+// DeleteChannelMessage removes a channel message (its sender or channel admins)
+func (ch *ChannelHandler) DeleteChannelMessage(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid channel ID"})
+		return
+	}
+	messageID, err := strconv.ParseUint(c.Param("messageId"), 10, 32)
+	if err != nil || messageID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid message ID"})
+		return
+	}
+
+	if err := ch.ChannelController.DeleteChannelMessage(c.GetUint("userID"), id, uint(messageID)); err != nil {
+		c.JSON(channelErrorStatus(err, http.StatusNotFound), gin.H{"message": "Failed to delete message: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Message deleted successfully"})
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"net/http"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/middleware"
+	"depression-diagnosis-system/api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DefaulterHandler struct {
+	DefaulterController interfaces.DefaulterInterface
+}
+
+func NewDefaulterHandler() *DefaulterHandler {
+	return &DefaulterHandler{
+		DefaulterController: controller.NewDefaulterController(),
+	}
+}
+
+// GetDefaulterWorklist lists patients with missed follow-ups, highest priority first
+// (requires defaulter:manage): ?department_id= defaults to the caller's department
+func (dh *DefaulterHandler) GetDefaulterWorklist(c *gin.Context) {
+	departmentID, err := util.UintQuery(c, "department_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	worklist, err := dh.DefaulterController.GetDefaulterWorklist(c.GetUint("userID"), departmentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to build defaulter worklist: " + err.Error()})
+		return
+	}
+
+	middleware.AuditShown(c, worklist)
+	c.JSON(http.StatusOK, gin.H{"defaulters": worklist})
+}
+
+// DetectMissedFollowUps runs the missed follow-up job immediately (requires defaulter:manage)
+func (dh *DefaulterHandler) DetectMissedFollowUps(c *gin.Context) {
+	found, err := dh.DefaulterController.DetectMissedFollowUps()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to detect missed follow-ups: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Missed follow-ups checked successfully",
+		"found":   found,
+	})
+}
+
+// ResolveMissedFollowUp records the outcome of outreach for a missed follow-up (requires
+// defaulter:manage)
+func (dh *DefaulterHandler) ResolveMissedFollowUp(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		Outcome string `json:"outcome" binding:"required"`
+		Notes   string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	missed, err := dh.DefaulterController.ResolveMissedFollowUp(c.GetUint("userID"), id, input.Outcome, input.Notes)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to resolve missed follow-up: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Missed follow-up resolved successfully",
+		"missed_follow_up": missed,
+	})
+}
+
+// GetPatientAttendance returns a patient's attendance rate
+func (dh *DefaulterHandler) GetPatientAttendance(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		return
+	}
+
+	attendance, err := dh.DefaulterController.GetPatientAttendance(c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to compute attendance: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attendance": attendance})
+}
@@ -21,14 +21,8 @@ func NewDepartmentHandler() *DepartmentHandler {
 	}
 }
 
-// CreateDepartment allows only admins to create departments
+// CreateDepartment creates a department (requires department:manage)
 func (dh *DepartmentHandler) CreateDepartment(c *gin.Context) {
-	role, exists := c.Get("userRole")
-	if !exists || role != model.RoleAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Only admins can create departments"})
-		return
-	}
-
 	var input model.Department
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
@@ -79,14 +73,8 @@ func (dh *DepartmentHandler) GetAllDepartments(c *gin.Context) {
 	})
 }
 
-// UpdateDepartment allows only admins to update departments
+// UpdateDepartment updates a department (requires department:manage)
 func (dh *DepartmentHandler) UpdateDepartment(c *gin.Context) {
-	role, exists := c.Get("userRole")
-	if !exists || role != model.RoleAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Only admins can update departments"})
-		return
-	}
-
 	id, err := util.GetIDParam(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid department ID"})
@@ -111,14 +99,64 @@ func (dh *DepartmentHandler) UpdateDepartment(c *gin.Context) {
 	})
 }
 
-// DeleteDepartment allows only admins to delete departments
-func (dh *DepartmentHandler) DeleteDepartment(c *gin.Context) {
-	role, exists := c.Get("userRole")
-	if !exists || role != model.RoleAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Only admins can delete departments"})
+// SetOnCallPsychiatrist assigns the department's on-call psychiatrist (requires department:manage)
+func (dh *DepartmentHandler) SetOnCallPsychiatrist(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid department ID"})
+		return
+	}
+
+	var input struct {
+		HealthWorkerID *uint `json:"health_worker_id"` // null clears the assignment
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	dept, err := dh.DepartmentController.SetOnCallPsychiatrist(id, input.HealthWorkerID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to set on-call psychiatrist: " + err.Error()})
 		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "On-call psychiatrist updated successfully",
+		"department": dept,
+	})
+}
+
+// SetDefaultNoteTemplate sets the department's default note template (requires department:manage)
+func (dh *DepartmentHandler) SetDefaultNoteTemplate(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid department ID"})
+		return
+	}
+
+	var input struct {
+		NoteTemplateID *uint `json:"note_template_id"` // null clears the default
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	dept, err := dh.DepartmentController.SetDefaultNoteTemplate(id, input.NoteTemplateID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to set default note template: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Default note template updated successfully",
+		"department": dept,
+	})
+}
+
+// DeleteDepartment deletes a department (requires department:manage)
+func (dh *DepartmentHandler) DeleteDepartment(c *gin.Context) {
 	id, err := util.GetIDParam(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid department ID"})
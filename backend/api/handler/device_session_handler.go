@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeviceSessionHandler struct {
+	DeviceSessionController interfaces.DeviceSessionInterface
+}
+
+func NewDeviceSessionHandler() *DeviceSessionHandler {
+	return &DeviceSessionHandler{
+		DeviceSessionController: controller.NewDeviceSessionController(),
+	}
+}
+
+// Refresh exchanges a refresh token for a new access/refresh token pair
+func (dsh *DeviceSessionHandler) Refresh(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	pair, err := dsh.DeviceSessionController.Refresh(input.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Token refresh failed: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Token refreshed successfully",
+		"token":              pair.AccessToken,
+		"access_expires_at":  pair.AccessExpiresAt,
+		"refresh_token":      pair.RefreshToken,
+		"refresh_expires_at": pair.RefreshExpiresAt,
+		"session":            pair.Session,
+	})
+}
+
+// GetMySessions lists the authenticated health worker's active device sessions
+func (dsh *DeviceSessionHandler) GetMySessions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+		return
+	}
+
+	sessions, err := dsh.DeviceSessionController.GetActiveSessions(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve sessions: " + err.Error()})
+		return
+	}
+
+	currentSessionID, _ := c.Get("sessionID")
+	c.JSON(http.StatusOK, gin.H{
+		"sessions":           sessions,
+		"current_session_id": currentSessionID,
+	})
+}
+
+// RevokeMySession signs one of the authenticated health worker's devices out
+func (dsh *DeviceSessionHandler) RevokeMySession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+		return
+	}
+
+	sessionID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid session ID"})
+		return
+	}
+
+	if err := dsh.DeviceSessionController.RevokeSession(userID.(uint), sessionID, "revoked by user"); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Failed to revoke session: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
@@ -35,10 +35,11 @@ func (dh *DiagnosisHandler) CreateDiagnosis(c *gin.Context) {
 		SessionID: uint(sessionID),
 	}
 
-	createdDiagnosis, err := dh.DiagnosisController.CreateDiagnosis(diagnosis)
+	createdDiagnosis, err := dh.DiagnosisController.CreateDiagnosis(c.Request.Context(), c.GetUint("userID"), diagnosis)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
+		status := accessErrorStatus(err, http.StatusInternalServerError)
+		c.JSON(status, gin.H{
+			"status":  status,
 			"message": "Failed to create diagnosis: " + err.Error(),
 		})
 		return
@@ -62,10 +63,11 @@ func (dh *DiagnosisHandler) GetDiagnosisBySessionID(c *gin.Context) {
 		return
 	}
 
-	diagnosis, err := dh.DiagnosisController.GetDiagnosisBySessionID(uint(sessionID))
+	diagnosis, err := dh.DiagnosisController.GetDiagnosisBySessionID(c.GetUint("userID"), uint(sessionID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
+		status := accessErrorStatus(err, http.StatusInternalServerError)
+		c.JSON(status, gin.H{
+			"status":  status,
 			"message": "Failed to retrieve diagnosis: " + err.Error(),
 		})
 		return
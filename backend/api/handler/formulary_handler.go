@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/util"
+	"depression-diagnosis-system/database/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FormularyHandler struct {
+	FormularyController interfaces.FormularyInterface
+}
+
+func NewFormularyHandler() *FormularyHandler {
+	return &FormularyHandler{
+		FormularyController: controller.NewFormularyController(),
+	}
+}
+
+// drugInput is the body of create and update requests; strengths and forms are plain lists
+type drugInput struct {
+	Name        string   `json:"name"`
+	GenericName string   `json:"generic_name"`
+	ATCCode     string   `json:"atc_code"`
+	ATCClass    string   `json:"atc_class"`
+	Strengths   []string `json:"strengths"`
+	Forms       []string `json:"forms"`
+}
+
+func (in *drugInput) drug() *model.Drug {
+	drug := &model.Drug{Name: in.Name, GenericName: in.GenericName, ATCCode: in.ATCCode, ATCClass: in.ATCClass}
+	if len(in.Strengths) > 0 {
+		drug.Strengths, _ = json.Marshal(in.Strengths)
+	}
+	if len(in.Forms) > 0 {
+		drug.Forms, _ = json.Marshal(in.Forms)
+	}
+	return drug
+}
+
+// GetDrugs lists the formulary; ?q= searches name, generic name and ATC code, ?include_inactive=true
+// adds withdrawn drugs
+func (fh *FormularyHandler) GetDrugs(c *gin.Context) {
+	drugs, err := fh.FormularyController.GetDrugs(c.Query("q"), c.Query("include_inactive") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve formulary: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"drugs": drugs})
+}
+
+// GetDrugByID fetches a formulary entry
+func (fh *FormularyHandler) GetDrugByID(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	drug, err := fh.FormularyController.GetDrugByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Drug not found: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"drug": drug})
+}
+
+// CreateDrug adds a drug to the formulary (requires formulary:manage)
+func (fh *FormularyHandler) CreateDrug(c *gin.Context) {
+	var input drugInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	drug, err := fh.FormularyController.CreateDrug(input.drug())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to add drug: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Drug added to the formulary successfully",
+		"drug":    drug,
+	})
+}
+
+// UpdateDrug changes a formulary entry (requires formulary:manage)
+func (fh *FormularyHandler) UpdateDrug(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	var input drugInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	drug, err := fh.FormularyController.UpdateDrug(id, input.drug())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to update drug: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Drug updated successfully",
+		"drug":    drug,
+	})
+}
+
+// SetActiveStatus withdraws or restores a drug (requires formulary:manage)
+func (fh *FormularyHandler) SetActiveStatus(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		IsActive *bool `json:"is_active" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if err := fh.FormularyController.SetActiveStatus(id, *input.IsActive); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update drug: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Drug status updated successfully"})
+}
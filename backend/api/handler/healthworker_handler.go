@@ -6,40 +6,37 @@ import (
 
 	"depression-diagnosis-system/api/controller"
 	"depression-diagnosis-system/api/interfaces"
-	"depression-diagnosis-system/api/middleware"
 	"depression-diagnosis-system/api/util"
 	"depression-diagnosis-system/database/model"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type HealthWorkerHandler struct {
-	HealthWorkerController interfaces.HealthWorkerInterface
+	HealthWorkerController  interfaces.HealthWorkerInterface
+	DeviceSessionController interfaces.DeviceSessionInterface
 }
 
 func NewHealthWorkerHandler() *HealthWorkerHandler {
 	return &HealthWorkerHandler{
-		HealthWorkerController: controller.NewHealthWorkerController(),
+		HealthWorkerController:  controller.NewHealthWorkerController(),
+		DeviceSessionController: controller.NewDeviceSessionController(),
 	}
 }
 
 // helper to parse "id" URL param to uint
 
 func (hwh *HealthWorkerHandler) CreateHealthWorker(c *gin.Context) {
-    role, exists := c.Get("userRole")
-    if !exists || role != model.RoleAdmin {
-        c.JSON(http.StatusForbidden, gin.H{"message": "Only admins can create health workers"})
-        return
+    var input struct {
+        model.HealthWorker
+        Password string `json:"password" binding:"required"`
     }
-
-    var input model.HealthWorker
     if err := c.ShouldBindJSON(&input); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
         return
     }
 
-    createdHW, err := hwh.HealthWorkerController.CreateHealthWorker(&input)
+    createdHW, err := hwh.HealthWorkerController.CreateHealthWorker(&input.HealthWorker, input.Password)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create health worker: " + err.Error()})
         return
@@ -185,6 +182,7 @@ func (hwh *HealthWorkerHandler) Login(c *gin.Context) {
 	var input struct {
 		Identifier string `json:"identifier" binding:"required"` // email or employee ID
 		Password   string `json:"password" binding:"required"`
+		DeviceName string `json:"device_name"`                   // e.g. "Ward 3 tablet"
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -198,14 +196,8 @@ func (hwh *HealthWorkerHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Validate password
-	if err := bcrypt.CompareHashAndPassword([]byte(hw.PasswordHash), []byte(input.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Incorrect password"})
-		return
-	}
-
-	// Generate token
-	token, err := middleware.GenerateToken(hw.ID, hw.Email)
+	// Open a device session and issue the access/refresh token pair
+	pair, err := hwh.DeviceSessionController.StartSession(hw, input.DeviceName, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Token generation failed: " + err.Error()})
 		return
@@ -213,11 +205,81 @@ func (hwh *HealthWorkerHandler) Login(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Login successful",
-		"token":   token,
+		"token":   pair.AccessToken,
+		"access_expires_at": pair.AccessExpiresAt,
+		"refresh_token": pair.RefreshToken,
+		"refresh_expires_at": pair.RefreshExpiresAt,
+		"session": pair.Session,
 		"health_worker": hw,
+		"must_change_password": hw.MustChangePassword,
 	})
 }
 
+// ChangePassword lets the authenticated health worker rotate their own password
+func (hwh *HealthWorkerHandler) ChangePassword(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+		return
+	}
+
+	var input struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if err := hwh.HealthWorkerController.ChangePassword(userID.(uint), input.CurrentPassword, input.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to change password: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully; sign in again on every device"})
+}
+
+// IssuePasswordReset generates a one-time reset token for a health worker
+func (hwh *HealthWorkerHandler) IssuePasswordReset(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	adminID, _ := c.Get("userID")
+	token, err := hwh.HealthWorkerController.IssuePasswordReset(id, adminID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to issue password reset: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Password reset token issued successfully",
+		"reset_token": token,
+	})
+}
+
+// ResetPassword sets a new password using an admin-issued one-time token
+func (hwh *HealthWorkerHandler) ResetPassword(c *gin.Context) {
+	var input struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if err := hwh.HealthWorkerController.ResetPassword(input.Token, input.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to reset password: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully; sign in again on every device"})
+}
+
 // Logout invalidates a JWT token
 func (hwh *HealthWorkerHandler) Logout(c *gin.Context) {
 	authHeader := c.GetHeader("Authorization")
@@ -238,9 +300,38 @@ func (hwh *HealthWorkerHandler) Logout(c *gin.Context) {
 		return
 	}
 
+	// End the device session too so its refresh token can no longer be used
+	userID, _ := c.Get("userID")
+	if sessionID, ok := c.Get("sessionID"); ok && sessionID.(uint) != 0 {
+		if err := hwh.DeviceSessionController.RevokeSession(userID.(uint), sessionID.(uint), "logout"); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Logout failed: " + err.Error()})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Logout successful"})
 }
 
+// LogoutAllDevices invalidates every token issued to the authenticated health worker
+func (hwh *HealthWorkerHandler) LogoutAllDevices(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+		return
+	}
+
+	if err := hwh.HealthWorkerController.LogoutAllDevices(userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Logout failed: " + err.Error()})
+		return
+	}
+	if err := hwh.DeviceSessionController.RevokeAllSessions(userID.(uint), "logout from all devices"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Logout failed: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all devices successfully"})
+}
+
 func (hwh *HealthWorkerHandler) SearchHealthWorkers(c *gin.Context) {
     // Extract query params
     queryParams := map[string]string{
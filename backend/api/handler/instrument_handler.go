@@ -0,0 +1,372 @@
+package handler
+
+import (
+	"net/http"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/util"
+	"depression-diagnosis-system/database/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InstrumentHandler struct {
+	InstrumentController interfaces.InstrumentInterface
+}
+
+func NewInstrumentHandler() *InstrumentHandler {
+	return &InstrumentHandler{
+		InstrumentController: controller.NewInstrumentController(),
+	}
+}
+
+// GetAllInstruments lists the questionnaires with the items, options and severity bands of their
+// published versions, items in the language asked for with ?lang= or Accept-Language
+func (ih *InstrumentHandler) GetAllInstruments(c *gin.Context) {
+	instruments, err := ih.InstrumentController.GetAllInstruments(util.RequestLocale(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve instruments: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"instruments": instruments})
+}
+
+// GetInstrumentByID fetches a questionnaire's full definition
+func (ih *InstrumentHandler) GetInstrumentByID(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	instrument, err := ih.InstrumentController.GetInstrumentByID(id, util.RequestLocale(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Instrument not found: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"instrument": instrument})
+}
+
+// GetInstrumentByCode fetches a questionnaire's full definition by code, e.g. GAD-7
+func (ih *InstrumentHandler) GetInstrumentByCode(c *gin.Context) {
+	instrument, err := ih.InstrumentController.GetInstrumentByCode(c.Param("code"), util.RequestLocale(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Instrument not found: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"instrument": instrument})
+}
+
+// CreateInstrument adds a new questionnaire with its first version (requires instrument:manage).
+// The version stays a draft unless publish is set.
+func (ih *InstrumentHandler) CreateInstrument(c *gin.Context) {
+	var input struct {
+		Code          string                         `json:"code" binding:"required"`
+		Name          string                         `json:"name" binding:"required"`
+		Description   string                         `json:"description"`
+		Instructions  string                         `json:"instructions"`
+		Items         []model.InstrumentItem         `json:"items" binding:"required"`
+		Options       []model.InstrumentOption       `json:"options"`
+		SeverityBands []model.InstrumentSeverityBand `json:"severity_bands"`
+		Publish       bool                           `json:"publish"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	instrument, err := ih.InstrumentController.CreateInstrument(c.GetUint("userID"),
+		&model.Instrument{Code: input.Code, Name: input.Name, Description: input.Description},
+		&model.InstrumentVersion{
+			Instructions:  input.Instructions,
+			Items:         input.Items,
+			Options:       input.Options,
+			SeverityBands: input.SeverityBands,
+		},
+		input.Publish)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to create instrument: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Instrument created successfully",
+		"instrument": instrument,
+	})
+}
+
+// SetActiveStatus retires or restores a questionnaire (requires instrument:manage)
+func (ih *InstrumentHandler) SetActiveStatus(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		IsActive *bool `json:"is_active" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if err := ih.InstrumentController.SetActiveStatus(id, *input.IsActive); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update instrument: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Instrument status updated successfully"})
+}
+
+// GetVersions lists a questionnaire's versions, newest first
+func (ih *InstrumentHandler) GetVersions(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	versions, err := ih.InstrumentController.GetVersions(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve versions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"versions": versions})
+}
+
+// GetVersionByID fetches a version's full definition, whether draft, published or superseded
+func (ih *InstrumentHandler) GetVersionByID(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	version, err := ih.InstrumentController.GetVersionByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Version not found: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"version": version})
+}
+
+// CreateVersion starts a draft of a questionnaire's next version (requires instrument:manage).
+// Without items the draft copies the published version.
+func (ih *InstrumentHandler) CreateVersion(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	var input model.InstrumentVersion
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	version, err := ih.InstrumentController.CreateVersion(id, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to create version: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Draft version created successfully",
+		"version": version,
+	})
+}
+
+// UpdateVersion edits a draft version (requires instrument:manage)
+func (ih *InstrumentHandler) UpdateVersion(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	var input model.InstrumentVersion
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	version, err := ih.InstrumentController.UpdateVersion(id, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to update version: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Version updated successfully",
+		"version": version,
+	})
+}
+
+// PublishVersion makes a draft the version new responses are recorded against (requires instrument:manage)
+func (ih *InstrumentHandler) PublishVersion(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	version, err := ih.InstrumentController.PublishVersion(c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to publish version: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Version published successfully",
+		"version": version,
+	})
+}
+
+// DeleteVersion discards a draft version (requires instrument:manage)
+func (ih *InstrumentHandler) DeleteVersion(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	if err := ih.InstrumentController.DeleteVersion(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to delete version: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Version deleted successfully"})
+}
+
+// CreateResponse records a session's answers to a questionnaire, identified by ID or code. The
+// language defaults to the one the questions were requested in.
+func (ih *InstrumentHandler) CreateResponse(c *gin.Context) {
+	var input struct {
+		InstrumentID   uint                     `json:"instrument_id"`
+		InstrumentCode string                   `json:"instrument_code"`
+		SessionID      uint                     `json:"session_id" binding:"required"`
+		Language       string                   `json:"language"`
+		Answers        []model.InstrumentAnswer `json:"answers" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if input.InstrumentID == 0 {
+		if input.InstrumentCode == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "instrument_id or instrument_code is required"})
+			return
+		}
+		instrument, err := ih.InstrumentController.GetInstrumentByCode(input.InstrumentCode, model.LocaleEnglish)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Instrument not found: " + err.Error()})
+			return
+		}
+		input.InstrumentID = instrument.ID
+	}
+
+	if input.Language == "" {
+		input.Language = util.RequestLocale(c)
+	}
+
+	response, err := ih.InstrumentController.CreateResponse(c.Request.Context(), c.GetUint("userID"), &model.InstrumentResponse{
+		InstrumentID: input.InstrumentID,
+		SessionID:    input.SessionID,
+		Language:     input.Language,
+		Answers:      input.Answers,
+	})
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to record response: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Response recorded successfully",
+		"response": response,
+	})
+}
+
+// GetResponsesBySession lists the questionnaires answered during a session
+func (ih *InstrumentHandler) GetResponsesBySession(c *gin.Context) {
+	sessionID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid session ID"})
+		return
+	}
+
+	responses, err := ih.InstrumentController.GetResponsesBySession(c.GetUint("userID"), sessionID)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to retrieve responses: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"responses": responses})
+}
+
+// GetResponseByID fetches a single questionnaire response
+func (ih *InstrumentHandler) GetResponseByID(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	response, err := ih.InstrumentController.GetResponseByID(c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusNotFound), gin.H{"message": "Response not found: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"response": response})
+}
+
+// UpdateResponse replaces a response's answers (requires instrument:response:update)
+func (ih *InstrumentHandler) UpdateResponse(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	var input model.InstrumentResponse
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	response, err := ih.InstrumentController.UpdateResponse(c.Request.Context(), c.GetUint("userID"), id, &input)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to update response: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Response updated successfully",
+		"response": response,
+	})
+}
+
+// DeleteResponse removes a questionnaire response (requires instrument:response:delete)
+func (ih *InstrumentHandler) DeleteResponse(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	if err := ih.InstrumentController.DeleteResponse(c.Request.Context(), c.GetUint("userID"), id); err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to delete response: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Response deleted successfully"})
+}
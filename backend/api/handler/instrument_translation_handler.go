@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InstrumentTranslationHandler struct {
+	InstrumentTranslationController interfaces.InstrumentTranslationInterface
+}
+
+func NewInstrumentTranslationHandler() *InstrumentTranslationHandler {
+	return &InstrumentTranslationHandler{
+		InstrumentTranslationController: controller.NewInstrumentTranslationController(),
+	}
+}
+
+// GetVersionTranslations lists a version's items next to their translations, optionally filtered
+// by ?lang=
+func (h *InstrumentTranslationHandler) GetVersionTranslations(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid version ID"})
+		return
+	}
+
+	items, err := h.InstrumentTranslationController.GetVersionTranslations(id, strings.ToLower(c.Query("lang")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve translations: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// SaveTranslation adds or rewords an item's translation, which then awaits review
+// (requires instrument:translate)
+func (h *InstrumentTranslationHandler) SaveTranslation(c *gin.Context) {
+	itemID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid item ID"})
+		return
+	}
+
+	var input struct {
+		Locale string `json:"locale" binding:"required"`
+		Text   string `json:"text" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	translation, err := h.InstrumentTranslationController.SaveTranslation(c.GetUint("userID"), itemID, input.Locale, input.Text)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to save translation: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Translation saved and awaiting review",
+		"translation": translation,
+	})
+}
+
+// ApproveTranslation releases a translation to patients (requires instrument:translation:review)
+func (h *InstrumentTranslationHandler) ApproveTranslation(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	translation, err := h.InstrumentTranslationController.ApproveTranslation(c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to approve translation: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Translation approved successfully",
+		"translation": translation,
+	})
+}
+
+// DeleteTranslation removes a translation (requires instrument:manage)
+func (h *InstrumentTranslationHandler) DeleteTranslation(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	if err := h.InstrumentTranslationController.DeleteTranslation(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to delete translation: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Translation deleted successfully"})
+}
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"depression-diagnosis-system/api/controller"
 	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/middleware"
 	"depression-diagnosis-system/api/util"
 	"depression-diagnosis-system/database/model"
 
@@ -15,6 +17,19 @@ type MedicationHistoryHandler struct {
 	MedicationHistoryController interfaces.MedicationHistoryInterface
 }
 
+// overrideRequired answers a write held back by a severe prescribing warning with the warnings,
+// so the prescriber can review them and resubmit with an override reason
+func overrideRequired(c *gin.Context, err error, medHist *model.MedicationHistory) bool {
+	if !errors.Is(err, controller.ErrOverrideRequired) || medHist == nil {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"message":         "Prescription needs review: " + err.Error(),
+		"safety_warnings": medHist.SafetyWarnings,
+	})
+	return true
+}
+
 func NewMedicationHistoryHandler() *MedicationHistoryHandler {
 	return &MedicationHistoryHandler{
 		MedicationHistoryController: controller.NewMedicationHistoryController(),
@@ -29,9 +44,12 @@ func (mh *MedicationHistoryHandler) CreateMedicationHistory(c *gin.Context) {
 		return
 	}
 
-	created, err := mh.MedicationHistoryController.CreateMedicationHistory(&input)
+	created, err := mh.MedicationHistoryController.CreateMedicationHistory(c.Request.Context(), c.GetUint("userID"), &input)
+	if overrideRequired(c, err, created) {
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create medication history: " + err.Error()})
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to create medication history: " + err.Error()})
 		return
 	}
 
@@ -49,9 +67,9 @@ func (mh *MedicationHistoryHandler) GetMedicationHistoryByID(c *gin.Context) {
 		return
 	}
 
-	medHist, err := mh.MedicationHistoryController.GetMedicationHistoryByID(id)
+	medHist, err := mh.MedicationHistoryController.GetMedicationHistoryByID(c.GetUint("userID"), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Medication history not found: " + err.Error()})
+		c.JSON(accessErrorStatus(err, http.StatusNotFound), gin.H{"message": "Medication history not found: " + err.Error()})
 		return
 	}
 
@@ -66,9 +84,9 @@ func (mh *MedicationHistoryHandler) GetMedicationHistoriesByPatient(c *gin.Conte
 		return
 	}
 
-	medHists, err := mh.MedicationHistoryController.GetMedicationHistoriesByPatient(patientID)
+	medHists, err := mh.MedicationHistoryController.GetMedicationHistoriesByPatient(c.GetUint("userID"), patientID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve medication histories: " + err.Error()})
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to retrieve medication histories: " + err.Error()})
 		return
 	}
 
@@ -89,9 +107,16 @@ func (mh *MedicationHistoryHandler) UpdateMedicationHistory(c *gin.Context) {
 		return
 	}
 
-	medHist, err := mh.MedicationHistoryController.UpdateMedicationHistory(id, &updated)
+	medHist, err := mh.MedicationHistoryController.UpdateMedicationHistory(c.Request.Context(), c.GetUint("userID"), id, &updated)
+	if overrideRequired(c, err, medHist) {
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update medication history: " + err.Error()})
+		status := accessErrorStatus(err, http.StatusInternalServerError)
+		if errors.Is(err, controller.ErrMedicationHistoryInUse) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"message": "Failed to update medication history: " + err.Error()})
 		return
 	}
 
@@ -109,8 +134,8 @@ func (mh *MedicationHistoryHandler) DeleteMedicationHistory(c *gin.Context) {
 		return
 	}
 
-	if err := mh.MedicationHistoryController.DeleteMedicationHistory(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete medication history: " + err.Error()})
+	if err := mh.MedicationHistoryController.DeleteMedicationHistory(c.Request.Context(), c.GetUint("userID"), id); err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to delete medication history: " + err.Error()})
 		return
 	}
 
@@ -119,12 +144,13 @@ func (mh *MedicationHistoryHandler) DeleteMedicationHistory(c *gin.Context) {
 
 // GetAllMedicationHistories returns all medication histories
 func (mh *MedicationHistoryHandler) GetAllMedicationHistories(c *gin.Context) {
-	medHists, err := mh.MedicationHistoryController.GetAllMedicationHistories()
+	medHists, err := mh.MedicationHistoryController.GetAllMedicationHistories(c.GetUint("userID"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve medication histories: " + err.Error()})
 		return
 	}
 
+	middleware.AuditShown(c, medHists)
 	c.JSON(http.StatusOK, gin.H{"medication_histories": medHists})
 }
 
@@ -137,11 +163,12 @@ func (mh *MedicationHistoryHandler) SearchMedicationHistories(c *gin.Context) {
 		"external_doctor_name": c.Query("external_doctor_name"),
 	}
 
-	medHists, err := mh.MedicationHistoryController.SearchMedicationHistories(queryParams)
+	medHists, err := mh.MedicationHistoryController.SearchMedicationHistories(c.GetUint("userID"), queryParams)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to search medication histories: " + err.Error()})
 		return
 	}
 
+	middleware.AuditShown(c, medHists)
 	c.JSON(http.StatusOK, gin.H{"medication_histories": medHists})
 }
@@ -5,12 +5,24 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 
 	"depression-diagnosis-system/api/controller"
 	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/middleware"
+	"depression-diagnosis-system/api/util"
 	"depression-diagnosis-system/database/model"
 )
 
+// upgrader opens realtime connections. They are authenticated with the access token rather than
+// cookies, so any origin may connect. The token subprotocol is echoed back as browsers require.
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	Subprotocols:    []string{middleware.WebSocketTokenProtocol},
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
 type MessageHandler struct {
 	MessageController interfaces.MessageInterface
 }
@@ -27,11 +39,12 @@ func (mh *MessageHandler) SendMessage(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
 		return
 	}
+	msg.SenderID = c.GetUint("userID")
 	msg.Timestamp = time.Now()
 
 	saved, err := mh.MessageController.SendMessage(&msg)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to send message: " + err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to send message: " + err.Error()})
 		return
 	}
 
@@ -76,3 +89,96 @@ func (mh *MessageHandler) GetInbox(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"inbox": messages})
 }
+
+// GetMessagesSince returns the caller's messages after ?cursor, for clients catching up without a
+// realtime connection
+func (mh *MessageHandler) GetMessagesSince(c *gin.Context) {
+	cursor, err := util.UintQuery(c, "cursor")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	messages, more, err := mh.MessageController.GetMessagesSince(c.GetUint("userID"), cursor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not retrieve messages: " + err.Error()})
+		return
+	}
+	if len(messages) > 0 {
+		cursor = messages[len(messages)-1].ID
+	}
+
+	c.JSON(http.StatusOK, gin.H{"messages": messages, "cursor": cursor, "more": more})
+}
+
+// GetConversations lists the caller's conversations with their latest message and unread count
+func (mh *MessageHandler) GetConversations(c *gin.Context) {
+	conversations, err := mh.MessageController.GetConversations(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not load conversations: " + err.Error()})
+		return
+	}
+
+	var unread int64
+	for _, conv := range conversations {
+		unread += conv.UnreadCount
+	}
+	c.JSON(http.StatusOK, gin.H{"conversations": conversations, "unread": unread})
+}
+
+// MarkMessageRead marks a single received message as read
+func (mh *MessageHandler) MarkMessageRead(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid message ID"})
+		return
+	}
+
+	if err := mh.MessageController.MarkMessageRead(c.GetUint("userID"), id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Message marked as read"})
+}
+
+// MarkConversationRead marks the messages received from a counterpart as read, up to up_to when
+// given
+func (mh *MessageHandler) MarkConversationRead(c *gin.Context) {
+	var req struct {
+		CounterpartID uint `json:"counterpart_id" binding:"required"`
+		UpTo          uint `json:"up_to"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	read, err := mh.MessageController.MarkConversationRead(c.GetUint("userID"), req.CounterpartID, req.UpTo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not mark messages as read: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Messages marked as read", "read": read})
+}
+
+// GetPresence lists the health workers currently online
+func (mh *MessageHandler) GetPresence(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"online": mh.MessageController.GetOnlineHealthWorkers()})
+}
+
+// Connect upgrades the request to a WebSocket pushing new messages, typing indicators and presence.
+// The access token is offered as Sec-WebSocket-Protocol: bearer, <token>. ?since replays the
+// messages after that message ID first.
+func (mh *MessageHandler) Connect(c *gin.Context) {
+	since, err := util.UintQuery(c, "since")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // the upgrader has already replied
+	}
+	mh.MessageController.Connect(conn, c.GetUint("userID"), c.GetUint("sessionID"), since, c.GetTime("tokenExpiresAt"))
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"net/http"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/util"
+	"depression-diagnosis-system/database/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NoteTemplateHandler struct {
+	NoteTemplateController interfaces.NoteTemplateInterface
+}
+
+func NewNoteTemplateHandler() *NoteTemplateHandler {
+	return &NoteTemplateHandler{
+		NoteTemplateController: controller.NewNoteTemplateController(),
+	}
+}
+
+// GetAllNoteTemplates lists every note template with its sections
+func (nh *NoteTemplateHandler) GetAllNoteTemplates(c *gin.Context) {
+	templates, err := nh.NoteTemplateController.GetAllNoteTemplates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve note templates: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"note_templates": templates})
+}
+
+// GetNoteTemplateByID fetches a note template with its sections
+func (nh *NoteTemplateHandler) GetNoteTemplateByID(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	template, err := nh.NoteTemplateController.GetNoteTemplateByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Note template not found: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"note_template": template})
+}
+
+// GetSessionNoteTemplate returns the default template for a session's summary; note_template is
+// null when the patient's department has none
+func (nh *NoteTemplateHandler) GetSessionNoteTemplate(c *gin.Context) {
+	sessionID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid session ID"})
+		return
+	}
+
+	template, err := nh.NoteTemplateController.GetSessionNoteTemplate(c.GetUint("userID"), sessionID)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to retrieve note template: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"note_template": template})
+}
+
+// CreateNoteTemplate adds a note template (requires note_template:manage)
+func (nh *NoteTemplateHandler) CreateNoteTemplate(c *gin.Context) {
+	var input struct {
+		Code        string                      `json:"code" binding:"required"`
+		Name        string                      `json:"name" binding:"required"`
+		Description string                      `json:"description"`
+		Sections    []model.NoteTemplateSection `json:"sections" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	template, err := nh.NoteTemplateController.CreateNoteTemplate(&model.NoteTemplate{
+		Code:        input.Code,
+		Name:        input.Name,
+		Description: input.Description,
+		Sections:    input.Sections,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to create note template: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":       "Note template created successfully",
+		"note_template": template,
+	})
+}
+
+// UpdateNoteTemplate renames a note template or replaces its sections (requires note_template:manage)
+func (nh *NoteTemplateHandler) UpdateNoteTemplate(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		Name        string                      `json:"name"`
+		Description string                      `json:"description"`
+		Sections    []model.NoteTemplateSection `json:"sections"` // omit to keep the current sections
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	template, err := nh.NoteTemplateController.UpdateNoteTemplate(id, &model.NoteTemplate{
+		Name:        input.Name,
+		Description: input.Description,
+		Sections:    input.Sections,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to update note template: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Note template updated successfully",
+		"note_template": template,
+	})
+}
+
+// SetActiveStatus retires or restores a note template (requires note_template:manage)
+func (nh *NoteTemplateHandler) SetActiveStatus(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		IsActive *bool `json:"is_active" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if err := nh.NoteTemplateController.SetActiveStatus(id, *input.IsActive); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update note template: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Note template status updated successfully"})
+}
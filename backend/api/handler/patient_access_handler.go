@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/util"
+	"depression-diagnosis-system/database/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PatientAccessHandler struct {
+	PatientAccessController interfaces.PatientAccessInterface
+}
+
+func NewPatientAccessHandler() *PatientAccessHandler {
+	return &PatientAccessHandler{
+		PatientAccessController: controller.NewPatientAccessController(),
+	}
+}
+
+// accessErrorStatus maps a denied patient access to 403, falling back to the given status otherwise
+func accessErrorStatus(err error, fallback int) int {
+	if errors.Is(err, controller.ErrPatientAccessDenied) {
+		return http.StatusForbidden
+	}
+	return fallback
+}
+
+// GetCareTeam lists the health workers assigned to a patient
+func (pah *PatientAccessHandler) GetCareTeam(c *gin.Context) {
+	patientID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		return
+	}
+
+	members, err := pah.PatientAccessController.GetCareTeam(c.GetUint("userID"), patientID)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to retrieve care team: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"care_team": members})
+}
+
+// AddCareTeamMember assigns a health worker to a patient (requires care_team:manage)
+func (pah *PatientAccessHandler) AddCareTeamMember(c *gin.Context) {
+	patientID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		return
+	}
+
+	var input struct {
+		HealthWorkerID uint   `json:"health_worker_id" binding:"required"`
+		Role           string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	assignedBy := c.GetUint("userID")
+	member, err := pah.PatientAccessController.AddCareTeamMember(&model.CareTeamMember{
+		PatientID:      patientID,
+		HealthWorkerID: input.HealthWorkerID,
+		Role:           input.Role,
+		AssignedByID:   &assignedBy,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to add care team member: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Care team member added successfully",
+		"member":  member,
+	})
+}
+
+// RemoveCareTeamMember takes a health worker off a patient's care team (requires care_team:manage)
+func (pah *PatientAccessHandler) RemoveCareTeamMember(c *gin.Context) {
+	patientID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		return
+	}
+	healthWorkerID, err := strconv.ParseUint(c.Param("memberId"), 10, 32)
+	if err != nil || healthWorkerID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid health worker ID"})
+		return
+	}
+
+	if err := pah.PatientAccessController.RemoveCareTeamMember(patientID, uint(healthWorkerID)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Failed to remove care team member: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Care team member removed successfully"})
+}
+
+// BreakGlass grants the caller temporary emergency access to a patient
+func (pah *PatientAccessHandler) BreakGlass(c *gin.Context) {
+	patientID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		return
+	}
+
+	var input struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	access, err := pah.PatientAccessController.BreakGlass(c.GetUint("userID"), patientID, input.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to grant emergency access: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Emergency access granted. This access has been flagged for supervisor review",
+		"access":  access,
+	})
+}
+
+// GetPendingBreakGlassReviews lists emergency accesses awaiting the caller's review
+func (pah *PatientAccessHandler) GetPendingBreakGlassReviews(c *gin.Context) {
+	accesses, err := pah.PatientAccessController.GetPendingBreakGlassReviews(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve pending reviews: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accesses": accesses})
+}
+
+// ReviewBreakGlass signs off an emergency access
+func (pah *PatientAccessHandler) ReviewBreakGlass(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		Notes string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	access, err := pah.PatientAccessController.ReviewBreakGlass(id, c.GetUint("userID"), input.Notes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to review emergency access: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Emergency access reviewed successfully",
+		"access":  access,
+	})
+}
+
+// GetDepartmentAccessRules lists department-wide visibility rules (requires department:manage)
+func (pah *PatientAccessHandler) GetDepartmentAccessRules(c *gin.Context) {
+	rules, err := pah.PatientAccessController.GetDepartmentAccessRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve access rules: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// CreateDepartmentAccessRule opens a department's patients to its staff (requires department:manage)
+func (pah *PatientAccessHandler) CreateDepartmentAccessRule(c *gin.Context) {
+	var input model.DepartmentAccessRule
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	rule, err := pah.PatientAccessController.CreateDepartmentAccessRule(&input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to create access rule: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Access rule created successfully",
+		"rule":    rule,
+	})
+}
+
+// DeleteDepartmentAccessRule removes a department-wide visibility rule (requires department:manage)
+func (pah *PatientAccessHandler) DeleteDepartmentAccessRule(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	if err := pah.PatientAccessController.DeleteDepartmentAccessRule(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete access rule: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Access rule deleted successfully"})
+}
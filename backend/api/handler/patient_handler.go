@@ -6,6 +6,7 @@ import (
 
 	"depression-diagnosis-system/api/controller"
 	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/middleware"
 	"depression-diagnosis-system/api/util"
 	"depression-diagnosis-system/database/model"
 
@@ -23,33 +24,6 @@ func NewPatientHandler() *PatientHandler {
 }
 
 func (ph *PatientHandler) CreatePatient(c *gin.Context) {
-	// Get the user's personnel type from context
-	ptypeI, exists := c.Get("userPersonnelType")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
-		return
-	}
-
-	ptype, ok := ptypeI.(string)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid personnel type"})
-		return
-	}
-
-	// Define allowed types
-	allowedTypes := map[string]bool{
-		"admin":            true,
-		"psychiatrist":     true,
-		"psychologist":     true,
-		"clinical_officer": true,
-	}
-
-	// Check permission
-	if !allowedTypes[ptype] {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Not allowed to register patients"})
-		return
-	}
-
 	// Bind and validate input
 	var input model.Patient
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -58,7 +32,7 @@ func (ph *PatientHandler) CreatePatient(c *gin.Context) {
 	}
 
 	// Call controller to create patient
-	createdPatient, err := ph.PatientController.CreatePatient(&input)
+	createdPatient, err := ph.PatientController.CreatePatient(c.Request.Context(), &input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create patient: " + err.Error()})
 		return
@@ -79,9 +53,9 @@ func (ph *PatientHandler) GetPatientByID(c *gin.Context) {
 		return
 	}
 
-	patient, err := ph.PatientController.GetPatientByID(id)
+	patient, err := ph.PatientController.GetPatientByID(c.GetUint("userID"), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Patient not found: " + err.Error()})
+		c.JSON(accessErrorStatus(err, http.StatusNotFound), gin.H{"message": "Patient not found: " + err.Error()})
 		return
 	}
 
@@ -89,12 +63,13 @@ func (ph *PatientHandler) GetPatientByID(c *gin.Context) {
 }
 
 func (ph *PatientHandler) GetAllPatients(c *gin.Context) {
-	patients, err := ph.PatientController.GetAllPatients()
+	patients, err := ph.PatientController.GetAllPatients(c.GetUint("userID"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve patients: " + err.Error()})
 		return
 	}
 
+	middleware.AuditShown(c, patients)
 	c.JSON(http.StatusOK, gin.H{"patients": patients})
 }
 
@@ -111,9 +86,9 @@ func (ph *PatientHandler) UpdatePatient(c *gin.Context) {
 		return
 	}
 
-	patient, err := ph.PatientController.UpdatePatient(id, &updated)
+	patient, err := ph.PatientController.UpdatePatient(c.Request.Context(), c.GetUint("userID"), id, &updated)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update patient: " + err.Error()})
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to update patient: " + err.Error()})
 		return
 	}
 
@@ -130,8 +105,8 @@ func (ph *PatientHandler) DeletePatient(c *gin.Context) {
 		return
 	}
 
-	if err := ph.PatientController.DeletePatient(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete patient: " + err.Error()})
+	if err := ph.PatientController.DeletePatient(c.Request.Context(), c.GetUint("userID"), id); err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to delete patient: " + err.Error()})
 		return
 	}
 
@@ -154,6 +129,7 @@ func (ph *PatientHandler) GetPatientsByHealthWorker(c *gin.Context) {
 		return
 	}
 
+	middleware.AuditShown(c, patients)
 	c.JSON(http.StatusOK, gin.H{"patients": patients})
 }
 
@@ -181,6 +157,7 @@ func (ph *PatientHandler) GetPatientByHealthWorker(c *gin.Context) {
 		return
 	}
 
+	middleware.AuditShown(c, patient)
 	c.JSON(http.StatusOK, gin.H{"patient": patient})
 }
 
@@ -191,12 +168,13 @@ func (ph *PatientHandler) GetPatientsByDepartment(c *gin.Context) {
 		return
 	}
 
-	patients, err := ph.PatientController.GetPatientsByDepartment(deptID)
+	patients, err := ph.PatientController.GetPatientsByDepartment(c.GetUint("userID"), deptID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve patients: " + err.Error()})
 		return
 	}
 
+	middleware.AuditShown(c, patients)
 	c.JSON(http.StatusOK, gin.H{"patients": patients})
 }
 
@@ -215,7 +193,7 @@ func (ph *PatientHandler) SetActiveStatus(c *gin.Context) {
         return
     }
 
-    patient, err := ph.PatientController.SetActiveStatus(id, body.IsActive)
+    patient, err := ph.PatientController.SetActiveStatus(c.Request.Context(), id, body.IsActive)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update status: " + err.Error()})
         return
@@ -242,11 +220,12 @@ func (ph *PatientHandler) SearchPatients(c *gin.Context) {
         "is_active":     c.Query("is_active"),
     }
 
-    patients, err := ph.PatientController.SearchPatients(queryParams)
+    patients, err := ph.PatientController.SearchPatients(c.GetUint("userID"), queryParams)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to search patients: " + err.Error()})
         return
     }
 
+    middleware.AuditShown(c, patients)
     c.JSON(http.StatusOK, gin.H{"patients": patients})
 }
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net/http"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/util"
+	"depression-diagnosis-system/database/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PermissionHandler struct {
+	PermissionController interfaces.PermissionInterface
+}
+
+func NewPermissionHandler() *PermissionHandler {
+	return &PermissionHandler{
+		PermissionController: controller.NewPermissionController(),
+	}
+}
+
+// GetPermissionCatalogue lists every permission that can be granted
+func (ph *PermissionHandler) GetPermissionCatalogue(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"permissions":   model.AllPermissions,
+		"subject_types": []string{model.SubjectRole, model.SubjectPersonnelType},
+	})
+}
+
+// GetAllGrants lists permission grants, optionally filtered by subject
+func (ph *PermissionHandler) GetAllGrants(c *gin.Context) {
+	subjectType := c.Query("subject_type")
+	subject := c.Query("subject")
+
+	var grants []model.PermissionGrant
+	var err error
+	if subjectType != "" && subject != "" {
+		grants, err = ph.PermissionController.GetGrantsForSubject(subjectType, subject)
+	} else {
+		grants, err = ph.PermissionController.GetAllGrants()
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve permission grants: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"grants": grants})
+}
+
+// CreateGrant gives a permission to a role or personnel type
+func (ph *PermissionHandler) CreateGrant(c *gin.Context) {
+	var input model.PermissionGrant
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	grant, err := ph.PermissionController.CreateGrant(&input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to create permission grant: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Permission granted successfully",
+		"grant":   grant,
+	})
+}
+
+// DeleteGrant revokes a permission grant
+func (ph *PermissionHandler) DeleteGrant(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	if err := ph.PermissionController.DeleteGrant(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to revoke permission grant: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Permission revoked successfully"})
+}
@@ -23,14 +23,8 @@ func NewPersonnelTypeHandler() *PersonnelTypeHandler {
 }
 
 
-// CreatePersonnelType adds a new personnel type (admin only)
+// CreatePersonnelType adds a new personnel type (requires personnel_type:manage)
 func (pth *PersonnelTypeHandler) CreatePersonnelType(c *gin.Context) {
-	role, exists := c.Get("userRole")
-	if !exists || role != model.RoleAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Only admins can create personnel types"})
-		return
-	}
-
 	var input model.PersonnelType
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
@@ -84,14 +78,8 @@ func (pth *PersonnelTypeHandler) GetPersonnelTypeByID(c *gin.Context) {
 	})
 }
 
-// DeletePersonnelType deletes a personnel type by ID (admin only)
+// DeletePersonnelType deletes a personnel type by ID (requires personnel_type:manage)
 func (pth *PersonnelTypeHandler) DeletePersonnelType(c *gin.Context) {
-	role, exists := c.Get("userRole")
-	if !exists || role != model.RoleAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Only admins can delete personnel types"})
-		return
-	}
-
 	id, err := util.GetIDParam(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
@@ -21,19 +21,8 @@ func NewPhq9QuestionHandler() *Phq9QuestionHandler {
 	}
 }
 
-// CreateQuestion adds a new PHQ-9 question (only admin)
+// CreateQuestion adds a new PHQ-9 question (requires phq9:question:manage)
 func (h *Phq9QuestionHandler) CreateQuestion(c *gin.Context) {
-	roleI, exists := c.Get("userRole")
-	if !exists {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Unauthorized"})
-		return
-	}
-	role, ok := roleI.(string)
-	if !ok || role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Only admins can create PHQ-9 questions"})
-		return
-	}
-
 	var input model.Phq9Question
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
@@ -52,9 +41,10 @@ func (h *Phq9QuestionHandler) CreateQuestion(c *gin.Context) {
 	})
 }
 
-// GetAllQuestions returns all PHQ-9 questions (open to authenticated users)
+// GetAllQuestions returns all PHQ-9 questions in the language asked for with ?lang= or
+// Accept-Language (open to authenticated users)
 func (h *Phq9QuestionHandler) GetAllQuestions(c *gin.Context) {
-	questions, err := h.Phq9QuestionController.GetAllQuestions()
+	questions, err := h.Phq9QuestionController.GetAllQuestions(util.RequestLocale(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve questions: " + err.Error()})
 		return
@@ -84,19 +74,8 @@ func (h *Phq9QuestionHandler) GetQuestionByID(c *gin.Context) {
 	})
 }
 
-// DeleteQuestion removes a PHQ-9 question (only admin)
+// DeleteQuestion removes a PHQ-9 question (requires phq9:question:manage)
 func (h *Phq9QuestionHandler) DeleteQuestion(c *gin.Context) {
-	roleI, exists := c.Get("userRole")
-	if !exists {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Unauthorized"})
-		return
-	}
-	role, ok := roleI.(string)
-	if !ok || role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Only admins can delete PHQ-9 questions"})
-		return
-	}
-
 	id, err := util.GetIDParam(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
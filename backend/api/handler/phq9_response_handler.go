@@ -21,10 +21,15 @@ func NewPhq9ResponseHandler() *Phq9ResponseHandler {
 	}
 }
 
-// CreateResponse submits a new PHQ-9 response (open to authenticated users)
+// CreateResponse submits a new PHQ-9 response, answered in the language given by ?lang= or
+// Accept-Language (open to authenticated users)
 func (h *Phq9ResponseHandler) CreateResponse(c *gin.Context) {
 	sessionID, err := util.GetIDParam(c)
-	
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid session ID"})
+		return
+	}
+
 	var input []model.Phq9ResponseStruct
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -33,14 +38,10 @@ func (h *Phq9ResponseHandler) CreateResponse(c *gin.Context) {
 		})
 		return
 	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
-		return
-	}
 
-	created, err := h.Phq9ResponseController.CreateResponse(uint(sessionID), input)
+	created, err := h.Phq9ResponseController.CreateResponse(c.Request.Context(), c.GetUint("userID"), sessionID, util.RequestLocale(c), input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create PHQ-9 response: " + err.Error()})
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to create PHQ-9 response: " + err.Error()})
 		return
 	}
 
@@ -58,9 +59,9 @@ func (h *Phq9ResponseHandler) GetResponseBySessionID(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.Phq9ResponseController.GetResponseBySessionID(sessionID)
+	resp, err := h.Phq9ResponseController.GetResponseBySessionID(c.GetUint("userID"), sessionID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "PHQ-9 response not found: " + err.Error()})
+		c.JSON(accessErrorStatus(err, http.StatusNotFound), gin.H{"message": "PHQ-9 response not found: " + err.Error()})
 		return
 	}
 
@@ -69,19 +70,8 @@ func (h *Phq9ResponseHandler) GetResponseBySessionID(c *gin.Context) {
 	})
 }
 
-// UpdateResponse modifies a PHQ-9 response (admin or psychiatrist)
+// UpdateResponse modifies a PHQ-9 response (requires phq9:response:update)
 func (h *Phq9ResponseHandler) UpdateResponse(c *gin.Context) {
-	roleI, exists := c.Get("userRole")
-	if !exists {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Unauthorized"})
-		return
-	}
-	role, ok := roleI.(string)
-	if !ok || (role != "admin" && role != "psychiatrist") {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Only admins or psychiatrists can update PHQ-9 responses"})
-		return
-	}
-
 	id, err := util.GetIDParam(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
@@ -94,9 +84,9 @@ func (h *Phq9ResponseHandler) UpdateResponse(c *gin.Context) {
 		return
 	}
 
-	updated, err := h.Phq9ResponseController.UpdateResponse(id, &input)
+	updated, err := h.Phq9ResponseController.UpdateResponse(c.Request.Context(), c.GetUint("userID"), id, &input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update response: " + err.Error()})
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to update response: " + err.Error()})
 		return
 	}
 
@@ -106,27 +96,16 @@ func (h *Phq9ResponseHandler) UpdateResponse(c *gin.Context) {
 	})
 }
 
-// DeleteResponse removes a PHQ-9 response by ID (admin only)
+// DeleteResponse removes a PHQ-9 response by ID (requires phq9:response:delete)
 func (h *Phq9ResponseHandler) DeleteResponse(c *gin.Context) {
-	roleI, exists := c.Get("userRole")
-	if !exists {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Unauthorized"})
-		return
-	}
-	role, ok := roleI.(string)
-	if !ok || role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Only admins can delete PHQ-9 responses"})
-		return
-	}
-
 	id, err := util.GetIDParam(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
 		return
 	}
 
-	if err := h.Phq9ResponseController.DeleteResponse(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete response: " + err.Error()})
+	if err := h.Phq9ResponseController.DeleteResponse(c.Request.Context(), c.GetUint("userID"), id); err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to delete response: " + err.Error()})
 		return
 	}
 
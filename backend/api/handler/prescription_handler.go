@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/middleware"
+	"depression-diagnosis-system/api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PrescriptionHandler struct {
+	PrescriptionController interfaces.PrescriptionInterface
+}
+
+func NewPrescriptionHandler() *PrescriptionHandler {
+	return &PrescriptionHandler{
+		PrescriptionController: controller.NewPrescriptionController(),
+	}
+}
+
+// GetPatientPrescriptions lists a patient's prescription lines; ?active=true keeps the drugs they
+// are currently on
+func (ph *PrescriptionHandler) GetPatientPrescriptions(c *gin.Context) {
+	patientID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		return
+	}
+
+	lines, err := ph.PrescriptionController.GetPatientPrescriptions(c.GetUint("userID"), patientID, c.Query("active") == "true")
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to retrieve prescriptions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"prescription_lines": lines})
+}
+
+// SearchPrescriptions finds who is prescribed a drug, e.g. ?drug=fluoxetine&strength=20 mg&active=true
+func (ph *PrescriptionHandler) SearchPrescriptions(c *gin.Context) {
+	lines, err := ph.PrescriptionController.SearchPrescriptions(c.GetUint("userID"), c.Query("drug"), c.Query("strength"), c.Query("active") == "true")
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to search prescriptions: " + err.Error()})
+		return
+	}
+
+	middleware.AuditShown(c, lines)
+	c.JSON(http.StatusOK, gin.H{"prescription_lines": lines})
+}
+
+// StopPrescriptionLine records that a drug was stopped (requires medication:update)
+func (ph *PrescriptionHandler) StopPrescriptionLine(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		StopDate *time.Time `json:"stop_date"` // defaults to now
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	line, err := ph.PrescriptionController.StopPrescriptionLine(c.Request.Context(), c.GetUint("userID"), id, input.StopDate)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to stop prescription line: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Prescription line stopped successfully",
+		"prescription_line": line,
+	})
+}
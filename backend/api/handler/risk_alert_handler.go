@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"net/http"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/util"
+	"depression-diagnosis-system/database/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RiskAlertHandler struct {
+	RiskAlertController interfaces.RiskAlertInterface
+}
+
+func NewRiskAlertHandler() *RiskAlertHandler {
+	return &RiskAlertHandler{
+		RiskAlertController: controller.NewRiskAlertController(),
+	}
+}
+
+// GetMyOpenRiskAlerts lists open alerts awaiting the caller's attention
+func (rh *RiskAlertHandler) GetMyOpenRiskAlerts(c *gin.Context) {
+	alerts, err := rh.RiskAlertController.GetMyOpenRiskAlerts(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve risk alerts: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"risk_alerts": alerts})
+}
+
+// GetRiskAlertsBySession lists the alerts raised for a session
+func (rh *RiskAlertHandler) GetRiskAlertsBySession(c *gin.Context) {
+	sessionID, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid session ID"})
+		return
+	}
+
+	alerts, err := rh.RiskAlertController.GetRiskAlertsBySession(c.GetUint("userID"), sessionID)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to retrieve risk alerts: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"risk_alerts": alerts})
+}
+
+// AcknowledgeRiskAlert closes an alert with a documented safety plan
+func (rh *RiskAlertHandler) AcknowledgeRiskAlert(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		SafetyPlan string `json:"safety_plan" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	alert, err := rh.RiskAlertController.AcknowledgeRiskAlert(c.Request.Context(), id, c.GetUint("userID"), input.SafetyPlan)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to acknowledge risk alert: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Risk alert acknowledged successfully",
+		"risk_alert": alert,
+	})
+}
+
+// GetRiskRules lists the risk rules (requires risk_rule:manage)
+func (rh *RiskAlertHandler) GetRiskRules(c *gin.Context) {
+	rules, err := rh.RiskAlertController.GetRiskRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve risk rules: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// CreateRiskRule adds a risk rule (requires risk_rule:manage)
+func (rh *RiskAlertHandler) CreateRiskRule(c *gin.Context) {
+	var input model.RiskRule
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	rule, err := rh.RiskAlertController.CreateRiskRule(&input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to create risk rule: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Risk rule created successfully",
+		"rule":    rule,
+	})
+}
+
+// UpdateRiskRule changes a risk rule (requires risk_rule:manage)
+func (rh *RiskAlertHandler) UpdateRiskRule(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	var input model.RiskRule
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	rule, err := rh.RiskAlertController.UpdateRiskRule(id, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to update risk rule: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Risk rule updated successfully",
+		"rule":    rule,
+	})
+}
+
+// DeleteRiskRule removes a risk rule (requires risk_rule:manage)
+func (rh *RiskAlertHandler) DeleteRiskRule(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	if err := rh.RiskAlertController.DeleteRiskRule(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete risk rule: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Risk rule deleted successfully"})
+}
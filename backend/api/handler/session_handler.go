@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"depression-diagnosis-system/api/controller"
 	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/middleware"
 	"depression-diagnosis-system/api/util"
 	"depression-diagnosis-system/database/model"
 
@@ -21,41 +23,42 @@ func NewSessionHandler() *SessionHandler {
 	}
 }
 
-// Only admins, psychologists, psychiatrists, clinical officers can create sessions
-func (sh *SessionHandler) CreateSession(c *gin.Context) {
-	ptypeI, exists := c.Get("userPersonnelType")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
-		return
-	}
-
-	ptype, ok := ptypeI.(string)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to parse personnel type"})
-		return
-	}
-
-	allowedTypes := map[string]bool{
-		"Admin":            true,
-		"Psychiatrist":     true,
-		"Psychologist":     true,
-		"Clinical Officer": true,
+// sessionErrorStatus maps refused status changes, such as completing with open risk alerts, to 409
+// and denied access to 403
+func sessionErrorStatus(err error, fallback int) int {
+	if errors.Is(err, controller.ErrOpenRiskAlerts) || errors.Is(err, controller.ErrSessionTransition) {
+		return http.StatusConflict
 	}
+	return accessErrorStatus(err, fallback)
+}
 
-	if !allowedTypes[ptype] {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Insufficient permissions to create session"})
-		return
+// sessionOverrideRequired answers a session write held back by a severe prescribing warning with
+// the warnings, like overrideRequired does for medication history entries
+func sessionOverrideRequired(c *gin.Context, err error, s *model.Session) bool {
+	if !errors.Is(err, controller.ErrOverrideRequired) || s == nil {
+		return false
 	}
+	c.JSON(http.StatusConflict, gin.H{
+		"message":         "Prescription needs review: " + err.Error(),
+		"safety_warnings": s.SafetyWarnings,
+	})
+	return true
+}
 
+// CreateSession opens a new session (requires session:create)
+func (sh *SessionHandler) CreateSession(c *gin.Context) {
 	var input model.Session
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
 		return
 	}
 
-	createdSession, err := sh.SessionController.CreateSession(&input)
+	createdSession, err := sh.SessionController.CreateSession(c.Request.Context(), c.GetUint("userID"), &input)
+	if sessionOverrideRequired(c, err, createdSession) {
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create session: " + err.Error()})
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to create session: " + err.Error()})
 		return
 	}
 
@@ -73,9 +76,9 @@ func (sh *SessionHandler) GetSessionByID(c *gin.Context) {
 		return
 	}
 
-	session, err := sh.SessionController.GetSessionByID(id)
+	session, err := sh.SessionController.GetSessionByID(c.GetUint("userID"), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Session not found: " + err.Error()})
+		c.JSON(accessErrorStatus(err, http.StatusNotFound), gin.H{"message": "Session not found: " + err.Error()})
 		return
 	}
 
@@ -83,12 +86,13 @@ func (sh *SessionHandler) GetSessionByID(c *gin.Context) {
 }
 
 func (sh *SessionHandler) GetAllSessions(c *gin.Context) {
-	sessions, err := sh.SessionController.GetAllSessions()
+	sessions, err := sh.SessionController.GetAllSessions(c.GetUint("userID"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve sessions: " + err.Error()})
 		return
 	}
 
+	middleware.AuditShown(c, sessions)
 	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
 }
 
@@ -99,12 +103,13 @@ func (sh *SessionHandler) GetSessionByCode(c *gin.Context) {
 		return
 	}
 
-	session, err := sh.SessionController.GetSessionByCode(code)
+	session, err := sh.SessionController.GetSessionByCode(c.GetUint("userID"), code)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Session not found: " + err.Error()})
+		c.JSON(accessErrorStatus(err, http.StatusNotFound), gin.H{"message": "Session not found: " + err.Error()})
 		return
 	}
 
+	middleware.AuditShown(c, session)
 	c.JSON(http.StatusOK, gin.H{"session": session})
 }
 
@@ -115,9 +120,9 @@ func (sh *SessionHandler) GetSessionsByPatient(c *gin.Context) {
 		return
 	}
 
-	sessions, err := sh.SessionController.GetSessionsByPatient(patientID)
+	sessions, err := sh.SessionController.GetSessionsByPatient(c.GetUint("userID"), patientID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch sessions: " + err.Error()})
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to fetch sessions: " + err.Error()})
 		return
 	}
 
@@ -140,6 +145,7 @@ func (sh *SessionHandler) GetSessionsByHealthWorker(c *gin.Context) {
 		return
 	}
 
+	middleware.AuditShown(c, sessions)
 	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
 }
 
@@ -157,9 +163,12 @@ func (sh *SessionHandler) UpdateSession(c *gin.Context) {
 		return
 	}
 
-	session, err := sh.SessionController.UpdateSession(id, &updated)
+	session, err := sh.SessionController.UpdateSession(c.Request.Context(), c.GetUint("userID"), id, &updated)
+	if sessionOverrideRequired(c, err, session) {
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update session: " + err.Error()})
+		c.JSON(sessionErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to update session: " + err.Error()})
 		return
 	}
 
@@ -176,17 +185,20 @@ func (sh *SessionHandler) DeleteSession(c *gin.Context) {
 		return
 	}
 
-	if err := sh.SessionController.DeleteSession(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete session: " + err.Error()})
+	if err := sh.SessionController.DeleteSession(c.Request.Context(), c.GetUint("userID"), id); err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to delete session: " + err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Session deleted successfully"})
 }
+
+// UpdateSessionStatus moves a session along its lifecycle (requires session:update)
 func (sh *SessionHandler) UpdateSessionStatus(c *gin.Context) {
 	var req struct {
 		SessionID uint   `json:"sessionID" binding:"required"`
-		Status    string `json:"status" binding:"required,oneof=ongoing completed cancelled"`
+		Status    string `json:"status" binding:"required,oneof=scheduled checked_in ongoing completed cancelled no_show"`
+		Reason    string `json:"reason"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -197,10 +209,11 @@ func (sh *SessionHandler) UpdateSessionStatus(c *gin.Context) {
 		return
 	}
 
-	err := sh.SessionController.UpdateSessionStatus(req.SessionID, req.Status)
+	err := sh.SessionController.UpdateSessionStatus(c.Request.Context(), c.GetUint("userID"), req.SessionID, req.Status, req.Reason)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
+		status := sessionErrorStatus(err, http.StatusInternalServerError)
+		c.JSON(status, gin.H{
+			"status":  status,
 			"message": "Failed to update session status: " + err.Error(),
 		})
 		return
@@ -212,19 +225,38 @@ func (sh *SessionHandler) UpdateSessionStatus(c *gin.Context) {
 	})
 }
 
+// GetSessionTransitions lists who changed a session's status and when
+func (sh *SessionHandler) GetSessionTransitions(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID"})
+		return
+	}
+
+	transitions, err := sh.SessionController.GetSessionTransitions(c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to retrieve session history: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transitions": transitions})
+}
+
 func (sh *SessionHandler) SearchSessions(c *gin.Context) {
 	// Extract query params
 	queryParams := map[string]string{
         "session_code":     c.Query("session_code"),
         "health_worker_id": c.Query("health_worker_id"),
         "patient_id":   	c.Query("patient_id"),
+        "status":           c.Query("status"),
     }
 
-	sessions, err := sh.SessionController.SearchSessions(queryParams)
+	sessions, err := sh.SessionController.SearchSessions(c.GetUint("userID"), queryParams)
 	if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"message": "Search failed: " + err.Error()})
         return
     }
 
+    middleware.AuditShown(c, sessions)
     c.JSON(http.StatusOK, gin.H{"sessions": sessions})
 }
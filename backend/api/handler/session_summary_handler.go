@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"depression-diagnosis-system/api/controller"
 	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/middleware"
 	"depression-diagnosis-system/api/util"
 	"depression-diagnosis-system/database/model"
 
@@ -21,28 +23,29 @@ func NewSessionSummaryHandler() *SessionSummaryHandler {
 	}
 }
 
-// CreateSummary creates a session summary (only admin or psychiatrist)
-func (ssh *SessionSummaryHandler) CreateSummary(c *gin.Context) {
-	roleI, exists := c.Get("userRole")
-	if !exists {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Unauthorized"})
-		return
-	}
-	role, ok := roleI.(string)
-	if !ok || (role != "admin" && role != "psychiatrist") {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Only admins or psychiatrists can create session summaries"})
-		return
+// summaryErrorStatus maps changes to a signed or locked summary to 409, and signing someone
+// else's summary or denied access to 403
+func summaryErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, controller.ErrSummaryFinal):
+		return http.StatusConflict
+	case errors.Is(err, controller.ErrSummarySignature):
+		return http.StatusForbidden
 	}
+	return accessErrorStatus(err, fallback)
+}
 
+// CreateSummary creates a session summary (requires session_summary:create)
+func (ssh *SessionSummaryHandler) CreateSummary(c *gin.Context) {
 	var input model.SessionSummary
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
 		return
 	}
 
-	createdSummary, err := ssh.SessionSummaryController.CreateSummary(&input)
+	createdSummary, err := ssh.SessionSummaryController.CreateSummary(c.Request.Context(), c.GetUint("userID"), &input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create session summary: " + err.Error()})
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to create session summary: " + err.Error()})
 		return
 	}
 
@@ -60,9 +63,9 @@ func (ssh *SessionSummaryHandler) GetSummaryBySessionID(c *gin.Context) {
 		return
 	}
 
-	summary, err := ssh.SessionSummaryController.GetSummaryBySessionID(sessionID)
+	summary, err := ssh.SessionSummaryController.GetSummaryBySessionID(c.GetUint("userID"), sessionID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Session summary not found: " + err.Error()})
+		c.JSON(accessErrorStatus(err, http.StatusNotFound), gin.H{"message": "Session summary not found: " + err.Error()})
 		return
 	}
 
@@ -71,19 +74,8 @@ func (ssh *SessionSummaryHandler) GetSummaryBySessionID(c *gin.Context) {
 	})
 }
 
-// UpdateSummary updates a session summary (only admin or psychiatrist)
+// UpdateSummary updates a session summary (requires session_summary:update)
 func (ssh *SessionSummaryHandler) UpdateSummary(c *gin.Context) {
-	roleI, exists := c.Get("userRole")
-	if !exists {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Unauthorized"})
-		return
-	}
-	role, ok := roleI.(string)
-	if !ok || (role != "admin" && role != "psychiatrist") {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Only admins or psychiatrists can update session summaries"})
-		return
-	}
-
 	id, err := util.GetIDParam(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid summary ID"})
@@ -96,9 +88,9 @@ func (ssh *SessionSummaryHandler) UpdateSummary(c *gin.Context) {
 		return
 	}
 
-	updatedSummary, err := ssh.SessionSummaryController.UpdateSummary(id, &input)
+	updatedSummary, err := ssh.SessionSummaryController.UpdateSummary(c.Request.Context(), c.GetUint("userID"), id, &input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update session summary: " + err.Error()})
+		c.JSON(summaryErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to update session summary: " + err.Error()})
 		return
 	}
 
@@ -108,31 +100,117 @@ func (ssh *SessionSummaryHandler) UpdateSummary(c *gin.Context) {
 	})
 }
 
-// DeleteSummary deletes a session summary by ID (only admin)
+// DeleteSummary deletes a draft session summary by ID (requires session_summary:delete)
 func (ssh *SessionSummaryHandler) DeleteSummary(c *gin.Context) {
-	roleI, exists := c.Get("userRole")
-	if !exists {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Unauthorized"})
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid summary ID"})
+		return
+	}
+
+	if err := ssh.SessionSummaryController.DeleteSummary(c.Request.Context(), c.GetUint("userID"), id); err != nil {
+		c.JSON(summaryErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to delete session summary: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Session summary deleted successfully",
+	})
+}
+
+// SignSummary e-signs a summary as its author (requires session_summary:update)
+func (ssh *SessionSummaryHandler) SignSummary(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid summary ID"})
 		return
 	}
-	role, ok := roleI.(string)
-	if !ok || role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Only admins can delete session summaries"})
+
+	summary, err := ssh.SessionSummaryController.SignSummary(c.Request.Context(), c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(summaryErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to sign session summary: " + err.Error()})
 		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Session summary signed successfully",
+		"session_summary": summary,
+	})
+}
+
+// CoSignSummary co-signs a summary as its author's supervisor
+func (ssh *SessionSummaryHandler) CoSignSummary(c *gin.Context) {
 	id, err := util.GetIDParam(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid summary ID"})
 		return
 	}
 
-	if err := ssh.SessionSummaryController.DeleteSummary(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete session summary: " + err.Error()})
+	summary, err := ssh.SessionSummaryController.CoSignSummary(c.Request.Context(), c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(summaryErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to co-sign session summary: " + err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Session summary deleted successfully",
+		"message":         "Session summary co-signed successfully",
+		"session_summary": summary,
 	})
 }
+
+// GetPendingCoSignatures lists the summaries waiting for the caller's co-signature
+func (ssh *SessionSummaryHandler) GetPendingCoSignatures(c *gin.Context) {
+	summaries, err := ssh.SessionSummaryController.GetPendingCoSignatures(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve pending co-signatures: " + err.Error()})
+		return
+	}
+
+	middleware.AuditShown(c, summaries)
+	c.JSON(http.StatusOK, gin.H{"session_summaries": summaries})
+}
+
+// AddAddendum appends an addendum to a signed or locked summary (requires session_summary:update)
+func (ssh *SessionSummaryHandler) AddAddendum(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid summary ID"})
+		return
+	}
+
+	var input struct {
+		Text string `json:"text" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	addendum, err := ssh.SessionSummaryController.AddAddendum(c.Request.Context(), c.GetUint("userID"), id, input.Text)
+	if err != nil {
+		c.JSON(summaryErrorStatus(err, http.StatusBadRequest), gin.H{"message": "Failed to add addendum: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Addendum added successfully",
+		"addendum": addendum,
+	})
+}
+
+// GetSummaryHistory lists every version of a summary, oldest first
+func (ssh *SessionSummaryHandler) GetSummaryHistory(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid summary ID"})
+		return
+	}
+
+	versions, err := ssh.SessionSummaryController.GetSummaryHistory(c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusNotFound), gin.H{"message": "Failed to retrieve summary history: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"versions": versions})
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"depression-diagnosis-system/api/controller"
+	"depression-diagnosis-system/api/interfaces"
+	"depression-diagnosis-system/api/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TrajectoryHandler struct {
+	TrajectoryController interfaces.TrajectoryInterface
+}
+
+func NewTrajectoryHandler() *TrajectoryHandler {
+	return &TrajectoryHandler{
+		TrajectoryController: controller.NewTrajectoryController(),
+	}
+}
+
+// GetPatientTrajectory returns a patient's PHQ-9 scores over time with per-item trends and
+// response, remission and reliable change flags
+func (h *TrajectoryHandler) GetPatientTrajectory(c *gin.Context) {
+	id, err := util.GetIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		return
+	}
+
+	trajectory, err := h.TrajectoryController.GetPatientTrajectory(c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(accessErrorStatus(err, http.StatusInternalServerError), gin.H{"message": "Failed to build trajectory: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"trajectory": trajectory})
+}
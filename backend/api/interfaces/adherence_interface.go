@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+
+	"depression-diagnosis-system/database/model"
+)
+
+type AdherenceInterface interface {
+	RecordDispensing(ctx context.Context, viewerID, lineID uint, event *model.DispensingEvent) (*model.DispensingEvent, error)
+	RecordCheckIn(ctx context.Context, viewerID, lineID uint, checkIn *model.AdherenceCheckIn) (*model.AdherenceCheckIn, error)
+	GetLineAdherence(viewerID, lineID uint) (*model.AdherenceDetail, error)
+	GetPatientAdherence(viewerID, patientID uint) ([]model.LineAdherence, error)
+	GetAtRiskPatients(viewerID, healthWorkerID uint) ([]model.AdherenceRisk, error)
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"depression-diagnosis-system/database/model"
+)
+
+type AdverseReactionInterface interface {
+	ReportReaction(ctx context.Context, viewerID, sessionID uint, r *model.AdverseReaction) (*model.AdverseReaction, error)
+	UpdateReaction(ctx context.Context, viewerID, id uint, updated *model.AdverseReaction, serious *bool, notes *string) (*model.AdverseReaction, error)
+	GetSessionReactions(viewerID, sessionID uint) ([]model.AdverseReaction, error)
+	GetPatientReactions(viewerID, patientID uint) ([]model.AdverseReaction, error)
+	ExportADRForms(viewerID uint, from, to time.Time) (string, []uint, error)
+}
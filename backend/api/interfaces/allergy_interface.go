@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+
+	"depression-diagnosis-system/database/model"
+)
+
+type AllergyInterface interface {
+	GetPatientAllergies(viewerID, patientID uint) ([]model.PatientAllergy, error)
+	AddAllergy(ctx context.Context, viewerID, patientID uint, allergy *model.PatientAllergy) (*model.PatientAllergy, error)
+	RemoveAllergy(ctx context.Context, viewerID, patientID, id uint) error
+}
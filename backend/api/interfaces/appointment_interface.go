@@ -0,0 +1,28 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"depression-diagnosis-system/database/model"
+)
+
+type AppointmentInterface interface {
+	BookAppointment(ctx context.Context, actorID uint, appt *model.Appointment) (*model.Appointment, error)
+	GetAppointmentByID(viewerID, id uint) (*model.Appointment, error)
+	GetAppointmentsByPatient(viewerID, patientID uint) ([]model.Appointment, error)
+	RescheduleAppointment(ctx context.Context, actorID, id, healthWorkerID uint, startsAt time.Time, reason string) (*model.Appointment, error)
+	CancelAppointment(ctx context.Context, actorID, id uint, reason string) (*model.Appointment, error)
+	CheckInAppointment(ctx context.Context, actorID, id uint) (*model.Appointment, error)
+
+	// Calendar
+	GetAvailableSlots(healthWorkerID uint, day time.Time) ([]model.AppointmentSlot, error)
+	GetCalendar(viewerID, healthWorkerID, departmentID uint, from time.Time, days int) (*model.Calendar, error)
+
+	// Working hours and leave
+	GetWorkingHours(healthWorkerID uint) ([]model.WorkingHours, error)
+	SetWorkingHours(healthWorkerID uint, hours []model.WorkingHours) ([]model.WorkingHours, error)
+	GetLeave(healthWorkerID uint) ([]model.HealthWorkerLeave, error)
+	CreateLeave(actorID uint, leave *model.HealthWorkerLeave) (*model.HealthWorkerLeave, error)
+	DeleteLeave(id uint) error
+}
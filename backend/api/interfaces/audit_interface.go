@@ -0,0 +1,10 @@
+package interfaces
+
+import "depression-diagnosis-system/database/model"
+
+type AuditInterface interface {
+	SearchAuditLogs(queryParams map[string]string) ([]model.AuditLog, error)
+	GetAuditLogsByPatient(patientID uint) ([]model.AuditLog, error)
+	GetAuditLogsByHealthWorker(healthWorkerID uint) ([]model.AuditLog, error)
+	VerifyAuditChain() (*model.AuditChainStatus, error)
+}
@@ -0,0 +1,8 @@
+package interfaces
+
+type CalendarFeedInterface interface {
+	IssueFeedToken(healthWorkerID uint) (string, error)
+	RevokeFeedToken(healthWorkerID uint) error
+	GetCalendarFeed(token string) (string, uint, []uint, error)
+	GetSessionCalendar(viewerID, sessionID uint) (string, error)
+}
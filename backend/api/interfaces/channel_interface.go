@@ -0,0 +1,25 @@
+package interfaces
+
+import (
+	"time"
+
+	"depression-diagnosis-system/database/model"
+)
+
+type ChannelInterface interface {
+	GetMyChannels(userID uint) ([]model.ChannelThread, error)
+	GetOpenGroups(userID uint) ([]model.Channel, error)
+	GetChannel(viewerID, channelID uint) (*model.Channel, error)
+	CreateGroup(viewerID uint, ch *model.Channel, memberIDs []uint) (*model.Channel, error)
+	OpenCareTeamChannel(viewerID, patientID uint) (*model.Channel, error)
+	UpdateChannel(viewerID, channelID uint, name, description *string, open, archived *bool) (*model.Channel, error)
+	JoinChannel(viewerID, channelID uint) (*model.Channel, error)
+	LeaveChannel(viewerID, channelID uint) error
+	AddChannelMember(viewerID, channelID, healthWorkerID uint, role string) (*model.Channel, error)
+	UpdateChannelMember(viewerID, channelID, healthWorkerID uint, role string, setMute bool, mutedUntil *time.Time) (*model.ChannelMember, error)
+	RemoveChannelMember(viewerID, channelID, healthWorkerID uint) error
+	GetChannelMessages(viewerID, channelID, before uint) ([]model.Message, bool, error)
+	SendChannelMessage(viewerID, channelID uint, text string) (*model.Message, error)
+	MarkChannelRead(viewerID, channelID, upTo uint) (int, error)
+	DeleteChannelMessage(viewerID, channelID, messageID uint) error
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import "depression-diagnosis-system/database/model"
+
+type DefaulterInterface interface {
+	DetectMissedFollowUps() (int, error)
+	GetDefaulterWorklist(viewerID, departmentID uint) ([]model.Defaulter, error)
+	GetPatientAttendance(viewerID, patientID uint) (*model.Attendance, error)
+	ResolveMissedFollowUp(actorID, id uint, outcome, notes string) (*model.MissedFollowUp, error)
+}
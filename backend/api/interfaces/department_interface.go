@@ -8,6 +8,8 @@ type DepartmentInterface interface {
 	GetAllDepartments() ([]model.Department, error)
 	UpdateDepartment(id uint, updated *model.Department) (*model.Department, error)
 	DeleteDepartment(id uint) error
+	SetOnCallPsychiatrist(id uint, healthWorkerID *uint) (*model.Department, error)
+	SetDefaultNoteTemplate(id uint, templateID *uint) (*model.Department, error)
 
 	// Optional
 	GetDepartmentByName(name string) (*model.Department, error)
@@ -0,0 +1,12 @@
+package interfaces
+
+import "depression-diagnosis-system/database/model"
+
+type DeviceSessionInterface interface {
+	StartSession(hw *model.HealthWorker, deviceName, userAgent, ipAddress string) (*model.TokenPair, error)
+	Refresh(refreshToken, userAgent, ipAddress string) (*model.TokenPair, error)
+
+	GetActiveSessions(healthWorkerID uint) ([]model.DeviceSession, error)
+	RevokeSession(healthWorkerID, sessionID uint, reason string) error
+	RevokeAllSessions(healthWorkerID uint, reason string) error
+}
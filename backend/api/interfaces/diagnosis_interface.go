@@ -1,11 +1,15 @@
 package interfaces
 
-import "depression-diagnosis-system/database/model"
+import (
+	"context"
+
+	"depression-diagnosis-system/database/model"
+)
 
 type DiagnosisInterface interface {
-	CreateDiagnosis(diagnosis *model.Diagnosis) (*model.Diagnosis, error)
-	GetDiagnosisByID(id uint) (*model.Diagnosis, error)
-	GetDiagnosisBySessionID(sessionID uint) (*model.Diagnosis, error)
-	UpdateDiagnosis(id uint, updated *model.Diagnosis) (*model.Diagnosis, error)
-	DeleteDiagnosis(id uint) error
+	CreateDiagnosis(ctx context.Context, viewerID uint, diagnosis *model.Diagnosis) (*model.Diagnosis, error)
+	GetDiagnosisByID(viewerID, id uint) (*model.Diagnosis, error)
+	GetDiagnosisBySessionID(viewerID, sessionID uint) (*model.Diagnosis, error)
+	UpdateDiagnosis(ctx context.Context, viewerID, id uint) (*model.Diagnosis, error)
+	DeleteDiagnosis(ctx context.Context, id uint) error
 }
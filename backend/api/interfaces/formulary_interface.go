@@ -0,0 +1,11 @@
+package interfaces
+
+import "depression-diagnosis-system/database/model"
+
+type FormularyInterface interface {
+	GetDrugs(query string, includeInactive bool) ([]model.Drug, error)
+	GetDrugByID(id uint) (*model.Drug, error)
+	CreateDrug(drug *model.Drug) (*model.Drug, error)
+	UpdateDrug(id uint, updated *model.Drug) (*model.Drug, error)
+	SetActiveStatus(id uint, active bool) error
+}
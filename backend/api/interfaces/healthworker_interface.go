@@ -3,7 +3,7 @@ package interfaces
 import "depression-diagnosis-system/database/model"
 
 type HealthWorkerInterface interface {
-	CreateHealthWorker(hw *model.HealthWorker) (*model.HealthWorker, error)
+	CreateHealthWorker(hw *model.HealthWorker, password string) (*model.HealthWorker, error)
 	GetHealthWorkerByID(id uint) (*model.HealthWorker, error)
 	GetAllHealthWorkers() ([]model.HealthWorker, error)
 	UpdateHealthWorker(id uint, updated *model.HealthWorker) (*model.HealthWorker, error)
@@ -15,6 +15,12 @@ type HealthWorkerInterface interface {
 	SetActiveStatus(id uint, active bool) (*model.HealthWorker, error)
 	Login(identifier string, password string) (*model.HealthWorker, error)
 	Logout(token string) error
+	LogoutAllDevices(id uint) error
+
+	// Credential lifecycle
+	ChangePassword(id uint, currentPassword, newPassword string) error
+	IssuePasswordReset(id uint, issuedByID uint) (string, error)
+	ResetPassword(token string, newPassword string) error
 
 	SearchHealthWorkers(queryParams map[string]string) ([]model.HealthWorker, error)
 }
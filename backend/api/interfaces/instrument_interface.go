@@ -0,0 +1,31 @@
+package interfaces
+
+import (
+	"context"
+
+	"depression-diagnosis-system/database/model"
+)
+
+type InstrumentInterface interface {
+	// Definitions
+	GetAllInstruments(locale string) ([]model.Instrument, error)
+	GetInstrumentByID(id uint, locale string) (*model.Instrument, error)
+	GetInstrumentByCode(code, locale string) (*model.Instrument, error)
+	CreateInstrument(actorID uint, inst *model.Instrument, first *model.InstrumentVersion, publish bool) (*model.Instrument, error)
+	SetActiveStatus(id uint, active bool) error
+
+	// Versions
+	GetVersions(instrumentID uint) ([]model.InstrumentVersion, error)
+	GetVersionByID(id uint) (*model.InstrumentVersion, error)
+	CreateVersion(instrumentID uint, draft *model.InstrumentVersion) (*model.InstrumentVersion, error)
+	UpdateVersion(id uint, updated *model.InstrumentVersion) (*model.InstrumentVersion, error)
+	PublishVersion(actorID, id uint) (*model.InstrumentVersion, error)
+	DeleteVersion(id uint) error
+
+	// Responses
+	CreateResponse(ctx context.Context, viewerID uint, response *model.InstrumentResponse) (*model.InstrumentResponse, error)
+	GetResponsesBySession(viewerID, sessionID uint) ([]model.InstrumentResponse, error)
+	GetResponseByID(viewerID, id uint) (*model.InstrumentResponse, error)
+	UpdateResponse(ctx context.Context, viewerID, id uint, updated *model.InstrumentResponse) (*model.InstrumentResponse, error)
+	DeleteResponse(ctx context.Context, viewerID, id uint) error
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import "depression-diagnosis-system/database/model"
+
+type InstrumentTranslationInterface interface {
+	GetVersionTranslations(versionID uint, locale string) ([]model.InstrumentItem, error)
+	SaveTranslation(translatorID, itemID uint, locale, text string) (*model.InstrumentItemTranslation, error)
+	ApproveTranslation(reviewerID, id uint) (*model.InstrumentItemTranslation, error)
+	DeleteTranslation(id uint) error
+}
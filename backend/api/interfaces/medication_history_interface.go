@@ -1,14 +1,18 @@
 package interfaces
 
-import "depression-diagnosis-system/database/model"
+import (
+	"context"
+
+	"depression-diagnosis-system/database/model"
+)
 
 type MedicationHistoryInterface interface {
-	CreateMedicationHistory(medHist *model.MedicationHistory) (*model.MedicationHistory, error)
-	GetMedicationHistoryByID(id uint) (*model.MedicationHistory, error)
-	GetMedicationHistoriesByPatient(patientID uint) ([]model.MedicationHistory, error)
-	UpdateMedicationHistory(id uint, updated *model.MedicationHistory) (*model.MedicationHistory, error)
-	DeleteMedicationHistory(id uint) error
-
-	GetAllMedicationHistories() ([]model.MedicationHistory, error)
-	SearchMedicationHistories(queryParams map[string]string) ([]model.MedicationHistory, error)
+	CreateMedicationHistory(ctx context.Context, viewerID uint, medHist *model.MedicationHistory) (*model.MedicationHistory, error)
+	GetMedicationHistoryByID(viewerID, id uint) (*model.MedicationHistory, error)
+	GetMedicationHistoriesByPatient(viewerID, patientID uint) ([]model.MedicationHistory, error)
+	UpdateMedicationHistory(ctx context.Context, viewerID, id uint, updated *model.MedicationHistory) (*model.MedicationHistory, error)
+	DeleteMedicationHistory(ctx context.Context, viewerID, id uint) error
+
+	GetAllMedicationHistories(viewerID uint) ([]model.MedicationHistory, error)
+	SearchMedicationHistories(viewerID uint, queryParams map[string]string) ([]model.MedicationHistory, error)
 }
@@ -1,9 +1,20 @@
 package interfaces
 
-import "depression-diagnosis-system/database/model"
+import (
+	"depression-diagnosis-system/database/model"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
 
 type MessageInterface interface {
 	SendMessage(msg *model.Message) (*model.Message, error)
 	GetMessagesBetween(senderID, receiverID uint) ([]model.Message, error)
 	GetInboxForUser(userID uint) ([]model.Message, error)
+	GetMessagesSince(userID, cursor uint) ([]model.Message, bool, error)
+	MarkMessageRead(userID, messageID uint) error
+	MarkConversationRead(userID, counterpartID, upTo uint) (int, error)
+	GetConversations(userID uint) ([]model.Conversation, error)
+	GetOnlineHealthWorkers() []uint
+	Connect(conn *websocket.Conn, healthWorkerID, sessionID, since uint, expiresAt time.Time)
 }
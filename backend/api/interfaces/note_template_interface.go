@@ -0,0 +1,12 @@
+package interfaces
+
+import "depression-diagnosis-system/database/model"
+
+type NoteTemplateInterface interface {
+	GetAllNoteTemplates() ([]model.NoteTemplate, error)
+	GetNoteTemplateByID(id uint) (*model.NoteTemplate, error)
+	GetSessionNoteTemplate(viewerID, sessionID uint) (*model.NoteTemplate, error)
+	CreateNoteTemplate(t *model.NoteTemplate) (*model.NoteTemplate, error)
+	UpdateNoteTemplate(id uint, updated *model.NoteTemplate) (*model.NoteTemplate, error)
+	SetActiveStatus(id uint, active bool) error
+}
@@ -0,0 +1,22 @@
+package interfaces
+
+import "depression-diagnosis-system/database/model"
+
+type PatientAccessInterface interface {
+	CanAccessPatient(healthWorkerID, patientID uint) (bool, error)
+
+	// Care team
+	GetCareTeam(viewerID, patientID uint) ([]model.CareTeamMember, error)
+	AddCareTeamMember(member *model.CareTeamMember) (*model.CareTeamMember, error)
+	RemoveCareTeamMember(patientID, healthWorkerID uint) error
+
+	// Department-wide visibility
+	GetDepartmentAccessRules() ([]model.DepartmentAccessRule, error)
+	CreateDepartmentAccessRule(rule *model.DepartmentAccessRule) (*model.DepartmentAccessRule, error)
+	DeleteDepartmentAccessRule(id uint) error
+
+	// Break-the-glass
+	BreakGlass(healthWorkerID, patientID uint, reason string) (*model.BreakGlassAccess, error)
+	GetPendingBreakGlassReviews(reviewerID uint) ([]model.BreakGlassAccess, error)
+	ReviewBreakGlass(id, reviewerID uint, notes string) (*model.BreakGlassAccess, error)
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import "depression-diagnosis-system/database/model"
+
+type PermissionInterface interface {
+	GetAllGrants() ([]model.PermissionGrant, error)
+	GetGrantsForSubject(subjectType, subject string) ([]model.PermissionGrant, error)
+	CreateGrant(grant *model.PermissionGrant) (*model.PermissionGrant, error)
+	DeleteGrant(id uint) error
+}
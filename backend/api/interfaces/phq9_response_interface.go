@@ -1,10 +1,14 @@
 package interfaces
 
-import "depression-diagnosis-system/database/model"
+import (
+	"context"
+
+	"depression-diagnosis-system/database/model"
+)
 
 type Phq9ResponseInterface interface {
-	CreateResponse(sessionID uint, resp []model.Phq9ResponseStruct) (*model.Phq9Response, error)
-	GetResponseBySessionID(sessionID uint) ([]model.Phq9ResponseStruct, error)
-	UpdateResponse(id uint, updated *model.Phq9Response) (*model.Phq9Response, error)
-	DeleteResponse(id uint) error
+	CreateResponse(ctx context.Context, viewerID, sessionID uint, language string, resp []model.Phq9ResponseStruct) (*model.Phq9Response, error)
+	GetResponseBySessionID(viewerID, sessionID uint) ([]model.Phq9ResponseStruct, error)
+	UpdateResponse(ctx context.Context, viewerID, id uint, updated *model.Phq9Response) (*model.Phq9Response, error)
+	DeleteResponse(ctx context.Context, viewerID, id uint) error
 }
@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"depression-diagnosis-system/database/model"
+)
+
+type PrescriptionInterface interface {
+	GetPatientPrescriptions(viewerID, patientID uint, activeOnly bool) ([]model.PrescriptionLine, error)
+	SearchPrescriptions(viewerID uint, drug, strength string, activeOnly bool) ([]model.PrescriptionLine, error)
+	StopPrescriptionLine(ctx context.Context, viewerID, id uint, stopDate *time.Time) (*model.PrescriptionLine, error)
+}
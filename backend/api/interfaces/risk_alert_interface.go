@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"context"
+
+	"depression-diagnosis-system/database/model"
+)
+
+type RiskAlertInterface interface {
+	GetMyOpenRiskAlerts(healthWorkerID uint) ([]model.RiskAlert, error)
+	GetRiskAlertsBySession(viewerID, sessionID uint) ([]model.RiskAlert, error)
+	AcknowledgeRiskAlert(ctx context.Context, id, healthWorkerID uint, safetyPlan string) (*model.RiskAlert, error)
+
+	// Rules
+	GetRiskRules() ([]model.RiskRule, error)
+	CreateRiskRule(rule *model.RiskRule) (*model.RiskRule, error)
+	UpdateRiskRule(id uint, updated *model.RiskRule) (*model.RiskRule, error)
+	DeleteRiskRule(id uint) error
+}
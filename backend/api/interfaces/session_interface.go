@@ -1,19 +1,24 @@
 package interfaces
 
-import "depression-diagnosis-system/database/model"
+import (
+	"context"
+
+	"depression-diagnosis-system/database/model"
+)
 
 type SessionInterface interface {
-	CreateSession(s *model.Session) (*model.Session, error)
-	GetSessionByID(id uint) (*model.Session, error)
-	GetAllSessions() ([]model.Session, error)
-	UpdateSession(id uint, updated *model.Session) (*model.Session, error)
-	DeleteSession(id uint) error
+	CreateSession(ctx context.Context, viewerID uint, s *model.Session) (*model.Session, error)
+	GetSessionByID(viewerID, id uint) (*model.Session, error)
+	GetAllSessions(viewerID uint) ([]model.Session, error)
+	UpdateSession(ctx context.Context, viewerID, id uint, updated *model.Session) (*model.Session, error)
+	DeleteSession(ctx context.Context, viewerID, id uint) error
 
 	// Custom queries
-	GetSessionsByPatient(patientID uint) ([]model.Session, error)
+	GetSessionsByPatient(viewerID, patientID uint) ([]model.Session, error)
 	GetSessionsByHealthWorker(healthWorkerID uint) ([]model.Session, error)
-	GetSessionByCode(code string) (*model.Session, error)
-	UpdateSessionStatus(id uint, status string) error
+	GetSessionByCode(viewerID uint, code string) (*model.Session, error)
+	UpdateSessionStatus(ctx context.Context, viewerID, id uint, status, reason string) error
+	GetSessionTransitions(viewerID, id uint) ([]model.SessionTransition, error)
 
-	SearchSessions(queryParams map[string]string) ([]model.Session, error)
+	SearchSessions(viewerID uint, queryParams map[string]string) ([]model.Session, error)
 }
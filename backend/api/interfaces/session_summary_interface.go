@@ -1,10 +1,19 @@
 package interfaces
 
-import "depression-diagnosis-system/database/model"
+import (
+	"context"
+
+	"depression-diagnosis-system/database/model"
+)
 
 type SessionSummaryInterface interface {
-	CreateSummary(s *model.SessionSummary) (*model.SessionSummary, error)
-	GetSummaryBySessionID(sessionID uint) (*model.SessionSummary, error)
-	UpdateSummary(id uint, updated *model.SessionSummary) (*model.SessionSummary, error)
-	DeleteSummary(id uint) error
+	CreateSummary(ctx context.Context, viewerID uint, s *model.SessionSummary) (*model.SessionSummary, error)
+	GetSummaryBySessionID(viewerID, sessionID uint) (*model.SessionSummary, error)
+	UpdateSummary(ctx context.Context, viewerID, id uint, updated *model.SessionSummary) (*model.SessionSummary, error)
+	DeleteSummary(ctx context.Context, viewerID, id uint) error
+	SignSummary(ctx context.Context, viewerID, id uint) (*model.SessionSummary, error)
+	CoSignSummary(ctx context.Context, viewerID, id uint) (*model.SessionSummary, error)
+	GetPendingCoSignatures(viewerID uint) ([]model.SessionSummary, error)
+	AddAddendum(ctx context.Context, viewerID, id uint, text string) (*model.SummaryAddendum, error)
+	GetSummaryHistory(viewerID, id uint) ([]model.SummaryVersion, error)
 }
@@ -0,0 +1,7 @@
+package interfaces
+
+import "depression-diagnosis-system/database/model"
+
+type TrajectoryInterface interface {
+	GetPatientTrajectory(viewerID, patientID uint) (*model.Trajectory, error)
+}
@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"depression-diagnosis-system/api/util"
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request ID in and out of the API
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware tags each request with an ID, reusing the caller's when it sends a sane one
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 64 {
+			generated, err := util.GenerateSecureToken(16)
+			if err != nil {
+				log.Printf("⚠️ Failed to generate request ID: %v", err)
+			}
+			requestID = generated
+		}
+
+		c.Set("requestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// withAuditActor puts the authenticated health worker on the request context so database writes
+// made while serving the request are attributed to them in the audit trail
+func withAuditActor(c *gin.Context, healthWorkerID uint) {
+	ctx := database.WithAuditActor(c.Request.Context(), database.AuditActor{
+		HealthWorkerID: healthWorkerID,
+		IPAddress:      c.ClientIP(),
+		RequestID:      c.GetString("requestID"),
+		Path:           c.Request.URL.RequestURI(),
+	})
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// AuditAs attributes the request to a health worker on routes authenticated by a token in the URL,
+// such as a calendar feed. The route pattern is recorded rather than the URL, which holds the token.
+func AuditAs(c *gin.Context, healthWorkerID uint) {
+	ctx := database.WithAuditActor(c.Request.Context(), database.AuditActor{
+		HealthWorkerID: healthWorkerID,
+		IPAddress:      c.ClientIP(),
+		RequestID:      c.GetString("requestID"),
+		Path:           c.FullPath(),
+	})
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// auditBuffer holds a handler's response back until its read has been audited
+type auditBuffer struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *auditBuffer) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *auditBuffer) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *auditBuffer) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *auditBuffer) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *auditBuffer) Status() int {
+	return w.status
+}
+
+func (w *auditBuffer) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *auditBuffer) Written() bool {
+	return w.written
+}
+
+// auditShownKey holds the auditShown of a list or search response on the request context
+const auditShownKey = "auditShown"
+
+// auditShown is what a list or search response showed: patients, and sessions whose patients the
+// audit trail looks up
+type auditShown struct {
+	patients map[uint]bool
+	sessions map[uint]bool
+}
+
+var patientType = reflect.TypeOf(model.Patient{})
+
+func shownOn(c *gin.Context) *auditShown {
+	if value, ok := c.Get(auditShownKey); ok {
+		return value.(*auditShown)
+	}
+	shown := &auditShown{patients: map[uint]bool{}, sessions: map[uint]bool{}}
+	c.Set(auditShownKey, shown)
+	return shown
+}
+
+// AuditShown names the records a list or search response returns, so its audited read is recorded
+// against every patient in it. data is walked for patients and for PatientID and SessionID fields.
+func AuditShown(c *gin.Context, data interface{}) {
+	shownOn(c).collect(reflect.ValueOf(data), 0)
+}
+
+// AuditPatients names patients a list or search response shows when it does not return records,
+// e.g. an export
+func AuditPatients(c *gin.Context, patientIDs ...uint) {
+	shown := shownOn(c)
+	for _, id := range patientIDs {
+		shown.patients[id] = true
+	}
+}
+
+func (s *auditShown) collect(v reflect.Value, depth int) {
+	if depth > 8 {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			s.collect(v.Elem(), depth+1)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			s.collect(v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			s.collect(v.MapIndex(key), depth+1)
+		}
+	case reflect.Struct:
+		if v.Type() == patientType {
+			add(s.patients, v.FieldByName("ID"))
+		}
+		for i := 0; i < v.NumField(); i++ {
+			switch field := v.Type().Field(i); {
+			case !field.IsExported():
+			case field.Name == "PatientID":
+				add(s.patients, v.Field(i))
+			case field.Name == "SessionID":
+				add(s.sessions, v.Field(i))
+			default:
+				s.collect(v.Field(i), depth+1)
+			}
+		}
+	}
+}
+
+// add records a uint or *uint ID unless it is unset
+func add(ids map[uint]bool, v reflect.Value) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Uint && v.Uint() != 0 {
+		ids[uint(v.Uint())] = true
+	}
+}
+
+func keys(ids map[uint]bool) []uint {
+	list := make([]uint, 0, len(ids))
+	for id := range ids {
+		list = append(list, id)
+	}
+	return list
+}
+
+// AuditRead records a successful read of patient data. The handler's response is held back until
+// the read is in the audit trail; when it cannot be recorded the caller gets a 500 instead of the
+// data. column says what the route's :id refers to ("id", "patient_id" or "session_id"); pass ""
+// for list and search routes, whose handlers name what they returned with AuditShown.
+func AuditRead(entityType, column string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		out := c.Writer
+		headers := out.Header().Clone()
+		buffer := &auditBuffer{ResponseWriter: out, status: http.StatusOK}
+		c.Writer = buffer
+		c.Next()
+		c.Writer = out
+
+		if buffer.status < 400 {
+			if err := recordRead(c, entityType, column); err != nil {
+				log.Printf("❌ Failed to record audit read of %s: %v", entityType, err)
+				for key := range out.Header() {
+					out.Header().Del(key)
+				}
+				for key, values := range headers {
+					out.Header()[key] = values
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"status":  http.StatusInternalServerError,
+					"message": "Unable to record access to patient data.",
+				})
+				return
+			}
+		}
+
+		out.WriteHeader(buffer.status)
+		if buffer.body.Len() == 0 {
+			out.WriteHeaderNow()
+			return
+		}
+		if _, err := out.Write(buffer.body.Bytes()); err != nil {
+			log.Printf("⚠️ Failed to write audited response: %v", err)
+		}
+	}
+}
+
+// recordRead adds the read to the audit trail
+func recordRead(c *gin.Context, entityType, column string) error {
+	if column == "" {
+		shown := shownOn(c)
+		return database.RecordListRead(c.Request.Context(), entityType, keys(shown.patients), keys(shown.sessions))
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return err
+	}
+	return database.RecordRead(c.Request.Context(), entityType, column, uint(id))
+}
@@ -5,9 +5,9 @@ import (
 	"net/http"
 	"os"
 	"strings"
-	"sync"
 	"time"
 
+	"depression-diagnosis-system/api/util"
 	"depression-diagnosis-system/database"
 	"depression-diagnosis-system/database/model"
 
@@ -16,31 +16,95 @@ import (
 )
 
 var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
-var tokenBlacklist = make(map[string]bool)
-var mu sync.Mutex
+
+// AccessTokenTTL is the lifetime of an access token; clients renew it with their refresh token
+const AccessTokenTTL = 15 * time.Minute
+
+// passwordRotationRoutes are reachable while a health worker still has to change their password
+var passwordRotationRoutes = map[string]bool{
+	"/api/v1/health-workers/me/password": true,
+	"/api/v1/health-workers/logout":      true,
+	"/api/v1/health-workers/logout-all":  true,
+}
 
 type Claims struct {
-	ID    uint   `json:"userID"`
-	Email string `json:"email"`
+	ID            uint   `json:"userID"`
+	Email         string `json:"email"`
+	SessionID     uint   `json:"sessionID"`        // device session the token was issued for
+	IssuedAtMicro int64  `json:"iat_us,omitempty"` // issue time in microseconds, compared against logout-all cut-offs
 	jwt.StandardClaims
 }
 
-func GenerateToken(id uint, email string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+// GenerateToken issues a short-lived access token bound to a device session
+func GenerateToken(id uint, email string, sessionID uint) (string, time.Time, error) {
+	tokenID, err := util.GenerateSecureToken(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(AccessTokenTTL)
 	claims := &Claims{
-		ID:    id,
-		Email: email,
+		ID:            id,
+		Email:         email,
+		SessionID:     sessionID,
+		IssuedAtMicro: now.UnixMicro(),
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
+			Id:        tokenID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: expiresAt.Unix(),
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	signed, err := token.SignedString(jwtSecret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// WebSocketTokenProtocol is the subprotocol a client offers, followed by its access token, when
+// opening a WebSocket: Sec-WebSocket-Protocol: bearer, <token>. Only this name is echoed back.
+const WebSocketTokenProtocol = "bearer"
+
+// isWebSocketUpgrade reports whether the request opens a WebSocket connection
+func isWebSocketUpgrade(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader("Upgrade"), "websocket")
+}
+
+// webSocketToken returns the access token offered after WebSocketTokenProtocol in
+// Sec-WebSocket-Protocol. Browsers cannot set other headers when opening a WebSocket, and unlike
+// the query string this header is not written to the access log.
+func webSocketToken(c *gin.Context) string {
+	offered := strings.Split(c.GetHeader("Sec-WebSocket-Protocol"), ",")
+	for i := 0; i+1 < len(offered); i++ {
+		if strings.TrimSpace(offered[i]) == WebSocketTokenProtocol {
+			return strings.TrimSpace(offered[i+1])
+		}
+	}
+	return ""
+}
+
+// DropQueryToken removes an access_token query parameter before the request is logged. Tokens are
+// not accepted there, but an outdated client may still send one.
+func DropQueryToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if query := c.Request.URL.Query(); query.Has("access_token") {
+			query.Del("access_token")
+			c.Request.URL.RawQuery = query.Encode()
+		}
+		c.Next()
+	}
 }
 
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" && isWebSocketUpgrade(c) {
+			if token := webSocketToken(c); token != "" {
+				authHeader = "Bearer " + token
+			}
+		}
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"status":  http.StatusUnauthorized,
@@ -51,24 +115,29 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if IsTokenBlacklisted(tokenString) {
+		claims, err := parseToken(tokenString)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"status":  http.StatusUnauthorized,
-				"message": "Token is blacklisted.",
+				"message": "Invalid or expired token.",
 			})
 			c.Abort()
 			return
 		}
 
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
+		revoked, err := IsTokenRevoked(tokenString, claims)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  http.StatusInternalServerError,
+				"message": "Unable to verify token.",
+			})
+			c.Abort()
+			return
+		}
+		if revoked {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"status":  http.StatusUnauthorized,
-				"message": "Invalid or expired token.",
+				"message": "Token has been revoked.",
 			})
 			c.Abort()
 			return
@@ -84,29 +153,115 @@ func AuthMiddleware() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		if !user.IsActive {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"status":  http.StatusUnauthorized,
+				"message": "Account is deactivated.",
+			})
+			c.Abort()
+			return
+		}
+
+		// Tokens bound to a revoked device session are no longer accepted
+		if claims.SessionID != 0 {
+			var session model.DeviceSession
+			if err := database.DB.First(&session, claims.SessionID).Error; err != nil || session.RevokedAt != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"status":  http.StatusUnauthorized,
+					"message": "Session has been revoked.",
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		// Accounts with a pending password rotation may only change their password or log out
+		if user.MustChangePassword && !passwordRotationRoutes[c.FullPath()] {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":               http.StatusForbidden,
+				"message":              "Password change required before continuing.",
+				"must_change_password": true,
+			})
+			c.Abort()
+			return
+		}
 
 		// Set authenticated user info into Gin context
 		c.Set("userID", user.ID)
 		c.Set("username", user.Email)
+		c.Set("userRole", user.Role)
+		c.Set("sessionID", claims.SessionID)
+		c.Set("tokenExpiresAt", time.Unix(claims.ExpiresAt, 0))
 		c.Set("userPersonnelType", user.PersonnelType.Name) // e.g. "admin", "psychiatrist", etc.
+		withAuditActor(c, user.ID)
 
 		c.Next()
 	}
 }
 
-func InvalidateToken(token string) error {
-	mu.Lock()
-	defer mu.Unlock()
+func parseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return jwtSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid or expired token")
+	}
+	return claims, nil
+}
 
+// revocationKey identifies a token in the revocation store. Tokens issued before token IDs
+// were introduced fall back to a hash of the raw token.
+func revocationKey(tokenString string, claims *Claims) string {
+	if claims.Id != "" {
+		return claims.Id
+	}
+	return util.HashToken(tokenString)
+}
+
+// InvalidateToken revokes a single token until it expires
+func InvalidateToken(token string) error {
 	if token == "" {
 		return errors.New("invalid token")
 	}
-	tokenBlacklist[token] = true
-	return nil
+
+	claims, err := parseToken(token)
+	if err != nil {
+		return err
+	}
+	return revocationStore.Revoke(revocationKey(token, claims), claims.ID, time.Unix(claims.ExpiresAt, 0))
+}
+
+// RevokeAllTokens revokes every token issued to a health worker up to now. The cut-off is kept to
+// the microsecond, the precision issue times are compared at.
+func RevokeAllTokens(healthWorkerID uint) error {
+	now := time.Now().Truncate(time.Microsecond)
+	return revocationStore.RevokeAllForUser(healthWorkerID, now, now.Add(AccessTokenTTL))
+}
+
+// IsTokenRevoked checks both the per-token and the per-health-worker revocations
+func IsTokenRevoked(token string, claims *Claims) (bool, error) {
+	revoked, err := revocationStore.IsRevoked(revocationKey(token, claims))
+	if err != nil || revoked {
+		return revoked, err
+	}
+
+	before, err := revocationStore.RevokedBefore(claims.ID)
+	if err != nil {
+		return false, err
+	}
+	return !before.IsZero() && issuedBefore(claims, before), nil
 }
 
-func IsTokenBlacklisted(token string) bool {
-	mu.Lock()
-	defer mu.Unlock()
-	return tokenBlacklist[token]
+// issuedBefore reports whether the token was issued strictly before the cut-off, so a login in the
+// same second as a logout-all is not caught by it. Tokens without a sub-second issue time only
+// carry whole seconds and are revoked if issued in the cut-off's second.
+func issuedBefore(claims *Claims, cutOff time.Time) bool {
+	if claims.IssuedAtMicro == 0 {
+		return claims.IssuedAt <= cutOff.Unix()
+	}
+	return claims.IssuedAtMicro < cutOff.UnixMicro()
 }
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HasPermission checks the authenticated health worker's role and personnel type against the
+// permission grants. It must run after AuthMiddleware has populated the context.
+func HasPermission(c *gin.Context, permission string) (bool, error) {
+	return CheckPermission(c.GetString("userRole"), c.GetString("userPersonnelType"), permission)
+}
+
+// CheckPermission checks a role / personnel type pair against the permission grants
+func CheckPermission(role, personnelType, permission string) (bool, error) {
+	var grants []model.PermissionGrant
+	if err := database.DB.Where("permission IN ?", []string{permission, model.PermAll}).Find(&grants).Error; err != nil {
+		return false, err
+	}
+	return model.GrantsAllow(grants, role, personnelType, permission), nil
+}
+
+// RequirePermission aborts the request with 403 unless the health worker holds the permission
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, err := HasPermission(c, permission)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  http.StatusInternalServerError,
+				"message": "Unable to verify permissions.",
+			})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":     http.StatusForbidden,
+				"message":    "You do not have permission to perform this action.",
+				"permission": permission,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
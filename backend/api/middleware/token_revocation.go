@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"depression-diagnosis-system/database"
+	"depression-diagnosis-system/database/model"
+
+	"gorm.io/gorm/clause"
+)
+
+// RevocationStore keeps track of revoked tokens. The default implementation is database backed so
+// revocations survive restarts and are shared by every server instance; other backends (e.g. Redis)
+// can be plugged in with SetRevocationStore.
+type RevocationStore interface {
+	// Revoke marks a single token as revoked until it expires
+	Revoke(tokenID string, healthWorkerID uint, expiresAt time.Time) error
+	// IsRevoked reports whether a single token has been revoked
+	IsRevoked(tokenID string) (bool, error)
+	// RevokeAllForUser revokes every token issued to a health worker before the given time
+	RevokeAllForUser(healthWorkerID uint, before time.Time, expiresAt time.Time) error
+	// RevokedBefore returns the cut-off set by RevokeAllForUser, or the zero time if there is none
+	RevokedBefore(healthWorkerID uint) (time.Time, error)
+	// PurgeExpired removes entries whose tokens have expired and returns how many were removed
+	PurgeExpired(now time.Time) (int64, error)
+}
+
+var revocationStore RevocationStore = &DBRevocationStore{}
+
+// SetRevocationStore replaces the store used by the auth middleware
+func SetRevocationStore(store RevocationStore) {
+	revocationStore = store
+}
+
+// DBRevocationStore persists revocations in the application database
+type DBRevocationStore struct{}
+
+func (s *DBRevocationStore) Revoke(tokenID string, healthWorkerID uint, expiresAt time.Time) error {
+	entry := model.RevokedToken{
+		TokenID:        tokenID,
+		HealthWorkerID: healthWorkerID,
+		ExpiresAt:      expiresAt,
+	}
+	return database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry).Error
+}
+
+func (s *DBRevocationStore) IsRevoked(tokenID string) (bool, error) {
+	var count int64
+	if err := database.DB.Model(&model.RevokedToken{}).Where("token_id = ?", tokenID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *DBRevocationStore) RevokeAllForUser(healthWorkerID uint, before time.Time, expiresAt time.Time) error {
+	entry := model.HealthWorkerTokenRevocation{
+		HealthWorkerID: healthWorkerID,
+		RevokedBefore:  before,
+		ExpiresAt:      expiresAt,
+	}
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "health_worker_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before", "expires_at", "updated_at"}),
+	}).Create(&entry).Error
+}
+
+func (s *DBRevocationStore) RevokedBefore(healthWorkerID uint) (time.Time, error) {
+	var entries []model.HealthWorkerTokenRevocation
+	if err := database.DB.Where("health_worker_id = ?", healthWorkerID).Limit(1).Find(&entries).Error; err != nil {
+		return time.Time{}, err
+	}
+	if len(entries) == 0 {
+		return time.Time{}, nil
+	}
+	return entries[0].RevokedBefore, nil
+}
+
+func (s *DBRevocationStore) PurgeExpired(now time.Time) (int64, error) {
+	tokens := database.DB.Unscoped().Where("expires_at < ?", now).Delete(&model.RevokedToken{})
+	if tokens.Error != nil {
+		return 0, tokens.Error
+	}
+	users := database.DB.Unscoped().Where("expires_at < ?", now).Delete(&model.HealthWorkerTokenRevocation{})
+	if users.Error != nil {
+		return tokens.RowsAffected, users.Error
+	}
+	return tokens.RowsAffected + users.RowsAffected, nil
+}
+
+// MemoryRevocationStore is a process-local store, useful for single-instance development setups
+type MemoryRevocationStore struct {
+	mu     sync.Mutex
+	tokens map[string]time.Time
+	users  map[uint]memoryUserRevocation
+}
+
+type memoryUserRevocation struct {
+	before    time.Time
+	expiresAt time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		tokens: make(map[string]time.Time),
+		users:  make(map[uint]memoryUserRevocation),
+	}
+}
+
+func (s *MemoryRevocationStore) Revoke(tokenID string, healthWorkerID uint, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[tokenID] = expiresAt
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(tokenID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.tokens[tokenID]
+	return ok, nil
+}
+
+func (s *MemoryRevocationStore) RevokeAllForUser(healthWorkerID uint, before time.Time, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[healthWorkerID] = memoryUserRevocation{before: before, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *MemoryRevocationStore) RevokedBefore(healthWorkerID uint) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.users[healthWorkerID].before, nil
+}
+
+func (s *MemoryRevocationStore) PurgeExpired(now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var purged int64
+	for id, exp := range s.tokens {
+		if exp.Before(now) {
+			delete(s.tokens, id)
+			purged++
+		}
+	}
+	for id, rev := range s.users {
+		if rev.expiresAt.Before(now) {
+			delete(s.users, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// StartRevocationPurger periodically removes revocation entries for tokens that have already expired.
+// It stops when ctx is cancelled.
+func StartRevocationPurger(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				purged, err := revocationStore.PurgeExpired(now)
+				if err != nil {
+					log.Printf("❌ Failed to purge expired token revocations: %v", err)
+				} else if purged > 0 {
+					log.Printf("🧹 Purged %d expired token revocations", purged)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,39 @@
+package util
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+)
+
+// csvFormulaPrefixes are the leading characters that make a spreadsheet read a cell as a formula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvSafe keeps a spreadsheet from reading a cell as a formula
+func csvSafe(cell string) string {
+	if cell != "" && strings.IndexByte(csvFormulaPrefixes, cell[0]) >= 0 {
+		return "'" + cell
+	}
+	return cell
+}
+
+// RenderCSV writes a header and rows as a CSV document with CRLF line endings
+func RenderCSV(header []string, rows [][]string) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.UseCRLF = true
+	if err := w.Write(header); err != nil {
+		return "", err
+	}
+	for _, row := range rows {
+		safe := make([]string, len(row))
+		for i, cell := range row {
+			safe[i] = csvSafe(cell)
+		}
+		if err := w.Write(safe); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
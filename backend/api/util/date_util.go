@@ -0,0 +1,36 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DateQuery reads a YYYY-MM-DD query parameter as local midnight, defaulting to today
+func DateQuery(c *gin.Context, key string) (time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local), nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a date (YYYY-MM-DD)", key)
+	}
+	return day, nil
+}
+
+// UintQuery reads an optional numeric ID query parameter; it is 0 when absent
+func UintQuery(c *gin.Context, key string) (uint, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number", key)
+	}
+	return uint(id), nil
+}
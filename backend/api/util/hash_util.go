@@ -1,6 +1,9 @@
 package util
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"regexp"
 
 	"golang.org/x/crypto/bcrypt"
@@ -18,10 +21,34 @@ func CheckPassword(hashedPassword, password string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)) == nil
 }
 
+// GenerateSecureToken returns a random hex token built from n bytes of crypto/rand output.
+func GenerateSecureToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken returns the SHA-256 hex digest of a token so it can be stored and looked up safely.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsValidPassword requires at least 8 characters with a lowercase letter, an uppercase letter,
+// a digit and one of !@#$%^&*.,? (RE2 has no lookaheads, so each rule is checked separately).
 func IsValidPassword(password string) bool {
-	const passwordRegex = `^(?=.*[a-z])(?=.*[A-Z])(?=.*\d)(?=.*[!@#\$%^&*.,?]).{8,}$`
-	matched, _ := regexp.MatchString(passwordRegex, password)
-	return matched
+	if len(password) < 8 {
+		return false
+	}
+	rules := []string{`[a-z]`, `[A-Z]`, `\d`, `[!@#\$%^&*.,?]`}
+	for _, rule := range rules {
+		if matched, _ := regexp.MatchString(rule, password); !matched {
+			return false
+		}
+	}
+	return true
 }
 
 
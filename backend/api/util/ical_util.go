@@ -0,0 +1,71 @@
+package util
+
+import (
+	"strings"
+	"time"
+)
+
+// ICalEvent is one VEVENT of an iCalendar (RFC 5545) document
+type ICalEvent struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Status      string // TENTATIVE, CONFIRMED or CANCELLED
+}
+
+const icalTimeFormat = "20060102T150405Z"
+
+// icalEscape escapes a TEXT value
+func icalEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// icalLine writes a content line, folding it so no line is longer than 75 octets without splitting
+// a UTF-8 character
+func icalLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // the leading space counts
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// RenderICalendar builds a VCALENDAR named name holding the events
+func RenderICalendar(name string, events []ICalEvent) string {
+	var b strings.Builder
+	stamp := time.Now().UTC().Format(icalTimeFormat)
+
+	icalLine(&b, "BEGIN:VCALENDAR")
+	icalLine(&b, "VERSION:2.0")
+	icalLine(&b, "PRODID:-//Depression Diagnosis System//Schedules//EN")
+	icalLine(&b, "CALSCALE:GREGORIAN")
+	icalLine(&b, "METHOD:PUBLISH")
+	icalLine(&b, "X-WR-CALNAME:"+icalEscape(name))
+	for _, e := range events {
+		icalLine(&b, "BEGIN:VEVENT")
+		icalLine(&b, "UID:"+e.UID)
+		icalLine(&b, "DTSTAMP:"+stamp)
+		icalLine(&b, "DTSTART:"+e.Start.UTC().Format(icalTimeFormat))
+		icalLine(&b, "DTEND:"+e.End.UTC().Format(icalTimeFormat))
+		icalLine(&b, "SUMMARY:"+icalEscape(e.Summary))
+		if e.Description != "" {
+			icalLine(&b, "DESCRIPTION:"+icalEscape(e.Description))
+		}
+		if e.Status != "" {
+			icalLine(&b, "STATUS:"+e.Status)
+		}
+		icalLine(&b, "END:VEVENT")
+	}
+	icalLine(&b, "END:VCALENDAR")
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package util
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"depression-diagnosis-system/database/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLocale picks the language to present questionnaires in: the lang query parameter if it is
+// supported, then the most preferred supported language in Accept-Language, then English
+func RequestLocale(c *gin.Context) string {
+	if lang := strings.ToLower(strings.TrimSpace(c.Query("lang"))); model.IsSupportedLocale(lang) {
+		return lang
+	}
+
+	type preference struct {
+		locale string
+		weight float64
+	}
+	var prefs []preference
+	for _, part := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if !model.IsSupportedLocale(base) {
+			continue
+		}
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if w, err := strconv.ParseFloat(q, 64); err == nil {
+				weight = w
+			}
+		}
+		if weight > 0 {
+			prefs = append(prefs, preference{base, weight})
+		}
+	}
+	sort.SliceStable(prefs, func(a, b int) bool { return prefs[a].weight > prefs[b].weight })
+
+	if len(prefs) > 0 {
+		return prefs[0].locale
+	}
+	return model.LocaleEnglish
+}
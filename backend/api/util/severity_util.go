@@ -1,17 +1,23 @@
 package util
 
+import "depression-diagnosis-system/database/model"
+
+// Phq9MaxScore is the highest PHQ-9 total: nine items scored 0–3
+const Phq9MaxScore = 27
+
+// DetermineSeverity maps a PHQ-9 total (0–27) to the standard severity bands
 func DetermineSeverity(phq9Score int) string {
 	switch {
-	case phq9Score >= 1 && phq9Score <= 9:
-		return "Minimal/No Depression"
-	case phq9Score >= 10 && phq9Score <= 18:
-		return "Mild Depression"
-	case phq9Score >= 19 && phq9Score <= 27:
-		return "Moderate Depression"
-	case phq9Score >= 28 && phq9Score <= 36:
-		return "Moderately Severe Depression"
-	case phq9Score >= 37 && phq9Score <= 45:
-		return "Severe Depression"
+	case phq9Score >= 0 && phq9Score <= 4:
+		return model.SeverityNone
+	case phq9Score >= 5 && phq9Score <= 9:
+		return model.SeverityMild
+	case phq9Score >= 10 && phq9Score <= 14:
+		return model.SeverityModerate
+	case phq9Score >= 15 && phq9Score <= 19:
+		return model.SeverityModeratelySevere
+	case phq9Score >= 20 && phq9Score <= 27:
+		return model.SeveritySevere
 	default:
 		return "Unknown"
 	}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"depression-diagnosis-system/api/controller"
 	"depression-diagnosis-system/api/middleware"
 	"depression-diagnosis-system/database"
 	"depression-diagnosis-system/routes"
@@ -30,6 +31,26 @@ func main() {
 	database.DBMigrate()
 	database.RunAllSeeders()
 
+	// Periodically drop revocation entries for tokens that have expired anyway
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	middleware.StartRevocationPurger(purgeCtx, time.Hour)
+
+	// Mark missed appointments and follow-ups for the defaulter worklist
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	controller.StartMissedFollowUpJob(jobCtx, time.Hour)
+
+	// Give every department its messaging channel
+	controller.SyncDepartmentChannels()
+
+	// Push messages, typing indicators and presence over WebSocket
+	realtimeCtx, stopRealtime := context.WithCancel(context.Background())
+	defer stopRealtime()
+	if err := controller.StartRealtimeHub(realtimeCtx); err != nil {
+		log.Fatalf("❌ Failed to start realtime hub: %v", err)
+	}
+
 	// Set Gin mode based on environment
 	mode := os.Getenv("GIN_MODE")
 	if mode == "" {
@@ -40,8 +61,9 @@ func main() {
 
 	// Set up Gin router
 	router := gin.New()
-	router.Use(gin.Logger(), gin.Recovery())
+	router.Use(middleware.DropQueryToken(), gin.Logger(), gin.Recovery())
 	router.Use(middleware.CORSMiddleware())
+	router.Use(middleware.RequestIDMiddleware())
 
 	// Register routes
 	routes.Routes(router)
@@ -57,6 +79,8 @@ func main() {
 		Addr:    fmt.Sprintf(":%s", port),
 		Handler: router,
 	}
+	// WebSocket connections are hijacked, so Shutdown does not wait for them; close them as going away
+	srv.RegisterOnShutdown(stopRealtime)
 
 	// Start server in a goroutine
 	go func() {
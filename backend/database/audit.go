@@ -0,0 +1,420 @@
+package database
+
+import (
+	"context"
+	"depression-diagnosis-system/database/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// auditChainLockID is the Postgres advisory lock that serialises appends to the audit hash chain
+const auditChainLockID = 7305001
+
+const auditBeforeKey = "audit:before"
+
+var errAuditLogImmutable = errors.New("audit log entries cannot be changed or deleted")
+
+// AuditActor identifies who is behind a database call. It travels on the request context so the
+// GORM callbacks can attribute writes without every controller passing it around explicitly.
+type AuditActor struct {
+	HealthWorkerID uint
+	IPAddress      string
+	RequestID      string
+	Path           string
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor attaches the acting health worker to a context
+func WithAuditActor(ctx context.Context, actor AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+func auditActorFrom(ctx context.Context) (AuditActor, bool) {
+	if ctx == nil {
+		return AuditActor{}, false
+	}
+	actor, ok := ctx.Value(auditActorKey{}).(AuditActor)
+	return actor, ok
+}
+
+// auditChange is one column's value before and after a write
+type auditChange struct {
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+func isAudited(table string) bool {
+	for _, entity := range model.AuditedEntities {
+		if entity == table {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterAuditCallbacks records every create, update and delete on the audited tables and
+// refuses changes to existing audit entries
+func RegisterAuditCallbacks(db *gorm.DB) error {
+	cb := db.Callback()
+	steps := []error{
+		cb.Create().After("gorm:create").Register("audit:after_create", auditAfterCreate),
+		cb.Update().Before("gorm:update").Register("audit:before_update", auditCaptureBefore),
+		cb.Update().After("gorm:update").Register("audit:after_update", auditAfterUpdate),
+		cb.Delete().Before("gorm:delete").Register("audit:before_delete", auditCaptureBefore),
+		cb.Delete().After("gorm:delete").Register("audit:after_delete", auditAfterDelete),
+		cb.Update().Before("gorm:update").Register("audit:immutable_update", rejectAuditLogChange),
+		cb.Delete().Before("gorm:delete").Register("audit:immutable_delete", rejectAuditLogChange),
+	}
+	return errors.Join(steps...)
+}
+
+// ProtectAuditLog installs a trigger so the audit trail cannot be altered even with raw SQL
+func ProtectAuditLog() error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION audit_logs_immutable() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_logs is append-only';
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_logs_immutable ON audit_logs`,
+		`CREATE TRIGGER audit_logs_immutable BEFORE UPDATE OR DELETE ON audit_logs
+		FOR EACH ROW EXECUTE FUNCTION audit_logs_immutable()`,
+		`DROP TRIGGER IF EXISTS audit_logs_no_truncate ON audit_logs`,
+		`CREATE TRIGGER audit_logs_no_truncate BEFORE TRUNCATE ON audit_logs
+		FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_immutable()`,
+	}
+	for _, stmt := range statements {
+		if err := DB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func rejectAuditLogChange(db *gorm.DB) {
+	if db.Statement.Table == "audit_logs" {
+		db.AddError(errAuditLogImmutable)
+	}
+}
+
+// auditSession returns a fresh query builder on the same connection (and transaction) as db
+func auditSession(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true, SkipHooks: true})
+}
+
+// auditPrimaryKeys collects the non-zero primary keys of the statement's model or destination
+func auditPrimaryKeys(db *gorm.DB) []uint {
+	stmt := db.Statement
+	if stmt.Schema == nil || stmt.Schema.PrioritizedPrimaryField == nil {
+		return nil
+	}
+	field := stmt.Schema.PrioritizedPrimaryField
+
+	var ids []uint
+	collect := func(rv reflect.Value) {
+		if value, zero := field.ValueOf(stmt.Context, rv); !zero {
+			if id, ok := toUint(value); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	rv := reflect.Indirect(stmt.ReflectValue)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			collect(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		collect(rv)
+	}
+	return ids
+}
+
+// auditLoadRows reads the rows a statement targets, by primary key or by its WHERE clause
+func auditLoadRows(db *gorm.DB) []map[string]interface{} {
+	stmt := db.Statement
+	query := auditSession(db).Table(stmt.Table)
+
+	ids := auditPrimaryKeys(db)
+	where, hasWhere := stmt.Clauses["WHERE"]
+	switch {
+	case len(ids) > 0:
+		query = query.Where("id IN ?", ids)
+	case hasWhere:
+		query = query.Clauses(where.Expression)
+	default:
+		return nil
+	}
+
+	var rows []map[string]interface{}
+	if err := query.Find(&rows).Error; err != nil {
+		db.AddError(err)
+		return nil
+	}
+	return rows
+}
+
+func auditCaptureBefore(db *gorm.DB) {
+	if db.Error != nil || !isAudited(db.Statement.Table) {
+		return
+	}
+	db.InstanceSet(auditBeforeKey, auditLoadRows(db))
+}
+
+func auditAfterCreate(db *gorm.DB) {
+	if db.Error != nil || db.RowsAffected == 0 || !isAudited(db.Statement.Table) {
+		return
+	}
+
+	ids := auditPrimaryKeys(db)
+	if len(ids) == 0 {
+		return
+	}
+	var rows []map[string]interface{}
+	if err := auditSession(db).Table(db.Statement.Table).Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		db.AddError(err)
+		return
+	}
+
+	for _, row := range rows {
+		changes := map[string]auditChange{}
+		for column, value := range row {
+			changes[column] = auditChange{To: value}
+		}
+		auditWrite(db, model.AuditActionCreate, row, changes)
+	}
+}
+
+func auditAfterUpdate(db *gorm.DB) {
+	if db.Error != nil || db.RowsAffected == 0 || !isAudited(db.Statement.Table) {
+		return
+	}
+
+	value, _ := db.InstanceGet(auditBeforeKey)
+	before, _ := value.([]map[string]interface{})
+	if len(before) == 0 {
+		return
+	}
+
+	ids := make([]interface{}, 0, len(before))
+	for _, row := range before {
+		ids = append(ids, row["id"])
+	}
+	var after []map[string]interface{}
+	if err := auditSession(db).Table(db.Statement.Table).Where("id IN ?", ids).Find(&after).Error; err != nil {
+		db.AddError(err)
+		return
+	}
+
+	beforeByID := make(map[string]map[string]interface{}, len(before))
+	for _, row := range before {
+		beforeByID[fmt.Sprint(row["id"])] = row
+	}
+
+	for _, row := range after {
+		old := beforeByID[fmt.Sprint(row["id"])]
+		changes := map[string]auditChange{}
+		for column, newValue := range row {
+			if column == "updated_at" {
+				continue
+			}
+			if oldValue := old[column]; fmt.Sprint(oldValue) != fmt.Sprint(newValue) {
+				changes[column] = auditChange{From: oldValue, To: newValue}
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		auditWrite(db, model.AuditActionUpdate, row, changes)
+	}
+}
+
+func auditAfterDelete(db *gorm.DB) {
+	if db.Error != nil || db.RowsAffected == 0 || !isAudited(db.Statement.Table) {
+		return
+	}
+
+	value, _ := db.InstanceGet(auditBeforeKey)
+	before, _ := value.([]map[string]interface{})
+	for _, row := range before {
+		changes := map[string]auditChange{}
+		for column, value := range row {
+			changes[column] = auditChange{From: value}
+		}
+		auditWrite(db, model.AuditActionDelete, row, changes)
+	}
+}
+
+// auditWrite appends an entry for one written row inside the write's own transaction, so the
+// change and its audit record commit or roll back together
+func auditWrite(db *gorm.DB, action string, row map[string]interface{}, changes map[string]auditChange) {
+	diff, err := json.Marshal(changes)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+
+	tx := auditSession(db)
+	entityID, _ := toUint(row["id"])
+	entry := &model.AuditLog{
+		Action:     action,
+		EntityType: db.Statement.Table,
+		EntityID:   entityID,
+		PatientID:  auditPatientID(tx, db.Statement.Table, row),
+		Changes:    string(diff),
+	}
+	if err := appendAuditLog(tx, entry); err != nil {
+		db.AddError(err)
+	}
+}
+
+// RecordRead logs that the actor on ctx viewed an audited entity. column says what the looked-up
+// value refers to: "id" for a single record, "patient_id" or "session_id" for records fetched by
+// their owner, or "" when nothing was looked up. List and search reads go through RecordListRead.
+func RecordRead(ctx context.Context, entityType, column string, value uint) error {
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		entry := &model.AuditLog{Action: model.AuditActionRead, EntityType: entityType}
+
+		switch column {
+		case "":
+		case "patient_id":
+			entry.PatientID = &value
+			if entityType == model.AuditEntityPatient {
+				entry.EntityID = value
+			}
+		default:
+			var rows []map[string]interface{}
+			if err := tx.Table(entityType).Where(clause.Eq{Column: clause.Column{Name: column}, Value: value}).
+				Limit(1).Find(&rows).Error; err != nil {
+				return err
+			}
+			if len(rows) > 0 {
+				entry.EntityID, _ = toUint(rows[0]["id"])
+				entry.PatientID = auditPatientID(tx, entityType, rows[0])
+			}
+		}
+
+		return appendAuditLog(tx, entry)
+	})
+}
+
+// RecordListRead logs that the actor on ctx viewed a list or search result of an audited entity,
+// with one entry per patient in it so the patient's trail shows every list they appeared in.
+// Sessions in the result are attributed to their patients. An empty result still gets one entry.
+func RecordListRead(ctx context.Context, entityType string, patientIDs, sessionIDs []uint) error {
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		shown := make(map[uint]bool, len(patientIDs))
+		for _, id := range patientIDs {
+			shown[id] = true
+		}
+		if len(sessionIDs) > 0 {
+			var owners []uint
+			if err := tx.Unscoped().Model(&model.Session{}).Where("id IN ?", sessionIDs).Pluck("patient_id", &owners).Error; err != nil {
+				return err
+			}
+			for _, id := range owners {
+				shown[id] = true
+			}
+		}
+		delete(shown, 0)
+
+		if len(shown) == 0 {
+			return appendAuditLog(tx, &model.AuditLog{Action: model.AuditActionRead, EntityType: entityType})
+		}
+		ids := make([]uint, 0, len(shown))
+		for id := range shown {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for _, id := range ids {
+			patientID := id
+			entry := &model.AuditLog{Action: model.AuditActionRead, EntityType: entityType, PatientID: &patientID}
+			if entityType == model.AuditEntityPatient {
+				entry.EntityID = id
+			}
+			if err := appendAuditLog(tx, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// auditPatientID works out which patient a row belongs to
+func auditPatientID(tx *gorm.DB, table string, row map[string]interface{}) *uint {
+	var raw interface{}
+	switch {
+	case table == model.AuditEntityPatient:
+		raw = row["id"]
+	case row["patient_id"] != nil:
+		raw = row["patient_id"]
+	case row["session_id"] != nil:
+		var session model.Session
+		if err := tx.Unscoped().Select("patient_id").First(&session, row["session_id"]).Error; err != nil {
+			return nil
+		}
+		raw = session.PatientID
+	}
+
+	id, ok := toUint(raw)
+	if !ok || id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// appendAuditLog links the entry to the end of the hash chain and stores it. Must run inside a
+// transaction so the advisory lock is held until commit.
+func appendAuditLog(tx *gorm.DB, entry *model.AuditLog) error {
+	if actor, ok := auditActorFrom(tx.Statement.Context); ok {
+		if actor.HealthWorkerID != 0 {
+			id := actor.HealthWorkerID
+			entry.ActorID = &id
+		}
+		entry.IPAddress = actor.IPAddress
+		entry.RequestID = actor.RequestID
+		entry.Path = actor.Path
+	}
+
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockID).Error; err != nil {
+		return err
+	}
+
+	var last model.AuditLog
+	if err := tx.Select("hash").Order("id DESC").Limit(1).Find(&last).Error; err != nil {
+		return err
+	}
+
+	// Postgres keeps microseconds; truncate now so the stored timestamp hashes identically later
+	entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	entry.PrevHash = last.Hash
+	entry.Hash = entry.ComputeHash()
+	return tx.Create(entry).Error
+}
+
+func toUint(value interface{}) (uint, bool) {
+	switch v := value.(type) {
+	case uint:
+		return v, true
+	case uint32:
+		return uint(v), true
+	case uint64:
+		return uint(v), true
+	case int:
+		return uint(v), v >= 0
+	case int32:
+		return uint(v), v >= 0
+	case int64:
+		return uint(v), v >= 0
+	}
+	return 0, false
+}
@@ -30,26 +30,122 @@ func Conn() {
 		log.Println("✅ Database connection successful")
 	}
 
+	if err := RegisterAuditCallbacks(DB); err != nil {
+		log.Fatalf("❌ Failed to register audit callbacks: %v\n", err)
+	}
+
 }
 
 func DBMigrate() {
+	remapRiskAlerts, renameErr := renameLegacyRiskAlertColumn()
+	if renameErr != nil {
+		log.Fatalf("❌ Error renaming risk alert column: %v\n", renameErr)
+	}
+
+	// Free-text prescriptions are converted once, when the prescription lines table is created;
+	// entries written afterwards get their unstructured line when they are created
+	convertPrescriptions := !DB.Migrator().HasTable(&model.PrescriptionLine{})
+
+	if err := dropSessionStatusCheck(); err != nil {
+		log.Fatalf("❌ Error updating session status constraint: %v\n", err)
+	}
+
 	if err := DB.AutoMigrate(
 		&model.HealthWorker{},
 		&model.Department{},
 		&model.PersonnelType{},
 		&model.Patient{},
+		&model.Drug{},
 		&model.MedicationHistory{},
 		&model.Session{},
+		&model.SessionTransition{},
+		&model.PrescriptionLine{},
+		&model.DrugInteraction{},
+		&model.PatientAllergy{},
+		&model.DispensingEvent{},
+		&model.AdherenceCheckIn{},
+		&model.AdverseReaction{},
+		&model.WorkingHours{},
+		&model.HealthWorkerLeave{},
+		&model.Appointment{},
+		&model.CalendarFeedToken{},
+		&model.MissedFollowUp{},
 		&model.Diagnosis{},
-		&model.Phq9Question{},
-		&model.Phq9Response{},
+		&model.Phq9Question{}, // legacy, read only by migrateLegacyPhq9
+		&model.Phq9Response{}, // legacy, read only by migrateLegacyPhq9
+		&model.Instrument{},
+		&model.InstrumentVersion{},
+		&model.InstrumentItem{},
+		&model.InstrumentItemTranslation{},
+		&model.InstrumentOption{},
+		&model.InstrumentSeverityBand{},
+		&model.InstrumentResponse{},
+		&model.InstrumentAnswer{},
+		&model.NoteTemplate{},
+		&model.NoteTemplateSection{},
 		&model.SessionSummary{},
+		&model.SummaryAddendum{},
+		&model.SummaryVersion{},
 		&model.Message{},
+		&model.MessageReceipt{},
+		&model.Channel{},
+		&model.ChannelMember{},
+		&model.PasswordResetToken{},
+		&model.RevokedToken{},
+		&model.HealthWorkerTokenRevocation{},
+		&model.DeviceSession{},
+		&model.RefreshToken{},
+		&model.PermissionGrant{},
+		&model.CareTeamMember{},
+		&model.DepartmentAccessRule{},
+		&model.BreakGlassAccess{},
+		&model.AuditLog{},
+		&model.RiskRule{},
+		&model.RiskAlert{},
+		&model.RiskAlertNotification{},
 		); err != nil {
 		log.Fatalf("❌ Error migrating database: %v\n", err)
 	} else {
 		log.Println("✅ Database migration successful")
 	}
+
+	if err := ProtectAuditLog(); err != nil {
+		log.Fatalf("❌ Error protecting audit log: %v\n", err)
+	}
+
+	if convertPrescriptions {
+		if err := migratePrescriptionText(); err != nil {
+			log.Fatalf("❌ Error migrating prescriptions to prescription lines: %v\n", err)
+		}
+	}
+
+	if err := migrateMessageReceipts(); err != nil {
+		log.Fatalf("❌ Error migrating message receipts: %v\n", err)
+	}
+
+	if err := migrateSessionSummaryHistory(); err != nil {
+		log.Fatalf("❌ Error migrating session summary history: %v\n", err)
+	}
+
+	if err := ProtectSessionSummaries(); err != nil {
+		log.Fatalf("❌ Error protecting session summaries: %v\n", err)
+	}
+
+	if err := migrateInstrumentVersions(); err != nil {
+		log.Fatalf("❌ Error migrating instruments to versions: %v\n", err)
+	}
+
+	if err := ProtectInstrumentVersions(); err != nil {
+		log.Fatalf("❌ Error protecting instrument versions: %v\n", err)
+	}
+
+	if err := migrateLegacyPhq9(remapRiskAlerts); err != nil {
+		log.Fatalf("❌ Error migrating PHQ-9 data to instruments: %v\n", err)
+	}
+
+	if err := ProtectInstrumentResponses(); err != nil {
+		log.Fatalf("❌ Error protecting instrument responses: %v\n", err)
+	}
 }
 
 
@@ -3,7 +3,6 @@ package database
 import (
 	"depression-diagnosis-system/api/util"
 	"depression-diagnosis-system/database/model"
-	"encoding/json"
 	"fmt"
 	"log"
 	"time"
@@ -12,9 +11,16 @@ import (
 func RunAllSeeders() {
 	SeedDepartments()
 	SeedPersonnelTypes()
-	SeedPHQ9Questions()
+	SeedPermissions()
+	SeedInstruments()
+	SeedInstrumentTranslations()
+	SeedNoteTemplates()
+	SeedFormulary()
+	SeedDrugInteractions()
+	SeedRiskRules()
 	SeedAdminUser()
 	SeedHealthWorkers()
+	SeedWorkingHours()
 	SeedDummyPatients()
 	SeedMessages()
 	SeedPatientSessions()
@@ -92,23 +98,97 @@ func SeedPersonnelTypes() {
 }
 
 
-func SeedPHQ9Questions() {
-	questions := []string{
-		"Little interest or pleasure in doing things?",
-		"Feeling down, depressed, or hopeless?",
-		"Trouble falling or staying asleep, or sleeping too much?",
-		"Feeling tired or having little energy?",
-		"Poor appetite or overeating?",
-		"Feeling bad about yourself — or that you are a failure or have let yourself or your family down?",
-		"Trouble concentrating on things, such as reading the newspaper or watching television?",
-		"Moving or speaking so slowly that other people could have noticed? Or the opposite — being so fidgety or restless that you have been moving around a lot more than usual?",
-		"Thoughts that you would be better off dead, or of hurting yourself in some way?",
-	}
-	for _, text := range questions {
-		var existing model.Phq9Question
-		if err := DB.Where("question = ?", text).First(&existing).Error; err != nil {
-			DB.Create(&model.Phq9Question{Question: text})
+// SeedPermissions installs the default permission matrix. Grants removed by an admin are not
+// restored unless the whole table is empty.
+func SeedPermissions() {
+	var count int64
+	DB.Model(&model.PermissionGrant{}).Count(&count)
+	if count > 0 {
+		log.Println("⚠️ Permission grants already exist")
+		return
+	}
+
+	for _, record := range defaultPermissionGrants() {
+		DB.FirstOrCreate(&record, record)
+	}
+	log.Println("🔐 Default permissions seeded")
+}
+
+// defaultPermissionGrants is the permission matrix a fresh install starts with
+func defaultPermissionGrants() []model.PermissionGrant {
+	clinical := []string{
+		model.PermPatientCreate, model.PermPatientUpdate,
+		model.PermSessionCreate, model.PermSessionUpdate,
+		model.PermSessionSummaryCreate, model.PermSessionSummaryUpdate,
+		model.PermDiagnosisCreate,
+		model.PermPhq9ResponseCreate,
+		model.PermInstrumentResponseCreate,
+		model.PermInstrumentTranslate,
+		model.PermMedicationCreate, model.PermMedicationUpdate,
+		model.PermAdverseReactionReport,
+		model.PermAppointmentManage,
+		model.PermDefaulterManage,
+	}
+	nursing := []string{
+		model.PermSessionUpdate,
+		model.PermAppointmentManage,
+		model.PermDefaulterManage,
+		model.PermPhq9ResponseCreate,
+		model.PermInstrumentResponseCreate,
+		model.PermInstrumentTranslate,
+		model.PermAdverseReactionReport,
+	}
+
+	grants := []struct {
+		SubjectType, Subject string
+		Permissions          []string
+	}{
+		{model.SubjectRole, model.RoleAdmin, []string{model.PermAll}},
+		{model.SubjectPersonnelType, "admin", []string{model.PermPatientCreate, model.PermSessionCreate, model.PermAppointmentManage, model.PermScheduleManage}},
+		{model.SubjectPersonnelType, "psychiatrist", append(clinical, model.PermPhq9ResponseUpdate, model.PermInstrumentResponseUpdate, model.PermInstrumentTranslationReview, model.PermCareTeamManage, model.PermBreakGlassReview, model.PermRiskAlertManage, model.PermPharmacovigilanceExport)},
+		{model.SubjectPersonnelType, "psychologist", clinical},
+		{model.SubjectPersonnelType, "clinical officer", clinical},
+		{model.SubjectPersonnelType, "nurse", nursing},
+		{model.SubjectPersonnelType, "midwife", nursing},
+	}
+
+	var records []model.PermissionGrant
+	for _, g := range grants {
+		for _, perm := range g.Permissions {
+			records = append(records, model.PermissionGrant{SubjectType: g.SubjectType, Subject: g.Subject, Permission: perm})
+		}
+	}
+	return records
+}
+
+// SeedRiskRules installs the default suicide-risk rules: any positive answer to PHQ-9 item 9 or
+// to EPDS item 10
+func SeedRiskRules() {
+	defaults := []struct {
+		Name, Instrument string
+		ItemNumber       int
+	}{
+		{"PHQ-9 item 9 (thoughts of self-harm)", model.InstrumentPHQ9, 9},
+		{"EPDS item 10 (thoughts of self-harm)", model.InstrumentEPDS, 10},
+	}
+
+	for _, d := range defaults {
+		var existing model.RiskRule
+		if err := DB.Where("name = ?", d.Name).First(&existing).Error; err == nil {
+			continue
+		}
+		var instrument model.Instrument
+		if err := DB.Where("code = ?", d.Instrument).First(&instrument).Error; err != nil {
+			log.Printf("❌ Failed to seed risk rule %q: instrument %s not found", d.Name, d.Instrument)
+			continue
 		}
+
+		rule := model.RiskRule{Name: d.Name, InstrumentID: instrument.ID, ItemNumber: d.ItemNumber, MinResponse: 1, IsActive: true}
+		if err := DB.Create(&rule).Error; err != nil {
+			log.Printf("❌ Failed to seed risk rule: %v", err)
+			continue
+		}
+		log.Printf("🚨 Risk rule seeded: %s", rule.Name)
 	}
 }
 
@@ -144,6 +224,7 @@ func SeedAdminUser() {
 		LastName:        data.LastName,
 		Email:           data.Email,
 		PasswordHash:    hashed,
+		MustChangePassword: true,
 		Role:            model.RoleAdmin,
 		EmployeeID:      util.GenerateEmployeeID(dept.Name),
 		DepartmentID:    dept.ID,
@@ -199,6 +280,7 @@ func SeedHealthWorkers() {
 			LastName:        w.LastName,
 			Email:           w.Email,
 			PasswordHash:    hashed,
+			MustChangePassword: true,
 			Role:            model.RoleHealthWorker,
 			EmployeeID:      util.GenerateEmployeeID(dept.Name),
 			DepartmentID:    dept.ID,
@@ -222,6 +304,30 @@ func SeedHealthWorkers() {
 	}
 }
 
+// SeedWorkingHours gives every health worker without a schedule weekday clinic hours, 08:00-13:00
+// and 14:00-17:00, in 30-minute slots
+func SeedWorkingHours() {
+	var healthWorkers []model.HealthWorker
+	DB.Where("role = ?", model.RoleHealthWorker).
+		Where("id NOT IN (SELECT health_worker_id FROM working_hours WHERE deleted_at IS NULL)").
+		Find(&healthWorkers)
+
+	for _, worker := range healthWorkers {
+		var hours []model.WorkingHours
+		for day := time.Monday; day <= time.Friday; day++ {
+			hours = append(hours,
+				model.WorkingHours{HealthWorkerID: worker.ID, Weekday: day, StartTime: "08:00", EndTime: "13:00", SlotMinutes: model.DefaultSlotMinutes},
+				model.WorkingHours{HealthWorkerID: worker.ID, Weekday: day, StartTime: "14:00", EndTime: "17:00", SlotMinutes: model.DefaultSlotMinutes},
+			)
+		}
+		if err := DB.Create(&hours).Error; err != nil {
+			log.Printf("❌ Failed to seed working hours for %s: %v", worker.Email, err)
+			continue
+		}
+		log.Printf("📅 Working hours seeded for %s", worker.Email)
+	}
+}
+
 func SeedDummyPatients() {
 	var healthWorkers []model.HealthWorker
 	DB.Where("role = ?", model.RoleHealthWorker).Find(&healthWorkers)
@@ -391,7 +497,7 @@ func SeedMessages() {
 		for j, text := range messages {
 			msg := model.Message{
 				SenderID:   sender.ID,
-				ReceiverID: receiver.ID,
+				ReceiverID: &receiver.ID,
 				Message:    text,
 				Timestamp:  now.Add(time.Duration(j) * time.Minute),
 			}
@@ -407,17 +513,27 @@ func SeedMessages() {
 func SeedPatientSessions() {
 	var patients []model.Patient
 	var healthWorkers []model.HealthWorker
-	var phq9Questions []model.Phq9Question
 
 	DB.Find(&patients)
 	DB.Where("role = ?", model.RoleHealthWorker).Find(&healthWorkers)
-	DB.Find(&phq9Questions) // Fetch all PHQ-9 questions once
 
 	if len(patients) == 0 || len(healthWorkers) == 0 {
 		log.Println("⚠️ Cannot seed sessions — no patients or health workers found")
 		return
 	}
 
+	phq9, err := publishedVersion(model.InstrumentPHQ9)
+	if err != nil {
+		log.Printf("⚠️ Cannot seed sessions — PHQ-9 instrument not found: %v", err)
+		return
+	}
+
+	// Patients whose history calls for an extra questionnaire alongside PHQ-9
+	screeningFor := map[string]string{
+		"Postpartum Depression": model.InstrumentEPDS,
+		"Alcohol Dependence":    model.InstrumentAUDIT,
+	}
+
 	statuses := []string{model.SessionCompleted, model.SessionOngoing, model.SessionCancelled}
 	now := time.Now()
 
@@ -444,7 +560,13 @@ func SeedPatientSessions() {
 			sessionDate := now.AddDate(0, 0, -7*i)
 			status := statuses[i%len(statuses)]
 
-			phq9Score := 3 + int((patient.ID*uint(i+1))%24)
+			// Simulated answers; the diagnosis is scored from them
+			answers := simulatedAnswers(phq9, int(patient.ID)+i)
+			phq9Score, _, err := phq9.Score(answers)
+			if err != nil {
+				log.Printf("❌ Failed to score simulated PHQ-9 answers: %v", err)
+				return
+			}
 			severity := util.DetermineSeverity(phq9Score)
 			sessionNote := sessionNotesBank[i%len(sessionNotesBank)]
 
@@ -472,6 +594,7 @@ func SeedPatientSessions() {
 				log.Printf("❌ Failed to create session for %s %s: %v", patient.FirstName, patient.LastName, err)
 				continue
 			}
+			DB.Create(&model.SessionTransition{SessionID: session.ID, ToStatus: status, Reason: "Seeded"})
 
 			diagnosis := model.Diagnosis{
 				SessionID: session.ID,
@@ -483,28 +606,41 @@ func SeedPatientSessions() {
 			summary := model.SessionSummary{
 				SessionID: session.ID,
 				Notes:     sessionNote,
+				AuthorID:  &worker.ID,
 			}
-			DB.Create(&summary)
-
-			// 💡 NEW: Generate PHQ-9 responses per question
-			responses := make([]model.Phq9ResponseStruct, 0, len(phq9Questions))
-			for _, q := range phq9Questions {
-				responses = append(responses, model.Phq9ResponseStruct{
-					QuestionID: q.ID,
-					Response:   int(patient.ID+uint(i)+q.ID)%4, // Simulated score between 0–3
-				})
+			if status == model.SessionCompleted {
+				summary.SignedAt, summary.SignedByID, summary.LockedAt = &sessionDate, &worker.ID, &sessionDate
 			}
-			raw, err := json.Marshal(responses)
-			if err != nil {
-				log.Printf("❌ Failed to marshal PHQ-9 responses for session %s: %v", session.SessionCode, err)
-				continue
+			if err := DB.Create(&summary).Error; err == nil {
+				history := model.NewSummaryVersion(&summary, model.SummaryCreated, worker.ID)
+				DB.Create(&history)
 			}
 
-			phq9 := model.Phq9Response{
-				SessionID: session.ID,
-				Responses: raw,
+			DB.Create(&model.InstrumentResponse{
+				InstrumentID:        phq9.InstrumentID,
+				InstrumentVersionID: phq9.ID,
+				SessionID:           session.ID,
+				TotalScore:          phq9Score,
+				Severity:            severity,
+				Answers:             answers,
+			})
+
+			if code, ok := screeningFor[patient.PreviousDiagnosis]; ok {
+				if extra, err := publishedVersion(code); err == nil {
+					extraAnswers := simulatedAnswers(extra, int(patient.ID)+i)
+					if total, band, err := extra.Score(extraAnswers); err == nil {
+						DB.Create(&model.InstrumentResponse{
+							InstrumentID:        extra.InstrumentID,
+							InstrumentVersionID: extra.ID,
+							SessionID:           session.ID,
+							TotalScore:          total,
+							Severity:            band,
+							Answers:             extraAnswers,
+						})
+						log.Printf("📋 %s %s | Session %d | %s: %d (%s)", patient.FirstName, patient.LastName, i+1, code, total, band)
+					}
+				}
 			}
-			DB.Create(&phq9)
 
 			log.Printf("🧠 %s %s | Session %d | PHQ-9: %d (%s) | Status: %s", patient.FirstName, patient.LastName, i+1, phq9Score, severity, status)
 
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"testing"
+
+	"depression-diagnosis-system/database/model"
+)
+
+// defaultAllowed lists, per seeded personnel type, every permission the default matrix gives it.
+// It is written out by hand so a change to the seeded defaults has to be made here as well.
+var defaultAllowed = map[string][]string{
+	"Psychiatrist": {
+		model.PermPatientCreate, model.PermPatientUpdate,
+		model.PermSessionCreate, model.PermSessionUpdate,
+		model.PermSessionSummaryCreate, model.PermSessionSummaryUpdate,
+		model.PermDiagnosisCreate,
+		model.PermPhq9ResponseCreate, model.PermPhq9ResponseUpdate,
+		model.PermInstrumentResponseCreate, model.PermInstrumentResponseUpdate,
+		model.PermInstrumentTranslate, model.PermInstrumentTranslationReview,
+		model.PermMedicationCreate, model.PermMedicationUpdate,
+		model.PermAdverseReactionReport, model.PermPharmacovigilanceExport,
+		model.PermAppointmentManage, model.PermDefaulterManage,
+		model.PermCareTeamManage, model.PermBreakGlassReview, model.PermRiskAlertManage,
+	},
+	"Psychologist": {
+		model.PermPatientCreate, model.PermPatientUpdate,
+		model.PermSessionCreate, model.PermSessionUpdate,
+		model.PermSessionSummaryCreate, model.PermSessionSummaryUpdate,
+		model.PermDiagnosisCreate,
+		model.PermPhq9ResponseCreate, model.PermInstrumentResponseCreate, model.PermInstrumentTranslate,
+		model.PermMedicationCreate, model.PermMedicationUpdate,
+		model.PermAdverseReactionReport,
+		model.PermAppointmentManage, model.PermDefaulterManage,
+	},
+	"Clinical Officer": {
+		model.PermPatientCreate, model.PermPatientUpdate,
+		model.PermSessionCreate, model.PermSessionUpdate,
+		model.PermSessionSummaryCreate, model.PermSessionSummaryUpdate,
+		model.PermDiagnosisCreate,
+		model.PermPhq9ResponseCreate, model.PermInstrumentResponseCreate, model.PermInstrumentTranslate,
+		model.PermMedicationCreate, model.PermMedicationUpdate,
+		model.PermAdverseReactionReport,
+		model.PermAppointmentManage, model.PermDefaulterManage,
+	},
+	"Nurse": {
+		model.PermSessionUpdate,
+		model.PermPhq9ResponseCreate, model.PermInstrumentResponseCreate, model.PermInstrumentTranslate,
+		model.PermAdverseReactionReport,
+		model.PermAppointmentManage, model.PermDefaulterManage,
+	},
+	"Midwife": {
+		model.PermSessionUpdate,
+		model.PermPhq9ResponseCreate, model.PermInstrumentResponseCreate, model.PermInstrumentTranslate,
+		model.PermAdverseReactionReport,
+		model.PermAppointmentManage, model.PermDefaulterManage,
+	},
+	"Admin": {
+		model.PermPatientCreate, model.PermSessionCreate,
+		model.PermAppointmentManage, model.PermScheduleManage,
+	},
+	"Pharmacist": nil, // not seeded
+	"":           nil, // no personnel type
+}
+
+func TestDefaultPermissionMatrix(t *testing.T) {
+	grants := defaultPermissionGrants()
+	roles := []string{model.RoleAdmin, model.RoleHealthWorker, "Janitor", ""}
+
+	for _, role := range roles {
+		for personnelType, allowed := range defaultAllowed {
+			holds := make(map[string]bool, len(allowed))
+			for _, p := range allowed {
+				holds[p] = true
+			}
+			for _, perm := range model.AllPermissions {
+				want := role == model.RoleAdmin || holds[perm]
+				if got := model.GrantsAllow(grants, role, personnelType, perm); got != want {
+					t.Errorf("role %q, personnel type %q, %s: got %v, want %v", role, personnelType, perm, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestGrantsAllow(t *testing.T) {
+	defaults := defaultPermissionGrants()
+	grants := []model.PermissionGrant{
+		{SubjectType: model.SubjectRole, Subject: "Auditor", Permission: model.PermAuditView},
+		{SubjectType: model.SubjectPersonnelType, Subject: "pharmacist", Permission: model.PermAll},
+	}
+
+	cases := []struct {
+		name          string
+		grants        []model.PermissionGrant
+		role          string
+		personnelType string
+		permission    string
+		want          bool
+	}{
+		{"admin wildcard allows a catalogued permission", defaults, model.RoleAdmin, "", model.PermPermissionManage, true},
+		{"admin wildcard allows a permission outside the catalogue", defaults, model.RoleAdmin, "", "reports:export", true},
+		{"admin role matches in any case", defaults, " ADMIN ", "", model.PermAuditView, true},
+		{"unknown role is denied", defaults, "janitor", "", model.PermPatientCreate, false},
+		{"unknown role is denied even a wildcard-only permission", defaults, "janitor", "", "reports:export", false},
+		{"no role and no personnel type is denied", defaults, "", "", model.PermSessionUpdate, false},
+		{"personnel type name used as a role is denied", defaults, "psychiatrist", "", model.PermPatientCreate, false},
+		{"role name used as a personnel type is denied", defaults, "", model.RoleAdmin, model.PermPermissionManage, false},
+		{"admin personnel type is not the admin role", defaults, model.RoleHealthWorker, "Admin", model.PermPermissionManage, false},
+		{"personnel type matches in any case", defaults, model.RoleHealthWorker, " NURSE ", model.PermSessionUpdate, true},
+		{"unknown permission is denied to a personnel type", defaults, model.RoleHealthWorker, "Psychiatrist", "reports:export", false},
+		{"no grants deny everything", nil, model.RoleAdmin, "Psychiatrist", model.PermPatientCreate, false},
+		{"mixed-case stored subject matches", grants, "auditor", "", model.PermAuditView, true},
+		{"role grant covers only its permission", grants, "auditor", "", model.PermPatientCreate, false},
+		{"wildcard granted to a personnel type allows everything", grants, "", "Pharmacist", model.PermFormularyManage, true},
+		{"wildcard granted to a personnel type does not leak to a role of that name", grants, "pharmacist", "", model.PermFormularyManage, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := model.GrantsAllow(tc.grants, tc.role, tc.personnelType, tc.permission); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"bytes"
+	"depression-diagnosis-system/database/model"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// formularyCSV is the bundled formulary: name, generic name, ATC code, ATC class, then strengths
+// and forms separated by "|"
+//
+//go:embed data/formulary.csv
+var formularyCSV []byte
+
+// parseFormulary reads the bundled formulary
+func parseFormulary() ([]model.Drug, error) {
+	rows, err := csv.NewReader(bytes.NewReader(formularyCSV)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("the formulary file has no entries")
+	}
+
+	drugs := make([]model.Drug, 0, len(rows)-1)
+	for n, row := range rows[1:] {
+		if len(row) != 6 {
+			return nil, fmt.Errorf("formulary line %d has %d columns, expected 6", n+2, len(row))
+		}
+		strengths, _ := json.Marshal(strings.Split(row[4], "|"))
+		forms, _ := json.Marshal(strings.Split(row[5], "|"))
+		drugs = append(drugs, model.Drug{
+			Name:        row[0],
+			GenericName: row[1],
+			ATCCode:     row[2],
+			ATCClass:    row[3],
+			Strengths:   strengths,
+			Forms:       forms,
+			IsActive:    true,
+		})
+	}
+	return drugs, nil
+}
+
+// SeedFormulary installs the bundled formulary. Drugs already in the formulary are left untouched,
+// so local changes survive.
+func SeedFormulary() {
+	drugs, err := parseFormulary()
+	if err != nil {
+		log.Printf("❌ Failed to read the bundled formulary: %v", err)
+		return
+	}
+
+	added := 0
+	for _, drug := range drugs {
+		var count int64
+		DB.Model(&model.Drug{}).Where("LOWER(name) = ?", strings.ToLower(drug.Name)).Count(&count)
+		if count > 0 {
+			continue
+		}
+		if err := drug.Validate(); err != nil {
+			log.Printf("❌ Failed to seed drug %s: %v", drug.Name, err)
+			continue
+		}
+		if err := DB.Create(&drug).Error; err != nil {
+			log.Printf("❌ Failed to seed drug %s: %v", drug.Name, err)
+			continue
+		}
+		added++
+	}
+	if added > 0 {
+		log.Printf("💊 Formulary seeded with %d drugs", added)
+	}
+}
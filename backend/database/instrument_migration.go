@@ -0,0 +1,271 @@
+package database
+
+import (
+	"depression-diagnosis-system/database/model"
+	"encoding/json"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// renameLegacyRiskAlertColumn renames risk_alerts.phq9_response_id, which predates the generic
+// instruments, so AutoMigrate does not add a second, empty reference column. It reports whether
+// the rename happened, in which case the stored IDs still point at phq9_responses.
+func renameLegacyRiskAlertColumn() (bool, error) {
+	migrator := DB.Migrator()
+	if !migrator.HasTable(&model.RiskAlert{}) || !migrator.HasColumn(&model.RiskAlert{}, "phq9_response_id") {
+		return false, nil
+	}
+	if err := migrator.RenameColumn(&model.RiskAlert{}, "phq9_response_id", "instrument_response_id"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// migrateInstrumentVersions moves definitions written before instruments were versioned into a
+// version 1 of each instrument and pins existing responses to it. It only does work while the old
+// instrument_id columns are still present.
+func migrateInstrumentVersions() error {
+	migrator := DB.Migrator()
+	if !migrator.HasColumn(&model.InstrumentItem{}, "instrument_id") {
+		return nil
+	}
+	hasInstructions := migrator.HasColumn(&model.Instrument{}, "instructions")
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var instruments []model.Instrument
+		if err := tx.Find(&instruments).Error; err != nil {
+			return err
+		}
+
+		for _, inst := range instruments {
+			version := model.InstrumentVersion{
+				InstrumentID: inst.ID,
+				Version:      1,
+				Status:       model.InstrumentVersionDraft,
+				ChangeNote:   "Definition in use before versioning",
+			}
+			if hasInstructions {
+				if err := tx.Table("instruments").Where("id = ?", inst.ID).
+					Select("instructions").Scan(&version.Instructions).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Create(&version).Error; err != nil {
+				return err
+			}
+
+			for _, table := range []string{"instrument_items", "instrument_options", "instrument_severity_bands"} {
+				if err := tx.Table(table).Where("instrument_id = ?", inst.ID).
+					Update("instrument_version_id", version.ID).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&model.InstrumentResponse{}).
+				Where("instrument_id = ? AND (instrument_version_id IS NULL OR instrument_version_id = 0)", inst.ID).
+				Update("instrument_version_id", version.ID).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Model(&version).Updates(map[string]interface{}{
+				"status":       model.InstrumentVersionPublished,
+				"published_at": inst.CreatedAt,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		log.Printf("✅ Moved %d instrument definitions into versions", len(instruments))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, column := range []struct {
+		model  interface{}
+		column string
+	}{
+		{&model.InstrumentItem{}, "instrument_id"},
+		{&model.InstrumentOption{}, "instrument_id"},
+		{&model.InstrumentSeverityBand{}, "instrument_id"},
+		{&model.Instrument{}, "instructions"},
+	} {
+		if migrator.HasColumn(column.model, column.column) {
+			if err := migrator.DropColumn(column.model, column.column); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ProtectInstrumentVersions installs triggers so a version's definition cannot change once it
+// leaves draft, even with raw SQL. The only change allowed to a published version is superseding it.
+// A partial unique index keeps an instrument to a single published version.
+func ProtectInstrumentVersions() error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION instrument_versions_immutable() RETURNS trigger AS $$
+		BEGIN
+			IF OLD.status = 'draft' THEN
+				IF TG_OP = 'DELETE' THEN
+					RETURN OLD;
+				END IF;
+				RETURN NEW;
+			END IF;
+			IF TG_OP = 'UPDATE' AND OLD.status = 'published' AND NEW.status = 'superseded'
+				AND to_jsonb(NEW) - 'status' - 'updated_at' = to_jsonb(OLD) - 'status' - 'updated_at' THEN
+				RETURN NEW;
+			END IF;
+			RAISE EXCEPTION 'instrument version % is % and cannot be changed', OLD.id, OLD.status;
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS instrument_versions_immutable ON instrument_versions`,
+		`CREATE TRIGGER instrument_versions_immutable BEFORE UPDATE OR DELETE ON instrument_versions
+		FOR EACH ROW EXECUTE FUNCTION instrument_versions_immutable()`,
+
+		// Options of a single item reach their version through the item
+		`CREATE OR REPLACE FUNCTION instrument_definition_version(rec jsonb) RETURNS bigint AS $$
+			SELECT COALESCE((rec->>'instrument_version_id')::bigint,
+				(SELECT instrument_version_id FROM instrument_items WHERE id = (rec->>'instrument_item_id')::bigint))
+		$$ LANGUAGE sql STABLE`,
+		`CREATE OR REPLACE FUNCTION instrument_definition_immutable() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP <> 'INSERT' AND EXISTS (SELECT 1 FROM instrument_versions
+				WHERE id = instrument_definition_version(to_jsonb(OLD)) AND status <> 'draft') THEN
+				RAISE EXCEPTION '% belongs to a published instrument version and cannot be changed', TG_TABLE_NAME;
+			END IF;
+			IF TG_OP <> 'DELETE' AND EXISTS (SELECT 1 FROM instrument_versions
+				WHERE id = instrument_definition_version(to_jsonb(NEW)) AND status <> 'draft') THEN
+				RAISE EXCEPTION '% can only be added to a draft instrument version', TG_TABLE_NAME;
+			END IF;
+			IF TG_OP = 'DELETE' THEN
+				RETURN OLD;
+			END IF;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql`,
+	}
+	for _, table := range []string{"instrument_items", "instrument_options", "instrument_severity_bands"} {
+		statements = append(statements,
+			`DROP TRIGGER IF EXISTS `+table+`_immutable ON `+table,
+			`CREATE TRIGGER `+table+`_immutable BEFORE INSERT OR UPDATE OR DELETE ON `+table+`
+			FOR EACH ROW EXECUTE FUNCTION instrument_definition_immutable()`,
+		)
+	}
+
+	statements = append(statements,
+		// Concurrent publishes could leave more than one version published; keep the newest
+		`UPDATE instrument_versions v SET status = 'superseded'
+		WHERE v.status = 'published' AND EXISTS (SELECT 1 FROM instrument_versions n
+			WHERE n.instrument_id = v.instrument_id AND n.status = 'published' AND n.version > v.version)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_instrument_versions_published ON instrument_versions (instrument_id)
+		WHERE status = 'published'`,
+	)
+
+	for _, stmt := range statements {
+		if err := DB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProtectInstrumentResponses keeps a session to one response per instrument with a partial unique
+// index. Duplicates recorded before the index existed are soft-deleted, keeping the first one.
+func ProtectInstrumentResponses() error {
+	statements := []string{
+		`UPDATE instrument_responses r SET deleted_at = NOW()
+		WHERE r.deleted_at IS NULL AND EXISTS (SELECT 1 FROM instrument_responses f
+			WHERE f.session_id = r.session_id AND f.instrument_id = r.instrument_id
+			AND f.deleted_at IS NULL AND f.id < r.id)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_instrument_responses_session ON instrument_responses (session_id, instrument_id)
+		WHERE deleted_at IS NULL`,
+	}
+	for _, stmt := range statements {
+		if err := DB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateLegacyPhq9 copies answers stored in phq9_responses into the PHQ-9 instrument. The old
+// question IDs are matched to PHQ-9 items by their order, which is how they were scored. Rows that
+// were already copied are skipped, so this is safe to run on every start. remapRiskAlerts points
+// alerts raised before the move at the copied responses.
+func migrateLegacyPhq9(remapRiskAlerts bool) error {
+	phq9, err := seedInstrument(phq9Definition())
+	if err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var questions []model.Phq9Question
+		if err := tx.Order("id").Find(&questions).Error; err != nil {
+			return err
+		}
+		itemByQuestion := make(map[uint]uint, len(questions))
+		for i, q := range questions {
+			if i < len(phq9.Items) {
+				itemByQuestion[q.ID] = phq9.Items[i].ID
+			}
+		}
+
+		var legacy []model.Phq9Response
+		if err := tx.Where("id NOT IN (SELECT legacy_phq9_response_id FROM instrument_responses WHERE legacy_phq9_response_id IS NOT NULL)").
+			Order("id").Find(&legacy).Error; err != nil {
+			return err
+		}
+
+		for _, old := range legacy {
+			var list []model.Phq9ResponseStruct
+			if err := json.Unmarshal(old.Responses, &list); err != nil {
+				log.Printf("⚠️ Skipping malformed PHQ-9 response %d: %v", old.ID, err)
+				continue
+			}
+
+			legacyID := old.ID
+			response := model.InstrumentResponse{
+				Model:                gorm.Model{CreatedAt: old.CreatedAt, UpdatedAt: old.UpdatedAt},
+				InstrumentID:         phq9.InstrumentID,
+				InstrumentVersionID:  phq9.ID,
+				SessionID:            old.SessionID,
+				LegacyPhq9ResponseID: &legacyID,
+			}
+			for _, r := range list {
+				itemID, ok := itemByQuestion[r.QuestionID]
+				if !ok {
+					continue
+				}
+				response.Answers = append(response.Answers, model.InstrumentAnswer{InstrumentItemID: itemID, Score: r.Response})
+				response.TotalScore += r.Response
+			}
+			response.Severity = phq9.SeverityFor(response.TotalScore)
+
+			if err := tx.Create(&response).Error; err != nil {
+				return err
+			}
+		}
+
+		if remapRiskAlerts {
+			if err := tx.Exec(`UPDATE risk_alerts SET instrument_response_id = ir.id
+				FROM instrument_responses ir
+				WHERE ir.legacy_phq9_response_id = risk_alerts.instrument_response_id`).Error; err != nil {
+				return err
+			}
+		}
+
+		// Rules written before instruments existed were about PHQ-9
+		if err := tx.Model(&model.RiskRule{}).
+			Where("instrument_id IS NULL OR instrument_id = 0").
+			Update("instrument_id", phq9.InstrumentID).Error; err != nil {
+			return err
+		}
+
+		if len(legacy) > 0 {
+			log.Printf("✅ Migrated %d PHQ-9 responses to the instrument tables", len(legacy))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,391 @@
+package database
+
+import (
+	"depression-diagnosis-system/database/model"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var phq9ItemTexts = []string{
+	"Little interest or pleasure in doing things?",
+	"Feeling down, depressed, or hopeless?",
+	"Trouble falling or staying asleep, or sleeping too much?",
+	"Feeling tired or having little energy?",
+	"Poor appetite or overeating?",
+	"Feeling bad about yourself — or that you are a failure or have let yourself or your family down?",
+	"Trouble concentrating on things, such as reading the newspaper or watching television?",
+	"Moving or speaking so slowly that other people could have noticed? Or the opposite — being so fidgety or restless that you have been moving around a lot more than usual?",
+	"Thoughts that you would be better off dead, or of hurting yourself in some way?",
+}
+
+// option is a single answer choice with an explicit score
+func option(label string, score int) model.InstrumentOption {
+	return model.InstrumentOption{Label: label, Score: score}
+}
+
+// scaledOptions scores the labels 0, 1, 2… in the order given
+func scaledOptions(labels ...string) []model.InstrumentOption {
+	options := make([]model.InstrumentOption, len(labels))
+	for i, label := range labels {
+		options[i] = model.InstrumentOption{Position: i + 1, Label: label, Score: i}
+	}
+	return options
+}
+
+// reversedOptions scores the labels from highest to 0 in the order given
+func reversedOptions(labels ...string) []model.InstrumentOption {
+	options := scaledOptions(labels...)
+	for i := range options {
+		options[i].Score = len(labels) - 1 - i
+	}
+	return options
+}
+
+// frequencyOptions are the two-week frequency choices shared by PHQ-9, PHQ-2 and GAD-7
+func frequencyOptions() []model.InstrumentOption {
+	return scaledOptions("Not at all", "Several days", "More than half the days", "Nearly every day")
+}
+
+func textItems(texts ...string) []model.InstrumentItem {
+	items := make([]model.InstrumentItem, len(texts))
+	for i, text := range texts {
+		items[i] = model.InstrumentItem{Position: i + 1, Text: text}
+	}
+	return items
+}
+
+// instrumentDefinition is an instrument together with the version first installed for it
+type instrumentDefinition struct {
+	Instrument model.Instrument
+	Version    model.InstrumentVersion
+}
+
+func phq9Definition() instrumentDefinition {
+	return instrumentDefinition{
+		Instrument: model.Instrument{
+			Code:        model.InstrumentPHQ9,
+			Name:        "Patient Health Questionnaire-9",
+			Description: "Screens for and measures the severity of depression.",
+			IsActive:    true,
+		},
+		Version: model.InstrumentVersion{
+			Instructions: "Over the last 2 weeks, how often have you been bothered by any of the following problems?",
+			Items:        textItems(phq9ItemTexts...),
+			Options:      frequencyOptions(),
+			SeverityBands: []model.InstrumentSeverityBand{
+				{MinScore: 0, MaxScore: 4, Label: model.SeverityNone},
+				{MinScore: 5, MaxScore: 9, Label: model.SeverityMild},
+				{MinScore: 10, MaxScore: 14, Label: model.SeverityModerate},
+				{MinScore: 15, MaxScore: 19, Label: model.SeverityModeratelySevere},
+				{MinScore: 20, MaxScore: 27, Label: model.SeveritySevere},
+			},
+		},
+	}
+}
+
+func phq2Definition() instrumentDefinition {
+	return instrumentDefinition{
+		Instrument: model.Instrument{
+			Code:        model.InstrumentPHQ2,
+			Name:        "Patient Health Questionnaire-2",
+			Description: "Two-item depression pre-screen. A positive screen should be followed by the full PHQ-9.",
+			IsActive:    true,
+		},
+		Version: model.InstrumentVersion{
+			Instructions: "Over the last 2 weeks, how often have you been bothered by any of the following problems?",
+			Items:        textItems(phq9ItemTexts[0], phq9ItemTexts[1]),
+			Options:      frequencyOptions(),
+			SeverityBands: []model.InstrumentSeverityBand{
+				{MinScore: 0, MaxScore: 2, Label: "Negative screen"},
+				{MinScore: 3, MaxScore: 6, Label: "Positive screen — administer PHQ-9"},
+			},
+		},
+	}
+}
+
+func gad7Definition() instrumentDefinition {
+	return instrumentDefinition{
+		Instrument: model.Instrument{
+			Code:        model.InstrumentGAD7,
+			Name:        "Generalized Anxiety Disorder-7",
+			Description: "Screens for and measures the severity of generalized anxiety disorder.",
+			IsActive:    true,
+		},
+		Version: model.InstrumentVersion{
+			Instructions: "Over the last 2 weeks, how often have you been bothered by the following problems?",
+			Items: textItems(
+				"Feeling nervous, anxious, or on edge",
+				"Not being able to stop or control worrying",
+				"Worrying too much about different things",
+				"Trouble relaxing",
+				"Being so restless that it is hard to sit still",
+				"Becoming easily annoyed or irritable",
+				"Feeling afraid, as if something awful might happen",
+			),
+			Options: frequencyOptions(),
+			SeverityBands: []model.InstrumentSeverityBand{
+				{MinScore: 0, MaxScore: 4, Label: "Minimal Anxiety"},
+				{MinScore: 5, MaxScore: 9, Label: "Mild Anxiety"},
+				{MinScore: 10, MaxScore: 14, Label: "Moderate Anxiety"},
+				{MinScore: 15, MaxScore: 21, Label: "Severe Anxiety"},
+			},
+		},
+	}
+}
+
+func epdsDefinition() instrumentDefinition {
+	items := textItems(
+		"I have been able to laugh and see the funny side of things",
+		"I have looked forward with enjoyment to things",
+		"I have blamed myself unnecessarily when things went wrong",
+		"I have been anxious or worried for no good reason",
+		"I have felt scared or panicky for no very good reason",
+		"Things have been getting on top of me",
+		"I have been so unhappy that I have had difficulty sleeping",
+		"I have felt sad or miserable",
+		"I have been so unhappy that I have been crying",
+		"The thought of harming myself has occurred to me",
+	)
+	options := [][]model.InstrumentOption{
+		scaledOptions("As much as I always could", "Not quite so much now", "Definitely not so much now", "Not at all"),
+		scaledOptions("As much as I ever did", "Rather less than I used to", "Definitely less than I used to", "Hardly at all"),
+		reversedOptions("Yes, most of the time", "Yes, some of the time", "Not very often", "No, never"),
+		scaledOptions("No, not at all", "Hardly ever", "Yes, sometimes", "Yes, very often"),
+		reversedOptions("Yes, quite a lot", "Yes, sometimes", "No, not much", "No, not at all"),
+		reversedOptions(
+			"Yes, most of the time I haven't been able to cope at all",
+			"Yes, sometimes I haven't been coping as well as usual",
+			"No, most of the time I have coped quite well",
+			"No, I have been coping as well as ever",
+		),
+		reversedOptions("Yes, most of the time", "Yes, sometimes", "Not very often", "No, not at all"),
+		reversedOptions("Yes, most of the time", "Yes, quite often", "Not very often", "No, not at all"),
+		reversedOptions("Yes, most of the time", "Yes, quite often", "Only occasionally", "No, never"),
+		reversedOptions("Yes, quite often", "Sometimes", "Hardly ever", "Never"),
+	}
+	for i := range items {
+		items[i].Options = options[i]
+	}
+
+	return instrumentDefinition{
+		Instrument: model.Instrument{
+			Code:        model.InstrumentEPDS,
+			Name:        "Edinburgh Postnatal Depression Scale",
+			Description: "Screens for depression during pregnancy and after childbirth.",
+			IsActive:    true,
+		},
+		Version: model.InstrumentVersion{
+			Instructions: "Please choose the answer that comes closest to how you have felt in the past 7 days, not just how you feel today.",
+			Items:        items,
+			SeverityBands: []model.InstrumentSeverityBand{
+				{MinScore: 0, MaxScore: 9, Label: "Depression Not Likely"},
+				{MinScore: 10, MaxScore: 12, Label: "Possible Depression"},
+				{MinScore: 13, MaxScore: 30, Label: "Probable Depression"},
+			},
+		},
+	}
+}
+
+func auditDefinition() instrumentDefinition {
+	items := textItems(
+		"How often do you have a drink containing alcohol?",
+		"How many drinks containing alcohol do you have on a typical day when you are drinking?",
+		"How often do you have six or more drinks on one occasion?",
+		"How often during the last year have you found that you were not able to stop drinking once you had started?",
+		"How often during the last year have you failed to do what was normally expected of you because of drinking?",
+		"How often during the last year have you needed a first drink in the morning to get yourself going after a heavy drinking session?",
+		"How often during the last year have you had a feeling of guilt or remorse after drinking?",
+		"How often during the last year have you been unable to remember what happened the night before because you had been drinking?",
+		"Have you or someone else been injured because of your drinking?",
+		"Has a relative, friend, doctor or other health worker been concerned about your drinking or suggested you cut down?",
+	)
+	frequency := func() []model.InstrumentOption {
+		return scaledOptions("Never", "Less than monthly", "Monthly", "Weekly", "Daily or almost daily")
+	}
+	injury := func() []model.InstrumentOption {
+		return []model.InstrumentOption{
+			option("No", 0),
+			option("Yes, but not in the last year", 2),
+			option("Yes, during the last year", 4),
+		}
+	}
+
+	items[0].Options = scaledOptions("Never", "Monthly or less", "2 to 4 times a month", "2 to 3 times a week", "4 or more times a week")
+	items[1].Options = scaledOptions("1 or 2", "3 or 4", "5 or 6", "7 to 9", "10 or more")
+	for i := 2; i < 8; i++ {
+		items[i].Options = frequency()
+	}
+	items[8].Options = injury()
+	items[9].Options = injury()
+
+	return instrumentDefinition{
+		Instrument: model.Instrument{
+			Code:        model.InstrumentAUDIT,
+			Name:        "Alcohol Use Disorders Identification Test",
+			Description: "WHO screen for hazardous and harmful alcohol use and possible dependence.",
+			IsActive:    true,
+		},
+		Version: model.InstrumentVersion{
+			Instructions: "The following questions are about your use of alcoholic beverages during the past year.",
+			Items:        items,
+			SeverityBands: []model.InstrumentSeverityBand{
+				{MinScore: 0, MaxScore: 7, Label: "Low Risk"},
+				{MinScore: 8, MaxScore: 15, Label: "Hazardous Drinking"},
+				{MinScore: 16, MaxScore: 19, Label: "Harmful Drinking"},
+				{MinScore: 20, MaxScore: 40, Label: "Possible Dependence"},
+			},
+		},
+	}
+}
+
+// seedInstrument installs the instrument with its definition as published version 1, unless an
+// instrument with the same code exists, and returns the instrument's published version
+func seedInstrument(def instrumentDefinition) (*model.InstrumentVersion, error) {
+	inst, version := def.Instrument, def.Version
+
+	var existing model.Instrument
+	if err := DB.Where("code = ?", inst.Code).First(&existing).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		for i := range version.Items {
+			for j := range version.Items[i].Options {
+				version.Items[i].Options[j].Position = j + 1
+			}
+		}
+		if err := errors.Join(inst.Validate(), version.Validate()); err != nil {
+			return nil, err
+		}
+
+		// Items can only be written while the version is a draft, so publish after creating it
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&inst).Error; err != nil {
+				return err
+			}
+			version.InstrumentID = inst.ID
+			version.Version = 1
+			version.Status = model.InstrumentVersionDraft
+			version.ChangeNote = "Initial version"
+			if err := tx.Create(&version).Error; err != nil {
+				return err
+			}
+			return tx.Model(&version).Updates(map[string]interface{}{
+				"status":       model.InstrumentVersionPublished,
+				"published_at": time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("📋 Instrument seeded: %s", inst.Code)
+	}
+
+	return publishedVersion(inst.Code)
+}
+
+// SeedInstruments installs the standard questionnaires. Existing instruments are left untouched.
+func SeedInstruments() {
+	definitions := []instrumentDefinition{
+		phq9Definition(),
+		phq2Definition(),
+		gad7Definition(),
+		epdsDefinition(),
+		auditDefinition(),
+	}
+	for _, def := range definitions {
+		if _, err := seedInstrument(def); err != nil {
+			log.Printf("❌ Failed to seed instrument %s: %v", def.Instrument.Code, err)
+		}
+	}
+}
+
+// publishedVersion loads the full definition of an instrument's published version by code
+func publishedVersion(code string) (*model.InstrumentVersion, error) {
+	var version model.InstrumentVersion
+	err := DB.Scopes(model.WithInstrumentDefinition).
+		Where("status = ? AND instrument_id IN (SELECT id FROM instruments WHERE code = ?)", model.InstrumentVersionPublished, code).
+		First(&version).Error
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// simulatedAnswers picks an answer option for every item from seed, so seeded sessions vary
+func simulatedAnswers(version *model.InstrumentVersion, seed int) []model.InstrumentAnswer {
+	answers := make([]model.InstrumentAnswer, 0, len(version.Items))
+	for idx := range version.Items {
+		item := &version.Items[idx]
+		options := version.OptionsFor(item)
+		choice := options[(seed+int(item.ID))%len(options)]
+		answers = append(answers, model.InstrumentAnswer{InstrumentItemID: item.ID, Score: choice.Score})
+	}
+	return answers
+}
+
+// phq9Translations are working translations of the PHQ-9 items, in item order. They are seeded as
+// pending so a fluent clinician reviews them before patients see them.
+var phq9Translations = map[string][]string{
+	model.LocaleLuganda: {
+		"Obutaba na kwagala oba essanyu mu kukola ebintu",
+		"Okuwulira ennaku, okwennyika, oba obutaba na ssuubi",
+		"Obuzibu mu kwebaka oba okusigala nga weebase, oba okwebaka ennyo",
+		"Okuwulira obukoowu oba obutaba na maanyi",
+		"Obutaba na kwagala kulya oba okulya ennyo",
+		"Okwewulira obubi ku lulwo — oba nti oli mulemeremu oba nti weeswazizza oba oswazizza ab'omu maka go",
+		"Obuzibu mu kussaayo omwoyo ku bintu, nga okusoma olupapula lw'amawulire oba okulaba ttivi",
+		"Okutambula oba okwogera mpola nnyo nti n'abalala bakyetegereza? Oba okwawukana ku ekyo — okutabukatabuka oba obutatereera okusinga bulijjo",
+		"Ebirowoozo nti kyandibadde kirungi singa ofudde, oba okwerumya mu ngeri yonna",
+	},
+	model.LocaleSwahili: {
+		"Kutokuwa na hamu au furaha ya kufanya mambo",
+		"Kujisikia mwenye huzuni, msongo wa mawazo, au kukosa matumaini",
+		"Shida ya kupata usingizi au kubaki usingizini, au kulala kupita kiasi",
+		"Kujisikia mchovu au kukosa nguvu",
+		"Kukosa hamu ya kula au kula kupita kiasi",
+		"Kujiona vibaya — au kwamba umeshindwa au umejiangusha mwenyewe au familia yako",
+		"Shida ya kuzingatia mambo, kama kusoma gazeti au kutazama televisheni",
+		"Kutembea au kuongea polepole sana hadi watu wengine wakagundua? Au kinyume chake — kuhangaika au kutotulia kuliko kawaida",
+		"Mawazo kwamba ingekuwa bora ukifa, au ya kujiumiza kwa namna yoyote",
+	},
+}
+
+// SeedInstrumentTranslations adds the PHQ-9 Luganda and Swahili translations to the published
+// version, skipping items that already have a translation into the language
+func SeedInstrumentTranslations() {
+	phq9, err := publishedVersion(model.InstrumentPHQ9)
+	if err != nil {
+		log.Printf("⚠️ Cannot seed translations — PHQ-9 instrument not found: %v", err)
+		return
+	}
+
+	for locale, texts := range phq9Translations {
+		added := 0
+		for idx, text := range texts {
+			item := phq9.ItemAt(idx + 1)
+			if item == nil {
+				continue
+			}
+			translation := model.InstrumentItemTranslation{
+				InstrumentItemID: item.ID,
+				Locale:           locale,
+				Text:             text,
+				Status:           model.TranslationPending,
+			}
+			result := DB.Where("instrument_item_id = ? AND locale = ?", item.ID, locale).FirstOrCreate(&translation)
+			if result.Error != nil {
+				log.Printf("❌ Failed to seed %s translation of PHQ-9 item %d: %v", locale, item.Position, result.Error)
+				continue
+			}
+			if result.RowsAffected > 0 {
+				added++
+			}
+		}
+		if added > 0 {
+			log.Printf("🌍 Seeded %d PHQ-9 translations into %s (pending review)", added, locale)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"bytes"
+	"depression-diagnosis-system/database/model"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// interactionsCSV is the bundled interaction table: kind, subject A, subject B, severity and
+// description. Subjects are generic names or ATC code prefixes.
+//
+//go:embed data/interactions.csv
+var interactionsCSV []byte
+
+// parseInteractions reads the bundled interaction table
+func parseInteractions() ([]model.DrugInteraction, error) {
+	rows, err := csv.NewReader(bytes.NewReader(interactionsCSV)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("the interaction file has no entries")
+	}
+
+	interactions := make([]model.DrugInteraction, 0, len(rows)-1)
+	for n, row := range rows[1:] {
+		if len(row) != 5 {
+			return nil, fmt.Errorf("interaction line %d has %d columns, expected 5", n+2, len(row))
+		}
+		if row[0] != model.InteractionDrug && row[0] != model.InteractionPregnancy {
+			return nil, fmt.Errorf("interaction line %d has unknown kind %q", n+2, row[0])
+		}
+		if !model.ValidInteractionSeverity(row[3]) {
+			return nil, fmt.Errorf("interaction line %d has unknown severity %q", n+2, row[3])
+		}
+		interactions = append(interactions, model.DrugInteraction{
+			Kind:        row[0],
+			SubjectA:    strings.TrimSpace(row[1]),
+			SubjectB:    strings.TrimSpace(row[2]),
+			Severity:    row[3],
+			Description: row[4],
+		})
+	}
+	return interactions, nil
+}
+
+// SeedDrugInteractions installs the bundled interaction table. Rows already present are left
+// untouched, so local changes to severities and descriptions survive.
+func SeedDrugInteractions() {
+	interactions, err := parseInteractions()
+	if err != nil {
+		log.Printf("❌ Failed to read the bundled interaction table: %v", err)
+		return
+	}
+
+	added := 0
+	for _, interaction := range interactions {
+		var count int64
+		DB.Model(&model.DrugInteraction{}).
+			Where("kind = ? AND subject_a = ? AND subject_b = ?", interaction.Kind, interaction.SubjectA, interaction.SubjectB).
+			Count(&count)
+		if count > 0 {
+			continue
+		}
+		if err := DB.Create(&interaction).Error; err != nil {
+			log.Printf("❌ Failed to seed interaction %s + %s: %v", interaction.SubjectA, interaction.SubjectB, err)
+			continue
+		}
+		added++
+	}
+	if added > 0 {
+		log.Printf("⚠️ Interaction table seeded with %d entries", added)
+	}
+}
@@ -0,0 +1,10 @@
+package database
+
+// migrateMessageReceipts gives messages sent before receipts existed a receipt for their receiver,
+// delivered and read when sent so they do not show up as unread. Safe to run on every start.
+func migrateMessageReceipts() error {
+	return DB.Exec(`INSERT INTO message_receipts (created_at, updated_at, message_id, recipient_id, delivered_at, read_at)
+		SELECT m.created_at, NOW(), m.id, m.receiver_id, m.timestamp, m.timestamp
+		FROM messages m
+		WHERE NOT EXISTS (SELECT 1 FROM message_receipts r WHERE r.message_id = m.id)`).Error
+}
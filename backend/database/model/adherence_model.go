@@ -0,0 +1,225 @@
+package model
+
+import (
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Adherence thresholds
+const (
+	AdherencePDCThreshold = 0.8 // proportion of days covered below this is poor adherence
+	AdherenceMissedDoses  = 4   // this many missed doses reported in the window is poor adherence
+	AdherenceWindowDays   = 90  // adherence is measured over at most the last this many days
+	RefillGraceDays       = 3   // a refill is overdue this many days after the supply ran out
+)
+
+// Reasons a prescription line is flagged as adherence at risk
+const (
+	AdherenceFlagLowPDC        = "low_pdc"
+	AdherenceFlagMissedDoses   = "missed_doses"
+	AdherenceFlagRefillOverdue = "refill_overdue"
+)
+
+// DispensingEvent records a supply of a prescription line's drug handed to the patient
+type DispensingEvent struct {
+	gorm.Model
+	PrescriptionLineID uint              `gorm:"not null;index" json:"prescription_line_id"`
+	PrescriptionLine   *PrescriptionLine `gorm:"foreignKey:PrescriptionLineID" json:"prescription_line,omitempty"`
+	PatientID          uint              `gorm:"not null;index" json:"patient_id"`
+	DispensedAt        time.Time         `gorm:"not null;index" json:"dispensed_at"`
+	DaysSupply         int               `gorm:"not null" json:"days_supply"`
+	Quantity           string            `json:"quantity"` // e.g. "28 capsules"
+	DispensedByID      *uint             `json:"dispensed_by_id"`
+	DispensedBy        *HealthWorker     `gorm:"foreignKey:DispensedByID" json:"dispensed_by,omitempty"`
+	Notes              string            `gorm:"type:text" json:"notes"`
+}
+
+// AdherenceCheckIn is what a patient reported about taking a prescription line's drug, usually
+// asked at a session: how many doses they missed over the last PeriodDays
+type AdherenceCheckIn struct {
+	gorm.Model
+	PrescriptionLineID uint              `gorm:"not null;index" json:"prescription_line_id"`
+	PrescriptionLine   *PrescriptionLine `gorm:"foreignKey:PrescriptionLineID" json:"prescription_line,omitempty"`
+	PatientID          uint              `gorm:"not null;index" json:"patient_id"`
+	SessionID          *uint             `gorm:"index" json:"session_id"`
+	CheckedAt          time.Time         `gorm:"not null;index" json:"checked_at"`
+	PeriodDays         int               `gorm:"not null" json:"period_days"`
+	MissedDoses        int               `json:"missed_doses"`
+	Notes              string            `gorm:"type:text" json:"notes"`
+	RecordedByID       *uint             `json:"recorded_by_id"`
+	RecordedBy         *HealthWorker     `gorm:"foreignKey:RecordedByID" json:"recorded_by,omitempty"`
+}
+
+// LineAdherence is how well a patient kept to one prescription line between From and To
+type LineAdherence struct {
+	PrescriptionLineID uint       `json:"prescription_line_id"`
+	PatientID          uint       `json:"patient_id"`
+	Drug               string     `json:"drug"`
+	From               time.Time  `json:"from"`
+	To                 time.Time  `json:"to"`
+	DaysInPeriod       int        `json:"days_in_period"`
+	DaysCovered        int        `json:"days_covered"`
+	PDC                *float64   `json:"pdc"` // unset when the line has never been dispensed here
+	MissedDoses        int        `json:"missed_doses"`
+	CheckIns           int        `json:"check_ins"`
+	LastDispensedAt    *time.Time `json:"last_dispensed_at"`
+	RefillDueDate      *time.Time `json:"refill_due_date"` // when the dispensed supply runs out
+	DaysOverdue        int        `json:"days_overdue"`
+	AtRisk             bool       `json:"at_risk"`
+	Reasons            []string   `json:"reasons"`
+}
+
+// AdherenceDetail is a line's adherence with the records it was computed from
+type AdherenceDetail struct {
+	Line        *PrescriptionLine  `json:"prescription_line"`
+	Adherence   LineAdherence      `json:"adherence"`
+	Dispensings []DispensingEvent  `json:"dispensings"`
+	CheckIns    []AdherenceCheckIn `json:"check_ins"`
+}
+
+// AdherenceRisk is a patient with at least one prescription line at risk
+type AdherenceRisk struct {
+	PatientID uint            `json:"patient_id"`
+	Patient   *Patient        `json:"patient"`
+	Lines     []LineAdherence `json:"lines"`
+	WorstPDC  *float64        `json:"worst_pdc"`
+}
+
+// AdherenceSnapshot is adherence across a patient's lines over the weeks before a session
+type AdherenceSnapshot struct {
+	PDC         *float64 `json:"pdc"` // lowest PDC among lines dispensed in the period
+	MissedDoses int      `json:"missed_doses"`
+	CheckIns    int      `json:"check_ins"`
+}
+
+// Poor reports whether the snapshot shows poor adherence
+func (s *AdherenceSnapshot) Poor() bool {
+	return (s.PDC != nil && *s.PDC < AdherencePDCThreshold) || s.MissedDoses >= AdherenceMissedDoses
+}
+
+// startOfDay truncates t to local midnight
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// daysBetween counts whole days from a to b
+func daysBetween(a, b time.Time) int {
+	return int(startOfDay(b).Sub(startOfDay(a)).Hours()/24 + 0.5)
+}
+
+// SupplyCoverage counts the days of [from, to) covered by the dispensed supplies and returns when
+// the supply runs out. A refill collected early is taken to start when the previous supply ends,
+// so stockpiled days carry forward.
+func SupplyCoverage(events []DispensingEvent, from, to time.Time) (covered int, runsOut *time.Time) {
+	sorted := append([]DispensingEvent(nil), events...)
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a].DispensedAt.Before(sorted[b].DispensedAt) })
+
+	from, to = startOfDay(from), startOfDay(to)
+	var cursor time.Time
+	for _, e := range sorted {
+		if e.DaysSupply <= 0 {
+			continue
+		}
+		start := startOfDay(e.DispensedAt)
+		if start.Before(cursor) {
+			start = cursor
+		}
+		end := start.AddDate(0, 0, e.DaysSupply)
+		cursor = end
+
+		lo, hi := start, end
+		if lo.Before(from) {
+			lo = from
+		}
+		if hi.After(to) {
+			hi = to
+		}
+		if hi.After(lo) {
+			covered += daysBetween(lo, hi)
+		}
+	}
+	if !cursor.IsZero() {
+		runsOut = &cursor
+	}
+	return covered, runsOut
+}
+
+// AdherenceWindow is the period a line's adherence is measured over up to now: from its start, or
+// AdherenceWindowDays back if it started earlier, to its stop date or now
+func AdherenceWindow(line *PrescriptionLine, now time.Time) (time.Time, time.Time) {
+	to := now
+	if line.StopDate != nil && line.StopDate.Before(to) {
+		to = *line.StopDate
+	}
+	from := to.AddDate(0, 0, -AdherenceWindowDays)
+	if line.StartDate != nil && line.StartDate.After(from) {
+		from = *line.StartDate
+	}
+	return from, to
+}
+
+// ComputeAdherence measures a line's adherence between from and to from its dispensing events and
+// check-ins. Proportion of days covered is only computed for lines dispensed at least once, since
+// drugs obtained elsewhere leave no dispensing record.
+func ComputeAdherence(line *PrescriptionLine, events []DispensingEvent, checkIns []AdherenceCheckIn, from, to, now time.Time) LineAdherence {
+	a := LineAdherence{
+		PrescriptionLineID: line.ID,
+		PatientID:          line.PatientID,
+		Drug:               line.Describe(),
+		From:               from,
+		To:                 to,
+		Reasons:            []string{},
+	}
+	// The period runs to the end of to's day while the drug is still being taken that day
+	end := startOfDay(to)
+	if line.ActiveOn(to) {
+		end = end.AddDate(0, 0, 1)
+	}
+	a.DaysInPeriod = daysBetween(from, end)
+	if a.DaysInPeriod < 1 {
+		a.DaysInPeriod = 1
+	}
+
+	for _, c := range checkIns {
+		if !c.CheckedAt.Before(from) && !c.CheckedAt.After(to) {
+			a.MissedDoses += c.MissedDoses
+			a.CheckIns++
+		}
+	}
+
+	if len(events) > 0 {
+		covered, runsOut := SupplyCoverage(events, from, end)
+		if covered > a.DaysInPeriod {
+			covered = a.DaysInPeriod
+		}
+		a.DaysCovered = covered
+		pdc := float64(covered) / float64(a.DaysInPeriod)
+		a.PDC = &pdc
+		a.RefillDueDate = runsOut
+
+		last := events[0].DispensedAt
+		for _, e := range events[1:] {
+			if e.DispensedAt.After(last) {
+				last = e.DispensedAt
+			}
+		}
+		a.LastDispensedAt = &last
+	}
+
+	if a.PDC != nil && *a.PDC < AdherencePDCThreshold {
+		a.Reasons = append(a.Reasons, AdherenceFlagLowPDC)
+	}
+	if a.MissedDoses >= AdherenceMissedDoses {
+		a.Reasons = append(a.Reasons, AdherenceFlagMissedDoses)
+	}
+	if a.RefillDueDate != nil && line.ActiveOn(now) {
+		if overdue := daysBetween(*a.RefillDueDate, now); overdue > RefillGraceDays {
+			a.DaysOverdue = overdue
+			a.Reasons = append(a.Reasons, AdherenceFlagRefillOverdue)
+		}
+	}
+	a.AtRisk = len(a.Reasons) > 0
+	return a
+}
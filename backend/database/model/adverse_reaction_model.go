@@ -0,0 +1,201 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Severities of an adverse drug reaction
+const (
+	ReactionMild            = "mild"
+	ReactionModerate        = "moderate"
+	ReactionSevere          = "severe"
+	ReactionLifeThreatening = "life_threatening"
+)
+
+var reactionSeverities = []string{ReactionMild, ReactionModerate, ReactionSevere, ReactionLifeThreatening}
+
+// Actions taken with the suspected drug
+const (
+	ReactionActionNone        = "none"
+	ReactionActionDoseReduced = "dose_reduced"
+	ReactionActionWithdrawn   = "drug_withdrawn"
+	ReactionActionSwitched    = "drug_switched"
+	ReactionActionTreated     = "reaction_treated"
+)
+
+var reactionActions = []string{ReactionActionNone, ReactionActionDoseReduced, ReactionActionWithdrawn, ReactionActionSwitched, ReactionActionTreated}
+
+// Outcomes of an adverse drug reaction
+const (
+	ReactionNotRecovered   = "not_recovered"
+	ReactionRecovering     = "recovering"
+	ReactionRecovered      = "recovered"
+	ReactionSequelae       = "recovered_with_sequelae"
+	ReactionFatal          = "fatal"
+	ReactionOutcomeUnknown = "unknown"
+)
+
+var reactionOutcomes = []string{ReactionNotRecovered, ReactionRecovering, ReactionRecovered, ReactionSequelae, ReactionFatal, ReactionOutcomeUnknown}
+
+// AdverseReaction is a side effect or adverse drug reaction reported at a session, with the drug
+// suspected of causing it taken from the patient's medication history
+type AdverseReaction struct {
+	gorm.Model
+	PatientID           uint               `gorm:"not null;index" json:"patient_id"`
+	Patient             *Patient           `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	SessionID           uint               `gorm:"not null;index" json:"session_id"`
+	Session             *Session           `gorm:"foreignKey:SessionID" json:"session,omitempty"`
+	MedicationHistoryID uint               `gorm:"not null;index" json:"medication_history_id"`
+	MedicationHistory   *MedicationHistory `gorm:"foreignKey:MedicationHistoryID" json:"medication_history,omitempty"`
+	PrescriptionLineID  *uint              `gorm:"index" json:"prescription_line_id"` // the suspected line, when structured
+	PrescriptionLine    *PrescriptionLine  `gorm:"foreignKey:PrescriptionLineID" json:"prescription_line,omitempty"`
+	SuspectedDrug       string             `gorm:"not null" json:"suspected_drug"` // taken from the line unless given
+
+	Reaction     string        `gorm:"type:text;not null" json:"reaction"` // e.g. "nausea", "agitation"
+	Severity     string        `gorm:"size:20;not null" json:"severity"`
+	Serious      bool          `json:"serious"` // led to death, hospitalisation, disability or was life-threatening
+	OnsetDate    time.Time     `gorm:"not null" json:"onset_date"`
+	ActionTaken  string        `gorm:"size:20;not null" json:"action_taken"`
+	Outcome      string        `gorm:"size:30;not null" json:"outcome"`
+	OutcomeDate  *time.Time    `json:"outcome_date"`
+	Notes        string        `gorm:"type:text" json:"notes"`
+	ReportedByID *uint         `json:"reported_by_id"`
+	ReportedBy   *HealthWorker `gorm:"foreignKey:ReportedByID" json:"reported_by,omitempty"`
+
+	RecordAs string `gorm:"-" json:"record_as,omitempty"` // "allergy" or "intolerance" adds the drug to the patient's list
+}
+
+// Validate checks a reaction's coded fields, defaulting the action to none and the outcome to
+// unknown. A life-threatening or fatal reaction is always serious.
+func (r *AdverseReaction) Validate() error {
+	r.Reaction = strings.TrimSpace(r.Reaction)
+	if r.Reaction == "" {
+		return errors.New("the reaction is required")
+	}
+	if _, ok := matchListEntry(reactionSeverities, r.Severity); !ok {
+		return fmt.Errorf("severity must be one of %s", strings.Join(reactionSeverities, ", "))
+	}
+	if r.ActionTaken == "" {
+		r.ActionTaken = ReactionActionNone
+	}
+	if _, ok := matchListEntry(reactionActions, r.ActionTaken); !ok {
+		return fmt.Errorf("action taken must be one of %s", strings.Join(reactionActions, ", "))
+	}
+	if r.Outcome == "" {
+		r.Outcome = ReactionOutcomeUnknown
+	}
+	if _, ok := matchListEntry(reactionOutcomes, r.Outcome); !ok {
+		return fmt.Errorf("outcome must be one of %s", strings.Join(reactionOutcomes, ", "))
+	}
+	if r.OnsetDate.IsZero() {
+		return errors.New("the onset date is required")
+	}
+	if r.OnsetDate.After(time.Now()) {
+		return errors.New("the onset date cannot be in the future")
+	}
+	if r.OutcomeDate != nil && r.OutcomeDate.Before(r.OnsetDate) {
+		return errors.New("the outcome date is before the onset")
+	}
+	if r.RecordAs != "" && !ValidAllergyKind(r.RecordAs) {
+		return errors.New("record_as must be allergy or intolerance")
+	}
+	if r.Severity == ReactionLifeThreatening || r.Outcome == ReactionFatal {
+		r.Serious = true
+	}
+	return nil
+}
+
+// ADRFormColumns is the layout of the pharmacovigilance export, following the sections of the
+// national adverse drug reaction reporting form: patient, reaction, suspected drug, reporter
+var ADRFormColumns = []string{
+	"report_id", "report_date",
+	"patient_code", "patient_initials", "sex", "age_years", "pregnancy_status",
+	"reaction_description", "onset_date", "severity", "serious", "outcome", "outcome_date",
+	"suspected_drug", "generic_name", "atc_code", "strength", "dosage_form", "dose", "route", "frequency",
+	"therapy_start_date", "therapy_stop_date", "indication", "action_taken",
+	"reporter_name", "reporter_profession", "reporter_contact", "health_facility",
+}
+
+// formDate writes a date for the reporting form, or nothing
+func formDate(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// initials abbreviates a name to its initials, as the form asks for
+func initials(names ...string) string {
+	var b strings.Builder
+	for _, n := range names {
+		if n = strings.TrimSpace(n); n != "" {
+			b.WriteRune(unicode.ToUpper([]rune(n)[0]))
+		}
+	}
+	return b.String()
+}
+
+// ADRFormRow lays a reaction out in ADRFormColumns order. Patient, line with its drug, session and
+// reporter with personnel type should be loaded; the indication is the session's diagnosis.
+func (r *AdverseReaction) ADRFormRow(facility string) []string {
+	row := make(map[string]string, len(ADRFormColumns))
+	row["report_id"] = strconv.FormatUint(uint64(r.ID), 10)
+	row["report_date"] = formDate(&r.CreatedAt)
+	if p := r.Patient; p != nil {
+		row["patient_code"] = p.PatientCode
+		row["patient_initials"] = initials(p.FirstName, p.LastName)
+		row["sex"] = p.Gender
+		if !p.DateOfBirth.IsZero() {
+			age := r.OnsetDate.Year() - p.DateOfBirth.Year()
+			if r.OnsetDate.Month() < p.DateOfBirth.Month() ||
+				(r.OnsetDate.Month() == p.DateOfBirth.Month() && r.OnsetDate.Day() < p.DateOfBirth.Day()) {
+				age--
+			}
+			row["age_years"] = strconv.Itoa(age)
+		}
+		row["pregnancy_status"] = p.PregnancyStatus
+	}
+	row["reaction_description"] = r.Reaction
+	row["onset_date"] = formDate(&r.OnsetDate)
+	row["severity"] = r.Severity
+	row["serious"] = "no"
+	if r.Serious {
+		row["serious"] = "yes"
+	}
+	row["outcome"] = r.Outcome
+	row["outcome_date"] = formDate(r.OutcomeDate)
+	row["suspected_drug"] = r.SuspectedDrug
+	if l := r.PrescriptionLine; l != nil {
+		if l.Drug != nil {
+			row["generic_name"] = l.Drug.GenericName
+			row["atc_code"] = l.Drug.ATCCode
+		}
+		row["strength"], row["dosage_form"], row["dose"] = l.Strength, l.Form, l.Dose
+		row["route"], row["frequency"] = l.Route, PrescriptionFrequencies[l.Frequency]
+		row["therapy_start_date"] = formDate(l.StartDate)
+		row["therapy_stop_date"] = formDate(l.StopDate)
+	}
+	if r.Session != nil && r.Session.Diagnosis.ID != 0 {
+		row["indication"] = r.Session.Diagnosis.Severity
+	}
+	row["action_taken"] = r.ActionTaken
+	if w := r.ReportedBy; w != nil {
+		row["reporter_name"] = strings.TrimSpace(w.FirstName + " " + w.LastName)
+		row["reporter_profession"] = w.PersonnelType.Name
+		row["reporter_contact"] = w.Email
+	}
+	row["health_facility"] = facility
+
+	out := make([]string, len(ADRFormColumns))
+	for i, col := range ADRFormColumns {
+		out[i] = row[col]
+	}
+	return out
+}
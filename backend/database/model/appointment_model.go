@@ -0,0 +1,212 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Appointment statuses. A booked appointment is checked in when the patient arrives, which opens
+// its session, or it is cancelled, or rescheduled, which replaces it with a new booking. One never
+// checked in is marked missed by the missed follow-up job.
+const (
+	AppointmentBooked      = "booked"
+	AppointmentCheckedIn   = "checked_in"
+	AppointmentCancelled   = "cancelled"
+	AppointmentRescheduled = "rescheduled"
+	AppointmentMissed      = "missed"
+)
+
+// DefaultSlotMinutes is the appointment length used when working hours do not set one
+const DefaultSlotMinutes = 30
+
+// WorkingHours is a weekly block in which a health worker sees patients, e.g. Monday 08:00-13:00,
+// split into bookable slots. Times are "HH:MM" in the clinic's local time.
+type WorkingHours struct {
+	gorm.Model
+	HealthWorkerID uint         `gorm:"not null;index" json:"health_worker_id"`
+	Weekday        time.Weekday `gorm:"not null" json:"weekday"` // 0 is Sunday
+	StartTime      string       `gorm:"size:5;not null" json:"start_time"`
+	EndTime        string       `gorm:"size:5;not null" json:"end_time"`
+	SlotMinutes    int          `gorm:"not null;default:30" json:"slot_minutes"`
+}
+
+// HealthWorkerLeave is a period in which a health worker takes no appointments
+type HealthWorkerLeave struct {
+	gorm.Model
+	HealthWorkerID uint          `gorm:"not null;index" json:"health_worker_id"`
+	HealthWorker   *HealthWorker `gorm:"foreignKey:HealthWorkerID" json:"health_worker,omitempty"`
+	StartsAt       time.Time     `gorm:"not null;index" json:"starts_at"`
+	EndsAt         time.Time     `gorm:"not null" json:"ends_at"`
+	Reason         string        `gorm:"type:text" json:"reason"`
+	RecordedByID   *uint         `json:"recorded_by_id"`
+}
+
+// Appointment books a patient into a health worker's time. Checking it in opens the follow-up
+// session, linked to the session the appointment was booked from.
+type Appointment struct {
+	gorm.Model
+	PatientID         uint          `gorm:"not null;index" json:"patient_id"`
+	Patient           *Patient      `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	HealthWorkerID    uint          `gorm:"not null;index" json:"health_worker_id"`
+	HealthWorker      *HealthWorker `gorm:"foreignKey:HealthWorkerID" json:"health_worker,omitempty"`
+	DepartmentID      uint          `gorm:"index" json:"department_id"` // the health worker's department when booked
+	StartsAt          time.Time     `gorm:"not null;index" json:"starts_at"`
+	EndsAt            time.Time     `gorm:"not null" json:"ends_at"`
+	Status            string        `gorm:"not null;default:'booked';index" json:"status"`
+	Purpose           string        `gorm:"type:text" json:"purpose"`
+	PreviousSessionID *uint         `gorm:"index" json:"previous_session_id"` // the session that asked for this follow-up
+	PreviousSession   *Session      `gorm:"foreignKey:PreviousSessionID" json:"previous_session,omitempty"`
+	SessionID         *uint         `gorm:"uniqueIndex" json:"session_id"` // opened at check-in
+	Session           *Session      `gorm:"foreignKey:SessionID" json:"session,omitempty"`
+	RescheduledFromID *uint         `gorm:"index" json:"rescheduled_from_id"`
+	BookedByID        *uint         `json:"booked_by_id"`
+	StatusReason      string        `gorm:"type:text" json:"status_reason"` // why it was cancelled or rescheduled
+	StatusChangedByID *uint         `json:"status_changed_by_id"`
+	StatusChangedAt   *time.Time    `json:"status_changed_at"`
+}
+
+// AppointmentSlot is a bookable period of a health worker's time
+type AppointmentSlot struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// CalendarDay is one health worker's day: when they work, whether they are away, what is booked
+// and what is still free
+type CalendarDay struct {
+	Date         string              `json:"date"` // YYYY-MM-DD
+	WorkingHours []WorkingHours      `json:"working_hours"`
+	Leave        []HealthWorkerLeave `json:"leave,omitempty"`
+	Appointments []Appointment       `json:"appointments"`
+	FreeSlots    []AppointmentSlot   `json:"free_slots"`
+}
+
+// WorkerCalendar is a health worker's days within a calendar
+type WorkerCalendar struct {
+	HealthWorker HealthWorker  `json:"health_worker"`
+	Days         []CalendarDay `json:"days"`
+}
+
+// Calendar shows one or more health workers over a day or a week
+type Calendar struct {
+	From    time.Time        `json:"from"`
+	To      time.Time        `json:"to"`
+	Workers []WorkerCalendar `json:"workers"`
+}
+
+// IsActive reports whether an appointment still holds its time
+func (a *Appointment) IsActive() bool {
+	return a.Status == AppointmentBooked || a.Status == AppointmentCheckedIn
+}
+
+// Overlaps reports whether two periods share any time; touching ends do not count
+func Overlaps(aStart, aEnd, bStart, bEnd time.Time) bool {
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
+
+// parseClock turns "HH:MM" into minutes after midnight
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time of day (HH:MM)", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Validate checks a block of working hours
+func (h *WorkingHours) Validate() error {
+	if h.Weekday < time.Sunday || h.Weekday > time.Saturday {
+		return errors.New("weekday must be between 0 (Sunday) and 6 (Saturday)")
+	}
+	start, err := parseClock(h.StartTime)
+	if err != nil {
+		return err
+	}
+	end, err := parseClock(h.EndTime)
+	if err != nil {
+		return err
+	}
+	if start >= end {
+		return fmt.Errorf("working hours on %s must end after they start", h.Weekday)
+	}
+	if h.SlotMinutes == 0 {
+		h.SlotMinutes = DefaultSlotMinutes
+	}
+	if h.SlotMinutes < 5 || h.SlotMinutes > end-start {
+		return fmt.Errorf("slots of %d minutes do not fit working hours %s-%s", h.SlotMinutes, h.StartTime, h.EndTime)
+	}
+	return nil
+}
+
+// On returns the start and end of the block on the given day, which must fall on its weekday
+func (h *WorkingHours) On(day time.Time) (time.Time, time.Time) {
+	start, _ := parseClock(h.StartTime)
+	end, _ := parseClock(h.EndTime)
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	return midnight.Add(time.Duration(start) * time.Minute), midnight.Add(time.Duration(end) * time.Minute)
+}
+
+// ValidateWeek checks that no two blocks of a weekly schedule overlap
+func ValidateWeek(hours []WorkingHours) error {
+	sorted := append([]WorkingHours(nil), hours...)
+	sort.Slice(sorted, func(a, b int) bool {
+		if sorted[a].Weekday != sorted[b].Weekday {
+			return sorted[a].Weekday < sorted[b].Weekday
+		}
+		return sorted[a].StartTime < sorted[b].StartTime
+	})
+	for idx := range sorted {
+		if err := sorted[idx].Validate(); err != nil {
+			return err
+		}
+		if idx > 0 && sorted[idx].Weekday == sorted[idx-1].Weekday && sorted[idx].StartTime < sorted[idx-1].EndTime {
+			return fmt.Errorf("working hours %s-%s and %s-%s on %s overlap",
+				sorted[idx-1].StartTime, sorted[idx-1].EndTime, sorted[idx].StartTime, sorted[idx].EndTime, sorted[idx].Weekday)
+		}
+	}
+	return nil
+}
+
+// FreeSlots lists the slots of a day that are within working hours, not on leave, not taken by an
+// active appointment and not yet past. Appointments and leave may cover other days too.
+func FreeSlots(day time.Time, hours []WorkingHours, leave []HealthWorkerLeave, appointments []Appointment, now time.Time) []AppointmentSlot {
+	slots := []AppointmentSlot{}
+	for idx := range hours {
+		block := &hours[idx]
+		if block.Weekday != day.Weekday() {
+			continue
+		}
+		step := time.Duration(block.SlotMinutes) * time.Minute
+		if step <= 0 {
+			step = DefaultSlotMinutes * time.Minute
+		}
+		opens, closes := block.On(day)
+		for start := opens; !start.Add(step).After(closes); start = start.Add(step) {
+			end := start.Add(step)
+			if start.Before(now) || slotTaken(start, end, leave, appointments) {
+				continue
+			}
+			slots = append(slots, AppointmentSlot{StartsAt: start, EndsAt: end})
+		}
+	}
+	sort.Slice(slots, func(a, b int) bool { return slots[a].StartsAt.Before(slots[b].StartsAt) })
+	return slots
+}
+
+func slotTaken(start, end time.Time, leave []HealthWorkerLeave, appointments []Appointment) bool {
+	for _, l := range leave {
+		if Overlaps(start, end, l.StartsAt, l.EndsAt) {
+			return true
+		}
+	}
+	for idx := range appointments {
+		if appointments[idx].IsActive() && Overlaps(start, end, appointments[idx].StartsAt, appointments[idx].EndsAt) {
+			return true
+		}
+	}
+	return false
+}
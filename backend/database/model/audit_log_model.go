@@ -0,0 +1,101 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Audit actions
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+	AuditActionRead   = "read"
+)
+
+// Audited entities, named after their tables
+const (
+	AuditEntityPatient           = "patients"
+	AuditEntitySession           = "sessions"
+	AuditEntityDiagnosis         = "diagnoses"
+	AuditEntitySessionSummary    = "session_summaries"
+	AuditEntityMedicationHistory = "medication_histories"
+	AuditEntityAppointment       = "appointments"
+	AuditEntityPrescriptionLine  = "prescription_lines"
+	AuditEntityPatientAllergy    = "patient_allergies"
+	AuditEntityDispensingEvent   = "dispensing_events"
+	AuditEntityAdherenceCheckIn  = "adherence_check_ins"
+	AuditEntityAdverseReaction   = "adverse_reactions"
+)
+
+// AuditedEntities lists every table whose reads and writes are recorded in the audit trail
+var AuditedEntities = []string{
+	AuditEntityPatient,
+	AuditEntitySession,
+	AuditEntityDiagnosis,
+	AuditEntitySessionSummary,
+	AuditEntityMedicationHistory,
+	AuditEntityAppointment,
+	AuditEntityPrescriptionLine,
+	AuditEntityPatientAllergy,
+	AuditEntityDispensingEvent,
+	AuditEntityAdherenceCheckIn,
+	AuditEntityAdverseReaction,
+}
+
+// AuditLog is an append-only record of who read or changed patient data. Each entry carries the
+// hash of the entry before it, so editing or deleting any row breaks the chain. It deliberately
+// has no UpdatedAt or DeletedAt.
+type AuditLog struct {
+	ID         uint          `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time     `gorm:"not null;index" json:"created_at"`
+	ActorID    *uint         `gorm:"index" json:"actor_id"` // nil for writes made outside a request (seeders, jobs)
+	Actor      *HealthWorker `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	Action     string        `gorm:"size:10;not null;index" json:"action"`
+	EntityType string        `gorm:"size:50;not null;index:idx_audit_entity" json:"entity_type"`
+	EntityID   uint          `gorm:"index:idx_audit_entity" json:"entity_id"` // 0 for list and search reads
+	PatientID  *uint         `gorm:"index" json:"patient_id"`
+	Changes    string        `gorm:"type:text" json:"changes,omitempty"` // JSON before/after diff for writes
+	Path       string        `json:"path,omitempty"`
+	IPAddress  string        `gorm:"size:45" json:"ip_address"`
+	RequestID  string        `gorm:"size:64;index" json:"request_id"`
+	PrevHash   string        `gorm:"size:64" json:"prev_hash"`
+	Hash       string        `gorm:"size:64;not null;uniqueIndex" json:"hash"`
+}
+
+// ComputeHash hashes the entry's content together with the previous entry's hash
+func (a *AuditLog) ComputeHash() string {
+	optional := func(id *uint) string {
+		if id == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*id), 10)
+	}
+
+	payload := strings.Join([]string{
+		a.PrevHash,
+		a.CreatedAt.UTC().Format(time.RFC3339Nano),
+		optional(a.ActorID),
+		a.Action,
+		a.EntityType,
+		strconv.FormatUint(uint64(a.EntityID), 10),
+		optional(a.PatientID),
+		a.Changes,
+		a.Path,
+		a.IPAddress,
+		a.RequestID,
+	}, "|")
+
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditChainStatus is the outcome of verifying the audit hash chain (not persisted)
+type AuditChainStatus struct {
+	Valid      bool  `json:"valid"`
+	Checked    int   `json:"checked"`
+	BrokenAtID *uint `json:"broken_at_id,omitempty"`
+}
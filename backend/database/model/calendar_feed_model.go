@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CalendarFeedToken lets a health worker's calendar app subscribe to their schedule without logging
+// in. Only the SHA-256 hash of the token is stored; issuing a new token replaces the old one.
+type CalendarFeedToken struct {
+	gorm.Model
+	HealthWorkerID uint         `gorm:"not null;uniqueIndex" json:"health_worker_id"`
+	HealthWorker   HealthWorker `gorm:"foreignKey:HealthWorkerID" json:"-"`
+	TokenHash      string       `gorm:"not null;uniqueIndex" json:"-"`
+	LastUsedAt     *time.Time   `json:"last_used_at"`
+}
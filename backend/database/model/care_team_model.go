@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Care team roles
+const (
+	CareTeamRolePrimary = "primary"
+	CareTeamRoleMember  = "member"
+)
+
+// CareTeamMember assigns a health worker to a patient's care team
+type CareTeamMember struct {
+	gorm.Model
+	PatientID      uint         `gorm:"not null;uniqueIndex:idx_care_team_member" json:"patient_id"`
+	Patient        *Patient     `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	HealthWorkerID uint         `gorm:"not null;uniqueIndex:idx_care_team_member;index" json:"health_worker_id"`
+	HealthWorker   HealthWorker `gorm:"foreignKey:HealthWorkerID" json:"health_worker"`
+	Role           string       `gorm:"not null;default:'member'" json:"role"` // primary, member
+	AssignedByID   *uint        `json:"assigned_by_id"`
+}
+
+// DepartmentAccessRule lets health workers of a department (optionally only one personnel type)
+// see every patient registered to that department
+type DepartmentAccessRule struct {
+	gorm.Model
+	DepartmentID  uint       `gorm:"not null;index" json:"department_id"`
+	Department    Department `gorm:"foreignKey:DepartmentID" json:"-"`
+	PersonnelType string     `json:"personnel_type"` // lower case; empty means every personnel type
+}
+
+// BreakGlassAccess is an emergency, time-limited override of patient access control.
+// Every use is kept for supervisor review.
+type BreakGlassAccess struct {
+	gorm.Model
+	HealthWorkerID uint          `gorm:"not null;index" json:"health_worker_id"`
+	HealthWorker   HealthWorker  `gorm:"foreignKey:HealthWorkerID" json:"health_worker"`
+	PatientID      uint          `gorm:"not null;index" json:"patient_id"`
+	Patient        Patient       `gorm:"foreignKey:PatientID" json:"patient"`
+	Reason         string        `gorm:"type:text;not null" json:"reason"`
+	ExpiresAt      time.Time     `gorm:"not null" json:"expires_at"`
+	ReviewedByID   *uint         `json:"reviewed_by_id"`
+	ReviewedBy     *HealthWorker `gorm:"foreignKey:ReviewedByID" json:"reviewed_by,omitempty"`
+	ReviewedAt     *time.Time    `json:"reviewed_at"`
+	ReviewNotes    string        `gorm:"type:text" json:"review_notes"`
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of group channel
+const (
+	ChannelDepartment = "department" // every active health worker of a department, kept in sync
+	ChannelGroup      = "group"      // ad-hoc group created by a health worker
+	ChannelCareTeam   = "care_team"  // a patient's care team, kept in sync
+)
+
+// Roles of a channel member
+const (
+	ChannelRoleAdmin  = "admin" // may add and remove members, mute them and delete messages
+	ChannelRoleMember = "member"
+)
+
+// Channel is a group conversation. Department and care-team channels take their members from the
+// department and the care team; members of ad-hoc groups join, leave or are added by its admins.
+type Channel struct {
+	gorm.Model
+	Kind         string          `gorm:"size:20;not null;index" json:"kind"`
+	Name         string          `gorm:"not null" json:"name"`
+	Description  string          `gorm:"type:text" json:"description"`
+	DepartmentID *uint           `gorm:"uniqueIndex" json:"department_id"`
+	Department   *Department     `gorm:"foreignKey:DepartmentID" json:"department,omitempty"`
+	PatientID    *uint           `gorm:"uniqueIndex" json:"patient_id"`
+	Patient      *Patient        `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	Open         bool            `json:"open"` // an ad-hoc group anyone may join
+	ArchivedAt   *time.Time      `json:"archived_at"`
+	CreatedByID  *uint           `json:"created_by_id"`
+	Members      []ChannelMember `gorm:"foreignKey:ChannelID" json:"members,omitempty"`
+}
+
+// Automatic reports whether the channel's members follow a department or care team
+func (c *Channel) Automatic() bool {
+	return c.Kind == ChannelDepartment || c.Kind == ChannelCareTeam
+}
+
+// ChannelMember is a health worker in a channel
+type ChannelMember struct {
+	gorm.Model
+	ChannelID      uint          `gorm:"not null;uniqueIndex:idx_channel_member" json:"channel_id"`
+	HealthWorkerID uint          `gorm:"not null;uniqueIndex:idx_channel_member;index" json:"health_worker_id"`
+	HealthWorker   *HealthWorker `gorm:"foreignKey:HealthWorkerID" json:"health_worker,omitempty"`
+	Role           string        `gorm:"size:20;not null;default:'member'" json:"role"`
+	MutedUntil     *time.Time    `json:"muted_until"` // may not post until then
+	AddedByID      *uint         `json:"added_by_id"`
+}
+
+// Muted reports whether the member may not post at now
+func (m *ChannelMember) Muted(now time.Time) bool {
+	return m.MutedUntil != nil && m.MutedUntil.After(now)
+}
+
+// ChannelThread is a channel a health worker belongs to with its latest message and how many
+// messages they have not read
+type ChannelThread struct {
+	Channel     *Channel `json:"channel"`
+	Role        string   `json:"role"`
+	LastMessage *Message `json:"last_message"`
+	UnreadCount int64    `json:"unread_count"`
+}
+
+// MessageDeletedPayload says a moderator removed a channel message
+type MessageDeletedPayload struct {
+	MessageID uint `json:"message_id"`
+	ChannelID uint `json:"channel_id"`
+}
@@ -6,6 +6,8 @@ type Department struct {
 	gorm.Model
 	Name          string          `gorm:"not null;uniqueIndex" json:"name"`
 	Description   string          `json:"description"`
+	OnCallPsychiatristID *uint    `json:"on_call_psychiatrist_id"` // notified of suicide-risk alerts
+	DefaultNoteTemplateID *uint   `json:"default_note_template_id"` // used for session summaries of the department's patients
 	HealthWorkers []HealthWorker  `gorm:"foreignKey:DepartmentID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"health_workers"`
 }
 
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DeviceSession is one signed-in device of a health worker. All refresh tokens rotated from the same
+// login belong to the same session (token family), so revoking the session revokes the whole family.
+type DeviceSession struct {
+	gorm.Model
+	HealthWorkerID uint         `gorm:"not null;index" json:"health_worker_id"`
+	HealthWorker   HealthWorker `gorm:"foreignKey:HealthWorkerID" json:"-"`
+	DeviceName     string       `json:"device_name"`
+	UserAgent      string       `json:"user_agent"`
+	IPAddress      string       `json:"ip_address"`
+	LastUsedAt     time.Time    `json:"last_used_at"`
+	RevokedAt      *time.Time   `json:"revoked_at"`
+	RevokedReason  string       `json:"revoked_reason,omitempty"`
+
+	RefreshTokens []RefreshToken `gorm:"foreignKey:DeviceSessionID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// RefreshToken is a single-use opaque token; only its SHA-256 hash is stored
+type RefreshToken struct {
+	gorm.Model
+	DeviceSessionID uint       `gorm:"not null;index" json:"device_session_id"`
+	TokenHash       string     `gorm:"not null;uniqueIndex" json:"-"`
+	ExpiresAt       time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt          *time.Time `json:"used_at"` // set when rotated; presenting it again is treated as reuse
+}
+
+// TokenPair is returned to clients on login and refresh (not persisted)
+type TokenPair struct {
+	AccessToken      string        `json:"access_token"`
+	AccessExpiresAt  time.Time     `json:"access_expires_at"`
+	RefreshToken     string        `json:"refresh_token"`
+	RefreshExpiresAt time.Time     `json:"refresh_expires_at"`
+	Session          DeviceSession `json:"session"`
+}
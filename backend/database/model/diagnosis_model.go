@@ -4,6 +4,15 @@ import (
 	"gorm.io/gorm"
 )
 
+// PHQ-9 severity bands
+const (
+	SeverityNone             = "Minimal/No Depression"        // 0–4
+	SeverityMild             = "Mild Depression"              // 5–9
+	SeverityModerate         = "Moderate Depression"          // 10–14
+	SeverityModeratelySevere = "Moderately Severe Depression" // 15–19
+	SeveritySevere           = "Severe Depression"            // 20–27
+)
+
 type Diagnosis struct {
 	gorm.Model
 	SessionID  		uint			`gorm:"not null;index" json:"session_id"`
@@ -11,4 +20,3 @@ type Diagnosis struct {
 	Phq9Score  		int				`gorm:"not null" json:"phq9_score"`
 	Severity   		string			`gorm:"not null" json:"severity"`
 }
-
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of rows in the interaction table
+const (
+	InteractionDrug      = "drug"      // SubjectA and SubjectB are drugs taken together
+	InteractionPregnancy = "pregnancy" // SubjectA is a drug, SubjectB a PregnancyStatus
+)
+
+// Severities of an interaction or contraindication, mildest first
+const (
+	InteractionMinor    = "minor"
+	InteractionModerate = "moderate"
+	InteractionSevere   = "severe"
+)
+
+var interactionSeverities = []string{InteractionMinor, InteractionModerate, InteractionSevere}
+
+// Pregnancy statuses a patient can be recorded with
+const (
+	PregnancyStatusPregnant      = "pregnant"
+	PregnancyStatusBreastfeeding = "breastfeeding"
+)
+
+// Kinds of prescribing safety warnings
+const (
+	SafetyWarningInteraction = "interaction"
+	SafetyWarningAllergy     = "allergy"
+	SafetyWarningIntolerance = "intolerance"
+	SafetyWarningPregnancy   = "pregnancy"
+)
+
+// Kinds of PatientAllergy
+const (
+	AllergyKindAllergy     = "allergy"     // immune reaction: the drug must not be given again
+	AllergyKindIntolerance = "intolerance" // a side effect the patient does not tolerate
+)
+
+// DrugInteraction is a row of the locally loaded interaction table. A subject names a drug by its
+// generic name ("lithium") or by an ATC code prefix ("N06AB" for all SSRIs, "M01A" for NSAIDs).
+type DrugInteraction struct {
+	gorm.Model
+	Kind        string `gorm:"size:20;not null;uniqueIndex:idx_interaction" json:"kind"`
+	SubjectA    string `gorm:"not null;uniqueIndex:idx_interaction" json:"subject_a"`
+	SubjectB    string `gorm:"not null;uniqueIndex:idx_interaction" json:"subject_b"`
+	Severity    string `gorm:"size:10;not null" json:"severity"`
+	Description string `gorm:"type:text" json:"description"`
+}
+
+// PatientAllergy is a drug or substance a patient is known to react to. Allergies raise severe
+// prescribing warnings, intolerances moderate ones.
+type PatientAllergy struct {
+	gorm.Model
+	PatientID         uint          `gorm:"not null;index" json:"patient_id"`
+	Kind              string        `gorm:"size:20;not null;default:'allergy'" json:"kind"`
+	Substance         string        `gorm:"not null" json:"substance"` // drug name, generic name, ATC code prefix or class
+	Reaction          string        `json:"reaction"`
+	AdverseReactionID *uint         `json:"adverse_reaction_id"` // the reported reaction it was recorded from
+	RecordedByID      *uint         `json:"recorded_by_id"`
+	RecordedBy        *HealthWorker `gorm:"foreignKey:RecordedByID" json:"recorded_by,omitempty"`
+}
+
+// SafetyWarning is a problem found when a prescription is checked against the patient's other
+// active medications, allergies and pregnancy status
+type SafetyWarning struct {
+	Kind        string `json:"kind"`
+	Severity    string `json:"severity"`
+	Drug        string `json:"drug"`
+	With        string `json:"with"` // the other drug, the allergy or the pregnancy status
+	Description string `json:"description"`
+}
+
+// ValidAllergyKind reports whether kind is a PatientAllergy kind
+func ValidAllergyKind(kind string) bool {
+	return kind == AllergyKindAllergy || kind == AllergyKindIntolerance
+}
+
+// ValidInteractionSeverity reports whether s is a known severity
+func ValidInteractionSeverity(s string) bool {
+	_, ok := matchListEntry(interactionSeverities, s)
+	return ok
+}
+
+// ValidPregnancyStatus reports whether s is a pregnancy status, or empty for none
+func ValidPregnancyStatus(s string) bool {
+	return s == "" || s == PregnancyStatusPregnant || s == PregnancyStatusBreastfeeding
+}
+
+// SubjectMatches reports whether an interaction subject or allergy substance names drug: its
+// name, generic name or ATC class, or a prefix of its ATC code
+func SubjectMatches(subject string, drug *Drug) bool {
+	subject = strings.TrimSpace(subject)
+	if subject == "" || drug == nil {
+		return false
+	}
+	if strings.EqualFold(subject, drug.Name) || strings.EqualFold(subject, drug.GenericName) ||
+		(drug.ATCClass != "" && strings.EqualFold(subject, drug.ATCClass)) {
+		return true
+	}
+	return drug.ATCCode != "" && len(subject) >= 3 && strings.HasPrefix(drug.ATCCode, strings.ToUpper(subject))
+}
+
+// Between reports whether a drug-drug interaction applies to a and b, in either order
+func (i *DrugInteraction) Between(a, b *Drug) bool {
+	if i.Kind != InteractionDrug {
+		return false
+	}
+	return (SubjectMatches(i.SubjectA, a) && SubjectMatches(i.SubjectB, b)) ||
+		(SubjectMatches(i.SubjectA, b) && SubjectMatches(i.SubjectB, a))
+}
+
+// HasSevereWarning reports whether any warning is severe enough to need an override reason
+func HasSevereWarning(warnings []SafetyWarning) bool {
+	for _, w := range warnings {
+		if w.Severity == InteractionSevere {
+			return true
+		}
+	}
+	return false
+}
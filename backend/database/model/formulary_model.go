@@ -0,0 +1,209 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Drug is an entry of the local formulary. Strengths and forms are the preparations stocked, e.g.
+// ["10 mg", "20 mg"] and ["capsule", "oral solution"].
+type Drug struct {
+	gorm.Model
+	Name        string          `gorm:"not null;uniqueIndex" json:"name"`
+	GenericName string          `gorm:"not null;index" json:"generic_name"`
+	ATCCode     string          `gorm:"size:7;index" json:"atc_code"` // WHO ATC code, e.g. N06AB03
+	ATCClass    string          `json:"atc_class"`                    // name of the code's chemical subgroup
+	Strengths   json.RawMessage `gorm:"type:jsonb" json:"strengths"`  // JSON array of strings
+	Forms       json.RawMessage `gorm:"type:jsonb" json:"forms"`      // JSON array of strings
+	IsActive    bool            `gorm:"default:true" json:"is_active"`
+}
+
+// Routes of administration a prescription line can use
+const (
+	RouteOral          = "oral"
+	RouteSublingual    = "sublingual"
+	RouteIntramuscular = "intramuscular"
+	RouteIntravenous   = "intravenous"
+	RouteSubcutaneous  = "subcutaneous"
+	RouteTopical       = "topical"
+	RouteRectal        = "rectal"
+	RouteInhaled       = "inhaled"
+)
+
+var prescriptionRoutes = []string{RouteOral, RouteSublingual, RouteIntramuscular, RouteIntravenous, RouteSubcutaneous, RouteTopical, RouteRectal, RouteInhaled}
+
+// PrescriptionFrequencies maps the frequency codes a prescription line can use to their meaning
+var PrescriptionFrequencies = map[string]string{
+	"OD":     "once daily",
+	"BD":     "twice daily",
+	"TDS":    "three times daily",
+	"QID":    "four times daily",
+	"MANE":   "every morning",
+	"NOCTE":  "at night",
+	"WEEKLY": "once weekly",
+	"PRN":    "when required",
+	"STAT":   "once, immediately",
+}
+
+// PrescriptionLine is one drug on a prescription. It belongs to a MedicationHistory entry or to a
+// Session's current prescription. Text written before prescriptions were structured is kept in an
+// unstructured line, which has no drug.
+type PrescriptionLine struct {
+	gorm.Model
+	PatientID           uint          `gorm:"not null;index" json:"patient_id"`
+	Patient             *Patient      `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	MedicationHistoryID *uint         `gorm:"index" json:"medication_history_id,omitempty"`
+	SessionID           *uint         `gorm:"index" json:"session_id,omitempty"`
+	Unstructured        bool          `gorm:"default:false" json:"unstructured"`
+	Text                string        `gorm:"type:text" json:"text,omitempty"` // the free text of an unstructured line
+	DrugID              *uint         `gorm:"index" json:"drug_id"`
+	Drug                *Drug         `gorm:"foreignKey:DrugID" json:"drug,omitempty"`
+	Strength            string        `json:"strength"` // one of the drug's strengths
+	Form                string        `json:"form"`     // one of the drug's forms
+	Dose                string        `json:"dose"`     // amount per administration, e.g. "1 capsule"
+	Route               string        `json:"route"`
+	Frequency           string        `json:"frequency"` // a PrescriptionFrequencies code
+	DurationDays        int           `json:"duration_days"`
+	StartDate           *time.Time    `json:"start_date"`
+	StopDate            *time.Time    `json:"stop_date"`     // nil while the drug is continued
+	PrescriberID        *uint         `json:"prescriber_id"` // nil when prescribed outside the facility
+	Prescriber          *HealthWorker `gorm:"foreignKey:PrescriberID" json:"prescriber,omitempty"`
+}
+
+// StringList decodes a JSON array of strings such as Drug.Strengths
+func StringList(raw json.RawMessage) []string {
+	var list []string
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &list)
+	}
+	return list
+}
+
+// normalizeStrength lets "20mg" match "20 mg"
+func normalizeStrength(s string) string {
+	return strings.ToLower(strings.ReplaceAll(s, " ", ""))
+}
+
+// matchListEntry returns the entry of list that value names, ignoring case and, for strengths,
+// spacing
+func matchListEntry(list []string, value string) (string, bool) {
+	for _, entry := range list {
+		if normalizeStrength(entry) == normalizeStrength(value) {
+			return entry, true
+		}
+	}
+	return "", false
+}
+
+// Validate checks a formulary entry and tidies its lists
+func (d *Drug) Validate() error {
+	d.Name = strings.TrimSpace(d.Name)
+	d.GenericName = strings.ToLower(strings.TrimSpace(d.GenericName))
+	d.ATCCode = strings.ToUpper(strings.TrimSpace(d.ATCCode))
+	if d.Name == "" || d.GenericName == "" {
+		return errors.New("name and generic name are required")
+	}
+	if d.ATCCode != "" && len(d.ATCCode) != 7 {
+		return fmt.Errorf("ATC code %q must have 7 characters", d.ATCCode)
+	}
+	for field, raw := range map[string]json.RawMessage{"strengths": d.Strengths, "forms": d.Forms} {
+		var list []string
+		if err := json.Unmarshal(raw, &list); err != nil || len(list) == 0 {
+			return fmt.Errorf("%s must be a non-empty list of strings", field)
+		}
+	}
+	return nil
+}
+
+// ActiveOn reports whether the line's drug is being taken on day
+func (l *PrescriptionLine) ActiveOn(day time.Time) bool {
+	if l.StartDate != nil && day.Before(*l.StartDate) {
+		return false
+	}
+	return l.StopDate == nil || day.Before(*l.StopDate)
+}
+
+// Validate checks a structured line against its drug, which must be loaded, and fills in the
+// formulary's spelling of strength and form. A duration without a stop date sets the stop date.
+func (l *PrescriptionLine) Validate() error {
+	if l.Unstructured {
+		if strings.TrimSpace(l.Text) == "" {
+			return errors.New("an unstructured prescription line needs its text")
+		}
+		return nil
+	}
+	if l.Drug == nil {
+		return errors.New("a prescription line needs a drug from the formulary")
+	}
+	if !l.Drug.IsActive {
+		return fmt.Errorf("%s is no longer in the formulary", l.Drug.Name)
+	}
+
+	strength, ok := matchListEntry(StringList(l.Drug.Strengths), l.Strength)
+	if !ok {
+		return fmt.Errorf("%s is not stocked in strength %q", l.Drug.Name, l.Strength)
+	}
+	l.Strength = strength
+	form, ok := matchListEntry(StringList(l.Drug.Forms), l.Form)
+	if !ok {
+		return fmt.Errorf("%s is not stocked as %q", l.Drug.Name, l.Form)
+	}
+	l.Form = form
+
+	l.Dose = strings.TrimSpace(l.Dose)
+	if l.Dose == "" {
+		return fmt.Errorf("a dose is required for %s", l.Drug.Name)
+	}
+	l.Route = strings.ToLower(strings.TrimSpace(l.Route))
+	if _, ok := matchListEntry(prescriptionRoutes, l.Route); !ok {
+		return fmt.Errorf("unknown route %q", l.Route)
+	}
+	l.Frequency = strings.ToUpper(strings.TrimSpace(l.Frequency))
+	if _, ok := PrescriptionFrequencies[l.Frequency]; !ok {
+		return fmt.Errorf("unknown frequency %q", l.Frequency)
+	}
+	if l.DurationDays < 0 {
+		return errors.New("duration cannot be negative")
+	}
+
+	if l.StartDate == nil {
+		now := time.Now()
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+		l.StartDate = &start
+	}
+	if l.StopDate == nil && l.DurationDays > 0 {
+		stop := l.StartDate.AddDate(0, 0, l.DurationDays)
+		l.StopDate = &stop
+	}
+	if l.StopDate != nil && l.StopDate.Before(*l.StartDate) {
+		return errors.New("stop date is before the start date")
+	}
+	return nil
+}
+
+// Describe writes the line out as prescription text, e.g. "Fluoxetine 20 mg capsule: 1 capsule
+// oral, once daily for 30 days"
+func (l *PrescriptionLine) Describe() string {
+	if l.Unstructured || l.Drug == nil {
+		return l.Text
+	}
+	text := fmt.Sprintf("%s %s %s: %s %s, %s", l.Drug.Name, l.Strength, l.Form, l.Dose, l.Route, PrescriptionFrequencies[l.Frequency])
+	if l.DurationDays > 0 {
+		text += fmt.Sprintf(" for %d days", l.DurationDays)
+	}
+	return text
+}
+
+// DescribePrescription writes out every line of a prescription, one per line
+func DescribePrescription(lines []PrescriptionLine) string {
+	texts := make([]string, 0, len(lines))
+	for i := range lines {
+		texts = append(texts, lines[i].Describe())
+	}
+	return strings.Join(texts, "\n")
+}
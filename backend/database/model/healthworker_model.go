@@ -1,6 +1,10 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 const (
 	RoleAdmin       = "admin"
@@ -29,5 +33,7 @@ type HealthWorker struct {
 	Supervisor      *HealthWorker `gorm:"foreignKey:SupervisorID"`
 	Role 			string 		 `gorm:"default:'healthworker'" json:"role"` 
 	PasswordHash    string       `json:"-"` // stored as hash
+	MustChangePassword bool       `gorm:"default:false" json:"must_change_password"` // forced rotation on next login
+	PasswordChangedAt  *time.Time `json:"password_changed_at"`
 	IsActive bool `gorm:"default:true" json:"is_active"`
 }
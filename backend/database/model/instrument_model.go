@@ -0,0 +1,280 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Codes of the instruments installed by default
+const (
+	InstrumentPHQ9  = "PHQ-9"
+	InstrumentPHQ2  = "PHQ-2"
+	InstrumentGAD7  = "GAD-7"
+	InstrumentEPDS  = "EPDS"
+	InstrumentAUDIT = "AUDIT"
+)
+
+// Instrument version statuses
+const (
+	InstrumentVersionDraft      = "draft"
+	InstrumentVersionPublished  = "published"
+	InstrumentVersionSuperseded = "superseded"
+)
+
+// Instrument is a scored questionnaire such as PHQ-9, GAD-7 or AUDIT. Its wording and scoring live
+// in versions; new responses are recorded against the published one.
+type Instrument struct {
+	gorm.Model
+	Code           string              `gorm:"not null;uniqueIndex" json:"code"`
+	Name           string              `gorm:"not null" json:"name"`
+	Description    string              `gorm:"type:text" json:"description"`
+	IsActive       bool                `gorm:"default:true" json:"is_active"`
+	Versions       []InstrumentVersion `gorm:"foreignKey:InstrumentID" json:"versions,omitempty"`
+	CurrentVersion *InstrumentVersion  `gorm:"-" json:"current_version,omitempty"` // the published version
+}
+
+// InstrumentVersion is one edition of an instrument's items, answer options and severity bands.
+// A response's total is the sum of the scores of the chosen options, and its severity is the band
+// the total falls in; reverse-scored items (e.g. in EPDS) list their own options with descending
+// scores. Drafts can be edited; once published a version never changes, so responses pinned to it
+// keep their meaning. Publishing a draft supersedes the previously published version.
+type InstrumentVersion struct {
+	gorm.Model
+	InstrumentID  uint                     `gorm:"not null;uniqueIndex:idx_instrument_version" json:"instrument_id"`
+	Instrument    *Instrument              `gorm:"foreignKey:InstrumentID" json:"instrument,omitempty"`
+	Version       int                      `gorm:"not null;uniqueIndex:idx_instrument_version" json:"version"`
+	Status        string                   `gorm:"not null;default:'draft';index" json:"status"`
+	Instructions  string                   `gorm:"type:text" json:"instructions"` // read to the patient before the items
+	ChangeNote    string                   `gorm:"type:text" json:"change_note"`
+	PublishedAt   *time.Time               `json:"published_at"`
+	PublishedByID *uint                    `json:"published_by_id"`
+	PublishedBy   *HealthWorker            `gorm:"foreignKey:PublishedByID" json:"published_by,omitempty"`
+	Items         []InstrumentItem         `gorm:"foreignKey:InstrumentVersionID;constraint:OnDelete:CASCADE" json:"items,omitempty"`
+	Options       []InstrumentOption       `gorm:"foreignKey:InstrumentVersionID;constraint:OnDelete:CASCADE" json:"options,omitempty"` // shared by items without their own
+	SeverityBands []InstrumentSeverityBand `gorm:"foreignKey:InstrumentVersionID;constraint:OnDelete:CASCADE" json:"severity_bands,omitempty"`
+}
+
+// InstrumentItem is one question of an instrument version. Position is its 1-based item number and
+// Text its English wording.
+type InstrumentItem struct {
+	gorm.Model
+	InstrumentVersionID uint                        `gorm:"index" json:"instrument_version_id"`
+	Position            int                         `gorm:"not null" json:"position"`
+	Text                string                      `gorm:"type:text;not null" json:"text"`
+	Options             []InstrumentOption          `gorm:"foreignKey:InstrumentItemID;constraint:OnDelete:CASCADE" json:"options,omitempty"` // replaces the version's options
+	Translations        []InstrumentItemTranslation `gorm:"foreignKey:InstrumentItemID;constraint:OnDelete:CASCADE" json:"translations,omitempty"`
+	Locale              string                      `gorm:"-" json:"locale,omitempty"` // set when Text has been swapped for a translation
+}
+
+// InstrumentOption is an answer choice and the score it carries. It belongs either to a whole
+// version or to a single item, never both.
+type InstrumentOption struct {
+	gorm.Model
+	InstrumentVersionID *uint  `gorm:"index" json:"instrument_version_id,omitempty"`
+	InstrumentItemID    *uint  `gorm:"index" json:"instrument_item_id,omitempty"`
+	Position            int    `gorm:"not null" json:"position"`
+	Label               string `gorm:"not null" json:"label"`
+	Score               int    `gorm:"not null" json:"score"`
+}
+
+// InstrumentSeverityBand names an inclusive range of total scores
+type InstrumentSeverityBand struct {
+	gorm.Model
+	InstrumentVersionID uint   `gorm:"index" json:"instrument_version_id"`
+	MinScore            int    `gorm:"not null" json:"min_score"`
+	MaxScore            int    `gorm:"not null" json:"max_score"`
+	Label               string `gorm:"not null" json:"label"`
+}
+
+// InstrumentResponse is one administration of an instrument during a session
+type InstrumentResponse struct {
+	gorm.Model
+	InstrumentID         uint               `gorm:"not null;index" json:"instrument_id"`
+	Instrument           *Instrument        `gorm:"foreignKey:InstrumentID" json:"instrument,omitempty"`
+	InstrumentVersionID  uint               `gorm:"index" json:"instrument_version_id"` // the version the patient answered
+	InstrumentVersion    *InstrumentVersion `gorm:"foreignKey:InstrumentVersionID" json:"instrument_version,omitempty"`
+	SessionID            uint               `gorm:"not null;index" json:"session_id"`
+	Session              *Session           `gorm:"foreignKey:SessionID" json:"session,omitempty"`
+	Language             string             `gorm:"size:8;not null;default:'en'" json:"language"` // the language the patient answered in
+	TotalScore           int                `gorm:"not null" json:"total_score"`
+	Severity             string             `json:"severity"`
+	Answers              []InstrumentAnswer `gorm:"foreignKey:InstrumentResponseID;constraint:OnDelete:CASCADE" json:"answers"`
+	LegacyPhq9ResponseID *uint              `gorm:"uniqueIndex" json:"-"`           // set on rows copied from phq9_responses
+	Diagnosis            *Diagnosis         `gorm:"-" json:"diagnosis,omitempty"`   // PHQ-9 only: the diagnosis scored from these answers
+	RiskAlerts           []RiskAlert        `gorm:"-" json:"risk_alerts,omitempty"` // alerts raised by these answers
+}
+
+// InstrumentAnswer is the score chosen for one item
+type InstrumentAnswer struct {
+	gorm.Model
+	InstrumentResponseID uint            `gorm:"not null;index" json:"instrument_response_id"`
+	InstrumentItemID     uint            `gorm:"not null" json:"item_id"`
+	Item                 *InstrumentItem `gorm:"foreignKey:InstrumentItemID" json:"item,omitempty"`
+	Score                int             `gorm:"not null" json:"score"`
+}
+
+// OptionsFor returns the answer choices of an item: its own, or else the version's
+func (v *InstrumentVersion) OptionsFor(item *InstrumentItem) []InstrumentOption {
+	if len(item.Options) > 0 {
+		return item.Options
+	}
+	return v.Options
+}
+
+// ItemMaxScore is the highest score an item can contribute
+func (v *InstrumentVersion) ItemMaxScore(item *InstrumentItem) int {
+	max := 0
+	for _, opt := range v.OptionsFor(item) {
+		if opt.Score > max {
+			max = opt.Score
+		}
+	}
+	return max
+}
+
+// MaxScore is the highest possible total
+func (v *InstrumentVersion) MaxScore() int {
+	total := 0
+	for idx := range v.Items {
+		total += v.ItemMaxScore(&v.Items[idx])
+	}
+	return total
+}
+
+// ItemAt returns the item with the given 1-based item number, or nil
+func (v *InstrumentVersion) ItemAt(position int) *InstrumentItem {
+	for idx := range v.Items {
+		if v.Items[idx].Position == position {
+			return &v.Items[idx]
+		}
+	}
+	return nil
+}
+
+// SeverityFor returns the label of the band containing total, or "Unknown"
+func (v *InstrumentVersion) SeverityFor(total int) string {
+	for _, band := range v.SeverityBands {
+		if total >= band.MinScore && total <= band.MaxScore {
+			return band.Label
+		}
+	}
+	return "Unknown"
+}
+
+// Score checks that every item is answered exactly once with one of its option scores and returns
+// the total and its severity. Items, options and bands must be loaded.
+func (v *InstrumentVersion) Score(answers []InstrumentAnswer) (int, string, error) {
+	if len(answers) != len(v.Items) {
+		return 0, "", fmt.Errorf("all %d items must be answered, got %d", len(v.Items), len(answers))
+	}
+
+	items := make(map[uint]*InstrumentItem, len(v.Items))
+	for idx := range v.Items {
+		items[v.Items[idx].ID] = &v.Items[idx]
+	}
+
+	answered := make(map[uint]bool, len(answers))
+	total := 0
+	for _, a := range answers {
+		item, ok := items[a.InstrumentItemID]
+		if !ok {
+			return 0, "", fmt.Errorf("item %d is not part of version %d", a.InstrumentItemID, v.Version)
+		}
+		if answered[item.ID] {
+			return 0, "", fmt.Errorf("item %d is answered more than once", item.Position)
+		}
+
+		valid := false
+		for _, opt := range v.OptionsFor(item) {
+			if opt.Score == a.Score {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return 0, "", fmt.Errorf("%d is not an answer option for item %d", a.Score, item.Position)
+		}
+
+		answered[item.ID] = true
+		total += a.Score
+	}
+	return total, v.SeverityFor(total), nil
+}
+
+// Validate checks that an instrument has a code and name
+func (i *Instrument) Validate() error {
+	i.Code = strings.TrimSpace(i.Code)
+	i.Name = strings.TrimSpace(i.Name)
+	if i.Code == "" || i.Name == "" {
+		return errors.New("code and name are required")
+	}
+	return nil
+}
+
+// Validate checks that a version's definition can be scored unambiguously
+func (v *InstrumentVersion) Validate() error {
+	if len(v.Items) == 0 {
+		return errors.New("a version needs at least one item")
+	}
+
+	positions := map[int]bool{}
+	for idx := range v.Items {
+		item := &v.Items[idx]
+		if strings.TrimSpace(item.Text) == "" {
+			return fmt.Errorf("item %d has no text", idx+1)
+		}
+		if item.Position == 0 {
+			item.Position = idx + 1
+		}
+		if positions[item.Position] {
+			return fmt.Errorf("item number %d is used twice", item.Position)
+		}
+		positions[item.Position] = true
+
+		options := v.OptionsFor(item)
+		if len(options) == 0 {
+			return fmt.Errorf("item %d has no answer options", item.Position)
+		}
+		scores := map[int]bool{}
+		for _, opt := range options {
+			if opt.Label == "" {
+				return fmt.Errorf("item %d has an option without a label", item.Position)
+			}
+			if opt.Score < 0 {
+				return fmt.Errorf("item %d has a negative option score", item.Position)
+			}
+			if scores[opt.Score] {
+				return fmt.Errorf("item %d has two options scoring %d", item.Position, opt.Score)
+			}
+			scores[opt.Score] = true
+		}
+	}
+
+	bands := append([]InstrumentSeverityBand(nil), v.SeverityBands...)
+	sort.Slice(bands, func(a, b int) bool { return bands[a].MinScore < bands[b].MinScore })
+	for idx, band := range bands {
+		if band.Label == "" || band.MinScore > band.MaxScore {
+			return fmt.Errorf("severity band %d-%d is invalid", band.MinScore, band.MaxScore)
+		}
+		if idx > 0 && band.MinScore <= bands[idx-1].MaxScore {
+			return fmt.Errorf("severity bands %q and %q overlap", bands[idx-1].Label, band.Label)
+		}
+	}
+	return nil
+}
+
+// WithInstrumentDefinition is a query scope that loads an instrument version's items, answer
+// options and severity bands in display order
+func WithInstrumentDefinition(db *gorm.DB) *gorm.DB {
+	byPosition := func(tx *gorm.DB) *gorm.DB { return tx.Order("position, id") }
+	return db.
+		Preload("Items", byPosition).
+		Preload("Items.Options", byPosition).
+		Preload("Options", byPosition).
+		Preload("SeverityBands", func(tx *gorm.DB) *gorm.DB { return tx.Order("min_score") })
+}
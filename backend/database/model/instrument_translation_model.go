@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Languages questionnaires can be administered in, as ISO 639 codes
+const (
+	LocaleEnglish    = "en"
+	LocaleLuganda    = "lg"
+	LocaleSwahili    = "sw"
+	LocaleRunyankole = "nyn"
+)
+
+// SupportedLocales lists the languages in the order they are offered
+var SupportedLocales = []string{LocaleEnglish, LocaleLuganda, LocaleSwahili, LocaleRunyankole}
+
+// IsSupportedLocale reports whether questionnaires can be administered in locale
+func IsSupportedLocale(locale string) bool {
+	for _, l := range SupportedLocales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// Translation review statuses
+const (
+	TranslationPending  = "pending"
+	TranslationApproved = "approved"
+)
+
+// InstrumentItemTranslation is an item's wording in a language other than English. Only approved
+// translations are shown to patients. Approved translations of published versions are as fixed as
+// the items themselves; better wording goes into the next version.
+type InstrumentItemTranslation struct {
+	gorm.Model
+	InstrumentItemID uint            `gorm:"not null;uniqueIndex:idx_item_locale" json:"instrument_item_id"`
+	Item             *InstrumentItem `gorm:"foreignKey:InstrumentItemID" json:"item,omitempty"`
+	Locale           string          `gorm:"size:8;not null;uniqueIndex:idx_item_locale" json:"locale"`
+	Text             string          `gorm:"type:text;not null" json:"text"`
+	Status           string          `gorm:"not null;default:'pending';index" json:"status"`
+	TranslatedByID   *uint           `json:"translated_by_id"`
+	TranslatedBy     *HealthWorker   `gorm:"foreignKey:TranslatedByID" json:"translated_by,omitempty"`
+	ReviewedByID     *uint           `json:"reviewed_by_id"`
+	ReviewedBy       *HealthWorker   `gorm:"foreignKey:ReviewedByID" json:"reviewed_by,omitempty"`
+	ReviewedAt       *time.Time      `json:"reviewed_at"`
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"encoding/json"
+
 	"gorm.io/gorm"
 )
 
@@ -15,4 +17,8 @@ type MedicationHistory struct {
 	ExternalDoctorName    	string 			`json:"external_doctor_name"`
 	ExternalDoctorContact 	string 			`json:"external_doctor_contact"`
 	HealthCenter 			string 			`json:"health_center"`
+	Lines 					[]PrescriptionLine	`gorm:"foreignKey:MedicationHistoryID" json:"lines"` // Prescription is rendered from these when left empty
+
+	SafetyWarnings 			json.RawMessage 	`gorm:"type:jsonb" json:"safety_warnings"` // []SafetyWarning found when the lines were last written
+	OverrideReason 			string 			`gorm:"type:text" json:"override_reason"` // required when a warning is severe
 }
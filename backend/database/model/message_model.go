@@ -9,10 +9,32 @@ import (
 
 type Message struct {
 	gorm.Model
-	SenderID     uint         `json:"sender_id"`                       // FK to HealthWorker
-	Sender       HealthWorker `gorm:"foreignKey:SenderID" json:"sender"`
-	ReceiverID   uint         `json:"receiver_id"`                     // FK to HealthWorker
-	Receiver     HealthWorker `gorm:"foreignKey:ReceiverID" json:"receiver"`
-	Message      string       `gorm:"type:text;not null" json:"message"`
-	Timestamp    time.Time    `gorm:"autoCreateTime" json:"timestamp"` // when message was sent
+	SenderID   uint             `json:"sender_id"` // FK to HealthWorker
+	Sender     HealthWorker     `gorm:"foreignKey:SenderID" json:"sender"`
+	ReceiverID *uint            `json:"receiver_id"` // FK to HealthWorker; unset for channel messages
+	Receiver   *HealthWorker    `gorm:"foreignKey:ReceiverID" json:"receiver"`
+	ChannelID  *uint            `gorm:"index" json:"channel_id,omitempty"` // group channel the message was posted to
+	Message    string           `gorm:"type:text;not null" json:"message"`
+	Timestamp  time.Time        `gorm:"autoCreateTime" json:"timestamp"` // when message was sent
+	Receipts   []MessageReceipt `gorm:"foreignKey:MessageID" json:"receipts,omitempty"`
+}
+
+// MessageReceipt is when a message reached one of its recipients and when they read it. Messages
+// sent before receipts existed count as read.
+type MessageReceipt struct {
+	gorm.Model
+	MessageID   uint       `gorm:"not null;uniqueIndex:idx_message_receipt" json:"message_id"`
+	RecipientID uint       `gorm:"not null;uniqueIndex:idx_message_receipt;index" json:"recipient_id"`
+	DeliveredAt *time.Time `json:"delivered_at"` // pushed to one of the recipient's connections or fetched
+	ReadAt      *time.Time `json:"read_at"`
+}
+
+// Conversation is a thread between a health worker and a counterpart, with its latest message and
+// how many of the counterpart's messages are unread
+type Conversation struct {
+	CounterpartID uint          `json:"counterpart_id"`
+	Counterpart   *HealthWorker `json:"counterpart"`
+	Online        bool          `json:"online"`
+	LastMessage   *Message      `json:"last_message"`
+	UnreadCount   int64         `json:"unread_count"`
 }
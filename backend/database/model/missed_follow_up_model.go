@@ -0,0 +1,107 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Where a missed follow-up was detected
+const (
+	MissedSourceAppointment = "appointment"       // a booked appointment was never checked in
+	MissedSourceSession     = "session"           // a scheduled session was never attended
+	MissedSourceNextSession = "next_session_date" // the patient did not come back by a session's NextSessionDate
+)
+
+// Outcomes of following up a defaulter
+const (
+	OutreachReturned    = "returned" // the patient attended again; recorded automatically
+	OutreachRebooked    = "rebooked"
+	OutreachUnreachable = "unreachable"
+	OutreachDeclined    = "declined"
+	OutreachTransferred = "transferred"
+)
+
+// OutreachOutcomes lists the outcomes an outreach team can record
+var OutreachOutcomes = []string{OutreachReturned, OutreachRebooked, OutreachUnreachable, OutreachDeclined, OutreachTransferred}
+
+// MissedFollowUp records a follow-up a patient did not attend. It stays open on the defaulter
+// worklist until the patient attends again or outreach records an outcome.
+type MissedFollowUp struct {
+	gorm.Model
+	PatientID    uint          `gorm:"not null;index" json:"patient_id"`
+	Patient      *Patient      `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	Source       string        `gorm:"size:20;not null;uniqueIndex:idx_missed_follow_up_source" json:"source"`
+	SourceID     uint          `gorm:"not null;uniqueIndex:idx_missed_follow_up_source" json:"source_id"` // the appointment or session
+	DueAt        time.Time     `gorm:"not null" json:"due_at"`
+	ResolvedAt   *time.Time    `gorm:"index" json:"resolved_at"`
+	ResolvedByID *uint         `json:"resolved_by_id"` // nil when the patient's return resolved it
+	ResolvedBy   *HealthWorker `gorm:"foreignKey:ResolvedByID" json:"resolved_by,omitempty"`
+	Outcome      string        `json:"outcome"`
+	Notes        string        `gorm:"type:text" json:"notes"`
+}
+
+// Attendance summarises how reliably a patient attends: sessions attended against follow-ups missed
+type Attendance struct {
+	PatientID uint    `json:"patient_id"`
+	Attended  int64   `json:"attended"`
+	Missed    int64   `json:"missed"`
+	Rate      float64 `json:"rate"` // attended / (attended + missed); 1 with no history
+}
+
+// Defaulter is a patient on the outreach worklist
+type Defaulter struct {
+	PatientID      uint             `json:"patient_id"`
+	PatientCode    string           `json:"patient_code"`
+	FirstName      string           `json:"first_name"`
+	LastName       string           `json:"last_name"`
+	Contact        string           `json:"contact"`
+	Address        string           `json:"address"`
+	MissedFollowUp []MissedFollowUp `json:"missed_follow_ups"` // the open ones, oldest first
+	DaysOverdue    int              `json:"days_overdue"`      // since the oldest open one was due
+	LastPhq9Score  *int             `json:"last_phq9_score"`
+	LastSeverity   string           `json:"last_severity"`
+	SelfHarmFlag   bool             `json:"self_harm_flag"` // a risk alert was raised recently
+	Attendance     Attendance       `json:"attendance"`
+	Priority       float64          `json:"priority"`
+}
+
+// NewAttendance computes the attendance rate
+func NewAttendance(patientID uint, attended, missed int64) Attendance {
+	a := Attendance{PatientID: patientID, Attended: attended, Missed: missed, Rate: 1}
+	if attended+missed > 0 {
+		a.Rate = float64(attended) / float64(attended+missed)
+	}
+	return a
+}
+
+// SeverityRank orders the PHQ-9 severity bands from 0 (minimal) to 4 (severe); unknown is 0
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityMild:
+		return 1
+	case SeverityModerate:
+		return 2
+	case SeverityModeratelySevere:
+		return 3
+	case SeveritySevere:
+		return 4
+	}
+	return 0
+}
+
+// DefaulterPriority ranks a defaulter for outreach. A self-harm flag puts the patient ahead of
+// everyone without one, the severity of their last PHQ-9 comes next, and time overdue (capped at
+// 90 days) and poor attendance break ties.
+func DefaulterPriority(severity string, selfHarm bool, daysOverdue int, attendanceRate float64) float64 {
+	priority := float64(SeverityRank(severity)) * 20
+	if selfHarm {
+		priority += 100
+	}
+	if daysOverdue > 90 {
+		daysOverdue = 90
+	}
+	priority += float64(daysOverdue) * 0.1
+	priority += (1 - attendanceRate) * 10
+	return priority
+}
@@ -0,0 +1,265 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Codes of the note templates installed by default
+const (
+	NoteTemplateSOAP      = "SOAP"
+	NoteTemplateIntake    = "INTAKE"
+	NoteTemplateMSE       = "MSE"
+	NoteTemplateDischarge = "DISCHARGE"
+)
+
+// Types of note template sections
+const (
+	NoteFieldText        = "text"
+	NoteFieldLongText    = "long_text"
+	NoteFieldNumber      = "number"
+	NoteFieldBoolean     = "boolean"
+	NoteFieldDate        = "date" // YYYY-MM-DD
+	NoteFieldChoice      = "choice"
+	NoteFieldMultiChoice = "multi_choice"
+)
+
+var noteFieldTypes = map[string]bool{
+	NoteFieldText: true, NoteFieldLongText: true, NoteFieldNumber: true, NoteFieldBoolean: true,
+	NoteFieldDate: true, NoteFieldChoice: true, NoteFieldMultiChoice: true,
+}
+
+// NoteTemplate structures a session summary into typed sections, e.g. the four parts of a SOAP note
+type NoteTemplate struct {
+	gorm.Model
+	Code        string                `gorm:"not null;uniqueIndex" json:"code"`
+	Name        string                `gorm:"not null" json:"name"`
+	Description string                `gorm:"type:text" json:"description"`
+	IsActive    bool                  `gorm:"default:true" json:"is_active"`
+	Sections    []NoteTemplateSection `gorm:"foreignKey:NoteTemplateID;constraint:OnDelete:CASCADE" json:"sections"`
+}
+
+// NoteTemplateSection is one typed entry of a note. Key identifies it in stored notes; Options
+// lists the answers of choice sections.
+type NoteTemplateSection struct {
+	gorm.Model
+	NoteTemplateID uint            `gorm:"not null;index" json:"note_template_id"`
+	Position       int             `gorm:"not null" json:"position"`
+	Key            string          `gorm:"not null" json:"key"`
+	Title          string          `gorm:"not null" json:"title"`
+	Type           string          `gorm:"not null" json:"type"`
+	Required       bool            `gorm:"default:false" json:"required"`
+	Options        json.RawMessage `gorm:"type:jsonb" json:"options,omitempty"` // JSON array of strings
+	HelpText       string          `gorm:"type:text" json:"help_text"`
+}
+
+// NoteSection is the value written for one section of a structured note. Title and Type are copied
+// from the template when the note is saved, so the note reads the same if the template changes.
+type NoteSection struct {
+	Key   string      `json:"key"`
+	Title string      `json:"title,omitempty"`
+	Type  string      `json:"type,omitempty"`
+	Value interface{} `json:"value"`
+}
+
+// Choices decodes a choice section's options
+func (s *NoteTemplateSection) Choices() []string {
+	var choices []string
+	if len(s.Options) > 0 {
+		_ = json.Unmarshal(s.Options, &choices)
+	}
+	return choices
+}
+
+// Validate checks that a template has a code, a name and well-formed sections
+func (t *NoteTemplate) Validate() error {
+	t.Code = strings.ToUpper(strings.TrimSpace(t.Code))
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Code == "" || t.Name == "" {
+		return errors.New("code and name are required")
+	}
+	if len(t.Sections) == 0 {
+		return errors.New("a note template needs at least one section")
+	}
+
+	keys := map[string]bool{}
+	for idx := range t.Sections {
+		s := &t.Sections[idx]
+		s.Key = strings.ToLower(strings.TrimSpace(s.Key))
+		s.Title = strings.TrimSpace(s.Title)
+		s.Position = idx + 1
+		if s.Key == "" || s.Title == "" {
+			return fmt.Errorf("section %d needs a key and a title", idx+1)
+		}
+		if keys[s.Key] {
+			return fmt.Errorf("section key %q is used twice", s.Key)
+		}
+		keys[s.Key] = true
+		if !noteFieldTypes[s.Type] {
+			return fmt.Errorf("section %q has unknown type %q", s.Key, s.Type)
+		}
+		if len(s.Options) > 0 {
+			var choices []string
+			if err := json.Unmarshal(s.Options, &choices); err != nil {
+				return fmt.Errorf("options of section %q must be a list of strings", s.Key)
+			}
+		}
+		if (s.Type == NoteFieldChoice || s.Type == NoteFieldMultiChoice) && len(s.Choices()) == 0 {
+			return fmt.Errorf("section %q needs options to choose from", s.Key)
+		}
+	}
+	return nil
+}
+
+// Fill checks written values against the template and returns them in template order with titles
+// and types filled in. Required sections must have a value; empty optional ones are left out.
+func (t *NoteTemplate) Fill(values []NoteSection) ([]NoteSection, error) {
+	byKey := make(map[string]interface{}, len(values))
+	for _, v := range values {
+		key := strings.ToLower(strings.TrimSpace(v.Key))
+		if _, dup := byKey[key]; dup {
+			return nil, fmt.Errorf("section %q is given twice", key)
+		}
+		byKey[key] = v.Value
+	}
+
+	filled := []NoteSection{}
+	for idx := range t.Sections {
+		s := &t.Sections[idx]
+		raw := byKey[s.Key]
+		delete(byKey, s.Key)
+
+		value, err := s.normalize(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.Title, err)
+		}
+		if value == nil {
+			if s.Required {
+				return nil, fmt.Errorf("%s is required", s.Title)
+			}
+			continue
+		}
+		filled = append(filled, NoteSection{Key: s.Key, Title: s.Title, Type: s.Type, Value: value})
+	}
+	for key := range byKey {
+		return nil, fmt.Errorf("%s has no section %q", t.Name, key)
+	}
+	return filled, nil
+}
+
+// normalize checks a value against the section's type. It returns nil for a missing or empty value.
+func (s *NoteTemplateSection) normalize(raw interface{}) (interface{}, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	switch s.Type {
+	case NoteFieldText, NoteFieldLongText:
+		text, ok := raw.(string)
+		if !ok {
+			return nil, errors.New("must be text")
+		}
+		if text = strings.TrimSpace(text); text == "" {
+			return nil, nil
+		}
+		return text, nil
+	case NoteFieldNumber:
+		number, ok := raw.(float64)
+		if !ok {
+			return nil, errors.New("must be a number")
+		}
+		return number, nil
+	case NoteFieldBoolean:
+		flag, ok := raw.(bool)
+		if !ok {
+			return nil, errors.New("must be true or false")
+		}
+		return flag, nil
+	case NoteFieldDate:
+		text, ok := raw.(string)
+		if !ok {
+			return nil, errors.New("must be a date (YYYY-MM-DD)")
+		}
+		if text = strings.TrimSpace(text); text == "" {
+			return nil, nil
+		}
+		if _, err := time.Parse("2006-01-02", text); err != nil {
+			return nil, errors.New("must be a date (YYYY-MM-DD)")
+		}
+		return text, nil
+	case NoteFieldChoice:
+		text, ok := raw.(string)
+		if !ok {
+			return nil, errors.New("must be one of the options")
+		}
+		if text == "" {
+			return nil, nil
+		}
+		if !containsString(s.Choices(), text) {
+			return nil, fmt.Errorf("%q is not one of the options", text)
+		}
+		return text, nil
+	case NoteFieldMultiChoice:
+		list, ok := raw.([]interface{})
+		if !ok {
+			return nil, errors.New("must be a list of options")
+		}
+		chosen := []string{}
+		for _, item := range list {
+			text, ok := item.(string)
+			if !ok || !containsString(s.Choices(), text) {
+				return nil, fmt.Errorf("%v is not one of the options", item)
+			}
+			if !containsString(chosen, text) {
+				chosen = append(chosen, text)
+			}
+		}
+		if len(chosen) == 0 {
+			return nil, nil
+		}
+		return chosen, nil
+	}
+	return nil, fmt.Errorf("unknown section type %q", s.Type)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderNote writes a structured note out as readable text, one titled paragraph per section
+func RenderNote(template *NoteTemplate, sections []NoteSection) string {
+	var b strings.Builder
+	b.WriteString(template.Name)
+	for _, s := range sections {
+		b.WriteString("\n\n")
+		b.WriteString(s.Title)
+		b.WriteString(":\n")
+		switch v := s.Value.(type) {
+		case string:
+			b.WriteString(v)
+		case float64:
+			b.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			if v {
+				b.WriteString("Yes")
+			} else {
+				b.WriteString("No")
+			}
+		case []string:
+			b.WriteString(strings.Join(v, ", "))
+		default:
+			fmt.Fprint(&b, v)
+		}
+	}
+	return b.String()
+}
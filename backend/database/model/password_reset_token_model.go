@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordResetToken is a one-time token issued by an admin so a health worker can set a new password.
+// Only the SHA-256 hash of the token is stored.
+type PasswordResetToken struct {
+	gorm.Model
+	HealthWorkerID uint         `gorm:"not null;index" json:"health_worker_id"`
+	HealthWorker   HealthWorker `gorm:"foreignKey:HealthWorkerID" json:"-"`
+	TokenHash      string       `gorm:"not null;uniqueIndex" json:"-"`
+	IssuedByID     uint         `json:"issued_by_id"` // admin who initiated the reset
+	ExpiresAt      time.Time    `gorm:"not null" json:"expires_at"`
+	UsedAt         *time.Time   `json:"used_at"`
+}
@@ -22,6 +22,7 @@ type Patient struct {
 	PatientCode       string       `gorm:"uniqueIndex" json:"patient_code"`   // e.g. Dd-192
 	
 	PreviousDiagnosis string       `gorm:"type:text" json:"previous_diagnosis"` // Previous diagnosis if any
+	PregnancyStatus   string       `gorm:"size:20" json:"pregnancy_status"`     // "", pregnant or breastfeeding; checked when prescribing
 	
 	DepartmentID      uint         `json:"department_id"`
 	Department        Department   `gorm:"foreignKey:DepartmentID"`
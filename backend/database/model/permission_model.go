@@ -0,0 +1,160 @@
+package model
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Permissions understood by the RequirePermission middleware
+const (
+	PermAll = "*" // grants every permission
+
+	PermHealthWorkerCreate        = "healthworker:create"
+	PermHealthWorkerUpdate        = "healthworker:update"
+	PermHealthWorkerDelete        = "healthworker:delete"
+	PermHealthWorkerManageStatus  = "healthworker:status:manage"
+	PermHealthWorkerResetPassword = "healthworker:password:reset"
+
+	PermPersonnelTypeManage = "personnel_type:manage"
+	PermDepartmentManage    = "department:manage"
+	PermPermissionManage    = "permission:manage"
+
+	PermPatientCreate       = "patient:create"
+	PermPatientUpdate       = "patient:update"
+	PermPatientDelete       = "patient:delete"
+	PermPatientManageStatus = "patient:status:manage"
+
+	PermSessionCreate = "session:create"
+	PermSessionUpdate = "session:update"
+	PermSessionDelete = "session:delete"
+
+	PermSessionSummaryCreate = "session_summary:create"
+	PermSessionSummaryUpdate = "session_summary:update"
+	PermSessionSummaryDelete = "session_summary:delete"
+	PermNoteTemplateManage   = "note_template:manage"
+
+	PermDiagnosisCreate = "diagnosis:create"
+
+	PermPhq9QuestionManage = "phq9:question:manage"
+	PermPhq9ResponseCreate = "phq9:response:create"
+	PermPhq9ResponseUpdate = "phq9:response:update"
+	PermPhq9ResponseDelete = "phq9:response:delete"
+
+	PermInstrumentManage         = "instrument:manage"
+	PermInstrumentResponseCreate = "instrument:response:create"
+	PermInstrumentResponseUpdate = "instrument:response:update"
+	PermInstrumentResponseDelete = "instrument:response:delete"
+
+	PermInstrumentTranslate         = "instrument:translate"
+	PermInstrumentTranslationReview = "instrument:translation:review" // approve translations shown to patients
+
+	PermAppointmentManage = "appointment:manage" // book, reschedule, cancel and check in appointments
+	PermScheduleManage    = "schedule:manage"    // set health workers' working hours and leave
+	PermDefaulterManage   = "defaulter:manage"   // work the defaulter worklist and record outreach outcomes
+
+	PermMedicationCreate = "medication:create"
+	PermMedicationUpdate = "medication:update"
+	PermMedicationDelete = "medication:delete"
+	PermFormularyManage  = "formulary:manage"
+
+	PermAdverseReactionReport   = "adverse_reaction:report"
+	PermPharmacovigilanceExport = "pharmacovigilance:export" // download ADR reports for the national centre
+
+	PermPatientAccessAll = "patient:access:all" // bypasses care-team checks
+	PermCareTeamManage   = "care_team:manage"
+	PermBreakGlassReview = "break_glass:review"
+
+	PermAuditView = "audit:view"
+
+	PermRiskAlertManage = "risk_alert:manage" // see and acknowledge every risk alert
+	PermRiskRuleManage  = "risk_rule:manage"
+
+	PermChannelModerate = "channel:moderate" // administer every group channel
+)
+
+// AllPermissions is the catalogue of grantable permissions
+var AllPermissions = []string{
+	PermHealthWorkerCreate, PermHealthWorkerUpdate, PermHealthWorkerDelete,
+	PermHealthWorkerManageStatus, PermHealthWorkerResetPassword,
+	PermPersonnelTypeManage, PermDepartmentManage, PermPermissionManage,
+	PermPatientCreate, PermPatientUpdate, PermPatientDelete, PermPatientManageStatus,
+	PermSessionCreate, PermSessionUpdate, PermSessionDelete,
+	PermSessionSummaryCreate, PermSessionSummaryUpdate, PermSessionSummaryDelete, PermNoteTemplateManage,
+	PermDiagnosisCreate,
+	PermPhq9QuestionManage, PermPhq9ResponseCreate, PermPhq9ResponseUpdate, PermPhq9ResponseDelete,
+	PermInstrumentManage, PermInstrumentResponseCreate, PermInstrumentResponseUpdate, PermInstrumentResponseDelete,
+	PermInstrumentTranslate, PermInstrumentTranslationReview,
+	PermAppointmentManage, PermScheduleManage, PermDefaulterManage,
+	PermMedicationCreate, PermMedicationUpdate, PermMedicationDelete, PermFormularyManage,
+	PermAdverseReactionReport, PermPharmacovigilanceExport,
+	PermPatientAccessAll, PermCareTeamManage, PermBreakGlassReview,
+	PermAuditView,
+	PermRiskAlertManage, PermRiskRuleManage,
+	PermChannelModerate,
+}
+
+// Subjects a permission can be granted to
+const (
+	SubjectRole          = "role"           // HealthWorker.Role, e.g. "admin"
+	SubjectPersonnelType = "personnel_type" // PersonnelType.Name, e.g. "psychiatrist"
+)
+
+// PermissionGrant gives a permission to every health worker with the given role or personnel type
+type PermissionGrant struct {
+	gorm.Model
+	SubjectType string `gorm:"not null;uniqueIndex:idx_permission_grant" json:"subject_type"`
+	Subject     string `gorm:"not null;uniqueIndex:idx_permission_grant" json:"subject"` // stored lower case
+	Permission  string `gorm:"not null;uniqueIndex:idx_permission_grant" json:"permission"`
+}
+
+// NormalizeSubject puts a role or personnel type name in the form grants are stored and compared in
+func NormalizeSubject(subject string) string {
+	return strings.ToLower(strings.TrimSpace(subject))
+}
+
+// BeforeSave keeps the subject lower case whichever path writes the grant
+func (g *PermissionGrant) BeforeSave(tx *gorm.DB) error {
+	g.SubjectType = strings.TrimSpace(g.SubjectType)
+	g.Subject = NormalizeSubject(g.Subject)
+	g.Permission = strings.TrimSpace(g.Permission)
+	return nil
+}
+
+// IsKnownPermission reports whether p is in the catalogue (or is the wildcard)
+func IsKnownPermission(p string) bool {
+	if p == PermAll {
+		return true
+	}
+	for _, known := range AllPermissions {
+		if known == p {
+			return true
+		}
+	}
+	return false
+}
+
+// GrantsAllow reports whether any of the grants gives the permission to a health worker
+// with the given role and personnel type. Subjects are compared case-insensitively.
+func GrantsAllow(grants []PermissionGrant, role, personnelType, permission string) bool {
+	role = NormalizeSubject(role)
+	personnelType = NormalizeSubject(personnelType)
+
+	for _, g := range grants {
+		if g.Permission != permission && g.Permission != PermAll {
+			continue
+		}
+		subject := NormalizeSubject(g.Subject)
+		switch g.SubjectType {
+		case SubjectRole:
+			if role != "" && subject == role {
+				return true
+			}
+		case SubjectPersonnelType:
+			if personnelType != "" && subject == personnelType {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -5,4 +5,5 @@ import "gorm.io/gorm"
 type Phq9Question struct {
 	gorm.Model
 	Question	string	`gorm:"not null" json:"question"`
+	Locale		string	`gorm:"-" json:"locale,omitempty"` // the language Question is in
 }
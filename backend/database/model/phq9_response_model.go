@@ -8,8 +8,9 @@ import (
 
 
 type Phq9ResponseStruct struct {
-	QuestionID uint `json:"question_id"`
-	Response   int  `json:"response"`
+	QuestionID uint   `json:"question_id"`
+	Question   string `json:"question,omitempty"` // as worded in the version the patient answered
+	Response   int    `json:"response"`
 }
 
 type Phq9Response struct {
@@ -17,4 +18,7 @@ type Phq9Response struct {
 	SessionID 	uint 			`gorm:"not null;index" json:"session_id"`
 	Session		Session			`gorm:"foreignKey:SessionID"`
 	Responses   json.RawMessage `gorm:"type:jsonb" json:"responses"` 
+	Language	string			`gorm:"-" json:"language,omitempty"` // the language the patient answered in
+	Diagnosis	*Diagnosis		`gorm:"-" json:"diagnosis,omitempty"` // scored from Responses on create and update
+	RiskAlerts	[]RiskAlert		`gorm:"-" json:"risk_alerts,omitempty"` // alerts raised by these answers
 }
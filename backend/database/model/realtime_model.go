@@ -0,0 +1,59 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Types of realtime events and of frames clients send
+const (
+	RealtimeMessage  = "message"         // a new Message; Payload is the message
+	RealtimeTyping   = "typing"          // a health worker started or stopped typing to the recipient
+	RealtimePresence = "presence"        // a health worker came online or went offline
+	RealtimeReceipt  = "receipt"         // messages were delivered to or read by a recipient
+	RealtimeDeleted  = "message_deleted" // a moderator removed a channel message
+	RealtimeReplay   = "replay"          // client frame: resend messages received after Since
+	RealtimeError    = "error"           // server frame: a client frame could not be handled
+)
+
+// RealtimeEvent is pushed to the connected health workers in Recipients, or to everyone connected
+// when Recipients is empty. Events travel between server instances through the broadcast backend.
+type RealtimeEvent struct {
+	Type       string          `json:"type"`
+	Recipients []uint          `json:"recipients,omitempty"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	SentAt     time.Time       `json:"sent_at"`
+	Origin     string          `json:"origin,omitempty"` // instance that published the event
+}
+
+// TypingPayload says whether SenderID is typing to the recipient, or in a channel
+type TypingPayload struct {
+	SenderID  uint `json:"sender_id"`
+	ChannelID uint `json:"channel_id,omitempty"`
+	Typing    bool `json:"typing"`
+}
+
+// PresencePayload says whether a health worker has any open connection on the origin instance
+type PresencePayload struct {
+	HealthWorkerID uint `json:"health_worker_id"`
+	Online         bool `json:"online"`
+}
+
+// ReceiptPayload says which messages reached, or were read by, RecipientID
+type ReceiptPayload struct {
+	RecipientID uint       `json:"recipient_id"`
+	MessageIDs  []uint     `json:"message_ids"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+}
+
+// ClientFrame is a frame sent by a connected client: a message, a typing indicator or a replay
+// request
+type ClientFrame struct {
+	Type       string `json:"type"`
+	ReceiverID uint   `json:"receiver_id"`
+	ChannelID  uint   `json:"channel_id"` // set instead of receiver_id for channel messages and typing
+	Message    string `json:"message"`
+	Typing     bool   `json:"typing"`
+	Since      uint   `json:"since"` // message ID cursor: replay messages with a greater ID
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RiskAlertOpen         = "open"
+	RiskAlertAcknowledged = "acknowledged"
+)
+
+// RiskRule fires a RiskAlert when an instrument item (or the total score) reaches a threshold.
+// ItemNumber is the 1-based item of the instrument; 0 means the rule looks at the total score.
+type RiskRule struct {
+	gorm.Model
+	Name         string      `gorm:"not null;uniqueIndex" json:"name"`
+	InstrumentID uint        `gorm:"index" json:"instrument_id"`
+	Instrument   *Instrument `gorm:"foreignKey:InstrumentID" json:"instrument,omitempty"`
+	ItemNumber   int         `gorm:"not null;default:0" json:"item_number"`
+	MinResponse  int         `gorm:"not null" json:"min_response"` // answer (or total) at or above which the rule fires
+	IsActive     bool        `gorm:"default:true" json:"is_active"`
+}
+
+// RiskAlert flags a session where a risk rule fired. The session cannot be completed until the
+// alert is acknowledged with a documented safety plan.
+type RiskAlert struct {
+	gorm.Model
+	SessionID            uint                    `gorm:"not null;index" json:"session_id"`
+	Session              *Session                `gorm:"foreignKey:SessionID" json:"session,omitempty"`
+	PatientID            uint                    `gorm:"not null;index" json:"patient_id"`
+	Patient              *Patient                `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	InstrumentResponseID uint                    `gorm:"not null" json:"instrument_response_id"`
+	RiskRuleID           uint                    `gorm:"not null" json:"risk_rule_id"`
+	RiskRule             *RiskRule               `gorm:"foreignKey:RiskRuleID" json:"risk_rule,omitempty"`
+	Reason               string                  `gorm:"type:text;not null" json:"reason"`
+	Status               string                  `gorm:"not null;default:'open';index" json:"status"`
+	AcknowledgedByID     *uint                   `json:"acknowledged_by_id"`
+	AcknowledgedBy       *HealthWorker           `gorm:"foreignKey:AcknowledgedByID" json:"acknowledged_by,omitempty"`
+	AcknowledgedAt       *time.Time              `json:"acknowledged_at"`
+	SafetyPlan           string                  `gorm:"type:text" json:"safety_plan"`
+	Notifications        []RiskAlertNotification `gorm:"foreignKey:RiskAlertID" json:"notifications,omitempty"`
+}
+
+// RiskAlertNotification records who was messaged about an alert
+type RiskAlertNotification struct {
+	gorm.Model
+	RiskAlertID    uint          `gorm:"not null;index" json:"risk_alert_id"`
+	HealthWorkerID uint          `gorm:"not null;index" json:"health_worker_id"`
+	HealthWorker   *HealthWorker `gorm:"foreignKey:HealthWorkerID" json:"health_worker,omitempty"`
+	MessageID      *uint         `json:"message_id"`
+}
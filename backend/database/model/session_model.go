@@ -1,18 +1,42 @@
 package model
 
 import (
+	"encoding/json"
 	"errors"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// Session statuses. A session moves scheduled → checked_in → ongoing → completed, and can be
+// cancelled before it completes or marked no_show before the patient checks in.
 const (
+	SessionScheduled = "scheduled"
+	SessionCheckedIn = "checked_in"
 	SessionOngoing   = "ongoing"
 	SessionCompleted = "completed"
 	SessionCancelled = "cancelled"
+	SessionNoShow    = "no_show"
 )
 
+// SessionTransitions lists the statuses each status can move to. Completed, cancelled and no_show
+// are final.
+var SessionTransitions = map[string][]string{
+	SessionScheduled: {SessionCheckedIn, SessionCancelled, SessionNoShow},
+	SessionCheckedIn: {SessionOngoing, SessionCancelled, SessionNoShow},
+	SessionOngoing:   {SessionCompleted, SessionCancelled},
+}
+
+// CanTransition reports whether a session may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, next := range SessionTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
 type Session struct {
 	gorm.Model
 	SessionCode             string         `gorm:"not null;uniqueIndex" json:"session_code"`  // e.g. DEP-S-9832
@@ -21,7 +45,7 @@ type Session struct {
 	PatientID               uint           `gorm:"not null;index" json:"patient_id"`
 	Patient                 Patient        `gorm:"foreignKey:PatientID"`
 	Date                    time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"date"`
-	Status                  string         `gorm:"not null;check:status IN ('ongoing', 'completed', 'cancelled')" json:"status"`
+	Status                  string         `gorm:"not null;check:status IN ('scheduled', 'checked_in', 'ongoing', 'completed', 'cancelled', 'no_show')" json:"status"`
 
 	// NEW fields
 	PatientStateAtRegistration string      `gorm:"type:text" json:"patient_state"`
@@ -31,6 +55,9 @@ type Session struct {
 	Description                string      `gorm:"type:text" json:"description"`       // summary of what was discussed or done
 	NextSessionDate            *time.Time  `json:"next_session_date"`                  // appointment scheduler
 	CurrentPrescription        string      `gorm:"type:text" json:"current_prescription"`
+	PrescriptionLines          []PrescriptionLine `gorm:"foreignKey:SessionID" json:"prescription_lines,omitempty"` // structured CurrentPrescription
+	SafetyWarnings             json.RawMessage `gorm:"type:jsonb" json:"safety_warnings"` // []SafetyWarning found when the lines were last written
+	OverrideReason             string      `gorm:"type:text" json:"override_reason"`  // required when a warning is severe
 
 	// Existing related models
 	Diagnosis      Diagnosis      `gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE"`
@@ -39,12 +66,15 @@ type Session struct {
 
 func (s *Session) ValidateStatus() error {
 	validStatuses := map[string]bool{
+		SessionScheduled: true,
+		SessionCheckedIn: true,
 		SessionOngoing:   true,
 		SessionCompleted: true,
 		SessionCancelled: true,
+		SessionNoShow:    true,
 	}
 	if !validStatuses[s.Status] {
-		return errors.New("invalid status: must be 'ongoing', 'completed', or 'cancelled'")
+		return errors.New("invalid status: must be 'scheduled', 'checked_in', 'ongoing', 'completed', 'cancelled' or 'no_show'")
 	}
 	return nil
 }
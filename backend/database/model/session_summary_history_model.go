@@ -0,0 +1,96 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// What happened to a session summary in a SummaryVersion
+const (
+	SummaryCreated  = "created"
+	SummaryEdited   = "edited"
+	SummarySigned   = "signed"
+	SummaryCoSigned = "co_signed"
+	SummaryLocked   = "locked" // its session was completed
+	SummaryAddended = "addendum"
+	SummaryDeleted  = "deleted"
+)
+
+// CoSignPersonnelTypes lists the personnel types whose signed summaries also need their
+// supervisor's co-signature
+var CoSignPersonnelTypes = []string{"intern", "nurse", "midwife"}
+
+// NeedsCoSignature reports whether summaries by a health worker of this personnel type must be
+// co-signed
+func NeedsCoSignature(personnelType string) bool {
+	for _, t := range CoSignPersonnelTypes {
+		if strings.EqualFold(t, strings.TrimSpace(personnelType)) {
+			return true
+		}
+	}
+	return false
+}
+
+// SummaryAddendum is an append-only correction or note added to a signed or locked summary
+type SummaryAddendum struct {
+	ID               uint          `gorm:"primaryKey" json:"id"`
+	CreatedAt        time.Time     `gorm:"not null" json:"created_at"`
+	SessionSummaryID uint          `gorm:"not null;index" json:"session_summary_id"`
+	AuthorID         uint          `gorm:"not null" json:"author_id"`
+	Author           *HealthWorker `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+	Text             string        `gorm:"type:text;not null" json:"text"`
+}
+
+// SummaryVersion is one entry of a summary's history: the content as it stood after each event.
+// Like the audit log it is append-only and has no UpdatedAt or DeletedAt.
+type SummaryVersion struct {
+	ID               uint            `gorm:"primaryKey" json:"id"`
+	CreatedAt        time.Time       `gorm:"not null" json:"created_at"`
+	SessionSummaryID uint            `gorm:"not null;index" json:"session_summary_id"`
+	Version          int             `gorm:"not null" json:"version"` // the summary's content version after the event
+	Event            string          `gorm:"not null" json:"event"`
+	ActorID          *uint           `json:"actor_id"` // nil for changes made by system jobs
+	Actor            *HealthWorker   `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	Notes            string          `gorm:"type:text" json:"notes"`
+	NoteTemplateID   *uint           `json:"note_template_id"`
+	Sections         json.RawMessage `gorm:"type:jsonb" json:"sections,omitempty"`
+	AddendumID       *uint           `json:"addendum_id,omitempty"`
+}
+
+// IsFinal reports whether a summary's content can no longer be edited or deleted: it has been
+// signed by its author or its session has been completed. Addenda can still be added.
+func (s *SessionSummary) IsFinal() bool {
+	return s.SignedAt != nil || s.LockedAt != nil
+}
+
+// AwaitingCoSignature reports whether a signed summary still needs the supervisor's co-signature
+func (s *SessionSummary) AwaitingCoSignature() bool {
+	return s.SignedAt != nil && s.CoSignRequired && s.CoSignedAt == nil
+}
+
+// NewSummaryVersion snapshots a summary's content for its history
+func NewSummaryVersion(s *SessionSummary, event string, actorID uint) SummaryVersion {
+	version := SummaryVersion{
+		SessionSummaryID: s.ID,
+		Version:          s.Version,
+		Event:            event,
+		Notes:            s.Notes,
+		NoteTemplateID:   s.NoteTemplateID,
+		Sections:         s.Sections,
+	}
+	if actorID != 0 {
+		version.ActorID = &actorID
+	}
+	return version
+}
+
+// BeforeCreate starts a new summary at version 1
+func (s *SessionSummary) BeforeCreate(tx *gorm.DB) error {
+	if s.Version == 0 {
+		s.Version = 1
+	}
+	return nil
+}
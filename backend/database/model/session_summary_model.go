@@ -1,10 +1,31 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"encoding/json"
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// SessionSummary holds the clinician's notes on a session. A structured summary is written on a
+// NoteTemplate: its sections are kept in Sections and rendered into Notes, so Notes always reads
+// as plain text. Once signed or locked the content is final and corrections go into Addenda.
 type SessionSummary struct {
 	gorm.Model
 	SessionID	uint 		`gorm:"not null;index" json:"session_id"`
 	Session		*Session	`gorm:"foreignKey:SessionID" json:"omitempty"`
 	Notes		string 		`gorm:"not null" json:"notes"`
-}
\ No newline at end of file
+	NoteTemplateID	*uint		`gorm:"index" json:"note_template_id"` // nil for a free-text summary
+	NoteTemplate	*NoteTemplate	`gorm:"foreignKey:NoteTemplateID" json:"note_template,omitempty"`
+	Sections	json.RawMessage	`gorm:"type:jsonb" json:"sections,omitempty"` // []NoteSection
+	Version		int		`gorm:"not null;default:1" json:"version"` // bumped on every edit
+	AuthorID	*uint		`gorm:"index" json:"author_id"` // nil for summaries written before authorship was recorded
+	Author		*HealthWorker	`gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+	SignedAt	*time.Time	`json:"signed_at"`
+	SignedByID	*uint		`json:"signed_by_id"`
+	CoSignRequired	bool		`gorm:"default:false" json:"co_sign_required"` // the author's supervisor must co-sign
+	CoSignedAt	*time.Time	`json:"co_signed_at"`
+	CoSignedByID	*uint		`json:"co_signed_by_id"`
+	LockedAt	*time.Time	`json:"locked_at"` // set when the session is completed
+	Addenda		[]SummaryAddendum `gorm:"foreignKey:SessionSummaryID" json:"addenda,omitempty"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "gorm.io/gorm"
+
+// SessionTransition records a session changing status. FromStatus is empty for the status a
+// session was created with.
+type SessionTransition struct {
+	gorm.Model
+	SessionID  uint          `gorm:"not null;index" json:"session_id"`
+	FromStatus string        `json:"from_status"`
+	ToStatus   string        `gorm:"not null" json:"to_status"`
+	ActorID    *uint         `json:"actor_id"` // unset for system jobs
+	Actor      *HealthWorker `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	Reason     string        `gorm:"type:text" json:"reason"`
+}
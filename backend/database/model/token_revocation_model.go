@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RevokedToken records a single JWT (by its token ID) that must no longer be accepted.
+// Rows can be purged once ExpiresAt has passed because the token would be rejected anyway.
+type RevokedToken struct {
+	gorm.Model
+	TokenID        string    `gorm:"not null;uniqueIndex" json:"token_id"`
+	HealthWorkerID uint      `gorm:"not null;index" json:"health_worker_id"`
+	ExpiresAt      time.Time `gorm:"not null;index" json:"expires_at"`
+}
+
+// HealthWorkerTokenRevocation invalidates every token issued to a health worker before RevokedBefore
+// ("log out all my devices").
+type HealthWorkerTokenRevocation struct {
+	gorm.Model
+	HealthWorkerID uint      `gorm:"not null;uniqueIndex" json:"health_worker_id"`
+	RevokedBefore  time.Time `gorm:"not null" json:"revoked_before"`
+	ExpiresAt      time.Time `gorm:"not null;index" json:"expires_at"` // when the last affected token expires
+}
@@ -0,0 +1,106 @@
+package model
+
+import "time"
+
+// Treatment-response thresholds for PHQ-9
+const (
+	Phq9ResponseReduction = 0.5 // response: total at least halved from baseline
+	Phq9RemissionBelow    = 5   // remission: total below this
+	Phq9ReliableChange    = 6   // reliable change: a move of at least this many points
+)
+
+// Trajectory flags
+const (
+	TrajectoryResponse              = "response"
+	TrajectoryRemission             = "remission"
+	TrajectoryReliableImprovement   = "reliable_improvement"
+	TrajectoryReliableDeterioration = "reliable_deterioration"
+	TrajectoryPossibleNonAdherence  = "possible_non_adherence" // no response, but adherence was poor
+)
+
+// AdherenceLookbackDays is how far before a patient's first scored session adherence is measured
+const AdherenceLookbackDays = 30
+
+// TrajectoryPoint is a patient's PHQ-9 total at one session, compared with their first
+type TrajectoryPoint struct {
+	SessionID     uint      `json:"session_id"`
+	SessionCode   string    `json:"session_code"`
+	Date          time.Time `json:"date"`
+	Score         int       `json:"score"`
+	Severity      string    `json:"severity"`
+	Prescription  string    `json:"prescription"`
+	Change        int       `json:"change"`                   // points since baseline; negative is improvement
+	PercentChange *float64  `json:"percent_change,omitempty"` // unset when the baseline is 0
+	Flags         []string  `json:"flags"`
+
+	Adherence *AdherenceSnapshot `json:"adherence,omitempty"` // since the previous session; unset without structured lines
+}
+
+// ItemScore is one session's answer to a PHQ-9 item
+type ItemScore struct {
+	SessionID uint      `json:"session_id"`
+	Date      time.Time `json:"date"`
+	Score     int       `json:"score"`
+}
+
+// ItemTrend follows one PHQ-9 item across the sessions it was answered in
+type ItemTrend struct {
+	Position int         `json:"position"`
+	Text     string      `json:"text"`
+	Scores   []ItemScore `json:"scores"`
+	Baseline int         `json:"baseline"`
+	Latest   int         `json:"latest"`
+	Change   int         `json:"change"`
+}
+
+// PrescriptionResponse compares scores since the current prescription was started
+type PrescriptionResponse struct {
+	Prescription  string    `json:"prescription"`
+	Since         time.Time `json:"since"`
+	Sessions      int       `json:"sessions"`
+	BaselineScore int       `json:"baseline_score"`
+	LatestScore   int       `json:"latest_score"`
+	Change        int       `json:"change"`
+	PercentChange *float64  `json:"percent_change,omitempty"`
+	Flags         []string  `json:"flags"`
+}
+
+// Trajectory is a patient's PHQ-9 history with treatment-response flags for the latest session
+type Trajectory struct {
+	PatientID           uint                  `json:"patient_id"`
+	Points              []TrajectoryPoint     `json:"points"`
+	Items               []ItemTrend           `json:"items"`
+	Baseline            *TrajectoryPoint      `json:"baseline,omitempty"`
+	Latest              *TrajectoryPoint      `json:"latest,omitempty"`
+	Flags               []string              `json:"flags"`
+	CurrentPrescription *PrescriptionResponse `json:"current_prescription,omitempty"`
+}
+
+// TreatmentFlags compares a PHQ-9 total with a baseline and returns the response, remission and
+// reliable change flags that apply
+func TreatmentFlags(baseline, score int) []string {
+	flags := []string{}
+	if baseline > 0 && float64(baseline-score) >= Phq9ResponseReduction*float64(baseline) {
+		flags = append(flags, TrajectoryResponse)
+	}
+	if score < Phq9RemissionBelow {
+		flags = append(flags, TrajectoryRemission)
+	}
+	if baseline-score >= Phq9ReliableChange {
+		flags = append(flags, TrajectoryReliableImprovement)
+	}
+	if score-baseline >= Phq9ReliableChange {
+		flags = append(flags, TrajectoryReliableDeterioration)
+	}
+	return flags
+}
+
+// PercentChange is the change from baseline to score as a percentage of baseline, or nil when the
+// baseline is 0
+func PercentChange(baseline, score int) *float64 {
+	if baseline == 0 {
+		return nil
+	}
+	pct := float64(score-baseline) / float64(baseline) * 100
+	return &pct
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"depression-diagnosis-system/database/model"
+	"encoding/json"
+	"log"
+)
+
+// noteSection is a template section; choices are only given for choice sections
+func noteSection(key, title, fieldType string, required bool, choices ...string) model.NoteTemplateSection {
+	s := model.NoteTemplateSection{Key: key, Title: title, Type: fieldType, Required: required}
+	if len(choices) > 0 {
+		s.Options, _ = json.Marshal(choices)
+	}
+	return s
+}
+
+func soapTemplate() model.NoteTemplate {
+	return model.NoteTemplate{
+		Code:        model.NoteTemplateSOAP,
+		Name:        "SOAP Note",
+		Description: "Progress note for a follow-up session",
+		Sections: []model.NoteTemplateSection{
+			noteSection("subjective", "Subjective", model.NoteFieldLongText, true),
+			noteSection("objective", "Objective", model.NoteFieldLongText, true),
+			noteSection("assessment", "Assessment", model.NoteFieldLongText, true),
+			noteSection("plan", "Plan", model.NoteFieldLongText, true),
+		},
+	}
+}
+
+func intakeTemplate() model.NoteTemplate {
+	return model.NoteTemplate{
+		Code:        model.NoteTemplateIntake,
+		Name:        "Intake Assessment",
+		Description: "First assessment of a patient referred for depression care",
+		Sections: []model.NoteTemplateSection{
+			noteSection("referral_source", "Referral Source", model.NoteFieldChoice, true,
+				"Self", "Family", "Community health worker", "Health facility", "Other"),
+			noteSection("presenting_complaint", "Presenting Complaint", model.NoteFieldLongText, true),
+			noteSection("history_of_illness", "History of Present Illness", model.NoteFieldLongText, true),
+			noteSection("psychiatric_history", "Past Psychiatric History", model.NoteFieldLongText, false),
+			noteSection("medical_history", "Medical History", model.NoteFieldLongText, false),
+			noteSection("substance_use", "Substance Use", model.NoteFieldMultiChoice, false,
+				"None", "Alcohol", "Tobacco", "Cannabis", "Khat", "Other"),
+			noteSection("social_history", "Social History", model.NoteFieldLongText, false),
+			noteSection("self_harm_history", "History of Self-Harm", model.NoteFieldBoolean, true),
+			noteSection("risk_assessment", "Risk Assessment", model.NoteFieldChoice, true, "Low", "Moderate", "High"),
+			noteSection("plan", "Plan", model.NoteFieldLongText, true),
+		},
+	}
+}
+
+func mseTemplate() model.NoteTemplate {
+	return model.NoteTemplate{
+		Code:        model.NoteTemplateMSE,
+		Name:        "Mental Status Examination",
+		Description: "Structured mental status examination",
+		Sections: []model.NoteTemplateSection{
+			noteSection("appearance", "Appearance and Behaviour", model.NoteFieldText, true),
+			noteSection("speech", "Speech", model.NoteFieldChoice, true, "Normal", "Slowed", "Pressured", "Reduced", "Mute"),
+			noteSection("mood", "Mood (patient's words)", model.NoteFieldText, true),
+			noteSection("affect", "Affect", model.NoteFieldChoice, true, "Euthymic", "Depressed", "Anxious", "Flat", "Labile", "Irritable"),
+			noteSection("thought_process", "Thought Process", model.NoteFieldText, true),
+			noteSection("thought_content", "Thought Content", model.NoteFieldLongText, true),
+			noteSection("suicidal_ideation", "Suicidal Ideation", model.NoteFieldBoolean, true),
+			noteSection("perception", "Perception", model.NoteFieldText, false),
+			noteSection("cognition", "Cognition", model.NoteFieldText, false),
+			noteSection("insight", "Insight", model.NoteFieldChoice, true, "Good", "Partial", "Poor"),
+			noteSection("judgement", "Judgement", model.NoteFieldChoice, true, "Good", "Partial", "Poor"),
+		},
+	}
+}
+
+func dischargeTemplate() model.NoteTemplate {
+	return model.NoteTemplate{
+		Code:        model.NoteTemplateDischarge,
+		Name:        "Discharge Summary",
+		Description: "Summary written when a patient leaves depression care",
+		Sections: []model.NoteTemplateSection{
+			noteSection("discharge_date", "Discharge Date", model.NoteFieldDate, true),
+			noteSection("reason", "Reason for Discharge", model.NoteFieldChoice, true,
+				"Remission", "Treatment completed", "Transferred", "Patient request", "Lost to follow-up"),
+			noteSection("course", "Course of Treatment", model.NoteFieldLongText, true),
+			noteSection("final_phq9", "Final PHQ-9 Score", model.NoteFieldNumber, false),
+			noteSection("medications", "Medications at Discharge", model.NoteFieldLongText, false),
+			noteSection("follow_up", "Follow-up Plan", model.NoteFieldLongText, true),
+		},
+	}
+}
+
+// SeedNoteTemplates installs the standard note templates. Existing templates are left untouched.
+func SeedNoteTemplates() {
+	templates := []model.NoteTemplate{soapTemplate(), intakeTemplate(), mseTemplate(), dischargeTemplate()}
+	for _, template := range templates {
+		var count int64
+		DB.Model(&model.NoteTemplate{}).Where("code = ?", template.Code).Count(&count)
+		if count > 0 {
+			continue
+		}
+		if err := template.Validate(); err != nil {
+			log.Printf("❌ Failed to seed note template %s: %v", template.Code, err)
+			continue
+		}
+		template.IsActive = true
+		if err := DB.Create(&template).Error; err != nil {
+			log.Printf("❌ Failed to seed note template %s: %v", template.Code, err)
+			continue
+		}
+		log.Printf("📝 Note template seeded: %s", template.Code)
+	}
+}
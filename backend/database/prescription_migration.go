@@ -0,0 +1,25 @@
+package database
+
+// migratePrescriptionText keeps the free-text prescriptions written before prescriptions were
+// structured: each becomes an unstructured line of its medication history entry or session. It
+// runs when the prescription lines table is first created; text that already has lines is skipped.
+func migratePrescriptionText() error {
+	statements := []string{
+		`INSERT INTO prescription_lines (created_at, updated_at, patient_id, medication_history_id, unstructured, text, start_date, prescriber_id)
+		SELECT m.created_at, NOW(), m.patient_id, m.id, TRUE, m.prescription, m.created_at, m.prescribing_doctor_id
+		FROM medication_histories m
+		WHERE m.deleted_at IS NULL AND TRIM(COALESCE(m.prescription, '')) <> ''
+			AND NOT EXISTS (SELECT 1 FROM prescription_lines l WHERE l.medication_history_id = m.id)`,
+		`INSERT INTO prescription_lines (created_at, updated_at, patient_id, session_id, unstructured, text, start_date, prescriber_id)
+		SELECT s.created_at, NOW(), s.patient_id, s.id, TRUE, s.current_prescription, s.date, s.health_worker_id
+		FROM sessions s
+		WHERE s.deleted_at IS NULL AND TRIM(COALESCE(s.current_prescription, '')) <> ''
+			AND NOT EXISTS (SELECT 1 FROM prescription_lines l WHERE l.session_id = s.id)`,
+	}
+	for _, stmt := range statements {
+		if err := DB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
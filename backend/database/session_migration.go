@@ -0,0 +1,13 @@
+package database
+
+import "depression-diagnosis-system/database/model"
+
+// dropSessionStatusCheck removes the sessions.status check constraint so AutoMigrate recreates it
+// with the current list of statuses; AutoMigrate never changes an existing constraint.
+func dropSessionStatusCheck() error {
+	migrator := DB.Migrator()
+	if !migrator.HasTable(&model.Session{}) || !migrator.HasConstraint(&model.Session{}, "chk_sessions_status") {
+		return nil
+	}
+	return migrator.DropConstraint(&model.Session{}, "chk_sessions_status")
+}
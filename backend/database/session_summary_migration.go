@@ -0,0 +1,78 @@
+package database
+
+// signatureColumns are the session summary columns that may still be filled in once a summary is
+// final; each may only be set once
+const signatureColumns = `- 'updated_at' - 'signed_at' - 'signed_by_id' - 'co_sign_required' - 'co_signed_at' - 'co_signed_by_id' - 'locked_at'`
+
+// migrateSessionSummaryHistory locks the summaries of sessions completed before locking existed
+// and gives every summary without a history its first version. Safe to run on every start.
+func migrateSessionSummaryHistory() error {
+	statements := []string{
+		`UPDATE session_summaries SET locked_at = NOW()
+		WHERE locked_at IS NULL AND deleted_at IS NULL
+			AND session_id IN (SELECT id FROM sessions WHERE status = 'completed')`,
+		`INSERT INTO summary_versions (created_at, session_summary_id, version, event, notes, note_template_id, sections)
+		SELECT s.created_at, s.id, s.version, 'created', s.notes, s.note_template_id, s.sections
+		FROM session_summaries s
+		WHERE s.deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM summary_versions v WHERE v.session_summary_id = s.id)`,
+	}
+	for _, stmt := range statements {
+		if err := DB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProtectSessionSummaries installs triggers so a signed or locked summary cannot be edited or
+// deleted, even with raw SQL, and so addenda and summary history are append-only. A final summary
+// can still be signed, co-signed and locked.
+func ProtectSessionSummaries() error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION session_summaries_final() RETURNS trigger AS $$
+		BEGIN
+			IF OLD.signed_at IS NULL AND OLD.locked_at IS NULL THEN
+				IF TG_OP = 'DELETE' THEN
+					RETURN OLD;
+				END IF;
+				RETURN NEW;
+			END IF;
+			IF TG_OP = 'UPDATE'
+				AND to_jsonb(NEW) ` + signatureColumns + ` = to_jsonb(OLD) ` + signatureColumns + `
+				AND (OLD.signed_at IS NULL OR (NEW.signed_at = OLD.signed_at
+					AND NEW.signed_by_id IS NOT DISTINCT FROM OLD.signed_by_id
+					AND NEW.co_sign_required = OLD.co_sign_required))
+				AND (OLD.co_signed_at IS NULL OR (NEW.co_signed_at = OLD.co_signed_at
+					AND NEW.co_signed_by_id IS NOT DISTINCT FROM OLD.co_signed_by_id))
+				AND (OLD.locked_at IS NULL OR NEW.locked_at = OLD.locked_at) THEN
+				RETURN NEW;
+			END IF;
+			RAISE EXCEPTION 'session summary % is signed or locked and cannot be changed', OLD.id;
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS session_summaries_final ON session_summaries`,
+		`CREATE TRIGGER session_summaries_final BEFORE UPDATE OR DELETE ON session_summaries
+		FOR EACH ROW EXECUTE FUNCTION session_summaries_final()`,
+
+		`CREATE OR REPLACE FUNCTION summary_records_immutable() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+		END;
+		$$ LANGUAGE plpgsql`,
+	}
+	for _, table := range []string{"summary_addendums", "summary_versions"} {
+		statements = append(statements,
+			`DROP TRIGGER IF EXISTS `+table+`_immutable ON `+table,
+			`CREATE TRIGGER `+table+`_immutable BEFORE UPDATE OR DELETE ON `+table+`
+			FOR EACH ROW EXECUTE FUNCTION summary_records_immutable()`,
+		)
+	}
+
+	for _, stmt := range statements {
+		if err := DB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
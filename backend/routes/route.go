@@ -3,6 +3,7 @@ package routes
 import (
 	"depression-diagnosis-system/api/handler"
 	"depression-diagnosis-system/api/middleware"
+	"depression-diagnosis-system/database/model"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,21 +18,40 @@ func Routes(router *gin.Engine) {
 	diagnosisHandler := handler.NewDiagnosisHandler()
 	sessionHandler := handler.NewSessionHandler()
 	summaryHandler := handler.NewSessionSummaryHandler()
+	noteTemplateHandler := handler.NewNoteTemplateHandler()
 	messageHandler := handler.NewMessageHandler()
+	deviceSessionHandler := handler.NewDeviceSessionHandler()
+	patientAccessHandler := handler.NewPatientAccessHandler()
+	auditHandler := handler.NewAuditHandler()
+	riskAlertHandler := handler.NewRiskAlertHandler()
+	instrumentHandler := handler.NewInstrumentHandler()
+	translationHandler := handler.NewInstrumentTranslationHandler()
+	trajectoryHandler := handler.NewTrajectoryHandler()
+	appointmentHandler := handler.NewAppointmentHandler()
+	calendarFeedHandler := handler.NewCalendarFeedHandler()
+	defaulterHandler := handler.NewDefaulterHandler()
+	allergyHandler := handler.NewAllergyHandler()
 
 	// ------------------- Health Worker Routes -------------------
 	healthRoutes := router.Group("/api/v1/health-workers")
 	healthRoutes.POST("/login", healthWorkerHandler.Login)
+	healthRoutes.POST("/password/reset", healthWorkerHandler.ResetPassword)
+	healthRoutes.POST("/refresh", deviceSessionHandler.Refresh)
 	healthRoutes.Use(middleware.AuthMiddleware())
 	{
-		healthRoutes.POST("/create", healthWorkerHandler.CreateHealthWorker)
+		healthRoutes.POST("/create", middleware.RequirePermission(model.PermHealthWorkerCreate), healthWorkerHandler.CreateHealthWorker)
 		healthRoutes.GET("/all", healthWorkerHandler.GetAllHealthWorkers)
 		healthRoutes.GET("/me", healthWorkerHandler.GetHealthWorkerByID)
-		healthRoutes.PUT("/:id", healthWorkerHandler.UpdateHealthWorker)
-		healthRoutes.DELETE("/:id", healthWorkerHandler.DeleteHealthWorker)
-		healthRoutes.PUT("/:id/active", healthWorkerHandler.SetActiveStatus)
+		healthRoutes.PUT("/:id", middleware.RequirePermission(model.PermHealthWorkerUpdate), healthWorkerHandler.UpdateHealthWorker)
+		healthRoutes.DELETE("/:id", middleware.RequirePermission(model.PermHealthWorkerDelete), healthWorkerHandler.DeleteHealthWorker)
+		healthRoutes.PUT("/:id/active", middleware.RequirePermission(model.PermHealthWorkerManageStatus), healthWorkerHandler.SetActiveStatus)
 		healthRoutes.POST("/logout", middleware.AuthMiddleware(), healthWorkerHandler.Logout)
+		healthRoutes.POST("/logout-all", healthWorkerHandler.LogoutAllDevices)
+		healthRoutes.GET("/me/sessions", deviceSessionHandler.GetMySessions)
+		healthRoutes.DELETE("/me/sessions/:id", deviceSessionHandler.RevokeMySession)
 		healthRoutes.GET("/search", healthWorkerHandler.SearchHealthWorkers)
+		healthRoutes.PUT("/me/password", healthWorkerHandler.ChangePassword)
+		healthRoutes.POST("/:id/password-reset", middleware.RequirePermission(model.PermHealthWorkerResetPassword), healthWorkerHandler.IssuePasswordReset)
 
 	}
 
@@ -39,10 +59,10 @@ func Routes(router *gin.Engine) {
 	ptRoutes := router.Group("/api/v1/personnel-types")
 	ptRoutes.Use(middleware.AuthMiddleware())
 	{
-		ptRoutes.POST("/create", personnelTypeHandler.CreatePersonnelType)
+		ptRoutes.POST("/create", middleware.RequirePermission(model.PermPersonnelTypeManage), personnelTypeHandler.CreatePersonnelType)
 		ptRoutes.GET("/all", personnelTypeHandler.GetAllPersonnelTypes)
 		ptRoutes.GET("/:id", personnelTypeHandler.GetPersonnelTypeByID)
-		ptRoutes.DELETE("/:id", personnelTypeHandler.DeletePersonnelType)
+		ptRoutes.DELETE("/:id", middleware.RequirePermission(model.PermPersonnelTypeManage), personnelTypeHandler.DeletePersonnelType)
 		ptRoutes.GET("/searcch", personnelTypeHandler.SearchPersonnelType)
 	}
 
@@ -50,72 +70,196 @@ func Routes(router *gin.Engine) {
 	patientRoutes := router.Group("/api/v1/patients")
 	patientRoutes.Use(middleware.AuthMiddleware())
 	{
-		patientRoutes.POST("/create", patientHandler.CreatePatient)
-		patientRoutes.GET("/all", patientHandler.GetAllPatients)
-		patientRoutes.GET("/:id", patientHandler.GetPatientByID)
-		patientRoutes.PUT("/:id", patientHandler.UpdatePatient)
-		patientRoutes.DELETE("/:id", patientHandler.DeletePatient)
+		patientRoutes.POST("/create", middleware.RequirePermission(model.PermPatientCreate), patientHandler.CreatePatient)
+		patientRoutes.GET("/all", middleware.AuditRead(model.AuditEntityPatient, ""), patientHandler.GetAllPatients)
+		patientRoutes.GET("/:id", middleware.AuditRead(model.AuditEntityPatient, "id"), patientHandler.GetPatientByID)
+		patientRoutes.PUT("/:id", middleware.RequirePermission(model.PermPatientUpdate), patientHandler.UpdatePatient)
+		patientRoutes.DELETE("/:id", middleware.RequirePermission(model.PermPatientDelete), patientHandler.DeletePatient)
 
-		patientRoutes.GET("/by-healthworker/:id", patientHandler.GetPatientsByHealthWorker)                  // GET /patients/by-healthworker/:id
-		patientRoutes.GET("/by-healthworker-specific/me", patientHandler.GetPatientByHealthWorker)
-		patientRoutes.GET("/by-department/:id", patientHandler.GetPatientsByDepartment)                      // GET /patients/by-department/:id
-		patientRoutes.PUT("/:id/active", patientHandler.SetActiveStatus)
-    	patientRoutes.GET("/search", patientHandler.SearchPatients)   
+		patientRoutes.GET("/by-healthworker/:id", middleware.AuditRead(model.AuditEntityPatient, ""), patientHandler.GetPatientsByHealthWorker)                  // GET /patients/by-healthworker/:id
+		patientRoutes.GET("/by-healthworker-specific/me", middleware.AuditRead(model.AuditEntityPatient, ""), patientHandler.GetPatientByHealthWorker)
+		patientRoutes.GET("/by-department/:id", middleware.AuditRead(model.AuditEntityPatient, ""), patientHandler.GetPatientsByDepartment)                      // GET /patients/by-department/:id
+		patientRoutes.PUT("/:id/active", middleware.RequirePermission(model.PermPatientManageStatus), patientHandler.SetActiveStatus)
+    	patientRoutes.GET("/search", middleware.AuditRead(model.AuditEntityPatient, ""), patientHandler.SearchPatients)   
+
+		patientRoutes.GET("/:id/care-team", patientAccessHandler.GetCareTeam)
+		patientRoutes.POST("/:id/care-team", middleware.RequirePermission(model.PermCareTeamManage), patientAccessHandler.AddCareTeamMember)
+		patientRoutes.DELETE("/:id/care-team/:memberId", middleware.RequirePermission(model.PermCareTeamManage), patientAccessHandler.RemoveCareTeamMember)
+		patientRoutes.POST("/:id/break-glass", patientAccessHandler.BreakGlass)
+		patientRoutes.GET("/:id/trajectory", middleware.AuditRead(model.AuditEntityPatient, "id"), trajectoryHandler.GetPatientTrajectory)
+		patientRoutes.GET("/:id/attendance", middleware.AuditRead(model.AuditEntityPatient, "id"), defaulterHandler.GetPatientAttendance)
+		patientRoutes.GET("/:id/allergies", middleware.AuditRead(model.AuditEntityPatientAllergy, "patient_id"), allergyHandler.GetPatientAllergies)
+		patientRoutes.POST("/:id/allergies", middleware.RequirePermission(model.PermPatientUpdate), allergyHandler.AddAllergy)
+		patientRoutes.DELETE("/:id/allergies/:allergyId", middleware.RequirePermission(model.PermPatientUpdate), allergyHandler.RemoveAllergy)
+	}
+
+	// ------------------- Break-the-Glass Review Routes -------------------
+	breakGlassRoutes := router.Group("/api/v1/break-glass")
+	breakGlassRoutes.Use(middleware.AuthMiddleware())
+	{
+		breakGlassRoutes.GET("/pending", patientAccessHandler.GetPendingBreakGlassReviews)
+		breakGlassRoutes.PUT("/:id/review", patientAccessHandler.ReviewBreakGlass)
 	}
 
 	// ------------------- PHQ-9 Question Routes -------------------
 	qRoutes := router.Group("/api/v1/phq9/questions")
 	qRoutes.Use(middleware.AuthMiddleware())
 	{
-		qRoutes.POST("/create", phq9QuestionHandler.CreateQuestion)
+		qRoutes.POST("/create", middleware.RequirePermission(model.PermPhq9QuestionManage), phq9QuestionHandler.CreateQuestion)
 		qRoutes.GET("/all", phq9QuestionHandler.GetAllQuestions)
 		qRoutes.GET("/:id", phq9QuestionHandler.GetQuestionByID)
-		qRoutes.DELETE("/:id", phq9QuestionHandler.DeleteQuestion)
+		qRoutes.DELETE("/:id", middleware.RequirePermission(model.PermPhq9QuestionManage), phq9QuestionHandler.DeleteQuestion)
 	}
 
 	// ------------------- PHQ-9 Response Routes -------------------
 	rRoutes := router.Group("/api/v1/phq9/responses")
 	rRoutes.Use(middleware.AuthMiddleware())
 	{
-		rRoutes.POST("/create", phq9ResponseHandler.CreateResponse)
+		rRoutes.POST("/create/:id", middleware.RequirePermission(model.PermPhq9ResponseCreate), phq9ResponseHandler.CreateResponse)
 		rRoutes.GET("/:id", phq9ResponseHandler.GetResponseBySessionID)
-		rRoutes.PUT("/:id", phq9ResponseHandler.UpdateResponse)
-		rRoutes.DELETE("/:id", phq9ResponseHandler.DeleteResponse)
+		rRoutes.PUT("/:id", middleware.RequirePermission(model.PermPhq9ResponseUpdate), phq9ResponseHandler.UpdateResponse)
+		rRoutes.DELETE("/:id", middleware.RequirePermission(model.PermPhq9ResponseDelete), phq9ResponseHandler.DeleteResponse)
+	}
+
+	// ------------------- Instrument Routes -------------------
+	instrumentRoutes := router.Group("/api/v1/instruments")
+	instrumentRoutes.Use(middleware.AuthMiddleware())
+	{
+		instrumentRoutes.POST("/create", middleware.RequirePermission(model.PermInstrumentManage), instrumentHandler.CreateInstrument)
+		instrumentRoutes.GET("/all", instrumentHandler.GetAllInstruments)
+		instrumentRoutes.GET("/code/:code", instrumentHandler.GetInstrumentByCode)
+		instrumentRoutes.GET("/:id", instrumentHandler.GetInstrumentByID)
+		instrumentRoutes.PUT("/:id/active", middleware.RequirePermission(model.PermInstrumentManage), instrumentHandler.SetActiveStatus)
+		instrumentRoutes.GET("/:id/versions", instrumentHandler.GetVersions)
+		instrumentRoutes.POST("/:id/versions", middleware.RequirePermission(model.PermInstrumentManage), instrumentHandler.CreateVersion)
+	}
+
+	// ------------------- Instrument Version Routes -------------------
+	instrumentVersionRoutes := router.Group("/api/v1/instrument-versions")
+	instrumentVersionRoutes.Use(middleware.AuthMiddleware())
+	{
+		instrumentVersionRoutes.GET("/:id", instrumentHandler.GetVersionByID)
+		instrumentVersionRoutes.PUT("/:id", middleware.RequirePermission(model.PermInstrumentManage), instrumentHandler.UpdateVersion)
+		instrumentVersionRoutes.POST("/:id/publish", middleware.RequirePermission(model.PermInstrumentManage), instrumentHandler.PublishVersion)
+		instrumentVersionRoutes.DELETE("/:id", middleware.RequirePermission(model.PermInstrumentManage), instrumentHandler.DeleteVersion)
+	}
+
+	// ------------------- Instrument Translation Routes -------------------
+	translationRoutes := router.Group("/api/v1/instrument-translations")
+	translationRoutes.Use(middleware.AuthMiddleware())
+	{
+		translationRoutes.GET("/version/:id", translationHandler.GetVersionTranslations)
+		translationRoutes.PUT("/item/:id", middleware.RequirePermission(model.PermInstrumentTranslate), translationHandler.SaveTranslation)
+		translationRoutes.POST("/:id/approve", middleware.RequirePermission(model.PermInstrumentTranslationReview), translationHandler.ApproveTranslation)
+		translationRoutes.DELETE("/:id", middleware.RequirePermission(model.PermInstrumentManage), translationHandler.DeleteTranslation)
+	}
+
+	// ------------------- Instrument Response Routes -------------------
+	instrumentResponseRoutes := router.Group("/api/v1/instrument-responses")
+	instrumentResponseRoutes.Use(middleware.AuthMiddleware())
+	{
+		instrumentResponseRoutes.POST("/create", middleware.RequirePermission(model.PermInstrumentResponseCreate), instrumentHandler.CreateResponse)
+		instrumentResponseRoutes.GET("/session/:id", instrumentHandler.GetResponsesBySession)
+		instrumentResponseRoutes.GET("/:id", instrumentHandler.GetResponseByID)
+		instrumentResponseRoutes.PUT("/:id", middleware.RequirePermission(model.PermInstrumentResponseUpdate), instrumentHandler.UpdateResponse)
+		instrumentResponseRoutes.DELETE("/:id", middleware.RequirePermission(model.PermInstrumentResponseDelete), instrumentHandler.DeleteResponse)
 	}
 
 	// ------------------- Diagnosis Routes -------------------
 	diagnosisRoutes := router.Group("/api/v1/diagnosis")
 	diagnosisRoutes.Use(middleware.AuthMiddleware())
 	{
-		diagnosisRoutes.POST("/:id", diagnosisHandler.CreateDiagnosis)
-		diagnosisRoutes.GET("/:id", diagnosisHandler.GetDiagnosisBySessionID)
+		diagnosisRoutes.POST("/:id", middleware.RequirePermission(model.PermDiagnosisCreate), diagnosisHandler.CreateDiagnosis)
+		diagnosisRoutes.GET("/:id", middleware.AuditRead(model.AuditEntityDiagnosis, "session_id"), diagnosisHandler.GetDiagnosisBySessionID)
 	}
 
 	// ------------------- Session Routes -------------------
 	sessionRoutes := router.Group("/api/v1/sessions")
 	sessionRoutes.Use(middleware.AuthMiddleware())
 	{
-		sessionRoutes.POST("/create", sessionHandler.CreateSession)
-		sessionRoutes.GET("/all", sessionHandler.GetAllSessions)
-		sessionRoutes.GET("/:id", sessionHandler.GetSessionByID)
-		sessionRoutes.GET("/healthworker/me", sessionHandler.GetSessionsByHealthWorker)
-		sessionRoutes.PUT("/:id", sessionHandler.UpdateSession)
-		sessionRoutes.DELETE("/:id", sessionHandler.DeleteSession)
-		sessionRoutes.GET("/code/:code", sessionHandler.GetSessionByCode)
-		sessionRoutes.PUT("/status", sessionHandler.UpdateSessionStatus)
-		sessionRoutes.GET("/patient/:id", sessionHandler.GetSessionsByPatient)
-		sessionRoutes.GET("/Search", sessionHandler.SearchSessions)
+		sessionRoutes.POST("/create", middleware.RequirePermission(model.PermSessionCreate), sessionHandler.CreateSession)
+		sessionRoutes.GET("/all", middleware.AuditRead(model.AuditEntitySession, ""), sessionHandler.GetAllSessions)
+		sessionRoutes.GET("/:id", middleware.AuditRead(model.AuditEntitySession, "id"), sessionHandler.GetSessionByID)
+		sessionRoutes.GET("/healthworker/me", middleware.AuditRead(model.AuditEntitySession, ""), sessionHandler.GetSessionsByHealthWorker)
+		sessionRoutes.PUT("/:id", middleware.RequirePermission(model.PermSessionUpdate), sessionHandler.UpdateSession)
+		sessionRoutes.DELETE("/:id", middleware.RequirePermission(model.PermSessionDelete), sessionHandler.DeleteSession)
+		sessionRoutes.GET("/code/:code", middleware.AuditRead(model.AuditEntitySession, ""), sessionHandler.GetSessionByCode)
+		sessionRoutes.PUT("/status", middleware.RequirePermission(model.PermSessionUpdate), sessionHandler.UpdateSessionStatus)
+		sessionRoutes.GET("/:id/transitions", middleware.AuditRead(model.AuditEntitySession, "id"), sessionHandler.GetSessionTransitions)
+		sessionRoutes.GET("/:id/calendar.ics", middleware.AuditRead(model.AuditEntitySession, "id"), calendarFeedHandler.GetSessionCalendar)
+		sessionRoutes.GET("/patient/:id", middleware.AuditRead(model.AuditEntitySession, "patient_id"), sessionHandler.GetSessionsByPatient)
+		sessionRoutes.GET("/Search", middleware.AuditRead(model.AuditEntitySession, ""), sessionHandler.SearchSessions)
 	
 	}
 
+	// ------------------- Appointment Routes -------------------
+	appointmentRoutes := router.Group("/api/v1/appointments")
+	appointmentRoutes.Use(middleware.AuthMiddleware())
+	{
+		appointmentRoutes.POST("/create", middleware.RequirePermission(model.PermAppointmentManage), appointmentHandler.BookAppointment)
+		appointmentRoutes.GET("/slots", appointmentHandler.GetAvailableSlots)
+		appointmentRoutes.GET("/calendar", middleware.AuditRead(model.AuditEntityAppointment, ""), appointmentHandler.GetCalendar)
+		appointmentRoutes.GET("/patient/:id", middleware.AuditRead(model.AuditEntityAppointment, "patient_id"), appointmentHandler.GetAppointmentsByPatient)
+		appointmentRoutes.GET("/:id", middleware.AuditRead(model.AuditEntityAppointment, "id"), appointmentHandler.GetAppointmentByID)
+		appointmentRoutes.PUT("/:id/reschedule", middleware.RequirePermission(model.PermAppointmentManage), appointmentHandler.RescheduleAppointment)
+		appointmentRoutes.PUT("/:id/cancel", middleware.RequirePermission(model.PermAppointmentManage), appointmentHandler.CancelAppointment)
+		appointmentRoutes.POST("/:id/check-in", middleware.RequirePermission(model.PermAppointmentManage), appointmentHandler.CheckInAppointment)
+	}
+
+	// ------------------- Schedule Routes -------------------
+	scheduleRoutes := router.Group("/api/v1/schedules")
+	scheduleRoutes.Use(middleware.AuthMiddleware())
+	{
+		scheduleRoutes.GET("/health-worker/:id/working-hours", appointmentHandler.GetWorkingHours)
+		scheduleRoutes.PUT("/health-worker/:id/working-hours", middleware.RequirePermission(model.PermScheduleManage), appointmentHandler.SetWorkingHours)
+		scheduleRoutes.GET("/health-worker/:id/leave", appointmentHandler.GetLeave)
+		scheduleRoutes.POST("/health-worker/:id/leave", middleware.RequirePermission(model.PermScheduleManage), appointmentHandler.CreateLeave)
+		scheduleRoutes.DELETE("/leave/:id", middleware.RequirePermission(model.PermScheduleManage), appointmentHandler.DeleteLeave)
+	}
+
+	// ------------------- Defaulter Routes -------------------
+	defaulterRoutes := router.Group("/api/v1/defaulters")
+	defaulterRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission(model.PermDefaulterManage))
+	{
+		defaulterRoutes.GET("/worklist", middleware.AuditRead(model.AuditEntityPatient, ""), defaulterHandler.GetDefaulterWorklist)
+		defaulterRoutes.POST("/detect", defaulterHandler.DetectMissedFollowUps)
+		defaulterRoutes.PUT("/:id/resolve", defaulterHandler.ResolveMissedFollowUp)
+	}
+
+	// ------------------- Calendar Feed Routes -------------------
+	calendarFeedRoutes := router.Group("/api/v1/calendar-feeds")
+	calendarFeedRoutes.GET("/:token", middleware.AuditRead(model.AuditEntitySession, ""), calendarFeedHandler.GetCalendarFeed) // authenticated by the token itself
+	calendarFeedRoutes.Use(middleware.AuthMiddleware())
+	{
+		calendarFeedRoutes.POST("/me", calendarFeedHandler.IssueCalendarFeed)
+		calendarFeedRoutes.DELETE("/me", calendarFeedHandler.RevokeCalendarFeed)
+	}
+
 	// ------------------- Session Summary Routes -------------------
 	summaryRoutes := router.Group("/api/v1/session-summaries")
 	summaryRoutes.Use(middleware.AuthMiddleware())
 	{
-		summaryRoutes.POST("/create", summaryHandler.CreateSummary)
-		summaryRoutes.GET("/:id", summaryHandler.GetSummaryBySessionID)
-		summaryRoutes.PUT("/:id", summaryHandler.UpdateSummary)
-		summaryRoutes.DELETE("/:id", summaryHandler.DeleteSummary)
+		summaryRoutes.POST("/create", middleware.RequirePermission(model.PermSessionSummaryCreate), summaryHandler.CreateSummary)
+		summaryRoutes.GET("/:id", middleware.AuditRead(model.AuditEntitySessionSummary, "session_id"), summaryHandler.GetSummaryBySessionID)
+		summaryRoutes.PUT("/:id", middleware.RequirePermission(model.PermSessionSummaryUpdate), summaryHandler.UpdateSummary)
+		summaryRoutes.DELETE("/:id", middleware.RequirePermission(model.PermSessionSummaryDelete), summaryHandler.DeleteSummary)
+		summaryRoutes.GET("/co-sign/pending", middleware.AuditRead(model.AuditEntitySessionSummary, ""), summaryHandler.GetPendingCoSignatures)
+		summaryRoutes.GET("/:id/history", middleware.AuditRead(model.AuditEntitySessionSummary, "id"), summaryHandler.GetSummaryHistory)
+		summaryRoutes.POST("/:id/sign", middleware.RequirePermission(model.PermSessionSummaryUpdate), summaryHandler.SignSummary)
+		summaryRoutes.POST("/:id/co-sign", summaryHandler.CoSignSummary)
+		summaryRoutes.POST("/:id/addenda", middleware.RequirePermission(model.PermSessionSummaryUpdate), summaryHandler.AddAddendum)
+	}
+
+	// ------------------- Note Template Routes -------------------
+	noteTemplateRoutes := router.Group("/api/v1/note-templates")
+	noteTemplateRoutes.Use(middleware.AuthMiddleware())
+	{
+		noteTemplateRoutes.GET("/all", noteTemplateHandler.GetAllNoteTemplates)
+		noteTemplateRoutes.GET("/session/:id", noteTemplateHandler.GetSessionNoteTemplate)
+		noteTemplateRoutes.GET("/:id", noteTemplateHandler.GetNoteTemplateByID)
+		noteTemplateRoutes.POST("/create", middleware.RequirePermission(model.PermNoteTemplateManage), noteTemplateHandler.CreateNoteTemplate)
+		noteTemplateRoutes.PUT("/:id", middleware.RequirePermission(model.PermNoteTemplateManage), noteTemplateHandler.UpdateNoteTemplate)
+		noteTemplateRoutes.PUT("/:id/active", middleware.RequirePermission(model.PermNoteTemplateManage), noteTemplateHandler.SetActiveStatus)
 	}
 
 	// ------------------- Department Routes -------------------
@@ -123,12 +267,17 @@ func Routes(router *gin.Engine) {
 	deptRoutes := router.Group("/api/v1/departments")
 	deptRoutes.Use(middleware.AuthMiddleware())
 	{
-		deptRoutes.POST("/create", departmentHandler.CreateDepartment)
+		deptRoutes.POST("/create", middleware.RequirePermission(model.PermDepartmentManage), departmentHandler.CreateDepartment)
 		deptRoutes.GET("/all", departmentHandler.GetAllDepartments)
 		deptRoutes.GET("/:id", departmentHandler.GetDepartmentByID)
-		deptRoutes.PUT("/:id", departmentHandler.UpdateDepartment)
-		deptRoutes.DELETE("/:id", departmentHandler.DeleteDepartment)
+		deptRoutes.PUT("/:id", middleware.RequirePermission(model.PermDepartmentManage), departmentHandler.UpdateDepartment)
+		deptRoutes.DELETE("/:id", middleware.RequirePermission(model.PermDepartmentManage), departmentHandler.DeleteDepartment)
 		deptRoutes.GET("/search", departmentHandler.SearchDepartments)
+		deptRoutes.PUT("/:id/on-call", middleware.RequirePermission(model.PermDepartmentManage), departmentHandler.SetOnCallPsychiatrist)
+		deptRoutes.PUT("/:id/note-template", middleware.RequirePermission(model.PermDepartmentManage), departmentHandler.SetDefaultNoteTemplate)
+		deptRoutes.GET("/access-rules", middleware.RequirePermission(model.PermDepartmentManage), patientAccessHandler.GetDepartmentAccessRules)
+		deptRoutes.POST("/access-rules", middleware.RequirePermission(model.PermDepartmentManage), patientAccessHandler.CreateDepartmentAccessRule)
+		deptRoutes.DELETE("/access-rules/:id", middleware.RequirePermission(model.PermDepartmentManage), patientAccessHandler.DeleteDepartmentAccessRule)
 	}
 
 	// ------------------- Medication History Routes -------------------
@@ -136,12 +285,93 @@ func Routes(router *gin.Engine) {
 	medicationHistoryRoutes := router.Group("/api/v1/medication-history")
 	medicationHistoryRoutes.Use(middleware.AuthMiddleware())
 	{
-		medicationHistoryRoutes.POST("/create", medicationHistoryHandler.CreateMedicationHistory)
-		medicationHistoryRoutes.GET("/all", medicationHistoryHandler.GetAllMedicationHistories)
-		medicationHistoryRoutes.GET("/:id", medicationHistoryHandler.GetMedicationHistoryByID)
-		medicationHistoryRoutes.PUT("/:id", medicationHistoryHandler.UpdateMedicationHistory)
-		medicationHistoryRoutes.DELETE("/:id", medicationHistoryHandler.DeleteMedicationHistory)
-		medicationHistoryRoutes.GET("/search", medicationHistoryHandler.SearchMedicationHistories)
+		medicationHistoryRoutes.POST("/create", middleware.RequirePermission(model.PermMedicationCreate), medicationHistoryHandler.CreateMedicationHistory)
+		medicationHistoryRoutes.GET("/all", middleware.AuditRead(model.AuditEntityMedicationHistory, ""), medicationHistoryHandler.GetAllMedicationHistories)
+		medicationHistoryRoutes.GET("/:id", middleware.AuditRead(model.AuditEntityMedicationHistory, "id"), medicationHistoryHandler.GetMedicationHistoryByID)
+		medicationHistoryRoutes.PUT("/:id", middleware.RequirePermission(model.PermMedicationUpdate), medicationHistoryHandler.UpdateMedicationHistory)
+		medicationHistoryRoutes.DELETE("/:id", middleware.RequirePermission(model.PermMedicationDelete), medicationHistoryHandler.DeleteMedicationHistory)
+		medicationHistoryRoutes.GET("/search", middleware.AuditRead(model.AuditEntityMedicationHistory, ""), medicationHistoryHandler.SearchMedicationHistories)
+	}
+
+	// ------------------- Prescription Routes -------------------
+	prescriptionHandler := handler.NewPrescriptionHandler()
+	prescriptionRoutes := router.Group("/api/v1/prescriptions")
+	prescriptionRoutes.Use(middleware.AuthMiddleware())
+	{
+		prescriptionRoutes.GET("/search", middleware.AuditRead(model.AuditEntityPrescriptionLine, ""), prescriptionHandler.SearchPrescriptions)
+		prescriptionRoutes.GET("/patient/:id", middleware.AuditRead(model.AuditEntityPrescriptionLine, "patient_id"), prescriptionHandler.GetPatientPrescriptions)
+		prescriptionRoutes.PUT("/:id/stop", middleware.RequirePermission(model.PermMedicationUpdate), prescriptionHandler.StopPrescriptionLine)
+	}
+
+	// ------------------- Adverse Reaction Routes -------------------
+	adverseReactionHandler := handler.NewAdverseReactionHandler()
+	adverseReactionRoutes := router.Group("/api/v1/adverse-reactions")
+	adverseReactionRoutes.Use(middleware.AuthMiddleware())
+	{
+		adverseReactionRoutes.GET("/export", middleware.RequirePermission(model.PermPharmacovigilanceExport), middleware.AuditRead(model.AuditEntityAdverseReaction, ""), adverseReactionHandler.ExportADRForms)
+		adverseReactionRoutes.GET("/session/:id", middleware.AuditRead(model.AuditEntityAdverseReaction, "session_id"), adverseReactionHandler.GetSessionReactions)
+		adverseReactionRoutes.GET("/patient/:id", middleware.AuditRead(model.AuditEntityAdverseReaction, "patient_id"), adverseReactionHandler.GetPatientReactions)
+		adverseReactionRoutes.POST("/session/:id", middleware.RequirePermission(model.PermAdverseReactionReport), adverseReactionHandler.ReportReaction)
+		adverseReactionRoutes.PUT("/:id", middleware.RequirePermission(model.PermAdverseReactionReport), adverseReactionHandler.UpdateReaction)
+	}
+
+	// ------------------- Adherence Routes -------------------
+	adherenceHandler := handler.NewAdherenceHandler()
+	adherenceRoutes := router.Group("/api/v1/adherence")
+	adherenceRoutes.Use(middleware.AuthMiddleware())
+	{
+		adherenceRoutes.GET("/at-risk", middleware.AuditRead(model.AuditEntityPrescriptionLine, ""), adherenceHandler.GetAtRiskPatients)
+		adherenceRoutes.GET("/patient/:id", middleware.AuditRead(model.AuditEntityPrescriptionLine, "patient_id"), adherenceHandler.GetPatientAdherence)
+		adherenceRoutes.GET("/lines/:id", middleware.AuditRead(model.AuditEntityPrescriptionLine, "id"), adherenceHandler.GetLineAdherence)
+		adherenceRoutes.POST("/lines/:id/dispensings", middleware.RequirePermission(model.PermMedicationUpdate), adherenceHandler.RecordDispensing)
+		adherenceRoutes.POST("/lines/:id/check-ins", middleware.RequirePermission(model.PermMedicationUpdate), adherenceHandler.RecordCheckIn)
+	}
+
+	// ------------------- Formulary Routes -------------------
+	formularyHandler := handler.NewFormularyHandler()
+	formularyRoutes := router.Group("/api/v1/formulary")
+	formularyRoutes.Use(middleware.AuthMiddleware())
+	{
+		formularyRoutes.GET("/all", formularyHandler.GetDrugs)
+		formularyRoutes.GET("/:id", formularyHandler.GetDrugByID)
+		formularyRoutes.POST("/create", middleware.RequirePermission(model.PermFormularyManage), formularyHandler.CreateDrug)
+		formularyRoutes.PUT("/:id", middleware.RequirePermission(model.PermFormularyManage), formularyHandler.UpdateDrug)
+		formularyRoutes.PUT("/:id/active", middleware.RequirePermission(model.PermFormularyManage), formularyHandler.SetActiveStatus)
+	}
+
+	// ------------------- Permission Routes -------------------
+	permissionHandler := handler.NewPermissionHandler()
+	permissionRoutes := router.Group("/api/v1/permissions")
+	permissionRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission(model.PermPermissionManage))
+	{
+		permissionRoutes.GET("/catalogue", permissionHandler.GetPermissionCatalogue)
+		permissionRoutes.GET("/grants", permissionHandler.GetAllGrants)
+		permissionRoutes.POST("/grants", permissionHandler.CreateGrant)
+		permissionRoutes.DELETE("/grants/:id", permissionHandler.DeleteGrant)
+	}
+
+	// ------------------- Risk Alert Routes -------------------
+	riskRoutes := router.Group("/api/v1/risk-alerts")
+	riskRoutes.Use(middleware.AuthMiddleware())
+	{
+		riskRoutes.GET("/open", riskAlertHandler.GetMyOpenRiskAlerts)
+		riskRoutes.GET("/session/:id", riskAlertHandler.GetRiskAlertsBySession)
+		riskRoutes.PUT("/:id/acknowledge", riskAlertHandler.AcknowledgeRiskAlert)
+
+		riskRoutes.GET("/rules", middleware.RequirePermission(model.PermRiskRuleManage), riskAlertHandler.GetRiskRules)
+		riskRoutes.POST("/rules", middleware.RequirePermission(model.PermRiskRuleManage), riskAlertHandler.CreateRiskRule)
+		riskRoutes.PUT("/rules/:id", middleware.RequirePermission(model.PermRiskRuleManage), riskAlertHandler.UpdateRiskRule)
+		riskRoutes.DELETE("/rules/:id", middleware.RequirePermission(model.PermRiskRuleManage), riskAlertHandler.DeleteRiskRule)
+	}
+
+	// ------------------- Audit Routes -------------------
+	auditRoutes := router.Group("/api/v1/audit-logs")
+	auditRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAuditView))
+	{
+		auditRoutes.GET("/search", auditHandler.SearchAuditLogs)
+		auditRoutes.GET("/patient/:id", auditHandler.GetAuditLogsByPatient)
+		auditRoutes.GET("/health-worker/:id", auditHandler.GetAuditLogsByHealthWorker)
+		auditRoutes.GET("/verify", auditHandler.VerifyAuditChain)
 	}
 
 	// ------------------- Message Routes -------------------
@@ -150,7 +380,35 @@ func Routes(router *gin.Engine) {
 	{
 		messageRoutes.POST("/send", messageHandler.SendMessage)   
 		messageRoutes.GET("/between", messageHandler.GetMessagesBetween)    
-		messageRoutes.GET("/inbox", messageHandler.GetInbox)             
+		messageRoutes.GET("/inbox", messageHandler.GetInbox)
+		messageRoutes.GET("/since", messageHandler.GetMessagesSince)
+		messageRoutes.GET("/conversations", messageHandler.GetConversations)
+		messageRoutes.POST("/read", messageHandler.MarkConversationRead)
+		messageRoutes.POST("/:id/read", messageHandler.MarkMessageRead)
+		messageRoutes.GET("/presence", messageHandler.GetPresence)
+		messageRoutes.GET("/ws", messageHandler.Connect)             
+	}
+
+	// ------------------- Channel Routes -------------------
+	channelHandler := handler.NewChannelHandler()
+	channelRoutes := router.Group("/api/v1/channels")
+	channelRoutes.Use(middleware.AuthMiddleware())
+	{
+		channelRoutes.GET("/mine", channelHandler.GetMyChannels)
+		channelRoutes.GET("/open", channelHandler.GetOpenGroups)
+		channelRoutes.POST("/groups", channelHandler.CreateGroup)
+		channelRoutes.POST("/care-team/:id", channelHandler.OpenCareTeamChannel)
+		channelRoutes.GET("/:id", channelHandler.GetChannel)
+		channelRoutes.PUT("/:id", channelHandler.UpdateChannel)
+		channelRoutes.POST("/:id/join", channelHandler.JoinChannel)
+		channelRoutes.POST("/:id/leave", channelHandler.LeaveChannel)
+		channelRoutes.POST("/:id/members", channelHandler.AddChannelMember)
+		channelRoutes.PUT("/:id/members/:memberId", channelHandler.UpdateChannelMember)
+		channelRoutes.DELETE("/:id/members/:memberId", channelHandler.RemoveChannelMember)
+		channelRoutes.GET("/:id/messages", channelHandler.GetChannelMessages)
+		channelRoutes.POST("/:id/messages", channelHandler.SendChannelMessage)
+		channelRoutes.POST("/:id/read", channelHandler.MarkChannelRead)
+		channelRoutes.DELETE("/:id/messages/:messageId", channelHandler.DeleteChannelMessage)
 	}
 }
 